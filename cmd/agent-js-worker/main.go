@@ -173,7 +173,8 @@ func getAgentHandlers(ctx *context.Provider,
 	handlers, err := agentctrl.GetCommandHandlers(ctx, agentctrl.WithBlocDomain(opts.BlocDomain),
 		agentctrl.WithDidAnchorOrigin(opts.DidAnchorOrigin), agentctrl.WithSidetreeToken(opts.SidetreeToken),
 		agentctrl.WithUnanchoredDIDMaxLifeTime(opts.UnanchoredDIDMaxLifeTime), agentctrl.WithMessageHandler(r),
-		agentctrl.WithNotifier(&wasmsetup.JSNotifier{}))
+		agentctrl.WithNotifier(&wasmsetup.JSNotifier{}), agentctrl.WithAllowedStores(opts.AllowedStores...),
+		agentctrl.WithDisabledModules(opts.DisabledModules...))
 	if err != nil {
 		return nil, err
 	}
@@ -586,6 +586,34 @@ const pkgs = {
         SendCreateConnectionRequest: {
             path: "/mediatorclient/send-connection-request",
             method: "POST",
+        },
+        GetOperation: {
+            path: "/mediatorclient/get-operation",
+            method: "POST",
+        },
+        CancelOperation: {
+            path: "/mediatorclient/cancel-operation",
+            method: "POST",
+        },
+        QueryKeylist: {
+            path: "/mediatorclient/keylist-query",
+            method: "POST",
+        },
+        RemoveKeys: {
+            path: "/mediatorclient/remove-keys",
+            method: "POST",
+        },
+        DecodeInvitation: {
+            path: "/mediatorclient/decode-invitation",
+            method: "POST",
+        },
+        ListInvitations: {
+            path: "/mediatorclient/list-invitations",
+            method: "POST",
+        },
+        RevokeInvitation: {
+            path: "/mediatorclient/revoke-invitation",
+            method: "POST",
         }
     },
     blindedrouting: {
@@ -596,6 +624,22 @@ const pkgs = {
         SendRegisterRouteRequest: {
             path: "/blindedrouting/send-router-registration",
             method: "POST",
+        },
+        ListRoutes: {
+            path: "/blindedrouting/list-routes",
+            method: "POST",
+        },
+        GetRoute: {
+            path: "/blindedrouting/get-route",
+            method: "POST",
+        },
+        RemoveRoute: {
+            path: "/blindedrouting/remove-route",
+            method: "POST",
+        },
+        EstablishBlindedRoute: {
+            path: "/blindedrouting/establish-route",
+            method: "POST",
         }
     },
     ld: {
@@ -92,6 +92,8 @@ function newMsg(pkg, fn, payload) {
  *      "agent-rest-wshook": "ws://controller.api.example.com"
  *      "context-provider-url": ["https://context-provider.example.com/ld_contexts.json"]
  *      "media-type-profiles": ["didcomm/v2"]
+ *      "allowed-stores": ["myapp_*"]
+ *      "disabled-modules": ["blindedrouting"]
  * }
  *
  * @param opts agent initialization options.
@@ -155,6 +157,19 @@ const Agent = function (opts) {
             return response
         },
 
+        /**
+         * Executes method of a command module, e.g. of a module registered with controller.RegisterModule
+         * in a custom build of the worker.
+         *
+         * @param pkg - name of the command module.
+         * @param fn - name of the method.
+         * @param req - json document.
+         * @returns {Promise<Object>}
+         */
+        execute: async function (pkg, fn, req) {
+            return invoke(aw, pending, pkg, fn, req, "timeout while executing " + pkg + "." + fn)
+        },
+
         startNotifier: function (callback, topics) {
             if (!callback) {
                 console.error("callback is required to start notifier")
@@ -1586,6 +1601,76 @@ const Agent = function (opts) {
                 return invoke(aw, pending, this.pkgname, "SendCreateConnectionRequest", req, "timeout while sending create connection request")
             },
 
+            /**
+             * getOperation returns status and result of an asynchronous mediator client operation.
+             *
+             * @param req - json document containing operation ID.
+             * @returns {Promise<Object>}
+             */
+            getOperation: async function (req) {
+                return invoke(aw, pending, this.pkgname, "GetOperation", req, "timeout while getting operation")
+            },
+
+            /**
+             * cancelOperation aborts an asynchronous mediator client operation which is still in progress.
+             *
+             * @param req - json document containing operation ID.
+             * @returns {Promise<Object>}
+             */
+            cancelOperation: async function (req) {
+                return invoke(aw, pending, this.pkgname, "CancelOperation", req, "timeout while cancelling operation")
+            },
+
+            /**
+             * queryKeylist queries recipient keys registered with the router over given or all router connections.
+             *
+             * @param req - json document containing optional router connection ID and pagination parameters.
+             * @returns {Promise<Object>}
+             */
+            queryKeylist: async function (req) {
+                return invoke(aw, pending, this.pkgname, "QueryKeylist", req, "timeout while querying keylist")
+            },
+
+            /**
+             * removeKeys removes recipient keys or all keys of a DID registered with the router.
+             *
+             * @param req - json document containing keys or DID and optional router connection ID.
+             * @returns {Promise<Object>}
+             */
+            removeKeys: async function (req) {
+                return invoke(aw, pending, this.pkgname, "RemoveKeys", req, "timeout while removing keys")
+            },
+
+            /**
+             * decodeInvitation decodes out-of-band invitation from invitation URL.
+             *
+             * @param req - json document containing invitation URL.
+             * @returns {Promise<Object>}
+             */
+            decodeInvitation: async function (req) {
+                return invoke(aw, pending, this.pkgname, "DecodeInvitation", req, "timeout while decoding invitation")
+            },
+
+            /**
+             * listInvitations lists invitations created through mediator client along with their status.
+             *
+             * @param req - json document containing optional status filter.
+             * @returns {Promise<Object>}
+             */
+            listInvitations: async function (req) {
+                return invoke(aw, pending, this.pkgname, "ListInvitations", req, "timeout while listing invitations")
+            },
+
+            /**
+             * revokeInvitation revokes invitation created through mediator client.
+             *
+             * @param req - json document containing invitation ID.
+             * @returns {Promise<Object>}
+             */
+            revokeInvitation: async function (req) {
+                return invoke(aw, pending, this.pkgname, "RevokeInvitation", req, "timeout while revoking invitation")
+            },
+
         },
 
         /**
@@ -1614,6 +1699,47 @@ const Agent = function (opts) {
             sendRegisterRouteRequest: async function (req) {
                 return invoke(aw, pending, this.pkgname, "SendRegisterRouteRequest", req, "timeout while sending register route request")
             },
+
+            /**
+             * listRoutes lists persisted blinded routing exchanges with routers.
+             *
+             * @param req - optional connection ID and status filters.
+             * @returns {Promise<Object>}
+             */
+            listRoutes: async function (req) {
+                return invoke(aw, pending, this.pkgname, "ListRoutes", req, "timeout listing routes")
+            },
+
+            /**
+             * getRoute returns persisted blinded routing exchange with a router.
+             *
+             * @param req - route ID.
+             * @returns {Promise<Object>}
+             */
+            getRoute: async function (req) {
+                return invoke(aw, pending, this.pkgname, "GetRoute", req, "timeout getting route")
+            },
+
+            /**
+             * removeRoute removes persisted blinded routing exchange with a router.
+             *
+             * @param req - route ID.
+             * @returns {Promise<Object>}
+             */
+            removeRoute: async function (req) {
+                return invoke(aw, pending, this.pkgname, "RemoveRoute", req, "timeout removing route")
+            },
+
+            /**
+             * establishBlindedRoute establishes blinded route over a connection to router, or resumes previously started exchange.
+             *
+             * @param req - connection ID of router or route ID to be resumed.
+             * @returns {Promise<Object>}
+             */
+            establishBlindedRoute: async function (req) {
+                return invoke(aw, pending, this.pkgname, "EstablishBlindedRoute", req, "timeout establishing blinded route")
+            },
+
         },
 
         /**
@@ -1644,7 +1770,7 @@ const Agent = function (opts) {
             },
 
             /**
-             * Retrieves data according to the given expression.
+             * Retrieves records matching the given expression, optionally limited and continued from a cursor.
              *
              * @param req - json document.
              * @returns {Promise<Object>}
@@ -1671,6 +1797,199 @@ const Agent = function (opts) {
             flush: async function () {
                 return invoke(aw, pending, this.pkgname, "Flush", {}, "timeout while flushing data")
             },
+
+            /**
+             * Lists opened stores and names of stores allowed to be opened.
+             *
+             * @returns {Promise<Object>}
+             */
+            listStores: async function () {
+                return invoke(aw, pending, this.pkgname, "ListStores", {}, "timeout while listing stores")
+            },
+
+            /**
+             * Fetches tags of the record based on key.
+             *
+             * @param req - json document.
+             * @returns {Promise<Object>}
+             */
+            getTags: async function (req) {
+                return invoke(aw, pending, this.pkgname, "GetTags", req, "timeout while getting tags")
+            },
+
+            /**
+             * Fetches records based on keys.
+             *
+             * @param req - json document.
+             * @returns {Promise<Object>}
+             */
+            getBulk: async function (req) {
+                return invoke(aw, pending, this.pkgname, "GetBulk", req, "timeout while getting data in bulk")
+            },
+
+            /**
+             * Performs put and delete operations atomically.
+             *
+             * @param req - json document.
+             * @returns {Promise<Object>}
+             */
+            batch: async function (req) {
+                return invoke(aw, pending, this.pkgname, "Batch", req, "timeout while performing batch operations")
+            },
+
+            /**
+             * Atomically adds delta to the integer counter stored in the record.
+             *
+             * @param req - json document.
+             * @returns {Promise<Object>}
+             */
+            increment: async function (req) {
+                return invoke(aw, pending, this.pkgname, "Increment", req, "timeout while incrementing counter")
+            },
+
+            /**
+             * Returns statistics of the sweeper deleting expired records.
+             *
+             * @returns {Promise<Object>}
+             */
+            sweeperStats: async function () {
+                return invoke(aw, pending, this.pkgname, "SweeperStats", {}, "timeout while getting sweeper stats")
+            },
+
+            /**
+             * Exports records and configurations of stores into an encrypted archive.
+             *
+             * @param req - json document.
+             * @returns {Promise<Object>}
+             */
+            exportStores: async function (req) {
+                return invoke(aw, pending, this.pkgname, "ExportStores", req, "timeout while exporting stores")
+            },
+
+            /**
+             * Imports records and configurations of stores from an encrypted archive.
+             *
+             * @param req - json document.
+             * @returns {Promise<Object>}
+             */
+            importStores: async function (req) {
+                return invoke(aw, pending, this.pkgname, "ImportStores", req, "timeout while importing stores")
+            },
+
+            /**
+             * Subscribes to changes of records in the store, change events are published on the returned topic.
+             *
+             * @param req - json document.
+             * @returns {Promise<Object>}
+             */
+            subscribe: async function (req) {
+                return invoke(aw, pending, this.pkgname, "Subscribe", req, "timeout while subscribing to changes")
+            },
+
+            /**
+             * Cancels subscription to changes of records.
+             *
+             * @param req - json document.
+             * @returns {Promise<Object>}
+             */
+            unsubscribe: async function (req) {
+                return invoke(aw, pending, this.pkgname, "Unsubscribe", req, "timeout while unsubscribing from changes")
+            },
+
+            /**
+             * Replaces JSON-path index definitions of the store, index tags are derived from values of records written afterwards.
+             *
+             * @param req - json document.
+             * @returns {Promise<Object>}
+             */
+            configureIndexes: async function (req) {
+                return invoke(aw, pending, this.pkgname, "ConfigureIndexes", req, "timeout while configuring indexes")
+            },
+
+            /**
+             * Gets JSON-path index definitions of the store.
+             *
+             * @param req - json document.
+             * @returns {Promise<Object>}
+             */
+            getIndexes: async function (req) {
+                return invoke(aw, pending, this.pkgname, "GetIndexes", req, "timeout while getting indexes")
+            },
+
+            /**
+             * Updates index tags of existing records of the store.
+             *
+             * @param req - json document.
+             * @returns {Promise<Object>}
+             */
+            rebuildIndexes: async function (req) {
+                return invoke(aw, pending, this.pkgname, "RebuildIndexes", req, "timeout while rebuilding indexes")
+            },
+        },
+
+        /**
+         * JSON schemas of command request and response models and the error catalog
+         *
+         */
+        schema: {
+            pkgname: "schema",
+
+            /**
+             * Gets JSON schemas of command methods, optionally of given command or method only.
+             *
+             * @param req - json document.
+             * @returns {Promise<Object>}
+             */
+            getSchemas: async function (req) {
+                return invoke(aw, pending, this.pkgname, "GetSchemas", req, "timeout while getting schemas")
+            },
+
+            /**
+             * Gets definitions of registered error codes, optionally of given command only.
+             *
+             * @param req - json document.
+             * @returns {Promise<Object>}
+             */
+            getErrors: async function (req) {
+                return invoke(aw, pending, this.pkgname, "GetErrors", req, "timeout while getting errors")
+            },
+        },
+
+        /**
+         * Webhook subscriptions receiving messages on matching topics
+         *
+         */
+        webhook: {
+            pkgname: "webhook",
+
+            /**
+             * Registers webhook receiving messages on topics matching the filter of the subscription.
+             *
+             * @param req - json document.
+             * @returns {Promise<Object>}
+             */
+            registerSubscription: async function (req) {
+                return invoke(aw, pending, this.pkgname, "RegisterSubscription", req, "timeout while registering subscription")
+            },
+
+            /**
+             * Lists registered webhook subscriptions.
+             *
+             * @returns {Promise<Object>}
+             */
+            listSubscriptions: async function () {
+                return invoke(aw, pending, this.pkgname, "ListSubscriptions", null, "timeout while listing subscriptions")
+            },
+
+            /**
+             * Deletes webhook subscription.
+             *
+             * @param req - json document.
+             * @returns {Promise<Object>}
+             */
+            deleteSubscription: async function (req) {
+                return invoke(aw, pending, this.pkgname, "DeleteSubscription", req, "timeout while deleting subscription")
+            },
         },
         /**
          * JSON-LD management API.
@@ -180,7 +180,7 @@ func getAgentHandlers(ctx *walletServices,
 		return nil, fmt.Errorf("failed to initialize DID client: %w", err)
 	}
 
-	storeCmd, err := store.New(ctx)
+	storeCmd, err := store.New(ctx, store.WithAllowedStores(opts.AllowedStores...))
 	if err != nil {
 		return nil, err
 	}
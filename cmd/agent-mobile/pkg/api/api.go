@@ -52,6 +52,9 @@ type AriesController interface { //nolint: interfacebloat
 	// GetBlindedRoutingController returns an implementation of BlindedRoutingController
 	GetBlindedRoutingController() (BlindedRoutingController, error)
 
+	// GetStoreController returns an implementation of StoreController
+	GetStoreController() (StoreController, error)
+
 	// RegisterHandler registers handler for handling notifications
 	RegisterHandler(h Handler, topics string) string
 
@@ -63,4 +66,7 @@ type AriesController interface { //nolint: interfacebloat
 
 	// GetLDController returns an implementation of LDController
 	GetLDController() (LDController, error)
+
+	// GetModuleController returns an implementation of ModuleController for the command module with given name
+	GetModuleController(name string) (ModuleController, error)
 }
@@ -17,4 +17,16 @@ type BlindedRoutingController interface {
 
 	// SendRegisterRouteRequest sends register route request as a response to reply from send DID doc request.
 	SendRegisterRouteRequest(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// ListRoutes lists persisted blinded routing exchanges with routers.
+	ListRoutes(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// GetRoute returns persisted blinded routing exchange with a router.
+	GetRoute(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// RemoveRoute removes persisted blinded routing exchange with a router.
+	RemoveRoute(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// EstablishBlindedRoute establishes blinded route over a connection to router, or resumes previously started exchange.
+	EstablishBlindedRoute(request *models.RequestEnvelope) *models.ResponseEnvelope
 }
@@ -18,4 +18,25 @@ type MediatorClient interface {
 
 	// SendCreateConnectionRequest sends create connection request to mediator.
 	SendCreateConnectionRequest(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// GetOperation returns status and result of an asynchronous operation.
+	GetOperation(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// CancelOperation aborts an asynchronous operation which is still in progress.
+	CancelOperation(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// QueryKeylist queries recipient keys registered with the router.
+	QueryKeylist(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// RemoveKeys removes recipient keys or all keys of a DID registered with the router.
+	RemoveKeys(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// DecodeInvitation decodes out-of-band invitation from invitation URL.
+	DecodeInvitation(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// ListInvitations lists invitations created through mediator client along with their status.
+	ListInvitations(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// RevokeInvitation revokes invitation created through mediator client.
+	RevokeInvitation(request *models.RequestEnvelope) *models.ResponseEnvelope
 }
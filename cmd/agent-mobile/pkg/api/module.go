@@ -0,0 +1,19 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"github.com/trustbloc/agent-sdk/cmd/agent-mobile/pkg/wrappers/models"
+)
+
+// ModuleController defines methods for controllers of command modules, e.g. of modules registered
+// with controller.RegisterModule.
+type ModuleController interface {
+
+	// Execute executes the method with given name.
+	Execute(method string, request *models.RequestEnvelope) *models.ResponseEnvelope
+}
@@ -0,0 +1,72 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package api
+
+import (
+	"github.com/trustbloc/agent-sdk/cmd/agent-mobile/pkg/wrappers/models"
+)
+
+// StoreController defines methods for the store controller.
+type StoreController interface {
+
+	// Put stores the key, value and (optional) tags.
+	Put(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// Get fetches the record based on key.
+	Get(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// Query retrieves records matching given expression.
+	Query(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// QueryRecords retrieves records matching given expression along with their keys, tags and versions.
+	QueryRecords(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// Delete deletes a record with a given key.
+	Delete(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// GetTags fetches tags of the record based on key.
+	GetTags(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// GetBulk fetches records based on keys.
+	GetBulk(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// Batch performs put and delete operations atomically.
+	Batch(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// Flush flushes data in all currently open stores.
+	Flush(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// ListStores lists opened stores and names of stores allowed to be opened.
+	ListStores(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// Increment atomically adds delta to the integer counter stored in the record.
+	Increment(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// SweeperStats returns statistics of the sweeper deleting expired records.
+	SweeperStats(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// ExportStores exports records and configurations of stores into an encrypted archive.
+	ExportStores(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// ImportStores imports records and configurations of stores from an encrypted archive.
+	ImportStores(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// Subscribe subscribes to changes of records in the store.
+	Subscribe(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// Unsubscribe cancels subscription to changes of records.
+	Unsubscribe(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// ConfigureIndexes replaces JSON-path index definitions of the store.
+	ConfigureIndexes(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// GetIndexes returns JSON-path index definitions of the store.
+	GetIndexes(request *models.RequestEnvelope) *models.ResponseEnvelope
+
+	// RebuildIndexes updates index tags of existing records of the store.
+	RebuildIndexes(request *models.RequestEnvelope) *models.ResponseEnvelope
+}
@@ -51,6 +51,7 @@ import (
 	"github.com/trustbloc/agent-sdk/pkg/controller/command/blindedrouting"
 	"github.com/trustbloc/agent-sdk/pkg/controller/command/didclient"
 	"github.com/trustbloc/agent-sdk/pkg/controller/command/mediatorclient"
+	"github.com/trustbloc/agent-sdk/pkg/controller/command/store"
 )
 
 var logger = log.New("aries-agent-mobile/wrappers/command")
@@ -97,6 +98,7 @@ func NewAries(opts *config.Options) (*Aries, error) {
 		sdkcontroller.WithBlocDomain(opts.TrustblocDomain),
 		sdkcontroller.WithMessageHandler(msgHandler),
 		sdkcontroller.WithNotifier(notifier.NewNotifier(notifications)),
+		sdkcontroller.WithDisabledModules(opts.DisabledModules...),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get sdk command handlers: %w", err)
@@ -406,6 +408,26 @@ func (a *Aries) GetBlindedRoutingController() (api.BlindedRoutingController, err
 	return &BlindedRouting{handlers: handlers}, nil
 }
 
+// GetStoreController returns a Store instance.
+func (a *Aries) GetStoreController() (api.StoreController, error) {
+	handlers, ok := a.handlers[store.CommandName]
+	if !ok {
+		return nil, fmt.Errorf("no handlers found for controller [%s]", store.CommandName)
+	}
+
+	return &Store{handlers: handlers}, nil
+}
+
+// GetModuleController returns a Module instance for the command module with given name.
+func (a *Aries) GetModuleController(name string) (api.ModuleController, error) {
+	handlers, ok := a.handlers[name]
+	if !ok {
+		return nil, fmt.Errorf("no handlers found for controller [%s]", name)
+	}
+
+	return &Module{name: name, handlers: handlers}, nil
+}
+
 // GetLDController returns an LD instance.
 func (a *Aries) GetLDController() (api.LDController, error) {
 	handlers, ok := a.handlers[ld.CommandName]
@@ -311,3 +311,35 @@ func TestAries_GetLDContextController(t *testing.T) {
 		require.NotNil(t, controller)
 	})
 }
+
+func TestAries_GetModuleController(t *testing.T) {
+	t.Run("it creates a controller", func(t *testing.T) {
+		a, err := NewAries(&config.Options{})
+		require.NoError(t, err)
+		require.NotNil(t, a)
+
+		controller, err := a.GetModuleController("store")
+		require.NoError(t, err)
+		require.NotNil(t, controller)
+
+		resp := controller.Execute("ListStores", &models.RequestEnvelope{Payload: []byte(`{}`)})
+		require.Nil(t, resp.Error)
+		require.JSONEq(t, `{"stores":["store"],"allowed":["store"]}`, string(resp.Payload))
+
+		resp = controller.Execute("Unknown", &models.RequestEnvelope{Payload: []byte(`{}`)})
+		require.NotNil(t, resp.Error)
+		require.Equal(t, "no handler found for method [Unknown] of controller [store]", resp.Error.Message)
+	})
+
+	t.Run("module is disabled", func(t *testing.T) {
+		opts := &config.Options{}
+		opts.AddDisabledModule("store")
+
+		a, err := NewAries(opts)
+		require.NoError(t, err)
+		require.NotNil(t, a)
+
+		_, err = a.GetModuleController("store")
+		require.EqualError(t, err, "no handlers found for controller [store]")
+	})
+}
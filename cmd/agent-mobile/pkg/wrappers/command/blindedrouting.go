@@ -51,3 +51,67 @@ func (br *BlindedRouting) SendRegisterRouteRequest(request *models.RequestEnvelo
 
 	return &models.ResponseEnvelope{Payload: response}
 }
+
+// ListRoutes lists persisted blinded routing exchanges with routers.
+func (br *BlindedRouting) ListRoutes(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := blindedrouting.ListRoutesRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(br.handlers[blindedrouting.ListRoutes], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// GetRoute returns persisted blinded routing exchange with a router.
+func (br *BlindedRouting) GetRoute(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := blindedrouting.GetRouteRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(br.handlers[blindedrouting.GetRoute], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// RemoveRoute removes persisted blinded routing exchange with a router.
+func (br *BlindedRouting) RemoveRoute(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := blindedrouting.RemoveRouteRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(br.handlers[blindedrouting.RemoveRoute], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// EstablishBlindedRoute establishes blinded route over a connection to router, or resumes previously started exchange.
+func (br *BlindedRouting) EstablishBlindedRoute(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := blindedrouting.EstablishBlindedRouteRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(br.handlers[blindedrouting.EstablishBlindedRoute], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
 
 	"github.com/trustbloc/agent-sdk/cmd/agent-mobile/pkg/wrappers/models"
+	agentcmd "github.com/trustbloc/agent-sdk/pkg/controller/command"
 )
 
 func exec(handlerFunc command.Exec, request interface{}) ([]byte, *models.CommandError) {
@@ -26,12 +27,31 @@ func exec(handlerFunc command.Exec, request interface{}) ([]byte, *models.Comman
 	requestReader := bytes.NewReader(marshaledRequest)
 
 	if err := handlerFunc(responseWriter, requestReader); err != nil {
-		return nil, &models.CommandError{
-			Message: err.Error(),
-			Code:    int(err.Code()),
-			Type:    int(err.Type()),
-		}
+		return nil, commandError(err)
 	}
 
 	return responseWriter.Bytes(), nil
 }
+
+func commandError(err command.Error) *models.CommandError {
+	info := agentcmd.DescribeError(err.Code(), err)
+
+	cmdErr := &models.CommandError{
+		Message:   info.Message,
+		Code:      int(info.Code),
+		Type:      int(err.Type()),
+		Name:      info.Name,
+		Retryable: info.Retryable,
+	}
+
+	if len(info.Details) == 0 {
+		return cmdErr
+	}
+
+	// details are passed as JSON since gomobile does not support slices of structs
+	if details, e := json.Marshal(info.Details); e == nil {
+		cmdErr.Details = details
+	}
+
+	return cmdErr
+}
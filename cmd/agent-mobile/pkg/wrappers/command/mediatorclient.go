@@ -67,3 +67,115 @@ func (mc *MediatorClient) SendCreateConnectionRequest(request *models.RequestEnv
 
 	return &models.ResponseEnvelope{Payload: response}
 }
+
+// GetOperation returns status and result of an asynchronous operation.
+func (mc *MediatorClient) GetOperation(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := mediatorclient.OperationRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(mc.handlers[mediatorclient.GetOperation], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// CancelOperation aborts an asynchronous operation which is still in progress.
+func (mc *MediatorClient) CancelOperation(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := mediatorclient.OperationRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(mc.handlers[mediatorclient.CancelOperation], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// QueryKeylist queries recipient keys registered with the router.
+func (mc *MediatorClient) QueryKeylist(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := mediatorclient.KeylistQueryRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(mc.handlers[mediatorclient.QueryKeylist], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// RemoveKeys removes recipient keys or all keys of a DID registered with the router.
+func (mc *MediatorClient) RemoveKeys(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := mediatorclient.RemoveKeysRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(mc.handlers[mediatorclient.RemoveKeys], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// DecodeInvitation decodes out-of-band invitation from invitation URL.
+func (mc *MediatorClient) DecodeInvitation(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := mediatorclient.DecodeInvitationRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(mc.handlers[mediatorclient.DecodeInvitation], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// ListInvitations lists invitations created through mediator client along with their status.
+func (mc *MediatorClient) ListInvitations(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := mediatorclient.ListInvitationsRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(mc.handlers[mediatorclient.ListInvitations], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// RevokeInvitation revokes invitation created through mediator client.
+func (mc *MediatorClient) RevokeInvitation(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := mediatorclient.RevokeInvitationRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(mc.handlers[mediatorclient.RevokeInvitation], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
@@ -0,0 +1,39 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+
+	"github.com/trustbloc/agent-sdk/cmd/agent-mobile/pkg/wrappers/models"
+)
+
+// Module contains necessary fields to support operations of a command module.
+type Module struct {
+	name     string
+	handlers map[string]command.Exec
+}
+
+// Execute executes the method with given name.
+func (m *Module) Execute(method string, request *models.RequestEnvelope) *models.ResponseEnvelope {
+	handler, ok := m.handlers[method]
+	if !ok {
+		return &models.ResponseEnvelope{Error: &models.CommandError{
+			Message: fmt.Sprintf("no handler found for method [%s] of controller [%s]", method, m.name),
+		}}
+	}
+
+	response, cmdErr := exec(handler, json.RawMessage(request.Payload))
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
@@ -0,0 +1,307 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command //nolint:dupl // store is a unique command
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+
+	"github.com/trustbloc/agent-sdk/cmd/agent-mobile/pkg/wrappers/models"
+	"github.com/trustbloc/agent-sdk/pkg/controller/command/store"
+)
+
+// Store contains necessary fields to support its operations.
+type Store struct {
+	handlers map[string]command.Exec
+}
+
+// Put stores the key, value and (optional) tags.
+func (s *Store) Put(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := store.PutRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(s.handlers[store.PutCommandMethod], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// Get fetches the record based on key.
+func (s *Store) Get(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := store.GetRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(s.handlers[store.GetCommandMethod], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// Query retrieves records matching given expression.
+func (s *Store) Query(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := store.QueryRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(s.handlers[store.QueryCommandMethod], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// QueryRecords retrieves records matching given expression along with their keys, tags and versions.
+func (s *Store) QueryRecords(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := store.QueryRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(s.handlers[store.QueryRecordsCommandMethod], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// Delete deletes a record with a given key.
+func (s *Store) Delete(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := store.DeleteRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(s.handlers[store.DeleteCommandMethod], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// GetTags fetches tags of the record based on key.
+func (s *Store) GetTags(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := store.GetTagsRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(s.handlers[store.GetTagsCommandMethod], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// GetBulk fetches records based on keys.
+func (s *Store) GetBulk(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := store.GetBulkRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(s.handlers[store.GetBulkCommandMethod], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// Batch performs put and delete operations atomically.
+func (s *Store) Batch(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := store.BatchRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(s.handlers[store.BatchCommandMethod], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// Flush flushes data in all currently open stores.
+func (s *Store) Flush(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	response, cmdErr := exec(s.handlers[store.FlushCommandMethod], request.Payload)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// ListStores lists opened stores and names of stores allowed to be opened.
+func (s *Store) ListStores(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	response, cmdErr := exec(s.handlers[store.ListStoresCommandMethod], request.Payload)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// Increment atomically adds delta to the integer counter stored in the record.
+func (s *Store) Increment(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := store.IncrementRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(s.handlers[store.IncrementCommandMethod], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// SweeperStats returns statistics of the sweeper deleting expired records.
+func (s *Store) SweeperStats(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	response, cmdErr := exec(s.handlers[store.SweeperStatsCommandMethod], request.Payload)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// ExportStores exports records and configurations of stores into an encrypted archive.
+func (s *Store) ExportStores(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := store.ExportStoresRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(s.handlers[store.ExportStoresCommandMethod], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// ImportStores imports records and configurations of stores from an encrypted archive.
+func (s *Store) ImportStores(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := store.ImportStoresRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(s.handlers[store.ImportStoresCommandMethod], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// Subscribe subscribes to changes of records in the store.
+func (s *Store) Subscribe(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := store.SubscribeRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(s.handlers[store.SubscribeCommandMethod], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// Unsubscribe cancels subscription to changes of records.
+func (s *Store) Unsubscribe(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := store.UnsubscribeRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(s.handlers[store.UnsubscribeCommandMethod], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// ConfigureIndexes replaces JSON-path index definitions of the store.
+func (s *Store) ConfigureIndexes(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := store.ConfigureIndexesRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(s.handlers[store.ConfigureIndexesCommandMethod], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// GetIndexes returns JSON-path index definitions of the store.
+func (s *Store) GetIndexes(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := store.GetIndexesRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(s.handlers[store.GetIndexesCommandMethod], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
+
+// RebuildIndexes updates index tags of existing records of the store.
+func (s *Store) RebuildIndexes(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	args := store.RebuildIndexesRequest{}
+
+	if err := json.Unmarshal(request.Payload, &args); err != nil {
+		return &models.ResponseEnvelope{Error: &models.CommandError{Message: err.Error()}}
+	}
+
+	response, cmdErr := exec(s.handlers[store.RebuildIndexesCommandMethod], args)
+	if cmdErr != nil {
+		return &models.ResponseEnvelope{Error: cmdErr}
+	}
+
+	return &models.ResponseEnvelope{Payload: response}
+}
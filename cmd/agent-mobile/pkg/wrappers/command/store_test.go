@@ -0,0 +1,133 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command //nolint:testpackage // uses internal implementation details
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/agent-sdk/cmd/agent-mobile/pkg/wrappers/models"
+	"github.com/trustbloc/agent-sdk/pkg/controller/command/store"
+)
+
+const sampleValueVersion = "zUJATVKtVcz6mspK3IKKpYAK2dOFoGcfvL9yQRgyBhk"
+
+func getStoreController(t *testing.T) *Store {
+	t.Helper()
+
+	a, err := getAgent()
+	require.NotNil(t, a)
+	require.NoError(t, err)
+
+	controller, err := a.GetStoreController()
+	require.NoError(t, err)
+	require.NotNil(t, controller)
+
+	s, ok := controller.(*Store)
+	require.Equal(t, ok, true)
+
+	return s
+}
+
+func TestStore(t *testing.T) {
+	t.Run("put, get and query", func(t *testing.T) {
+		controller := getStoreController(t)
+
+		resp := controller.Put(&models.RequestEnvelope{Payload: []byte(`{"key":"key","value":"dmFsdWU=",` +
+			`"tags":[{"name":"tag"}]}`)})
+		require.NotNil(t, resp)
+		require.Nil(t, resp.Error)
+
+		resp = controller.Get(&models.RequestEnvelope{Payload: []byte(`{"key":"key"}`)})
+		require.Nil(t, resp.Error)
+		require.JSONEq(t, `{"result":"dmFsdWU=","version":"`+sampleValueVersion+`"}`, string(resp.Payload))
+
+		resp = controller.GetTags(&models.RequestEnvelope{Payload: []byte(`{"key":"key"}`)})
+		require.Nil(t, resp.Error)
+		require.JSONEq(t, `{"tags":[{"name":"tag","value":""}]}`, string(resp.Payload))
+
+		resp = controller.GetBulk(&models.RequestEnvelope{Payload: []byte(`{"keys":["key","missing"]}`)})
+		require.Nil(t, resp.Error)
+		require.JSONEq(t, `{"results":["dmFsdWU=",null]}`, string(resp.Payload))
+
+		resp = controller.Query(&models.RequestEnvelope{Payload: []byte(`{"expression":"tag"}`)})
+		require.Nil(t, resp.Error)
+		require.JSONEq(t, `{"results":["dmFsdWU="]}`, string(resp.Payload))
+
+		resp = controller.QueryRecords(&models.RequestEnvelope{Payload: []byte(`{"expression":"tag"}`)})
+		require.Nil(t, resp.Error)
+		require.JSONEq(t, `{"records":[{"key":"key","value":"dmFsdWU=","tags":[{"name":"tag","value":""}],`+
+			`"version":"`+sampleValueVersion+`"}]}`, string(resp.Payload))
+
+		resp = controller.Increment(&models.RequestEnvelope{Payload: []byte(`{"key":"counter","delta":3}`)})
+		require.Nil(t, resp.Error)
+		require.Contains(t, string(resp.Payload), `"value":3`)
+
+		resp = controller.ListStores(&models.RequestEnvelope{Payload: []byte(`{}`)})
+		require.Nil(t, resp.Error)
+		require.JSONEq(t, `{"stores":["store"],"allowed":["store"]}`, string(resp.Payload))
+
+		resp = controller.SweeperStats(&models.RequestEnvelope{Payload: []byte(`{}`)})
+		require.Nil(t, resp.Error)
+		require.Contains(t, string(resp.Payload), `"enabled":true`)
+
+		resp = controller.Subscribe(&models.RequestEnvelope{Payload: []byte(`{"expression":"tag"}`)})
+		require.Nil(t, resp.Error)
+
+		var subscribeResp store.SubscribeResponse
+		require.NoError(t, json.Unmarshal(resp.Payload, &subscribeResp))
+		require.Equal(t, store.ChangeTopic(store.DefaultStoreName), subscribeResp.Topic)
+
+		resp = controller.Unsubscribe(&models.RequestEnvelope{Payload: []byte(`{"id":"` + subscribeResp.ID + `"}`)})
+		require.Nil(t, resp.Error)
+
+		resp = controller.ConfigureIndexes(&models.RequestEnvelope{
+			Payload: []byte(`{"indexes":[{"name":"type","path":"$.type"}]}`),
+		})
+		require.Nil(t, resp.Error)
+
+		resp = controller.GetIndexes(&models.RequestEnvelope{Payload: []byte(`{}`)})
+		require.Nil(t, resp.Error)
+		require.JSONEq(t, `{"indexes":[{"name":"type","path":"$.type"}]}`, string(resp.Payload))
+
+		resp = controller.RebuildIndexes(&models.RequestEnvelope{Payload: []byte(`{}`)})
+		require.Nil(t, resp.Error)
+		require.JSONEq(t, `{"updated":0}`, string(resp.Payload))
+	})
+
+	t.Run("delete, batch, flush, export and import", func(t *testing.T) {
+		controller := getStoreController(t)
+
+		mockResponse := `{}`
+		fakeHandler := mockCommandRunner{data: []byte(mockResponse)}
+
+		controller.handlers[store.DeleteCommandMethod] = fakeHandler.exec
+		controller.handlers[store.BatchCommandMethod] = fakeHandler.exec
+		controller.handlers[store.FlushCommandMethod] = fakeHandler.exec
+		controller.handlers[store.ExportStoresCommandMethod] = fakeHandler.exec
+		controller.handlers[store.ImportStoresCommandMethod] = fakeHandler.exec
+
+		for _, call := range []func(*models.RequestEnvelope) *models.ResponseEnvelope{
+			controller.Delete, controller.Batch, controller.Flush, controller.ExportStores, controller.ImportStores,
+		} {
+			resp := call(&models.RequestEnvelope{Payload: []byte(`{"key":"key"}`)})
+			require.NotNil(t, resp)
+			require.Nil(t, resp.Error)
+			require.Equal(t, mockResponse, string(resp.Payload))
+		}
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		controller := getStoreController(t)
+
+		resp := controller.Put(&models.RequestEnvelope{Payload: []byte(`---`)})
+		require.NotNil(t, resp.Error)
+		require.Contains(t, resp.Error.Message, "invalid character")
+	})
+}
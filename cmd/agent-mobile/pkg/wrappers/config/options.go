@@ -32,6 +32,7 @@ type Options struct {
 	// not intended to be used by golang code
 	HTTPResolvers     []string
 	OutboundTransport []string
+	DisabledModules   []string
 }
 
 // New returns an instance of Options which can be used to configure an aries controller instance.
@@ -48,3 +49,8 @@ func (o *Options) AddHTTPResolver(resolverURL string) {
 func (o *Options) AddOutboundTransport(transportType string) {
 	o.OutboundTransport = append(o.OutboundTransport, transportType)
 }
+
+// AddDisabledModule appends name of a controller module to be disabled to the options, e.g. blindedrouting.
+func (o *Options) AddDisabledModule(name string) {
+	o.DisabledModules = append(o.DisabledModules, name)
+}
@@ -12,6 +12,11 @@ type CommandError struct {
 	Message string `json:"message"`
 	Code    int    `json:"code,omitempty"`
 	Type    int    `json:"type,omitempty"`
+	// Name is stable machine-readable name of the error code from the error catalog.
+	Name string `json:"name,omitempty"`
+	// Details contains JSON-encoded list of field-level causes of the error, e.g. fields not matching schema.
+	Details   []byte `json:"details,omitempty"`
+	Retryable bool   `json:"retryable,omitempty"`
 }
 
 // RequestEnvelope contains a payload representing parameters for each operation on a protocol.
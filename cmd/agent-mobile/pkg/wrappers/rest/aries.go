@@ -39,6 +39,7 @@ import (
 	"github.com/trustbloc/agent-sdk/pkg/controller/rest/blindedrouting"
 	"github.com/trustbloc/agent-sdk/pkg/controller/rest/didclient"
 	"github.com/trustbloc/agent-sdk/pkg/controller/rest/mediatorclient"
+	"github.com/trustbloc/agent-sdk/pkg/controller/rest/store"
 )
 
 // Aries is an Aries implementation with endpoints to execute operations.
@@ -315,6 +316,22 @@ func (ar *Aries) GetBlindedRoutingController() (api.BlindedRoutingController, er
 	return &BlindedRouting{endpoints: endpoints, URL: ar.URL, Token: ar.Token, httpClient: &http.Client{}}, nil
 }
 
+// GetStoreController returns a Store instance.
+func (ar *Aries) GetStoreController() (api.StoreController, error) {
+	endpoints, ok := ar.endpoints[store.OperationID]
+	if !ok {
+		return nil, fmt.Errorf("no endpoints found for controller [%s]", store.OperationID)
+	}
+
+	return &Store{endpoints: endpoints, URL: ar.URL, Token: ar.Token, httpClient: &http.Client{}}, nil
+}
+
+// GetModuleController returns an error, as endpoints of command modules other than the built-in ones
+// are not known to the REST client.
+func (ar *Aries) GetModuleController(name string) (api.ModuleController, error) {
+	return nil, fmt.Errorf("no endpoints found for controller [%s]", name)
+}
+
 // GetVCWalletController returns a VCWalletController instance.
 func (ar *Aries) GetVCWalletController() (api.VCWalletController, error) {
 	endpoints, ok := ar.endpoints[vcwallet.OperationID]
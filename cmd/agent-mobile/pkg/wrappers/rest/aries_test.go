@@ -273,3 +273,14 @@ func TestAries_GetLDController(t *testing.T) {
 		require.NotNil(t, controller)
 	})
 }
+
+func TestAries_GetModuleController(t *testing.T) {
+	t.Run("endpoints of modules are not known", func(t *testing.T) {
+		a, err := NewAries(&config.Options{AgentURL: mockAgentURL})
+		require.NoError(t, err)
+		require.NotNil(t, a)
+
+		_, err = a.GetModuleController("custom")
+		require.EqualError(t, err, "no endpoints found for controller [custom]")
+	})
+}
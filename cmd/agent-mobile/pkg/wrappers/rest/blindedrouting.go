@@ -31,6 +31,26 @@ func (br *BlindedRouting) SendRegisterRouteRequest(request *models.RequestEnvelo
 	return br.createRespEnvelope(request, blindedrouting.SendRegisterRouteRequest)
 }
 
+// ListRoutes lists persisted blinded routing exchanges with routers.
+func (br *BlindedRouting) ListRoutes(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return br.createRespEnvelope(request, blindedrouting.ListRoutes)
+}
+
+// GetRoute returns persisted blinded routing exchange with a router.
+func (br *BlindedRouting) GetRoute(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return br.createRespEnvelope(request, blindedrouting.GetRoute)
+}
+
+// RemoveRoute removes persisted blinded routing exchange with a router.
+func (br *BlindedRouting) RemoveRoute(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return br.createRespEnvelope(request, blindedrouting.RemoveRoute)
+}
+
+// EstablishBlindedRoute establishes blinded route over a connection to router, or resumes previously started exchange.
+func (br *BlindedRouting) EstablishBlindedRoute(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return br.createRespEnvelope(request, blindedrouting.EstablishBlindedRoute)
+}
+
 func (br *BlindedRouting) createRespEnvelope(request *models.RequestEnvelope,
 	endpoint string,
 ) *models.ResponseEnvelope {
@@ -40,9 +40,11 @@ import (
 	cmdblindedrouting "github.com/trustbloc/agent-sdk/pkg/controller/command/blindedrouting"
 	cmddidclient "github.com/trustbloc/agent-sdk/pkg/controller/command/didclient"
 	cmdmediatorclient "github.com/trustbloc/agent-sdk/pkg/controller/command/mediatorclient"
+	cmdstore "github.com/trustbloc/agent-sdk/pkg/controller/command/store"
 	opblindedrouting "github.com/trustbloc/agent-sdk/pkg/controller/rest/blindedrouting"
 	opdidclient "github.com/trustbloc/agent-sdk/pkg/controller/rest/didclient"
 	opmediatorclient "github.com/trustbloc/agent-sdk/pkg/controller/rest/mediatorclient"
+	opstore "github.com/trustbloc/agent-sdk/pkg/controller/rest/store"
 )
 
 // endpoint describes the fields for making calls to external agents.
@@ -68,6 +70,7 @@ func getControllerEndpoints() map[string]map[string]*endpoint {
 	allEndpoints[opkms.KmsOperationID] = getKMSEndpoints()
 	allEndpoints[opmediatorclient.OperationID] = getMediatorClientEndpoints()
 	allEndpoints[opblindedrouting.OperationID] = getBlindedRoutingEndpoints()
+	allEndpoints[opstore.OperationID] = getStoreEndpoints()
 	allEndpoints[opvcwallet.OperationID] = getVCWalletEndpoints()
 	allEndpoints[opld.OperationID] = getLDEndpoints()
 
@@ -504,6 +507,34 @@ func getMediatorClientEndpoints() map[string]*endpoint {
 			Path:   opmediatorclient.SendCreateConnectionRequest,
 			Method: http.MethodPost,
 		},
+		cmdmediatorclient.GetOperation: {
+			Path:   opmediatorclient.GetOperationPath,
+			Method: http.MethodPost,
+		},
+		cmdmediatorclient.CancelOperation: {
+			Path:   opmediatorclient.CancelOperationPath,
+			Method: http.MethodPost,
+		},
+		cmdmediatorclient.QueryKeylist: {
+			Path:   opmediatorclient.QueryKeylistPath,
+			Method: http.MethodPost,
+		},
+		cmdmediatorclient.RemoveKeys: {
+			Path:   opmediatorclient.RemoveKeysPath,
+			Method: http.MethodPost,
+		},
+		cmdmediatorclient.DecodeInvitation: {
+			Path:   opmediatorclient.DecodeInvitationPath,
+			Method: http.MethodPost,
+		},
+		cmdmediatorclient.ListInvitations: {
+			Path:   opmediatorclient.ListInvitationsPath,
+			Method: http.MethodPost,
+		},
+		cmdmediatorclient.RevokeInvitation: {
+			Path:   opmediatorclient.RevokeInvitationPath,
+			Method: http.MethodPost,
+		},
 	}
 }
 
@@ -517,6 +548,103 @@ func getBlindedRoutingEndpoints() map[string]*endpoint {
 			Path:   opblindedrouting.SendRegisterRouteRequest,
 			Method: http.MethodPost,
 		},
+		cmdblindedrouting.ListRoutes: {
+			Path:   opblindedrouting.ListRoutesPath,
+			Method: http.MethodPost,
+		},
+		cmdblindedrouting.GetRoute: {
+			Path:   opblindedrouting.GetRoutePath,
+			Method: http.MethodPost,
+		},
+		cmdblindedrouting.RemoveRoute: {
+			Path:   opblindedrouting.RemoveRoutePath,
+			Method: http.MethodPost,
+		},
+		cmdblindedrouting.EstablishBlindedRoute: {
+			Path:   opblindedrouting.EstablishRoutePath,
+			Method: http.MethodPost,
+		},
+	}
+}
+
+func getStoreEndpoints() map[string]*endpoint {
+	return map[string]*endpoint{
+		cmdstore.PutCommandMethod: {
+			Path:   opstore.PutPath,
+			Method: http.MethodPost,
+		},
+		cmdstore.GetCommandMethod: {
+			Path:   opstore.GetPath,
+			Method: http.MethodPost,
+		},
+		cmdstore.QueryCommandMethod: {
+			Path:   opstore.QueryPath,
+			Method: http.MethodPost,
+		},
+		cmdstore.QueryRecordsCommandMethod: {
+			Path:   opstore.QueryRecordsPath,
+			Method: http.MethodPost,
+		},
+		cmdstore.DeleteCommandMethod: {
+			Path:   opstore.DeletePath,
+			Method: http.MethodPost,
+		},
+		cmdstore.GetTagsCommandMethod: {
+			Path:   opstore.GetTagsPath,
+			Method: http.MethodPost,
+		},
+		cmdstore.GetBulkCommandMethod: {
+			Path:   opstore.GetBulkPath,
+			Method: http.MethodPost,
+		},
+		cmdstore.BatchCommandMethod: {
+			Path:   opstore.BatchPath,
+			Method: http.MethodPost,
+		},
+		cmdstore.FlushCommandMethod: {
+			Path:   opstore.FlushPath,
+			Method: http.MethodPost,
+		},
+		cmdstore.ListStoresCommandMethod: {
+			Path:   opstore.ListStoresPath,
+			Method: http.MethodPost,
+		},
+		cmdstore.IncrementCommandMethod: {
+			Path:   opstore.IncrementPath,
+			Method: http.MethodPost,
+		},
+		cmdstore.SweeperStatsCommandMethod: {
+			Path:   opstore.SweeperStatsPath,
+			Method: http.MethodPost,
+		},
+		cmdstore.ExportStoresCommandMethod: {
+			Path:   opstore.ExportStoresPath,
+			Method: http.MethodPost,
+		},
+		cmdstore.ImportStoresCommandMethod: {
+			Path:   opstore.ImportStoresPath,
+			Method: http.MethodPost,
+		},
+		cmdstore.SubscribeCommandMethod: {
+			Path:   opstore.SubscribePath,
+			Method: http.MethodPost,
+		},
+		cmdstore.UnsubscribeCommandMethod: {
+			Path:   opstore.UnsubscribePath,
+			Method: http.MethodPost,
+		},
+		cmdstore.ConfigureIndexesCommandMethod: {
+			Path:   opstore.ConfigureIndexesPath,
+			Method: http.MethodPost,
+		},
+		cmdstore.GetIndexesCommandMethod: {
+			Path:   opstore.GetIndexesPath,
+			Method: http.MethodPost,
+		},
+		cmdstore.RebuildIndexesCommandMethod: {
+			Path:   opstore.RebuildIndexesPath,
+			Method: http.MethodPost,
+		},
 	}
 }
 
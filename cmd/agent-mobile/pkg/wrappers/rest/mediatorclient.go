@@ -36,6 +36,41 @@ func (mc *MediatorClient) SendCreateConnectionRequest(request *models.RequestEnv
 	return mc.createRespEnvelope(request, mediatorclient.SendCreateConnectionRequest)
 }
 
+// GetOperation returns status and result of an asynchronous operation.
+func (mc *MediatorClient) GetOperation(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return mc.createRespEnvelope(request, mediatorclient.GetOperation)
+}
+
+// CancelOperation aborts an asynchronous operation which is still in progress.
+func (mc *MediatorClient) CancelOperation(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return mc.createRespEnvelope(request, mediatorclient.CancelOperation)
+}
+
+// QueryKeylist queries recipient keys registered with the router.
+func (mc *MediatorClient) QueryKeylist(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return mc.createRespEnvelope(request, mediatorclient.QueryKeylist)
+}
+
+// RemoveKeys removes recipient keys or all keys of a DID registered with the router.
+func (mc *MediatorClient) RemoveKeys(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return mc.createRespEnvelope(request, mediatorclient.RemoveKeys)
+}
+
+// DecodeInvitation decodes out-of-band invitation from invitation URL.
+func (mc *MediatorClient) DecodeInvitation(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return mc.createRespEnvelope(request, mediatorclient.DecodeInvitation)
+}
+
+// ListInvitations lists invitations created through mediator client along with their status.
+func (mc *MediatorClient) ListInvitations(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return mc.createRespEnvelope(request, mediatorclient.ListInvitations)
+}
+
+// RevokeInvitation revokes invitation created through mediator client.
+func (mc *MediatorClient) RevokeInvitation(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return mc.createRespEnvelope(request, mediatorclient.RevokeInvitation)
+}
+
 func (mc *MediatorClient) createRespEnvelope(request *models.RequestEnvelope,
 	endpoint string,
 ) *models.ResponseEnvelope {
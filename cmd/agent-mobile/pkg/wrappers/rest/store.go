@@ -0,0 +1,128 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package rest //nolint: dupl
+
+import (
+	"github.com/trustbloc/agent-sdk/cmd/agent-mobile/pkg/wrappers/models"
+	"github.com/trustbloc/agent-sdk/pkg/controller/command/store"
+)
+
+// Store contains necessary fields to support its operations.
+type Store struct {
+	httpClient httpClient
+	endpoints  map[string]*endpoint
+
+	URL   string
+	Token string
+}
+
+// Put stores the key, value and (optional) tags.
+func (s *Store) Put(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return s.createRespEnvelope(request, store.PutCommandMethod)
+}
+
+// Get fetches the record based on key.
+func (s *Store) Get(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return s.createRespEnvelope(request, store.GetCommandMethod)
+}
+
+// Query retrieves records matching given expression.
+func (s *Store) Query(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return s.createRespEnvelope(request, store.QueryCommandMethod)
+}
+
+// QueryRecords retrieves records matching given expression along with their keys, tags and versions.
+func (s *Store) QueryRecords(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return s.createRespEnvelope(request, store.QueryRecordsCommandMethod)
+}
+
+// Delete deletes a record with a given key.
+func (s *Store) Delete(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return s.createRespEnvelope(request, store.DeleteCommandMethod)
+}
+
+// GetTags fetches tags of the record based on key.
+func (s *Store) GetTags(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return s.createRespEnvelope(request, store.GetTagsCommandMethod)
+}
+
+// GetBulk fetches records based on keys.
+func (s *Store) GetBulk(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return s.createRespEnvelope(request, store.GetBulkCommandMethod)
+}
+
+// Batch performs put and delete operations atomically.
+func (s *Store) Batch(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return s.createRespEnvelope(request, store.BatchCommandMethod)
+}
+
+// Flush flushes data in all currently open stores.
+func (s *Store) Flush(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return s.createRespEnvelope(request, store.FlushCommandMethod)
+}
+
+// ListStores lists opened stores and names of stores allowed to be opened.
+func (s *Store) ListStores(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return s.createRespEnvelope(request, store.ListStoresCommandMethod)
+}
+
+// Increment atomically adds delta to the integer counter stored in the record.
+func (s *Store) Increment(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return s.createRespEnvelope(request, store.IncrementCommandMethod)
+}
+
+// SweeperStats returns statistics of the sweeper deleting expired records.
+func (s *Store) SweeperStats(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return s.createRespEnvelope(request, store.SweeperStatsCommandMethod)
+}
+
+// ExportStores exports records and configurations of stores into an encrypted archive.
+func (s *Store) ExportStores(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return s.createRespEnvelope(request, store.ExportStoresCommandMethod)
+}
+
+// ImportStores imports records and configurations of stores from an encrypted archive.
+func (s *Store) ImportStores(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return s.createRespEnvelope(request, store.ImportStoresCommandMethod)
+}
+
+// Subscribe subscribes to changes of records in the store.
+func (s *Store) Subscribe(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return s.createRespEnvelope(request, store.SubscribeCommandMethod)
+}
+
+// Unsubscribe cancels subscription to changes of records.
+func (s *Store) Unsubscribe(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return s.createRespEnvelope(request, store.UnsubscribeCommandMethod)
+}
+
+// ConfigureIndexes replaces JSON-path index definitions of the store.
+func (s *Store) ConfigureIndexes(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return s.createRespEnvelope(request, store.ConfigureIndexesCommandMethod)
+}
+
+// GetIndexes returns JSON-path index definitions of the store.
+func (s *Store) GetIndexes(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return s.createRespEnvelope(request, store.GetIndexesCommandMethod)
+}
+
+// RebuildIndexes updates index tags of existing records of the store.
+func (s *Store) RebuildIndexes(request *models.RequestEnvelope) *models.ResponseEnvelope {
+	return s.createRespEnvelope(request, store.RebuildIndexesCommandMethod)
+}
+
+func (s *Store) createRespEnvelope(request *models.RequestEnvelope,
+	endpoint string,
+) *models.ResponseEnvelope {
+	return exec(&restOperation{
+		url:        s.URL,
+		token:      s.Token,
+		httpClient: s.httpClient,
+		endpoint:   s.endpoints[endpoint],
+		request:    request,
+	})
+}
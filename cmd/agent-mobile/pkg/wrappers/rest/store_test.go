@@ -0,0 +1,150 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package rest //nolint:testpackage // uses internal implementation details
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/agent-sdk/cmd/agent-mobile/pkg/wrappers/models"
+	"github.com/trustbloc/agent-sdk/pkg/controller/rest/store"
+)
+
+func getStoreController(t *testing.T) *Store {
+	t.Helper()
+
+	a, err := getAgent()
+	require.NotNil(t, a)
+	require.NoError(t, err)
+
+	controller, err := a.GetStoreController()
+	require.NoError(t, err)
+	require.NotNil(t, controller)
+
+	s, ok := controller.(*Store)
+	require.Equal(t, ok, true)
+
+	return s
+}
+
+func TestStore(t *testing.T) {
+	controller := getStoreController(t)
+
+	tests := []struct {
+		name     string
+		path     string
+		request  string
+		response string
+		call     func(*models.RequestEnvelope) *models.ResponseEnvelope
+	}{
+		{
+			name: "put", path: store.PutPath, request: `{"key":"key","value":"dmFsdWU="}`, response: `{}`,
+			call: controller.Put,
+		},
+		{
+			name: "get", path: store.GetPath, request: `{"key":"key"}`, response: `{"result":"dmFsdWU="}`,
+			call: controller.Get,
+		},
+		{
+			name: "query", path: store.QueryPath, request: `{"expression":"tag","limit":1}`,
+			response: `{"results":["dmFsdWU="],"cursor":"abc"}`,
+			call:     controller.Query,
+		},
+		{
+			name: "query records", path: store.QueryRecordsPath, request: `{"expression":"tag","limit":1}`,
+			response: `{"records":[{"key":"key","value":"dmFsdWU=","tags":[{"name":"tag"}]}],"cursor":"abc"}`,
+			call:     controller.QueryRecords,
+		},
+		{
+			name: "delete", path: store.DeletePath, request: `{"key":"key"}`, response: `{}`,
+			call: controller.Delete,
+		},
+		{
+			name: "flush", path: store.FlushPath, request: `{}`, response: `{}`,
+			call: controller.Flush,
+		},
+		{
+			name: "list stores", path: store.ListStoresPath, request: `{}`,
+			response: `{"stores":["store"],"allowed":["store"]}`,
+			call:     controller.ListStores,
+		},
+		{
+			name: "sweeper stats", path: store.SweeperStatsPath, request: `{}`,
+			response: `{"enabled":true,"interval":"1m0s","runs":0,"expiredRecords":0,"lastRunExpiredRecords":0}`,
+			call:     controller.SweeperStats,
+		},
+		{
+			name: "export stores", path: store.ExportStoresPath, request: `{"storeNames":["store"],"passphrase":"secret"}`,
+			response: `{"archive":"archive"}`,
+			call:     controller.ExportStores,
+		},
+		{
+			name: "import stores", path: store.ImportStoresPath, request: `{"archive":"archive","passphrase":"secret"}`,
+			response: `{"stores":[]}`,
+			call:     controller.ImportStores,
+		},
+		{
+			name: "subscribe", path: store.SubscribePath, request: `{"expression":"tag"}`,
+			response: `{"id":"id","topic":"store-changes-store"}`,
+			call:     controller.Subscribe,
+		},
+		{
+			name: "unsubscribe", path: store.UnsubscribePath, request: `{"id":"id"}`, response: `{}`,
+			call: controller.Unsubscribe,
+		},
+		{
+			name: "configure indexes", path: store.ConfigureIndexesPath,
+			request: `{"indexes":[{"name":"type","path":"$.type"}]}`, response: `{}`,
+			call: controller.ConfigureIndexes,
+		},
+		{
+			name: "get indexes", path: store.GetIndexesPath, request: `{}`,
+			response: `{"indexes":[{"name":"type","path":"$.type"}]}`,
+			call:     controller.GetIndexes,
+		},
+		{
+			name: "rebuild indexes", path: store.RebuildIndexesPath, request: `{}`, response: `{"updated":1}`,
+			call: controller.RebuildIndexes,
+		},
+		{
+			name: "get tags", path: store.GetTagsPath, request: `{"key":"key"}`, response: `{"tags":[{"name":"tag"}]}`,
+			call: controller.GetTags,
+		},
+		{
+			name: "get bulk", path: store.GetBulkPath, request: `{"keys":["key"]}`, response: `{"results":["dmFsdWU="]}`,
+			call: controller.GetBulk,
+		},
+		{
+			name: "increment", path: store.IncrementPath, request: `{"key":"counter","delta":1}`,
+			response: `{"value":1,"version":"a4ayc_80_OGda4BO_1o_V0etpOqiLx1JwB5S3beHW0s"}`,
+			call:     controller.Increment,
+		},
+		{
+			name: "batch", path: store.BatchPath, request: `{"operations":[{"key":"key"}]}`, response: `{}`,
+			call: controller.Batch,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			controller.httpClient = &mockHTTPClient{
+				data:   tc.response,
+				method: http.MethodPost, url: mockAgentURL + tc.path,
+			}
+
+			resp := tc.call(&models.RequestEnvelope{Payload: []byte(tc.request)})
+
+			require.NotNil(t, resp)
+			require.Nil(t, resp.Error)
+			require.Equal(t, tc.response, string(resp.Payload))
+		})
+	}
+}
@@ -191,6 +191,50 @@ const (
 		" Possible values [true] [false]. Defaults to false if not set." +
 		" Alternatively, this can be set with the following environment variable: " + agentAutoAcceptEnvKey
 
+	// blinded router flag.
+	agentBlindedRouterFlagName  = "blinded-router"
+	agentBlindedRouterEnvKey    = "ARIESD_BLINDED_ROUTER"
+	agentBlindedRouterFlagUsage = "Respond to blinded routing requests from other agents." +
+		" Possible values [true] [false]. Defaults to false if not set." +
+		" Alternatively, this can be set with the following environment variable: " + agentBlindedRouterEnvKey
+
+	// allowed stores flag.
+	agentAllowedStoresFlagName  = "allowed-stores"
+	agentAllowedStoresEnvKey    = "ARIESD_ALLOWED_STORES"
+	agentAllowedStoresFlagUsage = "Names of stores, in addition to the default store, that clients are allowed to" +
+		" access through store API. A name ending with '*' allows all stores with given prefix." +
+		" This flag can be repeated, allowing for multiple stores." +
+		" Alternatively, this can be set with the following environment variable (in CSV format): " +
+		agentAllowedStoresEnvKey
+
+	// webhook allowed hosts flag.
+	agentWebhookAllowedHostsFlagName  = "webhook-allowed-hosts"
+	agentWebhookAllowedHostsEnvKey    = "ARIESD_WEBHOOK_ALLOWED_HOSTS"
+	agentWebhookAllowedHostsFlagUsage = "Hosts that webhook subscriptions are allowed to post to." +
+		" A host starting with '*.' allows all its subdomains." +
+		" Subscriptions may post to any host with public addresses if not set." +
+		" This flag can be repeated, allowing for multiple hosts." +
+		" Alternatively, this can be set with the following environment variable (in CSV format): " +
+		agentWebhookAllowedHostsEnvKey
+
+	// invitation URL hosts flag.
+	agentInvitationURLHostsFlagName  = "invitation-url-hosts"
+	agentInvitationURLHostsEnvKey    = "ARIESD_INVITATION_URL_HOSTS"
+	agentInvitationURLHostsFlagUsage = "Hosts whose shortened invitation URLs are resolved by mediator client." +
+		" A host starting with '*.' allows all its subdomains and '*' allows any host." +
+		" Hosts with private addresses are refused. Shortened invitation URLs are not resolved if not set." +
+		" This flag can be repeated, allowing for multiple hosts." +
+		" Alternatively, this can be set with the following environment variable (in CSV format): " +
+		agentInvitationURLHostsEnvKey
+
+	// disabled modules flag.
+	agentDisabledModulesFlagName  = "disabled-modules"
+	agentDisabledModulesEnvKey    = "ARIESD_DISABLED_MODULES"
+	agentDisabledModulesFlagUsage = "Names of controller modules whose APIs are not served," +
+		" e.g. blindedrouting or store. This flag can be repeated, allowing for multiple modules." +
+		" Alternatively, this can be set with the following environment variable (in CSV format): " +
+		agentDisabledModulesEnvKey
+
 	// transport return route option flag.
 	agentTransportReturnRouteFlagName  = "transport-return-route"
 	agentTransportReturnRouteEnvKey    = "ARIESD_TRANSPORT_RETURN_ROUTE"
@@ -265,6 +309,11 @@ type agentParameters struct {
 	inboundHostInternals, inboundHostExternals     []string
 	contextProviderURLs                            []string
 	autoAccept                                     bool
+	blindedRouter                                  bool
+	allowedStores                                  []string
+	webhookAllowedHosts                            []string
+	invitationURLHosts                             []string
+	disabledModules                                []string
 	msgHandler                                     command.MessageHandler
 	dbParam                                        *dbParam
 	keyType                                        string
@@ -379,6 +428,33 @@ func createStartCMD(server server) *cobra.Command { //nolint: funlen, gocyclo, g
 				return err
 			}
 
+			blindedRouter, err := getBlindedRouterValue(cmd)
+			if err != nil {
+				return err
+			}
+
+			allowedStores, err := getUserSetVars(cmd, agentAllowedStoresFlagName, agentAllowedStoresEnvKey, true)
+			if err != nil {
+				return err
+			}
+
+			webhookAllowedHosts, err := getUserSetVars(cmd, agentWebhookAllowedHostsFlagName,
+				agentWebhookAllowedHostsEnvKey, true)
+			if err != nil {
+				return err
+			}
+
+			invitationURLHosts, err := getUserSetVars(cmd, agentInvitationURLHostsFlagName,
+				agentInvitationURLHostsEnvKey, true)
+			if err != nil {
+				return err
+			}
+
+			disabledModules, err := getUserSetVars(cmd, agentDisabledModulesFlagName, agentDisabledModulesEnvKey, true)
+			if err != nil {
+				return err
+			}
+
 			webhookURLs, err := getUserSetVars(cmd, agentWebhookFlagName, agentWebhookEnvKey, true)
 			if err != nil {
 				return err
@@ -466,6 +542,11 @@ func createStartCMD(server server) *cobra.Command { //nolint: funlen, gocyclo, g
 				trustblocResolver:    trustblocResolver,
 				outboundTransports:   outboundTransports,
 				autoAccept:           autoAccept,
+				blindedRouter:        blindedRouter,
+				allowedStores:        allowedStores,
+				webhookAllowedHosts:  webhookAllowedHosts,
+				invitationURLHosts:   invitationURLHosts,
+				disabledModules:      disabledModules,
 				transportReturnRoute: transportReturnRoute,
 				contextProviderURLs:  contextProviderURLs,
 				tlsCertFile:          tlsCertFile,
@@ -533,6 +614,19 @@ func getAutoAcceptValue(cmd *cobra.Command) (bool, error) {
 	return strconv.ParseBool(v)
 }
 
+func getBlindedRouterValue(cmd *cobra.Command) (bool, error) {
+	v, err := getUserSetVar(cmd, agentBlindedRouterFlagName, agentBlindedRouterEnvKey, true)
+	if err != nil {
+		return false, err
+	}
+
+	if v == "" {
+		return false, nil
+	}
+
+	return strconv.ParseBool(v)
+}
+
 func getWebSocketReadLimit(cmd *cobra.Command) (int64, error) {
 	readLimitVal, err := getUserSetVar(cmd, agentWebSocketReadLimitFlagName,
 		agentWebSocketReadLimitEnvKey, true)
@@ -608,6 +702,21 @@ func createFlags(startCmd *cobra.Command) { //nolint: funlen
 	// auto accept flag
 	startCmd.Flags().StringP(agentAutoAcceptFlagName, "", "", agentAutoAcceptFlagUsage)
 
+	// blinded router flag
+	startCmd.Flags().StringP(agentBlindedRouterFlagName, "", "", agentBlindedRouterFlagUsage)
+
+	// allowed stores flag
+	startCmd.Flags().StringSliceP(agentAllowedStoresFlagName, "", []string{}, agentAllowedStoresFlagUsage)
+
+	// webhook allowed hosts flag
+	startCmd.Flags().StringSliceP(agentWebhookAllowedHostsFlagName, "", []string{}, agentWebhookAllowedHostsFlagUsage)
+
+	// invitation URL hosts flag
+	startCmd.Flags().StringSliceP(agentInvitationURLHostsFlagName, "", []string{}, agentInvitationURLHostsFlagUsage)
+
+	// disabled modules flag
+	startCmd.Flags().StringSliceP(agentDisabledModulesFlagName, "", []string{}, agentDisabledModulesFlagUsage)
+
 	// transport return route option flag
 	startCmd.Flags().StringP(agentTransportReturnRouteFlagName, "", "", agentTransportReturnRouteFlagUsage)
 
@@ -883,7 +992,12 @@ func startAgent(parameters *agentParameters) error {
 	}
 
 	sdkHandlers, err := sdkcontroller.GetRESTHandlers(ctx, sdkcontroller.WithBlocDomain(parameters.trustblocDomain),
-		sdkcontroller.WithMessageHandler(parameters.msgHandler))
+		sdkcontroller.WithMessageHandler(parameters.msgHandler),
+		sdkcontroller.WithBlindedRouter(parameters.blindedRouter),
+		sdkcontroller.WithAllowedStores(parameters.allowedStores...),
+		sdkcontroller.WithWebhookAllowedHosts(parameters.webhookAllowedHosts...),
+		sdkcontroller.WithInvitationURLHosts(parameters.invitationURLHosts...),
+		sdkcontroller.WithDisabledModules(parameters.disabledModules...))
 	if err != nil {
 		return fmt.Errorf("failed to start sdk agent rest on port [%s], failed to get rest service api:  %w",
 			parameters.host, err)
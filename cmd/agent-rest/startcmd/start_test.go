@@ -546,6 +546,25 @@ func TestStartCmdInvalidSyntax(t *testing.T) {
 	require.Contains(t, err.Error(), "parsing \"oops\": invalid syntax")
 }
 
+func TestStartCmdInvalidBlindedRouter(t *testing.T) {
+	startCmd, err := Cmd(&mockServer{})
+	require.NoError(t, err)
+
+	args := []string{
+		"--" + agentHostFlagName,
+		randomURL(t),
+		"--" + databaseTypeFlagName,
+		databaseTypeMemOption,
+		"--" + agentBlindedRouterFlagName,
+		"oops",
+	}
+	startCmd.SetArgs(args)
+
+	err = startCmd.Execute()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "parsing \"oops\": invalid syntax")
+}
+
 func TestStartCmdWithInvalidReadLimit(t *testing.T) {
 	startCmd, err := Cmd(&mockServer{})
 	require.NoError(t, err)
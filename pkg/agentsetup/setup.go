@@ -122,6 +122,8 @@ type AgentStartOpts struct {
 	GNAPAccessToken          string      `json:"gnap-access-token"`
 	GNAPUserSubject          string      `json:"gnap-user-subject"`
 	ValidateDataModel        bool        `json:"validate-data-model"`
+	AllowedStores            []string    `json:"allowed-stores"`
+	DisabledModules          []string    `json:"disabled-modules"`
 }
 
 type UserConfig struct {
@@ -11,6 +11,7 @@ package blindedrouting
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"time"
@@ -21,15 +22,15 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
 	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
 	"github.com/hyperledger/aries-framework-go/spi/storage"
 	"github.com/trustbloc/edge-core/pkg/log"
 
 	"github.com/trustbloc/agent-sdk/pkg/controller/command"
 	"github.com/trustbloc/agent-sdk/pkg/controller/internal/cmdutil"
-	"github.com/trustbloc/agent-sdk/pkg/controller/internal/logutil"
 )
 
-var logger = log.New("agent-sdk-mediatorclient")
+var logger = log.New("agent-sdk-blindedrouting")
 
 const (
 	// CommandName package command name.
@@ -38,23 +39,37 @@ const (
 	SendDIDDocRequest = "SendDIDDocRequest"
 	// SendRegisterRouteRequest command name.
 	SendRegisterRouteRequest = "SendRegisterRouteRequest"
+	// ListRoutes command name.
+	ListRoutes = "ListRoutes"
+	// GetRoute command name.
+	GetRoute = "GetRoute"
+	// RemoveRoute command name.
+	RemoveRoute = "RemoveRoute"
+	// EstablishBlindedRoute command name.
+	EstablishBlindedRoute = "EstablishBlindedRoute"
 )
 
 const (
 	// InvalidRequestErrorCode is typically a code for validation errors.
-	InvalidRequestErrorCode = ariescmd.Code(iota + command.MediatorClient)
+	InvalidRequestErrorCode = ariescmd.Code(iota + command.BlindedRouting)
 
 	// SendDIDDocRequestError is typically a code for send did doc request command errors.
 	SendDIDDocRequestError
 	// SendRegisterRouteRequestError is typically a code for send register route request command errors.
 	SendRegisterRouteRequestError
+	// ListRoutesError is typically a code for list routes command errors.
+	ListRoutesError
+	// GetRouteError is typically a code for get route command errors.
+	GetRouteError
+	// RemoveRouteError is typically a code for remove route command errors.
+	RemoveRouteError
+	// EstablishBlindedRouteError is typically a code for establish blinded route command errors.
+	EstablishBlindedRouteError
 
 	// errors.
 	errInvalidConnectionID = "invalid connection ID"
 	errInvalidMessageID    = "invalid message ID"
-
-	// log constants.
-	successString = "success"
+	errInvalidRouteID      = "invalid route ID"
 
 	// timeout constants.
 	sendMsgTimeOut = 20 * time.Second
@@ -64,10 +79,18 @@ const (
 	didDocResponseMsgType        = "https://trustbloc.dev/blinded-routing/1.0/diddoc-resp"
 	registerRouteRequestMsgType  = "https://trustbloc.dev/blinded-routing/1.0/register-route-req"
 	registerRouteResponseMsgType = "https://trustbloc.dev/blinded-routing/1.0/register-route-resp"
+
+	// DIDComm V2 message types.
+	didDocRequestMsgTypeV2         = "https://trustbloc.dev/blinded-routing/2.0/diddoc-req"
+	didDocResponseMsgTypeV2        = "https://trustbloc.dev/blinded-routing/2.0/diddoc-resp"
+	registerRouteRequestMsgTypeV2  = "https://trustbloc.dev/blinded-routing/2.0/register-route-req"
+	registerRouteResponseMsgTypeV2 = "https://trustbloc.dev/blinded-routing/2.0/register-route-resp"
 )
 
 // Provider describes dependencies for this command.
 type Provider interface {
+	Service(id string) (interface{}, error)
+	ServiceEndpoint() string
 	VDRegistry() vdr.Registry
 	Messenger() service.Messenger
 	ProtocolStateStorageProvider() storage.Provider
@@ -77,18 +100,75 @@ type Provider interface {
 
 // Command is controller command for blinded routing.
 type Command struct {
-	messenger *messaging.Client
+	messenger   *messaging.Client
+	connections *connection.Lookup
+	routes      *routeStore
+	notifier    ariescmd.Notifier
+	vdrRegistry vdr.Registry
+	kms         kms.KeyManager
+}
+
+type options struct {
+	router bool
+}
+
+// Opt represents a blinded routing command option.
+type Opt func(opts *options)
+
+// WithRouter enables responding to blinded routing requests from other agents,
+// allowing this agent to act as a blinded router.
+func WithRouter(enable bool) Opt {
+	return func(opts *options) {
+		opts.router = enable
+	}
 }
 
 // New returns new blinded routing controller command instance.
-func New(p Provider, msgHandler ariescmd.MessageHandler, notifier ariescmd.Notifier) (*Command, error) {
+func New(p Provider, msgHandler ariescmd.MessageHandler, notifier ariescmd.Notifier, opts ...Opt) (*Command, error) {
+	if err := command.RegisterErrors(Errors()...); err != nil {
+		return nil, err
+	}
+
+	cmdOpts := &options{}
+
+	for _, opt := range opts {
+		opt(cmdOpts)
+	}
+
 	messengerClient, err := messaging.New(p, msgHandler, notifier)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create messenger client : %w", err)
 	}
 
+	connections, err := connection.NewLookup(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection lookup : %w", err)
+	}
+
+	routes, err := newRouteStore(p.StorageProvider())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create route store : %w", err)
+	}
+
+	if cmdOpts.router {
+		r, e := newRouter(p)
+		if e != nil {
+			return nil, fmt.Errorf("failed to create blinded router : %w", e)
+		}
+
+		err = msgHandler.Register(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to register blinded router : %w", err)
+		}
+	}
+
 	return &Command{
-		messenger: messengerClient,
+		messenger:   messengerClient,
+		connections: connections,
+		routes:      routes,
+		notifier:    notifier,
+		vdrRegistry: p.VDRegistry(),
+		kms:         p.KMS(),
 	}, nil
 }
 
@@ -97,6 +177,57 @@ func (c *Command) GetHandlers() []ariescmd.Handler {
 	return []ariescmd.Handler{
 		cmdutil.NewCommandHandler(CommandName, SendDIDDocRequest, c.SendDIDDocRequest),
 		cmdutil.NewCommandHandler(CommandName, SendRegisterRouteRequest, c.SendRegisterRouteRequest),
+		cmdutil.NewCommandHandler(CommandName, ListRoutes, c.ListRoutes),
+		cmdutil.NewCommandHandler(CommandName, GetRoute, c.GetRoute),
+		cmdutil.NewCommandHandler(CommandName, RemoveRoute, c.RemoveRoute),
+		cmdutil.NewCommandHandler(CommandName, EstablishBlindedRoute, c.EstablishBlindedRoute),
+	}
+}
+
+// Schemas returns JSON schemas of request and response models of blinded routing command methods.
+func Schemas() []command.MethodSchema {
+	return []command.MethodSchema{
+		command.NewMethodSchema(CommandName, SendDIDDocRequest, &DIDDocRequest{}, &DIDDocResponse{}),
+		command.NewMethodSchema(CommandName, SendRegisterRouteRequest, &RegisterRouteRequest{}, &RegisterRouteResponse{}),
+		command.NewMethodSchema(CommandName, ListRoutes, &ListRoutesRequest{}, &ListRoutesResponse{}),
+		command.NewMethodSchema(CommandName, GetRoute, &GetRouteRequest{}, &GetRouteResponse{}),
+		command.NewMethodSchema(CommandName, RemoveRoute, &RemoveRouteRequest{}, nil),
+		command.NewMethodSchema(CommandName, EstablishBlindedRoute, &EstablishBlindedRouteRequest{},
+			&EstablishBlindedRouteResponse{}),
+	}
+}
+
+// Errors returns definitions of blinded routing error codes for the error catalog.
+func Errors() []command.ErrorDefinition {
+	return []command.ErrorDefinition{
+		{
+			Code: InvalidRequestErrorCode, Name: "BLINDEDROUTING_INVALID_REQUEST", Command: CommandName,
+			Description: "invalid request",
+		},
+		{
+			Code: SendDIDDocRequestError, Name: "BLINDEDROUTING_DIDDOC_REQUEST_FAILED", Command: CommandName,
+			Description: "failed to send DID document request", Retryable: true,
+		},
+		{
+			Code: SendRegisterRouteRequestError, Name: "BLINDEDROUTING_REGISTER_ROUTE_FAILED", Command: CommandName,
+			Description: "failed to send register route request", Retryable: true,
+		},
+		{
+			Code: ListRoutesError, Name: "BLINDEDROUTING_LIST_ROUTES_FAILED", Command: CommandName,
+			Description: "failed to list routes",
+		},
+		{
+			Code: GetRouteError, Name: "BLINDEDROUTING_GET_ROUTE_FAILED", Command: CommandName,
+			Description: "failed to get route",
+		},
+		{
+			Code: RemoveRouteError, Name: "BLINDEDROUTING_REMOVE_ROUTE_FAILED", Command: CommandName,
+			Description: "failed to remove route",
+		},
+		{
+			Code: EstablishBlindedRouteError, Name: "BLINDEDROUTING_ESTABLISH_ROUTE_FAILED", Command: CommandName,
+			Description: "failed to establish blinded route", Retryable: true,
+		},
 	}
 }
 
@@ -104,83 +235,370 @@ func (c *Command) GetHandlers() []ariescmd.Handler {
 func (c *Command) SendDIDDocRequest(rw io.Writer, req io.Reader) ariescmd.Error {
 	var request DIDDocRequest
 
-	err := json.NewDecoder(req).Decode(&request)
+	err := command.DecodeRequest(req, &request)
 	if err != nil {
-		logutil.LogError(logger, CommandName, SendDIDDocRequest, err.Error())
-
-		return ariescmd.NewValidationError(InvalidRequestErrorCode, err)
+		return command.NewValidationError(InvalidRequestErrorCode, err)
 	}
 
 	if request.ConnectionID == "" {
-		logutil.LogError(logger, CommandName, SendDIDDocRequest, errInvalidConnectionID)
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errInvalidConnectionID))
+	}
 
-		return ariescmd.NewValidationError(SendDIDDocRequestError, fmt.Errorf(errInvalidConnectionID))
+	resMsg, err := c.sendDIDDocRequest(newRoute(request.ConnectionID))
+	if err != nil {
+		return command.NewExecuteError(SendDIDDocRequestError, err)
 	}
 
-	msgStr := fmt.Sprintf(`{"@id":%q,"@type":%q}`, uuid.New().String(), didDocRequestMsgType)
+	command.WriteNillableResponse(rw, &DIDDocResponse{resMsg}, logger)
 
-	ctx, cancel := context.WithTimeout(context.Background(), sendMsgTimeOut)
-	defer cancel()
+	return nil
+}
+
+// SendRegisterRouteRequest sends register route request as a response to reply from send DID doc request.
+func (c *Command) SendRegisterRouteRequest(rw io.Writer, req io.Reader) ariescmd.Error {
+	var request RegisterRouteRequest
 
-	resMsg, err := c.messenger.Send(json.RawMessage([]byte(msgStr)),
-		messaging.SendByConnectionID(request.ConnectionID),
-		messaging.WaitForResponse(ctx, didDocResponseMsgType))
+	err := command.DecodeRequest(req, &request)
 	if err != nil {
-		logutil.LogError(logger, CommandName, SendDIDDocRequest, err.Error())
+		return command.NewValidationError(InvalidRequestErrorCode, err)
+	}
 
-		return ariescmd.NewExecuteError(SendDIDDocRequestError, err)
+	if request.MessageID == "" {
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errInvalidMessageID))
 	}
 
-	command.WriteNillableResponse(rw, &DIDDocResponse{resMsg}, logger)
+	route, err := c.routeForDIDDocResponse(request.MessageID)
+	if err != nil {
+		return command.NewExecuteError(SendRegisterRouteRequestError, err)
+	}
+
+	res, err := c.sendRegisterRouteRequest(route, request.DIDDocument)
+	if err != nil {
+		return command.NewExecuteError(SendRegisterRouteRequestError, err)
+	}
 
-	logutil.LogDebug(logger, CommandName, SendDIDDocRequest, successString)
+	command.WriteNillableResponse(rw, &RegisterRouteResponse{res}, logger)
 
 	return nil
 }
 
-// SendRegisterRouteRequest sends register route request as a response to reply from send DID doc request.
-func (c *Command) SendRegisterRouteRequest(rw io.Writer, req io.Reader) ariescmd.Error {
-	var request RegisterRouteRequest
+// ListRoutes lists persisted blinded routing exchanges with routers.
+func (c *Command) ListRoutes(rw io.Writer, req io.Reader) ariescmd.Error {
+	var request ListRoutesRequest
+
+	err := command.DecodeRequest(req, &request)
+	if err != nil {
+		return command.NewValidationError(InvalidRequestErrorCode, err)
+	}
 
-	err := json.NewDecoder(req).Decode(&request)
+	records, err := c.routes.list(request.ConnectionID)
 	if err != nil {
-		logutil.LogError(logger, CommandName, SendRegisterRouteRequest, err.Error())
+		return command.NewExecuteError(ListRoutesError, err)
+	}
 
-		return ariescmd.NewValidationError(InvalidRequestErrorCode, err)
+	response := &ListRoutesResponse{Routes: []*RouteRecord{}}
+
+	for _, record := range records {
+		if request.Status == "" || request.Status == record.Status {
+			response.Routes = append(response.Routes, record)
+		}
 	}
 
-	if request.MessageID == "" {
-		logutil.LogError(logger, CommandName, SendRegisterRouteRequest, errInvalidMessageID)
+	command.WriteNillableResponse(rw, response, logger)
+
+	return nil
+}
+
+// GetRoute returns persisted blinded routing exchange with a router.
+func (c *Command) GetRoute(rw io.Writer, req io.Reader) ariescmd.Error {
+	var request GetRouteRequest
 
-		return ariescmd.NewValidationError(SendRegisterRouteRequestError, fmt.Errorf(errInvalidMessageID))
+	err := command.DecodeRequest(req, &request)
+	if err != nil {
+		return command.NewValidationError(InvalidRequestErrorCode, err)
 	}
 
-	msgBytes, err := json.Marshal(map[string]interface{}{
-		"@id":   uuid.New().String(),
-		"@type": registerRouteRequestMsgType,
-		"data": map[string]interface{}{
-			"didDoc": request.DIDDocument,
-		},
-	})
+	if request.ID == "" {
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errInvalidRouteID))
+	}
+
+	record, err := c.routes.get(request.ID)
+	if err != nil {
+		return command.NewExecuteError(GetRouteError, err)
+	}
+
+	command.WriteNillableResponse(rw, &GetRouteResponse{Route: record}, logger)
+
+	return nil
+}
+
+// RemoveRoute removes persisted blinded routing exchange with a router.
+// Route registered with router is not affected.
+func (c *Command) RemoveRoute(rw io.Writer, req io.Reader) ariescmd.Error {
+	var request RemoveRouteRequest
+
+	err := command.DecodeRequest(req, &request)
+	if err != nil {
+		return command.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if request.ID == "" {
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errInvalidRouteID))
+	}
+
+	err = c.routes.remove(request.ID)
+	if err != nil {
+		return command.NewExecuteError(RemoveRouteError, err)
+	}
+
+	command.WriteNillableResponse(rw, nil, logger)
+
+	return nil
+}
+
+// sendDIDDocRequest sends DID doc request of given route to router and records the response.
+func (c *Command) sendDIDDocRequest(route *RouteRecord) (json.RawMessage, error) {
+	version, err := c.didCommVersion(route.ConnectionID)
+	if err != nil {
+		c.updateRoute(route, RouteStatusFailed, err.Error())
+
+		return nil, err
+	}
+
+	msg := newRoutingMsg(version, didDocRequestMsgType, nil)
+
+	msgBytes, err := json.Marshal(msg)
 	if err != nil {
-		logutil.LogError(logger, CommandName, SendRegisterRouteRequest, err.Error())
+		return nil, err
+	}
+
+	route.Status = RouteStatusRequested
+	route.ErrorMsg = ""
+	route.DIDCommVersion = version
+	route.DIDDocRequestID = msg.ID()
+	route.UpdatedAt = time.Now().UTC()
 
-		return ariescmd.NewValidationError(SendRegisterRouteRequestError, err)
+	err = c.saveRoute(route)
+	if err != nil {
+		return nil, err
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), sendMsgTimeOut)
 	defer cancel()
 
-	res, err := c.messenger.Reply(ctx, msgBytes, request.MessageID, true, registerRouteResponseMsgType)
+	resMsg, err := c.messenger.Send(json.RawMessage(msgBytes),
+		messaging.SendByConnectionID(route.ConnectionID),
+		messaging.WaitForResponse(ctx, versionedMsgType(version, didDocResponseMsgType)))
 	if err != nil {
-		logutil.LogError(logger, CommandName, SendRegisterRouteRequest, err.Error())
+		c.updateRoute(route, RouteStatusFailed, err.Error())
 
-		return ariescmd.NewExecuteError(SendRegisterRouteRequestError, err)
+		return nil, err
 	}
 
-	command.WriteNillableResponse(rw, &RegisterRouteResponse{res}, logger)
+	c.didDocReceived(route, resMsg)
+
+	return resMsg, nil
+}
+
+// sendRegisterRouteRequest sends register route request of given route to router in reply to
+// DID doc response of the route and records the response.
+func (c *Command) sendRegisterRouteRequest(route *RouteRecord, didDoc json.RawMessage) (json.RawMessage, error) {
+	msg := newRoutingMsg(route.DIDCommVersion, registerRouteRequestMsgType, &routingMsgData{DIDDoc: didDoc})
+
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	route.Status = RouteStatusRegistering
+	route.ErrorMsg = ""
+	route.RegisterRouteRequestID = msg.ID()
+	route.RegisteredDIDDoc = didDoc
+	route.UpdatedAt = time.Now().UTC()
+
+	err = c.saveRoute(route)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendMsgTimeOut)
+	defer cancel()
+
+	res, err := c.messenger.Reply(ctx, msgBytes, route.DIDDocResponseID, true,
+		versionedMsgType(route.DIDCommVersion, registerRouteResponseMsgType))
+	if err != nil {
+		c.updateRoute(route, RouteStatusFailed, err.Error())
+
+		return nil, err
+	}
 
-	logutil.LogDebug(logger, CommandName, SendRegisterRouteRequest, successString)
+	c.routeRegistered(route, res)
+
+	return res, nil
+}
+
+// EstablishBlindedRoute establishes blinded route over a connection to router: requests router DID document,
+// creates peer DID routed through router and registers it with router. Progress is published on RouteTopic.
+// Interrupted exchange can be resumed by providing ID of its route, failed exchange is restarted from a fresh
+// DID doc request.
+func (c *Command) EstablishBlindedRoute(rw io.Writer, req io.Reader) ariescmd.Error {
+	var request EstablishBlindedRouteRequest
+
+	err := command.DecodeRequest(req, &request)
+	if err != nil {
+		return command.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	var route *RouteRecord
+
+	switch {
+	case request.RouteID != "":
+		route, err = c.routes.get(request.RouteID)
+		if err != nil {
+			return command.NewExecuteError(EstablishBlindedRouteError, fmt.Errorf("failed to get route: %w", err))
+		}
+	case request.ConnectionID != "":
+		route = newRoute(request.ConnectionID)
+	default:
+		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errInvalidConnectionID))
+	}
+
+	err = c.establishRoute(route)
+	if err != nil {
+		return command.NewExecuteError(EstablishBlindedRouteError, err)
+	}
+
+	command.WriteNillableResponse(rw, &EstablishBlindedRouteResponse{Route: route}, logger)
 
 	return nil
 }
+
+// didDocReceived records DID doc response received from router.
+func (c *Command) didDocReceived(route *RouteRecord, resMsg json.RawMessage) {
+	msg, err := parseRoutingMsg(resMsg)
+	if err != nil {
+		c.updateRoute(route, RouteStatusFailed, fmt.Sprintf("invalid DID doc response: %s", err))
+
+		return
+	}
+
+	route.DIDDocResponseID = msg.ID
+
+	switch {
+	case msg.Data == nil || len(msg.Data.DIDDoc) == 0:
+		errMsg := "DID document missing in DID doc response"
+		if msg.Data != nil && msg.Data.ErrorMsg != "" {
+			errMsg = msg.Data.ErrorMsg
+		}
+
+		c.updateRoute(route, RouteStatusFailed, errMsg)
+	default:
+		route.RouterDIDDoc = msg.Data.DIDDoc
+
+		c.updateRoute(route, RouteStatusDIDDocReceived, "")
+	}
+}
+
+// routeForDIDDocResponse returns route of the exchange in which router responded with given DID doc response.
+// Exchanges started outside of this command are recorded from the register route request on.
+func (c *Command) routeForDIDDocResponse(didDocResponseID string) (*RouteRecord, error) {
+	route, err := c.routes.getByDIDDocResponseID(didDocResponseID)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		route = newRoute("")
+		route.DIDDocResponseID = didDocResponseID
+
+		return route, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get route: %w", err)
+	}
+
+	return route, nil
+}
+
+// routeRegistered records register route response received from router.
+func (c *Command) routeRegistered(route *RouteRecord, resMsg json.RawMessage) {
+	msg, err := parseRoutingMsg(resMsg)
+	if err != nil {
+		c.updateRoute(route, RouteStatusFailed, fmt.Sprintf("invalid register route response: %s", err))
+
+		return
+	}
+
+	route.RegisterRouteResponseID = msg.ID
+
+	if msg.Data != nil && msg.Data.ErrorMsg != "" {
+		c.updateRoute(route, RouteStatusFailed, msg.Data.ErrorMsg)
+
+		return
+	}
+
+	c.updateRoute(route, RouteStatusRegistered, "")
+}
+
+// didCommVersion returns DIDComm version of given connection, blinded routing messages are exchanged in its format.
+func (c *Command) didCommVersion(connID string) (service.Version, error) {
+	record, err := c.connections.GetConnectionRecord(connID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get connection %s: %w", connID, err)
+	}
+
+	if record.DIDCommVersion == "" {
+		return service.V1, nil
+	}
+
+	return record.DIDCommVersion, nil
+}
+
+// updateRoute persists new status of given route. Since messages were already exchanged with router,
+// failures are only logged.
+func (c *Command) updateRoute(route *RouteRecord, status, errMsg string) {
+	route.Status = status
+	route.ErrorMsg = errMsg
+	route.UpdatedAt = time.Now().UTC()
+
+	if err := c.saveRoute(route); err != nil {
+		logger.Warnf("%s", err)
+	}
+}
+
+// saveRoute persists given route and publishes its progress.
+func (c *Command) saveRoute(route *RouteRecord) error {
+	err := c.routes.save(route)
+	if err != nil {
+		return fmt.Errorf("failed to save route %s: %w", route.ID, err)
+	}
+
+	c.notifyRoute(route)
+
+	return nil
+}
+
+func (c *Command) notifyRoute(route *RouteRecord) {
+	if c.notifier == nil {
+		return
+	}
+
+	msg, err := json.Marshal(route)
+	if err != nil {
+		logger.Warnf("failed to marshal route notification: %s", err)
+
+		return
+	}
+
+	if err = c.notifier.Notify(RouteTopic, msg); err != nil {
+		logger.Warnf("failed to publish route notification: %s", err)
+	}
+}
+
+func newRoute(connID string) *RouteRecord {
+	now := time.Now().UTC()
+
+	return &RouteRecord{
+		ID:           uuid.New().String(),
+		ConnectionID: connID,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
@@ -11,12 +11,23 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	mediatorsvc "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/mediator"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdrapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
 	mockmsghandler "github.com/hyperledger/aries-framework-go/pkg/mock/didcomm/msghandler"
+	mockroute "github.com/hyperledger/aries-framework-go/pkg/mock/didcomm/protocol/mediator"
+	mocksvc "github.com/hyperledger/aries-framework-go/pkg/mock/didcomm/service"
+	mockkms "github.com/hyperledger/aries-framework-go/pkg/mock/kms"
 	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	mockvdr "github.com/hyperledger/aries-framework-go/pkg/mock/vdr"
 	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
 	"github.com/stretchr/testify/require"
 
 	"github.com/trustbloc/agent-sdk/pkg/controller/internal/mocks"
@@ -31,7 +42,7 @@ func TestNew(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, c)
 		require.NotEmpty(t, c.GetHandlers())
-		require.Len(t, c.GetHandlers(), 2)
+		require.Len(t, c.GetHandlers(), 6)
 	})
 
 	t.Run("test failure while creating messaging client", func(t *testing.T) {
@@ -45,6 +56,48 @@ func TestNew(t *testing.T) {
 		require.Nil(t, c)
 		require.Contains(t, err.Error(), "failed to create messenger client")
 	})
+
+	t.Run("test failure while opening route store", func(t *testing.T) {
+		prov := newMockProvider()
+		prov.StoreProvider = &mockstorage.MockStoreProvider{
+			Store:         &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)},
+			FailNamespace: routeStoreName,
+		}
+
+		c, err := New(prov, mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.Error(t, err)
+		require.Nil(t, c)
+		require.Contains(t, err.Error(), "failed to create route store")
+	})
+
+	t.Run("test success with router", func(t *testing.T) {
+		registrar := mockmsghandler.NewMockMsgServiceProvider()
+
+		c, err := New(newMockRouterProvider(), registrar, mocks.NewMockNotifier(), WithRouter(true))
+		require.NoError(t, err)
+		require.NotNil(t, c)
+		require.Len(t, registrar.Services(), 1)
+		require.Equal(t, RouterServiceName, registrar.Services()[0].Name())
+		require.True(t, registrar.Services()[0].Accept(didDocRequestMsgType, nil))
+		require.True(t, registrar.Services()[0].Accept(registerRouteRequestMsgType, nil))
+		require.False(t, registrar.Services()[0].Accept(didDocResponseMsgType, nil))
+	})
+
+	t.Run("test failure while creating router", func(t *testing.T) {
+		c, err := New(newMockProvider(), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier(),
+			WithRouter(true))
+		require.Error(t, err)
+		require.Nil(t, c)
+		require.Contains(t, err.Error(), "failed to create blinded router")
+	})
+
+	t.Run("test failure while registering router", func(t *testing.T) {
+		c, err := New(newMockRouterProvider(), &mockmsghandler.MockMsgSvcProvider{RegisterErr: fmt.Errorf(sampleErr)},
+			mocks.NewMockNotifier(), WithRouter(true))
+		require.Error(t, err)
+		require.Nil(t, c)
+		require.Contains(t, err.Error(), "failed to register blinded router")
+	})
 }
 
 func TestCommand_SendDIDDocRequest(t *testing.T) {
@@ -53,9 +106,10 @@ func TestCommand_SendDIDDocRequest(t *testing.T) {
 		require.NoError(t, err)
 
 		var b bytes.Buffer
-		err = c.SendDIDDocRequest(&b, bytes.NewBufferString(`{}`))
-		require.Error(t, err)
-		require.Equal(t, err.Error(), errInvalidConnectionID)
+		cmdErr := c.SendDIDDocRequest(&b, bytes.NewBufferString(`{}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+		require.Equal(t, cmdErr.Error(), errInvalidConnectionID)
 	})
 
 	t.Run("test send error", func(t *testing.T) {
@@ -136,9 +190,10 @@ func TestCommand_SendRegisterRouteRequest(t *testing.T) {
 		require.NoError(t, err)
 
 		var b bytes.Buffer
-		err = c.SendRegisterRouteRequest(&b, bytes.NewBufferString(`{}`))
-		require.Error(t, err)
-		require.Equal(t, err.Error(), errInvalidMessageID)
+		cmdErr := c.SendRegisterRouteRequest(&b, bytes.NewBufferString(`{}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+		require.Equal(t, cmdErr.Error(), errInvalidMessageID)
 	})
 
 	t.Run("test invalid request", func(t *testing.T) {
@@ -162,7 +217,7 @@ func TestCommand_SendRegisterRouteRequest(t *testing.T) {
 
 		var b bytes.Buffer
 		err = c.SendRegisterRouteRequest(&b,
-			bytes.NewBufferString(`{"messageID":"sample-msg-01", "data": {"didDoc": {"@id": "sample-did-id"}}}`))
+			bytes.NewBufferString(`{"messageID":"sample-msg-01", "didDoc": {"@id": "sample-did-id"}}`))
 		require.Error(t, err)
 		require.Contains(t, err.Error(), sampleErr)
 	})
@@ -201,12 +256,1244 @@ func TestCommand_SendRegisterRouteRequest(t *testing.T) {
 
 		var b bytes.Buffer
 		err = c.SendRegisterRouteRequest(&b,
-			bytes.NewBufferString(`{"messageID":"sample-msg-01", "data": {"didDoc": {"@id": "sample-did-id"}}}`))
+			bytes.NewBufferString(`{"messageID":"sample-msg-01", "didDoc": {"@id": "sample-did-id"}}`))
 		require.NoError(t, err)
 		require.NotEmpty(t, b.Bytes())
 	})
 }
 
+func TestCommand_Routes(t *testing.T) {
+	const (
+		didDocReplyStr = `{
+			"@id": "diddoc-resp-01",
+			"@type": "https://trustbloc.dev/blinded-routing/1.0/diddoc-resp",
+			"~thread" : {"thid": "%s"},
+			"data": {"didDoc": {"id": "did:peer:router"}}
+		}`
+		registerReplyStr = `{
+			"@id": "register-route-resp-01",
+			"@type": "https://trustbloc.dev/blinded-routing/1.0/register-route-resp",
+			"~thread" : {"thid": "%s"},
+			"data": {}
+		}`
+	)
+
+	newCommand := func(t *testing.T) (*Command, *sdkmockprotocol.MockMessenger,
+		*mockmsghandler.MockMsgSvcProvider,
+	) {
+		t.Helper()
+
+		prov := newMockProvider()
+
+		connBytes, err := json.Marshal(&connection.Record{
+			ConnectionID: "sample-conn-01",
+			State:        "completed", MyDID: "mydid", TheirDID: "theirDID-001",
+		})
+		require.NoError(t, err)
+
+		mockStore := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
+		require.NoError(t, mockStore.Put("conn_sample-conn-01", connBytes))
+
+		connBytes, err = json.Marshal(&connection.Record{
+			ConnectionID: "sample-conn-v2",
+			State:        "completed", MyDID: "mydid", TheirDID: "theirDID-002",
+			DIDCommVersion: service.V2,
+		})
+		require.NoError(t, err)
+		require.NoError(t, mockStore.Put("conn_sample-conn-v2", connBytes))
+
+		prov.StoreProvider = mockstorage.NewCustomMockStoreProvider(mockStore)
+
+		registrar := mockmsghandler.NewMockMsgServiceProvider()
+		mockMessenger := sdkmockprotocol.NewMockMessenger()
+		prov.CustomMessenger = mockMessenger
+
+		c, err := New(prov, registrar, mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		return c, mockMessenger, registrar
+	}
+
+	listRoutes := func(t *testing.T, c *Command, request string) []*RouteRecord {
+		t.Helper()
+
+		var b bytes.Buffer
+		require.NoError(t, c.ListRoutes(&b, bytes.NewBufferString(request)))
+
+		var response ListRoutesResponse
+		require.NoError(t, json.Unmarshal(b.Bytes(), &response))
+
+		return response.Routes
+	}
+
+	t.Run("test routes persisted", func(t *testing.T) {
+		c, mockMessenger, registrar := newCommand(t)
+
+		go replyFromRouter(t, registrar, mockMessenger, "", didDocReplyStr)
+
+		var b bytes.Buffer
+		err := c.SendDIDDocRequest(&b, bytes.NewBufferString(`{"connectionID":"sample-conn-01"}`))
+		require.NoError(t, err)
+
+		routes := listRoutes(t, c, `{}`)
+		require.Len(t, routes, 1)
+		require.Equal(t, "sample-conn-01", routes[0].ConnectionID)
+		require.Equal(t, RouteStatusDIDDocReceived, routes[0].Status)
+		require.Equal(t, "diddoc-resp-01", routes[0].DIDDocResponseID)
+		require.NotEmpty(t, routes[0].DIDDocRequestID)
+		require.JSONEq(t, `{"id": "did:peer:router"}`, string(routes[0].RouterDIDDoc))
+
+		lastID := mockMessenger.GetLastID()
+
+		go replyFromRouter(t, registrar, mockMessenger, lastID, registerReplyStr)
+
+		b.Reset()
+		err = c.SendRegisterRouteRequest(&b,
+			bytes.NewBufferString(`{"messageID":"diddoc-resp-01", "didDoc": {"id": "did:peer:alice"}}`))
+		require.NoError(t, err)
+
+		b.Reset()
+		err = c.GetRoute(&b, bytes.NewBufferString(fmt.Sprintf(`{"id":%q}`, routes[0].ID)))
+		require.NoError(t, err)
+
+		var response GetRouteResponse
+		require.NoError(t, json.Unmarshal(b.Bytes(), &response))
+		require.Equal(t, RouteStatusRegistered, response.Route.Status)
+		require.Equal(t, "register-route-resp-01", response.Route.RegisterRouteResponseID)
+		require.NotEmpty(t, response.Route.RegisterRouteRequestID)
+		require.JSONEq(t, `{"id": "did:peer:alice"}`, string(response.Route.RegisteredDIDDoc))
+
+		require.Len(t, listRoutes(t, c, `{"connectionID":"sample-conn-01"}`), 1)
+		require.Len(t, listRoutes(t, c, `{"status":"registered"}`), 1)
+		require.Empty(t, listRoutes(t, c, `{"connectionID":"sample-conn-02"}`))
+		require.Empty(t, listRoutes(t, c, `{"status":"failed"}`))
+
+		b.Reset()
+		err = c.RemoveRoute(&b, bytes.NewBufferString(fmt.Sprintf(`{"id":%q}`, routes[0].ID)))
+		require.NoError(t, err)
+		require.Empty(t, listRoutes(t, c, `{}`))
+
+		b.Reset()
+		err = c.GetRoute(&b, bytes.NewBufferString(fmt.Sprintf(`{"id":%q}`, routes[0].ID)))
+		require.Error(t, err)
+		require.Equal(t, GetRouteError, err.Code())
+	})
+
+	t.Run("test routes persisted in DIDComm V2 format", func(t *testing.T) {
+		c, mockMessenger, registrar := newCommand(t)
+
+		go replyFromRouter(t, registrar, mockMessenger, "", `{
+			"id": "diddoc-resp-01",
+			"type": "https://trustbloc.dev/blinded-routing/2.0/diddoc-resp",
+			"thid": "%s",
+			"body": {"didDoc": {"id": "did:peer:router"}}
+		}`)
+
+		var b bytes.Buffer
+		err := c.SendDIDDocRequest(&b, bytes.NewBufferString(`{"connectionID":"sample-conn-v2"}`))
+		require.NoError(t, err)
+
+		routes := listRoutes(t, c, `{}`)
+		require.Len(t, routes, 1)
+		require.Equal(t, service.V2, routes[0].DIDCommVersion)
+		require.Equal(t, RouteStatusDIDDocReceived, routes[0].Status)
+		require.Equal(t, "diddoc-resp-01", routes[0].DIDDocResponseID)
+		require.JSONEq(t, `{"id": "did:peer:router"}`, string(routes[0].RouterDIDDoc))
+
+		lastID := mockMessenger.GetLastID()
+
+		go replyFromRouter(t, registrar, mockMessenger, lastID, `{
+			"id": "register-route-resp-01",
+			"type": "https://trustbloc.dev/blinded-routing/2.0/register-route-resp",
+			"thid": "%s",
+			"body": {}
+		}`)
+
+		b.Reset()
+		err = c.SendRegisterRouteRequest(&b,
+			bytes.NewBufferString(`{"messageID":"diddoc-resp-01", "didDoc": {"id": "did:peer:alice"}}`))
+		require.NoError(t, err)
+
+		routes = listRoutes(t, c, `{}`)
+		require.Len(t, routes, 1)
+		require.Equal(t, RouteStatusRegistered, routes[0].Status)
+		require.Equal(t, "register-route-resp-01", routes[0].RegisterRouteResponseID)
+	})
+
+	t.Run("test failed routes persisted", func(t *testing.T) {
+		c, _, _ := newCommand(t)
+
+		var b bytes.Buffer
+		err := c.SendDIDDocRequest(&b, bytes.NewBufferString(`{"connectionID":"sample-conn-02"}`))
+		require.Error(t, err)
+
+		routes := listRoutes(t, c, `{"status":"failed"}`)
+		require.Len(t, routes, 1)
+		require.Equal(t, "sample-conn-02", routes[0].ConnectionID)
+		require.NotEmpty(t, routes[0].ErrorMsg)
+	})
+
+	t.Run("test router error persisted", func(t *testing.T) {
+		c, mockMessenger, registrar := newCommand(t)
+
+		go replyFromRouter(t, registrar, mockMessenger, "", `{
+			"@id": "diddoc-resp-01",
+			"@type": "https://trustbloc.dev/blinded-routing/1.0/diddoc-resp",
+			"~thread" : {"thid": "%s"},
+			"data": {"errorMsg": "failed to create routing DID"}
+		}`)
+
+		var b bytes.Buffer
+		err := c.SendDIDDocRequest(&b, bytes.NewBufferString(`{"connectionID":"sample-conn-01"}`))
+		require.NoError(t, err)
+
+		routes := listRoutes(t, c, `{}`)
+		require.Len(t, routes, 1)
+		require.Equal(t, RouteStatusFailed, routes[0].Status)
+		require.Equal(t, "failed to create routing DID", routes[0].ErrorMsg)
+	})
+
+	t.Run("test register route without DID doc request", func(t *testing.T) {
+		c, mockMessenger, registrar := newCommand(t)
+
+		go replyFromRouter(t, registrar, mockMessenger, "", `{
+			"@id": "register-route-resp-01",
+			"@type": "https://trustbloc.dev/blinded-routing/1.0/register-route-resp",
+			"~thread" : {"thid": "%s"},
+			"data": {"errorMsg": "unknown blinded routing session"}
+		}`)
+
+		var b bytes.Buffer
+		err := c.SendRegisterRouteRequest(&b,
+			bytes.NewBufferString(`{"messageID":"sample-msg-01", "didDoc": {"id": "did:peer:alice"}}`))
+		require.NoError(t, err)
+
+		routes := listRoutes(t, c, `{}`)
+		require.Len(t, routes, 1)
+		require.Equal(t, "sample-msg-01", routes[0].DIDDocResponseID)
+		require.Equal(t, RouteStatusFailed, routes[0].Status)
+		require.Equal(t, "unknown blinded routing session", routes[0].ErrorMsg)
+	})
+
+	t.Run("test request validation", func(t *testing.T) {
+		c, _, _ := newCommand(t)
+
+		var b bytes.Buffer
+
+		err := c.ListRoutes(&b, bytes.NewBufferString(`}`))
+		require.Error(t, err)
+		require.Equal(t, InvalidRequestErrorCode, err.Code())
+
+		err = c.GetRoute(&b, bytes.NewBufferString(`}`))
+		require.Error(t, err)
+		require.Equal(t, InvalidRequestErrorCode, err.Code())
+
+		err = c.GetRoute(&b, bytes.NewBufferString(`{}`))
+		require.Error(t, err)
+		require.Equal(t, errInvalidRouteID, err.Error())
+
+		err = c.RemoveRoute(&b, bytes.NewBufferString(`}`))
+		require.Error(t, err)
+		require.Equal(t, InvalidRequestErrorCode, err.Code())
+
+		err = c.RemoveRoute(&b, bytes.NewBufferString(`{}`))
+		require.Error(t, err)
+		require.Equal(t, errInvalidRouteID, err.Error())
+
+		err = c.RemoveRoute(&b, bytes.NewBufferString(`{"id":"unknown"}`))
+		require.Error(t, err)
+		require.Equal(t, RemoveRouteError, err.Code())
+	})
+
+	t.Run("test store errors", func(t *testing.T) {
+		prov := newMockProvider()
+		prov.StoreProvider = mockstorage.NewCustomMockStoreProvider(&mockstorage.MockStore{
+			Store:    make(map[string]mockstorage.DBEntry),
+			ErrPut:   fmt.Errorf(sampleErr),
+			ErrQuery: fmt.Errorf(sampleErr),
+		})
+
+		c, err := New(prov, mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+
+		cmdErr := c.SendDIDDocRequest(&b, bytes.NewBufferString(`{"connectionID":"sample-conn-01"}`))
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), "failed to save route")
+
+		cmdErr = c.SendRegisterRouteRequest(&b,
+			bytes.NewBufferString(`{"messageID":"sample-msg-01", "didDoc": {"id": "did:peer:alice"}}`))
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), "failed to get route")
+
+		cmdErr = c.ListRoutes(&b, bytes.NewBufferString(`{}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, ListRoutesError, cmdErr.Code())
+	})
+}
+
+// replyFromRouter injects reply of router to the last message sent once it was sent and returns ID of the message,
+// previous is ID of the last message sent before.
+func replyFromRouter(t *testing.T, registrar *mockmsghandler.MockMsgSvcProvider,
+	messenger *sdkmockprotocol.MockMessenger, previous, replyStr string,
+) string {
+	t.Helper()
+
+	for {
+		lastID := messenger.GetLastID()
+
+		if len(registrar.Services()) > 0 && lastID != "" && lastID != previous {
+			replyMsg, e := service.ParseDIDCommMsgMap([]byte(fmt.Sprintf(replyStr, lastID)))
+			require.NoError(t, e)
+
+			_, e = registrar.Services()[0].HandleInbound(replyMsg, &sdkmockprotocol.MockDIDCommContext{
+				MyDIDValue:    "sampleDID",
+				TheirDIDValue: "sampleTheirDID",
+			})
+			require.NoError(t, e)
+
+			return lastID
+		}
+	}
+}
+
+func TestCommand_EstablishBlindedRoute(t *testing.T) {
+	const (
+		didDocReplyStr = `{
+			"@id": "diddoc-resp-01",
+			"@type": "https://trustbloc.dev/blinded-routing/1.0/diddoc-resp",
+			"~thread" : {"thid": "%s"},
+			"data": {"didDoc": {
+				"@context": ["https://www.w3.org/ns/did/v1"],
+				"id": "did:peer:router",
+				"service": [{
+					"id": "did:peer:router#didcomm",
+					"type": "did-communication",
+					"serviceEndpoint": "https://router.example.com",
+					"recipientKeys": ["did:key:router"]
+				}]
+			}}
+		}`
+		registerReplyStr = `{
+			"@id": "register-route-resp-01",
+			"@type": "https://trustbloc.dev/blinded-routing/1.0/register-route-resp",
+			"~thread" : {"thid": "%s"},
+			"data": {}
+		}`
+	)
+
+	type notification struct {
+		topic string
+		route RouteRecord
+	}
+
+	newCommand := func(t *testing.T) (*Command, *sdkmockprotocol.MockMessenger,
+		*mockmsghandler.MockMsgSvcProvider, func() []notification,
+	) {
+		t.Helper()
+
+		prov := newMockProvider()
+
+		connBytes, err := json.Marshal(&connection.Record{
+			ConnectionID: "sample-conn-01",
+			State:        "completed", MyDID: "mydid", TheirDID: "theirDID-001",
+		})
+		require.NoError(t, err)
+
+		mockStore := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
+		require.NoError(t, mockStore.Put("conn_sample-conn-01", connBytes))
+		prov.StoreProvider = mockstorage.NewCustomMockStoreProvider(mockStore)
+
+		registrar := mockmsghandler.NewMockMsgServiceProvider()
+		mockMessenger := sdkmockprotocol.NewMockMessenger()
+		prov.CustomMessenger = mockMessenger
+
+		var (
+			notifications []notification
+			lock          sync.Mutex
+		)
+
+		notifier := mocks.NewMockNotifier()
+		notifier.NotifyFunc = func(topic string, message []byte) error {
+			lock.Lock()
+			defer lock.Unlock()
+
+			n := notification{topic: topic}
+			require.NoError(t, json.Unmarshal(message, &n.route))
+			notifications = append(notifications, n)
+
+			return nil
+		}
+
+		c, err := New(prov, registrar, notifier)
+		require.NoError(t, err)
+
+		c.kms = &mockkms.KeyManager{
+			CrAndExportPubKeyID:    "key-1",
+			CrAndExportPubKeyValue: []byte("0123456789abcdef0123456789abcdef"),
+		}
+		c.vdrRegistry = &mockvdr.MockVDRegistry{
+			CreateFunc: func(_ string, doc *did.Doc, _ ...vdrapi.DIDMethodOption) (*did.DocResolution, error) {
+				doc.ID = "did:peer:alice"
+
+				return &did.DocResolution{DIDDocument: doc}, nil
+			},
+		}
+
+		return c, mockMessenger, registrar, func() []notification {
+			lock.Lock()
+			defer lock.Unlock()
+
+			return notifications
+		}
+	}
+
+	establish := func(t *testing.T, c *Command, request string) (*RouteRecord, error) {
+		t.Helper()
+
+		var b bytes.Buffer
+
+		cmdErr := c.EstablishBlindedRoute(&b, bytes.NewBufferString(request))
+		if cmdErr != nil {
+			return nil, cmdErr
+		}
+
+		var response EstablishBlindedRouteResponse
+		require.NoError(t, json.Unmarshal(b.Bytes(), &response))
+
+		return response.Route, nil
+	}
+
+	t.Run("test establish blinded route", func(t *testing.T) {
+		c, mockMessenger, registrar, notifications := newCommand(t)
+
+		go func() {
+			lastID := replyFromRouter(t, registrar, mockMessenger, "", didDocReplyStr)
+			replyFromRouter(t, registrar, mockMessenger, lastID, registerReplyStr)
+		}()
+
+		route, err := establish(t, c, `{"connectionID":"sample-conn-01"}`)
+		require.NoError(t, err)
+		require.Equal(t, RouteStatusRegistered, route.Status)
+		require.Equal(t, "sample-conn-01", route.ConnectionID)
+		require.Equal(t, "diddoc-resp-01", route.DIDDocResponseID)
+		require.Equal(t, "register-route-resp-01", route.RegisterRouteResponseID)
+
+		doc, err := did.ParseDocument(route.RegisteredDIDDoc)
+		require.NoError(t, err)
+		require.Equal(t, "did:peer:alice", doc.ID)
+		require.Len(t, doc.Service, 1)
+		require.Equal(t, []string{"did:key:router"}, doc.Service[0].RoutingKeys)
+		require.Len(t, doc.Service[0].RecipientKeys, 1)
+
+		uri, err := doc.Service[0].ServiceEndpoint.URI()
+		require.NoError(t, err)
+		require.Equal(t, "https://router.example.com", uri)
+
+		var statuses []string
+
+		for _, n := range notifications() {
+			if n.topic == RouteTopic {
+				require.Equal(t, route.ID, n.route.ID)
+				statuses = append(statuses, n.route.Status)
+			}
+		}
+
+		require.Equal(t, []string{
+			RouteStatusRequested, RouteStatusDIDDocReceived, RouteStatusDIDCreated,
+			RouteStatusRegistering, RouteStatusRegistered,
+		}, statuses)
+	})
+
+	t.Run("test resume failed blinded route", func(t *testing.T) {
+		c, mockMessenger, registrar, _ := newCommand(t)
+
+		go replyFromRouter(t, registrar, mockMessenger, "", didDocReplyStr)
+
+		var b bytes.Buffer
+		require.NoError(t, c.SendDIDDocRequest(&b, bytes.NewBufferString(`{"connectionID":"sample-conn-01"}`)))
+
+		routes, err := c.routes.list("")
+		require.NoError(t, err)
+		require.Len(t, routes, 1)
+
+		lastID := mockMessenger.GetLastID()
+
+		go replyFromRouter(t, registrar, mockMessenger, lastID, `{
+			"@id": "register-route-resp-01",
+			"@type": "https://trustbloc.dev/blinded-routing/1.0/register-route-resp",
+			"~thread" : {"thid": "%s"},
+			"data": {"errorMsg": "failed to register keys"}
+		}`)
+
+		_, err = establish(t, c, fmt.Sprintf(`{"routeID":%q}`, routes[0].ID))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to register keys")
+
+		route, err := c.routes.get(routes[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, RouteStatusFailed, route.Status)
+		require.NotEmpty(t, route.RegisteredDIDDoc)
+
+		failedID := mockMessenger.GetLastID()
+
+		go func() {
+			didDocReqID := replyFromRouter(t, registrar, mockMessenger, failedID,
+				strings.Replace(didDocReplyStr, "diddoc-resp-01", "diddoc-resp-02", 1))
+			replyFromRouter(t, registrar, mockMessenger, didDocReqID, registerReplyStr)
+		}()
+
+		// failed exchange starts over with DID doc request.
+		route, err = establish(t, c, fmt.Sprintf(`{"routeID":%q}`, routes[0].ID))
+		require.NoError(t, err)
+		require.Equal(t, RouteStatusRegistered, route.Status)
+		require.Equal(t, "diddoc-resp-02", route.DIDDocResponseID)
+		require.NotEqual(t, routes[0].DIDDocRequestID, route.DIDDocRequestID)
+
+		registered, err := did.ParseDocument(route.RegisteredDIDDoc)
+		require.NoError(t, err)
+		require.Equal(t, "did:peer:alice", registered.ID)
+
+		// registered route is returned as is.
+		route, err = establish(t, c, fmt.Sprintf(`{"routeID":%q}`, routes[0].ID))
+		require.NoError(t, err)
+		require.Equal(t, RouteStatusRegistered, route.Status)
+	})
+
+	t.Run("test router DID document without DIDComm service", func(t *testing.T) {
+		c, mockMessenger, registrar, _ := newCommand(t)
+
+		go replyFromRouter(t, registrar, mockMessenger, "", `{
+			"@id": "diddoc-resp-01",
+			"@type": "https://trustbloc.dev/blinded-routing/1.0/diddoc-resp",
+			"~thread" : {"thid": "%s"},
+			"data": {"didDoc": {"@context": ["https://www.w3.org/ns/did/v1"], "id": "did:peer:router"}}
+		}`)
+
+		_, err := establish(t, c, `{"connectionID":"sample-conn-01"}`)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no DIDComm service with keys found")
+
+		routes, e := c.routes.list("sample-conn-01")
+		require.NoError(t, e)
+		require.Len(t, routes, 1)
+		require.Equal(t, RouteStatusFailed, routes[0].Status)
+	})
+
+	t.Run("test router DID document with DIDComm V2 service", func(t *testing.T) {
+		c, mockMessenger, registrar, _ := newCommand(t)
+
+		routerDoc := `{
+			"@context": ["https://www.w3.org/ns/did/v1"],
+			"id": "did:peer:router",
+			"service": [{
+				"id": "did:peer:router#didcomm",
+				"type": "DIDCommMessaging",
+				"serviceEndpoint": [{"uri": "https://router.example.com", "accept": ["didcomm/v2"]}],
+				"recipientKeys": ["did:key:router"]
+			}]
+		}`
+
+		go func() {
+			lastID := replyFromRouter(t, registrar, mockMessenger, "", `{
+				"@id": "diddoc-resp-01",
+				"@type": "https://trustbloc.dev/blinded-routing/1.0/diddoc-resp",
+				"~thread" : {"thid": "%s"},
+				"data": {"didDoc": `+routerDoc+`}
+			}`)
+			replyFromRouter(t, registrar, mockMessenger, lastID, registerReplyStr)
+		}()
+
+		route, err := establish(t, c, `{"connectionID":"sample-conn-01"}`)
+		require.NoError(t, err)
+		require.Equal(t, RouteStatusRegistered, route.Status)
+
+		doc, err := did.ParseDocument(route.RegisteredDIDDoc)
+		require.NoError(t, err)
+		require.Equal(t, didCommServiceType, doc.Service[0].Type)
+		require.Equal(t, []string{"did:key:router"}, doc.Service[0].RoutingKeys)
+
+		didDocV2, err := c.createRouteDID(service.V2, json.RawMessage(routerDoc))
+		require.NoError(t, err)
+
+		doc, err = did.ParseDocument(didDocV2)
+		require.NoError(t, err)
+		require.Equal(t, didCommV2ServiceType, doc.Service[0].Type)
+
+		uri, err := doc.Service[0].ServiceEndpoint.URI()
+		require.NoError(t, err)
+		require.Equal(t, "https://router.example.com", uri)
+
+		routingKeys, err := doc.Service[0].ServiceEndpoint.RoutingKeys()
+		require.NoError(t, err)
+		require.Equal(t, []string{"did:key:router"}, routingKeys)
+	})
+
+	t.Run("test router error", func(t *testing.T) {
+		c, mockMessenger, registrar, _ := newCommand(t)
+
+		go replyFromRouter(t, registrar, mockMessenger, "", `{
+			"@id": "diddoc-resp-01",
+			"@type": "https://trustbloc.dev/blinded-routing/1.0/diddoc-resp",
+			"~thread" : {"thid": "%s"},
+			"data": {"errorMsg": "failed to create routing DID"}
+		}`)
+
+		_, err := establish(t, c, `{"connectionID":"sample-conn-01"}`)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "router failed to provide DID document")
+	})
+
+	t.Run("test failure while creating peer DID", func(t *testing.T) {
+		c, mockMessenger, registrar, _ := newCommand(t)
+		c.vdrRegistry = &mockvdr.MockVDRegistry{CreateErr: fmt.Errorf(sampleErr)}
+
+		go replyFromRouter(t, registrar, mockMessenger, "", didDocReplyStr)
+
+		_, err := establish(t, c, `{"connectionID":"sample-conn-01"}`)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to create peer DID")
+	})
+
+	t.Run("test send error", func(t *testing.T) {
+		c, _, _, _ := newCommand(t)
+
+		_, err := establish(t, c, `{"connectionID":"sample-conn-02"}`)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to request router DID document")
+	})
+
+	t.Run("test request validation", func(t *testing.T) {
+		c, _, _, _ := newCommand(t)
+
+		_, err := establish(t, c, `}`)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid character")
+
+		_, err = establish(t, c, `{}`)
+		require.Error(t, err)
+		require.Equal(t, errInvalidConnectionID, err.Error())
+
+		_, err = establish(t, c, `{"routeID":"unknown"}`)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get route")
+	})
+}
+
+func TestRouter(t *testing.T) {
+	const (
+		routerDID   = "did:peer:router"
+		theirDIDDoc = `{
+			"@context": ["https://www.w3.org/ns/did/v1"],
+			"id": "did:peer:alice",
+			"service": [{
+				"id": "did:peer:alice#didcomm",
+				"type": "did-communication",
+				"serviceEndpoint": "https://alice.example.com",
+				"recipientKeys": ["did:key:alice"]
+			}]
+		}`
+	)
+
+	connCtx := &sdkmockprotocol.MockDIDCommContext{
+		MyDIDValue: "did:peer:router-conn", TheirDIDValue: "did:peer:alice-conn",
+	}
+
+	newRouterWithMocks := func(t *testing.T) (*router, *sdkmockprotocol.MockMessenger, *mockRoutes) {
+		t.Helper()
+
+		prov := newMockRouterProvider()
+
+		messenger := sdkmockprotocol.NewMockMessenger()
+		prov.CustomMessenger = messenger
+
+		r, err := newRouter(prov)
+		require.NoError(t, err)
+
+		routes := &mockRoutes{routes: map[string]string{}}
+		r.routes = routes
+
+		r.vdrRegistry = &mockvdr.MockVDRegistry{
+			CreateFunc: func(_ string, doc *did.Doc, _ ...vdrapi.DIDMethodOption) (*did.DocResolution, error) {
+				if doc.ID == "" {
+					doc.ID = routerDID
+				}
+
+				return &did.DocResolution{DIDDocument: doc}, nil
+			},
+		}
+
+		return r, messenger, routes
+	}
+
+	replyData := func(t *testing.T, messenger *sdkmockprotocol.MockMessenger) *routingMsgData {
+		t.Helper()
+
+		reply, err := decodeRoutingMsg(messenger.GetLastReply())
+		require.NoError(t, err)
+		require.NotNil(t, reply.Data)
+
+		return reply.Data
+	}
+
+	registerRequest := func(t *testing.T, pthID string) service.DIDCommMsgMap {
+		t.Helper()
+
+		registerReq, err := service.ParseDIDCommMsgMap([]byte(fmt.Sprintf(`{
+			"@id": "register-route-req-01",
+			"@type": %q,
+			"~thread": {"thid": "register-route-req-01", "pthid": %q},
+			"data": {"didDoc": %s}
+		}`, registerRouteRequestMsgType, pthID, theirDIDDoc)))
+		require.NoError(t, err)
+
+		return registerReq
+	}
+
+	t.Run("test blinded routing exchange", func(t *testing.T) {
+		r, messenger, routes := newRouterWithMocks(t)
+
+		didDocReq := service.DIDCommMsgMap{"@id": "diddoc-req-01", "@type": didDocRequestMsgType}
+
+		_, err := r.HandleInbound(didDocReq, connCtx)
+		require.NoError(t, err)
+		require.Equal(t, didDocResponseMsgType, messenger.GetLastReply().Type())
+
+		data := replyData(t, messenger)
+		require.Empty(t, data.ErrorMsg)
+
+		doc, err := did.ParseDocument(data.DIDDoc)
+		require.NoError(t, err)
+		require.Equal(t, routerDID, doc.ID)
+		require.Len(t, doc.Service, 1)
+		require.Equal(t, didCommServiceType, doc.Service[0].Type)
+		require.Len(t, doc.Service[0].RecipientKeys, 1)
+
+		_, err = r.HandleInbound(registerRequest(t, "diddoc-req-01"), connCtx)
+		require.NoError(t, err)
+		require.Equal(t, registerRouteResponseMsgType, messenger.GetLastReply().Type())
+		require.Empty(t, replyData(t, messenger).ErrorMsg)
+
+		connID := routes.get("did:key:alice")
+		require.NotEmpty(t, connID)
+
+		record, err := r.connections.GetConnectionRecord(connID)
+		require.NoError(t, err)
+		require.Equal(t, routerDID, record.MyDID)
+		require.Equal(t, "did:peer:alice", record.TheirDID)
+
+		_, err = r.store.Get("diddoc-req-01")
+		require.ErrorIs(t, err, storage.ErrDataNotFound)
+	})
+
+	t.Run("test blinded routing exchange in DIDComm V2 format", func(t *testing.T) {
+		r, messenger, routes := newRouterWithMocks(t)
+
+		require.True(t, r.Accept(didDocRequestMsgTypeV2, nil))
+		require.True(t, r.Accept(registerRouteRequestMsgTypeV2, nil))
+
+		didDocReq := service.DIDCommMsgMap{
+			"id":   "diddoc-req-01",
+			"type": didDocRequestMsgTypeV2,
+			"body": map[string]interface{}{},
+		}
+
+		_, err := r.HandleInbound(didDocReq, connCtx)
+		require.NoError(t, err)
+		require.Equal(t, didDocResponseMsgTypeV2, messenger.GetLastReply().Type())
+		require.Contains(t, messenger.GetLastReply(), "body")
+		require.NotContains(t, messenger.GetLastReply(), "@id")
+
+		doc, err := did.ParseDocument(replyData(t, messenger).DIDDoc)
+		require.NoError(t, err)
+		require.Equal(t, routerDID, doc.ID)
+		require.Equal(t, didCommV2ServiceType, doc.Service[0].Type)
+
+		uri, err := doc.Service[0].ServiceEndpoint.URI()
+		require.NoError(t, err)
+		require.NotEmpty(t, uri)
+
+		registerReq, err := service.ParseDIDCommMsgMap([]byte(fmt.Sprintf(`{
+			"id": "register-route-req-01",
+			"type": %q,
+			"thid": "register-route-req-01",
+			"pthid": "diddoc-req-01",
+			"body": {"didDoc": %s}
+		}`, registerRouteRequestMsgTypeV2, theirDIDDoc)))
+		require.NoError(t, err)
+
+		_, err = r.HandleInbound(registerReq, connCtx)
+		require.NoError(t, err)
+		require.Equal(t, registerRouteResponseMsgTypeV2, messenger.GetLastReply().Type())
+		require.Empty(t, replyData(t, messenger).ErrorMsg)
+		require.NotEmpty(t, routes.get("did:key:alice"))
+	})
+
+	t.Run("test register route for unknown session", func(t *testing.T) {
+		r, messenger, routes := newRouterWithMocks(t)
+
+		_, err := r.HandleInbound(registerRequest(t, "unknown"), connCtx)
+		require.NoError(t, err)
+		require.Contains(t, replyData(t, messenger).ErrorMsg, "unknown blinded routing session")
+		require.Empty(t, routes.routes)
+	})
+
+	t.Run("test register route in thread of DID doc request", func(t *testing.T) {
+		r, messenger, routes := newRouterWithMocks(t)
+
+		_, err := r.HandleInbound(service.DIDCommMsgMap{"@id": "diddoc-req-01", "@type": didDocRequestMsgType}, connCtx)
+		require.NoError(t, err)
+
+		registerReq, err := service.ParseDIDCommMsgMap([]byte(fmt.Sprintf(`{
+			"@id": "register-route-req-01",
+			"@type": %q,
+			"~thread": {"thid": "diddoc-req-01"},
+			"data": {"didDoc": %s}
+		}`, registerRouteRequestMsgType, theirDIDDoc)))
+		require.NoError(t, err)
+
+		_, err = r.HandleInbound(registerReq, connCtx)
+		require.NoError(t, err)
+		require.Contains(t, replyData(t, messenger).ErrorMsg, "not nested in thread of DID doc response")
+		require.Empty(t, routes.routes)
+	})
+
+	t.Run("test register route over another connection", func(t *testing.T) {
+		r, messenger, routes := newRouterWithMocks(t)
+
+		_, err := r.HandleInbound(service.DIDCommMsgMap{"@id": "diddoc-req-01", "@type": didDocRequestMsgType}, connCtx)
+		require.NoError(t, err)
+
+		_, err = r.HandleInbound(registerRequest(t, "diddoc-req-01"), &sdkmockprotocol.MockDIDCommContext{
+			MyDIDValue: "did:peer:router-conn", TheirDIDValue: "did:peer:mallory-conn",
+		})
+		require.NoError(t, err)
+		require.Contains(t, replyData(t, messenger).ErrorMsg, "received over another connection")
+		require.Empty(t, routes.routes)
+
+		// session is kept for its requester.
+		_, err = r.HandleInbound(registerRequest(t, "diddoc-req-01"), connCtx)
+		require.NoError(t, err)
+		require.Empty(t, replyData(t, messenger).ErrorMsg)
+		require.NotEmpty(t, routes.get("did:key:alice"))
+	})
+
+	t.Run("test register route for expired session", func(t *testing.T) {
+		r, messenger, routes := newRouterWithMocks(t)
+
+		_, err := r.HandleInbound(service.DIDCommMsgMap{"@id": "diddoc-req-01", "@type": didDocRequestMsgType}, connCtx)
+		require.NoError(t, err)
+
+		expireSession(t, r, "diddoc-req-01")
+
+		_, err = r.HandleInbound(registerRequest(t, "diddoc-req-01"), connCtx)
+		require.NoError(t, err)
+		require.Contains(t, replyData(t, messenger).ErrorMsg, "blinded routing session expired")
+		require.Empty(t, routes.routes)
+
+		_, err = r.store.Get("diddoc-req-01")
+		require.ErrorIs(t, err, storage.ErrDataNotFound)
+	})
+
+	t.Run("test expired sessions removed", func(t *testing.T) {
+		r, _, _ := newRouterWithMocks(t)
+
+		_, err := r.HandleInbound(service.DIDCommMsgMap{"@id": "diddoc-req-01", "@type": didDocRequestMsgType}, connCtx)
+		require.NoError(t, err)
+
+		expireSession(t, r, "diddoc-req-01")
+
+		_, err = r.HandleInbound(service.DIDCommMsgMap{"@id": "diddoc-req-02", "@type": didDocRequestMsgType}, connCtx)
+		require.NoError(t, err)
+
+		_, err = r.store.Get("diddoc-req-01")
+		require.ErrorIs(t, err, storage.ErrDataNotFound)
+
+		_, err = r.store.Get("diddoc-req-02")
+		require.NoError(t, err)
+	})
+
+	t.Run("test register route with invalid DID document", func(t *testing.T) {
+		r, messenger, _ := newRouterWithMocks(t)
+
+		_, err := r.HandleInbound(service.DIDCommMsgMap{"@id": "diddoc-req-01", "@type": didDocRequestMsgType}, connCtx)
+		require.NoError(t, err)
+
+		_, err = r.HandleInbound(service.DIDCommMsgMap{
+			"@id":     "register-route-req-01",
+			"@type":   registerRouteRequestMsgType,
+			"~thread": map[string]interface{}{"thid": "register-route-req-01", "pthid": "diddoc-req-01"},
+		}, connCtx)
+		require.NoError(t, err)
+		require.Contains(t, replyData(t, messenger).ErrorMsg, "DID document missing")
+	})
+
+	t.Run("test failure while registering keys", func(t *testing.T) {
+		r, messenger, routes := newRouterWithMocks(t)
+		routes.err = fmt.Errorf(sampleErr)
+
+		_, err := r.HandleInbound(service.DIDCommMsgMap{"@id": "diddoc-req-01", "@type": didDocRequestMsgType}, connCtx)
+		require.NoError(t, err)
+
+		_, err = r.HandleInbound(registerRequest(t, "diddoc-req-01"), connCtx)
+		require.NoError(t, err)
+		require.Contains(t, replyData(t, messenger).ErrorMsg, "failed to register keys with mediator")
+	})
+
+	t.Run("test failure while creating routing DID", func(t *testing.T) {
+		r, messenger, _ := newRouterWithMocks(t)
+		r.vdrRegistry = &mockvdr.MockVDRegistry{CreateErr: fmt.Errorf(sampleErr)}
+
+		_, err := r.HandleInbound(service.DIDCommMsgMap{"@id": "diddoc-req-01", "@type": didDocRequestMsgType}, connCtx)
+		require.NoError(t, err)
+		require.Equal(t, didDocResponseMsgType, messenger.GetLastReply().Type())
+		require.NotEmpty(t, replyData(t, messenger).ErrorMsg)
+	})
+
+	t.Run("test reply failure", func(t *testing.T) {
+		r, messenger, _ := newRouterWithMocks(t)
+		messenger.ErrReplyTo = fmt.Errorf(sampleErr)
+
+		_, err := r.HandleInbound(service.DIDCommMsgMap{"@id": "diddoc-req-01", "@type": didDocRequestMsgType}, connCtx)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), sampleErr)
+	})
+
+	t.Run("test unsupported message", func(t *testing.T) {
+		r, _, _ := newRouterWithMocks(t)
+
+		_, err := r.HandleInbound(service.DIDCommMsgMap{"@id": "msg-01", "@type": didDocResponseMsgType}, connCtx)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unsupported message type")
+	})
+
+	t.Run("test routes registered through mediator keylist update", func(t *testing.T) {
+		mediator := &mockMediator{}
+		record := &connection.Record{ConnectionID: "conn-01", MyDID: routerDID, TheirDID: "did:peer:alice"}
+
+		err := (&mediatorRoutes{mediator: mediator}).addRoutes(record, []string{"did:key:alice", "did:key:bob"})
+		require.NoError(t, err)
+		require.Equal(t, mediatorsvc.KeylistUpdateMsgType, mediator.msg.Type())
+		require.Equal(t, routerDID, mediator.ctx.MyDID())
+		require.Equal(t, "did:peer:alice", mediator.ctx.TheirDID())
+
+		update := mediatorsvc.KeylistUpdate{}
+		require.NoError(t, mediator.msg.Decode(&update))
+		require.Equal(t, []mediatorsvc.Update{
+			{RecipientKey: "did:key:alice", Action: keylistUpdateActionAdd},
+			{RecipientKey: "did:key:bob", Action: keylistUpdateActionAdd},
+		}, update.Updates)
+
+		mediator.err = fmt.Errorf(sampleErr)
+
+		err = (&mediatorRoutes{mediator: mediator}).addRoutes(record, []string{"did:key:alice"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), sampleErr)
+	})
+}
+
+func TestBlindedRoutingWithRouter(t *testing.T) {
+	c, r, routes := newLinkedRouter(t)
+
+	var b bytes.Buffer
+
+	require.Nil(t, c.EstablishBlindedRoute(&b, bytes.NewBufferString(`{"connectionID":"router-conn-01"}`)))
+
+	var response EstablishBlindedRouteResponse
+	require.NoError(t, json.Unmarshal(b.Bytes(), &response))
+	require.Equal(t, RouteStatusRegistered, response.Route.Status)
+	require.Empty(t, response.Route.ErrorMsg)
+
+	registered, err := did.ParseDocument(response.Route.RegisteredDIDDoc)
+	require.NoError(t, err)
+	require.Len(t, registered.Service, 1)
+	require.Len(t, registered.Service[0].RecipientKeys, 1)
+
+	connID := routes.get(registered.Service[0].RecipientKeys[0])
+	require.NotEmpty(t, connID)
+
+	record, err := r.connections.GetConnectionRecord(connID)
+	require.NoError(t, err)
+	require.Equal(t, "did:peer:router", record.MyDID)
+	require.Equal(t, "did:peer:alice", record.TheirDID)
+}
+
+func TestBlindedRoutingWithRouterExpiredSession(t *testing.T) {
+	c, r, routes := newLinkedRouter(t)
+
+	var b bytes.Buffer
+
+	require.Nil(t, c.SendDIDDocRequest(&b, bytes.NewBufferString(`{"connectionID":"router-conn-01"}`)))
+
+	started, err := c.routes.list("router-conn-01")
+	require.NoError(t, err)
+	require.Len(t, started, 1)
+
+	expireSession(t, r, started[0].DIDDocRequestID)
+
+	request := fmt.Sprintf(`{"routeID":%q}`, started[0].ID)
+
+	cmdErr := c.EstablishBlindedRoute(&b, bytes.NewBufferString(request))
+	require.Error(t, cmdErr)
+	require.Contains(t, cmdErr.Error(), "blinded routing session expired")
+	require.Empty(t, routes.routes)
+
+	failed, err := c.routes.get(started[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, RouteStatusFailed, failed.Status)
+
+	b.Reset()
+
+	// failed route starts over with a fresh session instead of replaying the expired one.
+	require.Nil(t, c.EstablishBlindedRoute(&b, bytes.NewBufferString(request)))
+
+	var established EstablishBlindedRouteResponse
+	require.NoError(t, json.Unmarshal(b.Bytes(), &established))
+	require.Equal(t, RouteStatusRegistered, established.Route.Status)
+	require.NotEqual(t, started[0].DIDDocRequestID, established.Route.DIDDocRequestID)
+	require.NotEqual(t, started[0].DIDDocResponseID, established.Route.DIDDocResponseID)
+	require.NotEmpty(t, routes.routes)
+}
+
+// newLinkedRouter creates blinded routing command exchanging messages with router over a loopback connection.
+func newLinkedRouter(t *testing.T) (*Command, *router, *mockRoutes) {
+	t.Helper()
+
+	prov := newMockProvider()
+
+	connBytes, err := json.Marshal(&connection.Record{
+		ConnectionID: "router-conn-01",
+		State:        "completed", MyDID: "did:peer:alice-conn", TheirDID: "did:peer:router-conn",
+	})
+	require.NoError(t, err)
+
+	mockStore := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
+	require.NoError(t, mockStore.Put("conn_router-conn-01", connBytes))
+	prov.StoreProvider = mockstorage.NewCustomMockStoreProvider(mockStore)
+
+	link := &loopback{
+		t:         t,
+		registrar: mockmsghandler.NewMockMsgServiceProvider(),
+		clientCtx: &sdkmockprotocol.MockDIDCommContext{
+			MyDIDValue: "did:peer:alice-conn", TheirDIDValue: "did:peer:router-conn",
+		},
+		routerCtx: &sdkmockprotocol.MockDIDCommContext{
+			MyDIDValue: "did:peer:router-conn", TheirDIDValue: "did:peer:alice-conn",
+		},
+		threads: map[string]string{},
+	}
+	prov.CustomMessenger = &clientMessenger{MockMessenger: &mocksvc.MockMessenger{}, link: link}
+
+	c, err := New(prov, link.registrar, mocks.NewMockNotifier())
+	require.NoError(t, err)
+
+	c.kms = &mockkms.KeyManager{
+		CrAndExportPubKeyID:    "key-1",
+		CrAndExportPubKeyValue: []byte("0123456789abcdef0123456789abcdef"),
+	}
+	c.vdrRegistry = &mockvdr.MockVDRegistry{
+		CreateFunc: func(_ string, doc *did.Doc, _ ...vdrapi.DIDMethodOption) (*did.DocResolution, error) {
+			doc.ID = "did:peer:alice"
+
+			return &did.DocResolution{DIDDocument: doc}, nil
+		},
+	}
+
+	r, err := newRouter(newMockRouterProvider())
+	require.NoError(t, err)
+
+	routes := &mockRoutes{routes: map[string]string{}}
+	r.routes = routes
+	r.messenger = &routerMessenger{MockMessenger: &mocksvc.MockMessenger{}, link: link}
+	r.vdrRegistry = &mockvdr.MockVDRegistry{
+		CreateFunc: func(_ string, doc *did.Doc, _ ...vdrapi.DIDMethodOption) (*did.DocResolution, error) {
+			if doc.ID == "" {
+				doc.ID = "did:peer:router"
+			}
+
+			return &did.DocResolution{DIDDocument: doc}, nil
+		},
+	}
+	link.router = r
+
+	return c, r, routes
+}
+
+func expireSession(t *testing.T, r *router, thID string) {
+	t.Helper()
+
+	sessionBytes, err := r.store.Get(thID)
+	require.NoError(t, err)
+
+	session := &routerSession{}
+	require.NoError(t, json.Unmarshal(sessionBytes, session))
+
+	session.CreatedAt = session.CreatedAt.Add(-routerSessionTTL - time.Minute)
+
+	sessionBytes, err = json.Marshal(session)
+	require.NoError(t, err)
+	require.NoError(t, r.store.Put(thID, sessionBytes, storage.Tag{Name: routerSessionTag}))
+}
+
+type mockRoutes struct {
+	routes map[string]string
+	err    error
+	lock   sync.RWMutex
+}
+
+func (m *mockRoutes) addRoutes(record *connection.Record, keys []string) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, key := range keys {
+		m.routes[key] = record.ConnectionID
+	}
+
+	return nil
+}
+
+func (m *mockRoutes) get(key string) string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.routes[key]
+}
+
+// mockMediator captures messages handed to mediator service.
+type mockMediator struct {
+	msg service.DIDCommMsg
+	ctx service.DIDCommContext
+	err error
+}
+
+func (m *mockMediator) HandleInbound(msg service.DIDCommMsg, ctx service.DIDCommContext) (string, error) {
+	m.msg, m.ctx = msg, ctx
+
+	return "", m.err
+}
+
+// loopback delivers messages between blinded routing client and router, threading them as messenger does.
+type loopback struct {
+	t         *testing.T
+	registrar *mockmsghandler.MockMsgSvcProvider
+	router    *router
+	clientCtx service.DIDCommContext
+	routerCtx service.DIDCommContext
+	threads   map[string]string
+	lock      sync.Mutex
+}
+
+func (l *loopback) thread(msgID string) string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.threads[msgID]
+}
+
+func (l *loopback) setThread(msg service.DIDCommMsgMap, thID, pthID string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.threads[msg.ID()] = thID
+
+	thread := map[string]interface{}{"thid": thID}
+	if pthID != "" {
+		thread["pthid"] = pthID
+	}
+
+	msg["~thread"] = thread
+}
+
+// toRouter delivers message to router.
+func (l *loopback) toRouter(msg service.DIDCommMsgMap) error {
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	received, err := service.ParseDIDCommMsgMap(msgBytes)
+	if err != nil {
+		return err
+	}
+
+	_, err = l.router.HandleInbound(received, l.routerCtx)
+
+	return err
+}
+
+// toClient delivers message to message service of client waiting for it, messages nobody waits for are dropped.
+func (l *loopback) toClient(msg service.DIDCommMsgMap) {
+	msgBytes, err := json.Marshal(msg)
+	require.NoError(l.t, err)
+
+	received, err := service.ParseDIDCommMsgMap(msgBytes)
+	require.NoError(l.t, err)
+
+	go func() {
+		for i := 0; i < 100; i++ {
+			for _, svc := range l.registrar.Services() {
+				if svc.Accept(received.Type(), nil) {
+					if _, e := svc.HandleInbound(received, l.clientCtx); e != nil {
+						l.t.Errorf("failed to deliver message to client: %s", e)
+					}
+
+					return
+				}
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+}
+
+type clientMessenger struct {
+	*mocksvc.MockMessenger
+	link *loopback
+}
+
+func (m *clientMessenger) Send(msg service.DIDCommMsgMap, _, _ string, _ ...service.Opt) error {
+	m.link.setThread(msg, msg.ID(), "")
+
+	return m.link.toRouter(msg)
+}
+
+func (m *clientMessenger) ReplyToNested(msg service.DIDCommMsgMap, opts *service.NestedReplyOpts) error {
+	m.link.setThread(msg, msg.ID(), m.link.thread(opts.MsgID))
+
+	return m.link.toRouter(msg)
+}
+
+type routerMessenger struct {
+	*mocksvc.MockMessenger
+	link *loopback
+}
+
+func (m *routerMessenger) ReplyTo(msgID string, msg service.DIDCommMsgMap, _ ...service.Opt) error {
+	m.link.setThread(msg, m.link.thread(msgID), "")
+	m.link.toClient(msg)
+
+	return nil
+}
+
+func newMockRouterProvider() *sdkmockprotocol.MockProvider {
+	prov := newMockProvider()
+	prov.ServiceMap = map[string]interface{}{
+		mediatorsvc.Coordination: &mockroute.MockMediatorSvc{},
+	}
+	prov.CustomKMS = &mockkms.KeyManager{
+		CrAndExportPubKeyID:    "key-1",
+		CrAndExportPubKeyValue: []byte("0123456789abcdef0123456789abcdef"),
+	}
+	prov.ServiceEndpointValue = "https://router.example.com"
+
+	return prov
+}
+
 func newMockProvider() *sdkmockprotocol.MockProvider {
 	prov := sdkmockprotocol.NewMockProvider()
 	prov.StoreProvider = mockstorage.NewMockStoreProvider()
@@ -0,0 +1,163 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blindedrouting
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/pkg/common/model"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/hyperledger/aries-framework-go/pkg/vdr/fingerprint"
+	"github.com/hyperledger/aries-framework-go/pkg/vdr/peer"
+)
+
+// establishRoute drives blinded routing exchange of given route until the route is registered with router.
+// Steps already completed by the route are skipped, so that exchanges interrupted by restarts can be resumed.
+// Failed exchanges are restarted from a fresh DID doc request, as router session bound to DID doc response
+// of the failed exchange may have expired.
+func (c *Command) establishRoute(route *RouteRecord) error {
+	switch route.Status {
+	case RouteStatusRegistered:
+		return nil
+	case RouteStatusFailed:
+		route.restart()
+	}
+
+	if len(route.RouterDIDDoc) == 0 {
+		if route.ConnectionID == "" {
+			return errors.New("connection ID of the route is unknown")
+		}
+
+		_, err := c.sendDIDDocRequest(route)
+		if err != nil {
+			return fmt.Errorf("failed to request router DID document: %w", err)
+		}
+
+		if route.Status == RouteStatusFailed {
+			return fmt.Errorf("router failed to provide DID document: %s", route.ErrorMsg)
+		}
+	}
+
+	if len(route.RegisteredDIDDoc) == 0 {
+		didDoc, err := c.createRouteDID(route.DIDCommVersion, route.RouterDIDDoc)
+		if err != nil {
+			c.updateRoute(route, RouteStatusFailed, err.Error())
+
+			return err
+		}
+
+		route.RegisteredDIDDoc = didDoc
+
+		c.updateRoute(route, RouteStatusDIDCreated, "")
+	}
+
+	_, err := c.sendRegisterRouteRequest(route, route.RegisteredDIDDoc)
+	if err != nil {
+		return fmt.Errorf("failed to register route: %w", err)
+	}
+
+	if route.Status == RouteStatusFailed {
+		return fmt.Errorf("router failed to register route: %s", route.ErrorMsg)
+	}
+
+	return nil
+}
+
+// restart discards progress of failed blinded routing exchange, so that it starts over with DID doc request.
+func (r *RouteRecord) restart() {
+	r.DIDDocRequestID = ""
+	r.DIDDocResponseID = ""
+	r.RouterDIDDoc = nil
+	r.RegisterRouteRequestID = ""
+	r.RegisterRouteResponseID = ""
+	r.RegisteredDIDDoc = nil
+}
+
+// createRouteDID creates peer DID with DIDComm service of given version to be registered with router,
+// messages to the DID are routed through router described by given DID document.
+func (c *Command) createRouteDID(version service.Version, routerDIDDoc json.RawMessage) (json.RawMessage, error) {
+	routerDoc, err := did.ParseDocument(routerDIDDoc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid router DID document: %w", err)
+	}
+
+	var routerSvc *did.Service
+
+	for i := range routerDoc.Service {
+		if routerDoc.Service[i].Type == didCommServiceType || routerDoc.Service[i].Type == didCommV2ServiceType {
+			routerSvc = &routerDoc.Service[i]
+
+			break
+		}
+	}
+
+	if routerSvc == nil || len(routerSvc.RecipientKeys) == 0 {
+		return nil, fmt.Errorf("no DIDComm service with keys found in router DID document %s", routerDoc.ID)
+	}
+
+	uri, err := routerSvc.ServiceEndpoint.URI()
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint of router DID document %s: %w", routerDoc.ID, err)
+	}
+
+	doc, err := createPeerDID(c.kms, c.vdrRegistry, version, uri, routerSvc.RecipientKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.JSONBytes()
+}
+
+// createPeerDID creates peer DID with a new key and DIDComm service of given version, reachable at given URI
+// through given routing keys.
+func createPeerDID(km kms.KeyManager, registry vdr.Registry, version service.Version, uri string,
+	routingKeys []string,
+) (*did.Doc, error) {
+	keyID, pubKey, err := km.CreateAndExportPubKeyBytes(kms.ED25519Type)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create key: %w", err)
+	}
+
+	didKey, _ := fingerprint.CreateDIDKey(pubKey)
+
+	svc := did.Service{
+		ID:              uuid.New().String(),
+		Type:            didCommServiceType,
+		ServiceEndpoint: model.NewDIDCommV1Endpoint(uri),
+		RecipientKeys:   []string{didKey},
+		RoutingKeys:     routingKeys,
+	}
+
+	if version == service.V2 {
+		svc.Type = didCommV2ServiceType
+		svc.ServiceEndpoint = model.NewDIDCommV2Endpoint([]model.DIDCommV2Endpoint{{
+			URI:         uri,
+			Accept:      []string{didCommV2Profile},
+			RoutingKeys: routingKeys,
+		}})
+		svc.RoutingKeys = nil
+	}
+
+	docResolution, err := registry.Create(peer.DIDMethod, &did.Doc{
+		Service: []did.Service{svc},
+		VerificationMethod: []did.VerificationMethod{
+			*did.NewVerificationMethodFromBytes("#"+keyID, verificationKeyType, "", pubKey),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create peer DID: %w", err)
+	}
+
+	return docResolution.DIDDocument, nil
+}
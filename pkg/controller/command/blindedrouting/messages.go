@@ -0,0 +1,128 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blindedrouting
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+)
+
+// routingMsg is blinded routing message exchanged with router, in either DIDComm V1 or V2 format.
+type routingMsg struct {
+	ID   string
+	Type string
+	Data *routingMsgData
+}
+
+// routingMsgFormats holds fields of both DIDComm V1 ('@id', '@type', 'data') and
+// DIDComm V2 ('id', 'type', 'body') formats of blinded routing messages.
+type routingMsgFormats struct {
+	IDV1   string          `json:"@id,omitempty"`
+	TypeV1 string          `json:"@type,omitempty"`
+	Data   *routingMsgData `json:"data,omitempty"`
+	ID     string          `json:"id,omitempty"`
+	Type   string          `json:"type,omitempty"`
+	Body   *routingMsgData `json:"body,omitempty"`
+}
+
+func (f *routingMsgFormats) routingMsg() *routingMsg {
+	if f.Type != "" {
+		return &routingMsg{ID: f.ID, Type: f.Type, Data: f.Body}
+	}
+
+	return &routingMsg{ID: f.IDV1, Type: f.TypeV1, Data: f.Data}
+}
+
+// parseRoutingMsg parses blinded routing message of either format.
+func parseRoutingMsg(msgBytes []byte) (*routingMsg, error) {
+	var formats routingMsgFormats
+
+	err := json.Unmarshal(msgBytes, &formats)
+	if err != nil {
+		return nil, err
+	}
+
+	return formats.routingMsg(), nil
+}
+
+// decodeRoutingMsg decodes received blinded routing message of either format.
+func decodeRoutingMsg(msg service.DIDCommMsg) (*routingMsg, error) {
+	var formats routingMsgFormats
+
+	err := msg.Decode(&formats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode blinded routing message: %w", err)
+	}
+
+	return formats.routingMsg(), nil
+}
+
+// newRoutingMsg returns new blinded routing message in format of given DIDComm version,
+// V1 message is returned for unknown versions.
+func newRoutingMsg(version service.Version, msgType string, data *routingMsgData) service.DIDCommMsgMap {
+	if version == service.V2 {
+		if data == nil {
+			data = &routingMsgData{}
+		}
+
+		return service.DIDCommMsgMap{
+			"id":   uuid.New().String(),
+			"type": versionedMsgType(version, msgType),
+			"body": data,
+		}
+	}
+
+	msg := service.DIDCommMsgMap{
+		"@id":   uuid.New().String(),
+		"@type": msgType,
+	}
+
+	if data != nil {
+		msg["data"] = data
+	}
+
+	return msg
+}
+
+// msgTypeV2 returns DIDComm V2 variant of given blinded routing message type.
+func msgTypeV2(msgType string) string {
+	switch msgType {
+	case didDocRequestMsgType:
+		return didDocRequestMsgTypeV2
+	case didDocResponseMsgType:
+		return didDocResponseMsgTypeV2
+	case registerRouteRequestMsgType:
+		return registerRouteRequestMsgTypeV2
+	case registerRouteResponseMsgType:
+		return registerRouteResponseMsgTypeV2
+	default:
+		return msgType
+	}
+}
+
+// msgVersion returns DIDComm version of blinded routing messages of given type.
+func msgVersion(msgType string) service.Version {
+	switch msgType {
+	case didDocRequestMsgTypeV2, didDocResponseMsgTypeV2, registerRouteRequestMsgTypeV2, registerRouteResponseMsgTypeV2:
+		return service.V2
+	default:
+		return service.V1
+	}
+}
+
+// versionedMsgType returns variant of given blinded routing message type for given DIDComm version.
+func versionedMsgType(version service.Version, msgType string) string {
+	if version == service.V2 {
+		return msgTypeV2(msgType)
+	}
+
+	return msgType
+}
@@ -9,6 +9,9 @@ package blindedrouting
 
 import (
 	"encoding/json"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 )
 
 // DIDDocRequest model
@@ -48,3 +51,115 @@ type RegisterRouteResponse struct {
 	// Payload contains response from a connection for a register route request.
 	Payload json.RawMessage `json:"payload"`
 }
+
+// ListRoutesRequest model
+//
+// This is used for listing persisted blinded routing exchanges.
+type ListRoutesRequest struct {
+	// ConnectionID filter.
+	// Optional: if missing, routes of all connections are returned.
+	ConnectionID string `json:"connectionID,omitempty"`
+
+	// Status filter, one of 'requested', 'diddoc-received', 'did-created', 'registering', 'registered' or 'failed'.
+	// Optional: if missing, routes in any status are returned.
+	Status string `json:"status,omitempty"`
+}
+
+// ListRoutesResponse model
+//
+// Response of list routes command.
+type ListRoutesResponse struct {
+	Routes []*RouteRecord `json:"routes"`
+}
+
+// GetRouteRequest model
+//
+// This is used for getting persisted blinded routing exchange.
+type GetRouteRequest struct {
+	// ID of the route.
+	ID string `json:"id"`
+}
+
+// GetRouteResponse model
+//
+// Response of get route command.
+type GetRouteResponse struct {
+	Route *RouteRecord `json:"route"`
+}
+
+// RemoveRouteRequest model
+//
+// This is used for removing persisted blinded routing exchange.
+type RemoveRouteRequest struct {
+	// ID of the route.
+	ID string `json:"id"`
+}
+
+// EstablishBlindedRouteRequest model
+//
+// This is used for establishing blinded route with a router.
+type EstablishBlindedRouteRequest struct {
+	// ConnectionID of the connection to router over which new blinded route is established.
+	ConnectionID string `json:"connectionID,omitempty"`
+
+	// RouteID of previously started blinded routing exchange to be resumed,
+	// failed exchange is restarted from a fresh DID doc request.
+	// Optional: if provided, connection ID is ignored.
+	RouteID string `json:"routeID,omitempty"`
+}
+
+// EstablishBlindedRouteResponse model
+//
+// Response of establish blinded route command.
+type EstablishBlindedRouteResponse struct {
+	Route *RouteRecord `json:"route"`
+}
+
+// RouteRecord is persisted blinded routing exchange with a router.
+type RouteRecord struct {
+	// ID of the route.
+	ID string `json:"id"`
+
+	// ConnectionID of the connection to router.
+	ConnectionID string `json:"connectionID,omitempty"`
+
+	// Status of the route, one of 'requested', 'diddoc-received', 'did-created', 'registering', 'registered'
+	// or 'failed'.
+	Status string `json:"status"`
+
+	// ErrorMsg describes why the exchange failed, if it did.
+	ErrorMsg string `json:"errorMsg,omitempty"`
+
+	// DIDCommVersion of messages exchanged with router, 'v1' or 'v2'.
+	DIDCommVersion service.Version `json:"didCommVersion,omitempty"`
+
+	// DIDDocRequestID is ID of the DID doc request message sent to router.
+	DIDDocRequestID string `json:"didDocRequestID,omitempty"`
+
+	// DIDDocResponseID is ID of the DID doc response message received from router.
+	DIDDocResponseID string `json:"didDocResponseID,omitempty"`
+
+	// RouterDIDDoc is DID document issued by router for routing.
+	RouterDIDDoc json.RawMessage `json:"routerDIDDoc,omitempty"`
+
+	// RegisterRouteRequestID is ID of the register route request message sent to router.
+	RegisterRouteRequestID string `json:"registerRouteRequestID,omitempty"`
+
+	// RegisterRouteResponseID is ID of the register route response message received from router.
+	RegisterRouteResponseID string `json:"registerRouteResponseID,omitempty"`
+
+	// RegisteredDIDDoc is DID document registered with router.
+	RegisteredDIDDoc json.RawMessage `json:"registeredDIDDoc,omitempty"`
+
+	// CreatedAt is the time when the exchange was started.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// UpdatedAt is the time when the exchange was last updated.
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// routingMsgData is data of blinded routing messages exchanged with router.
+type routingMsgData struct {
+	ErrorMsg string          `json:"errorMsg,omitempty"`
+	DIDDoc   json.RawMessage `json:"didDoc,omitempty"`
+}
@@ -0,0 +1,412 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blindedrouting
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/pkg/common/model"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	didexchangeSvc "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/didexchange"
+	mediatorSvc "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/mediator"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+	"github.com/hyperledger/aries-framework-go/pkg/vdr/peer"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+)
+
+const (
+	// RouterServiceName is name of the message service responding to blinded routing requests.
+	RouterServiceName = "blindedrouting-router"
+
+	// store name and tag for blinded routing sessions of router.
+	routerStoreName  = "blindedrouting_router"
+	routerSessionTag = "routerSession"
+
+	// routerSessionTTL is the time within which requester has to register route after DID doc response.
+	routerSessionTTL = 10 * time.Minute
+
+	// keylist update action registering recipient key with mediator service.
+	keylistUpdateActionAdd = "add"
+
+	// DID document properties.
+	didCommServiceType   = "did-communication"
+	didCommV2ServiceType = "DIDCommMessaging"
+	didCommV2Profile     = "didcomm/v2"
+	verificationKeyType  = "Ed25519VerificationKey2018"
+	peerDIDPrefix        = "did:peer:"
+	storeDIDOption       = "store"
+
+	errUnknownRoutingSession = "unknown blinded routing session: %s"
+	errExpiredRoutingSession = "blinded routing session expired: %s"
+	errSessionConnection     = "register route request for blinded routing session %s received over another connection"
+)
+
+// router is a message service responding to blinded routing requests from other agents,
+// allowing this agent to act as a blinded router.
+//
+// On 'diddoc-req' a new peer DID is issued for the requester and returned in 'diddoc-resp'.
+// On 'register-route-req', sent in a thread nested in the thread of 'diddoc-resp' over the same connection,
+// requester's DID document is stored, connection between issued DID and requester's DID is created and
+// requester's keys are registered with mediator service of this agent.
+type router struct {
+	vdrRegistry vdr.Registry
+	kms         kms.KeyManager
+	messenger   service.Messenger
+	routes      routeRegistry
+	connections *connection.Recorder
+	store       storage.Store
+	endpoint    string
+}
+
+// routerSession links register route request to DID issued in response to DID doc request.
+// Sessions are keyed by thread of DID doc response and are bound to the connection of DID doc request.
+type routerSession struct {
+	ThreadID  string    `json:"threadID"`
+	RouterDID string    `json:"routerDID"`
+	MyDID     string    `json:"myDID"`
+	TheirDID  string    `json:"theirDID"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// routeRegistry registers routes of recipient keys with mediator service of this agent.
+type routeRegistry interface {
+	addRoutes(record *connection.Record, keys []string) error
+}
+
+// mediatorRoutes registers routes with mediator service of this agent the way requester connected over given
+// connection would, handing keylist update of the requester to the service. Mediator service responds to the
+// requester with keylist update response and routes messages forwarded to registered keys over the connection.
+type mediatorRoutes struct {
+	mediator service.InboundHandler
+}
+
+func (m *mediatorRoutes) addRoutes(record *connection.Record, keys []string) error {
+	updates := make([]mediatorSvc.Update, len(keys))
+	for i, key := range keys {
+		updates[i] = mediatorSvc.Update{RecipientKey: key, Action: keylistUpdateActionAdd}
+	}
+
+	msg := service.NewDIDCommMsgMap(&mediatorSvc.KeylistUpdate{
+		ID:      uuid.New().String(),
+		Type:    mediatorSvc.KeylistUpdateMsgType,
+		Updates: updates,
+	})
+
+	_, err := m.mediator.HandleInbound(msg, service.NewDIDCommContext(record.MyDID, record.TheirDID, nil))
+	if err != nil {
+		return fmt.Errorf("failed to update keylist: %w", err)
+	}
+
+	return nil
+}
+
+func newRouter(p Provider) (*router, error) {
+	svc, err := p.Service(mediatorSvc.Coordination)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up mediator service: %w", err)
+	}
+
+	mediator, ok := svc.(service.InboundHandler)
+	if !ok {
+		return nil, errors.New("cast service to mediator service failed")
+	}
+
+	connections, err := connection.NewRecorder(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection recorder: %w", err)
+	}
+
+	store, err := p.StorageProvider().OpenStore(routerStoreName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open router store: %w", err)
+	}
+
+	return &router{
+		vdrRegistry: p.VDRegistry(),
+		kms:         p.KMS(),
+		messenger:   p.Messenger(),
+		routes:      &mediatorRoutes{mediator: mediator},
+		connections: connections,
+		store:       store,
+		endpoint:    p.ServiceEndpoint(),
+	}, nil
+}
+
+// Name of the message service.
+func (r *router) Name() string {
+	return RouterServiceName
+}
+
+// Accept accepts blinded routing requests.
+func (r *router) Accept(msgType string, _ []string) bool {
+	switch msgType {
+	case didDocRequestMsgType, didDocRequestMsgTypeV2, registerRouteRequestMsgType, registerRouteRequestMsgTypeV2:
+		return true
+	default:
+		return false
+	}
+}
+
+// HandleInbound handles blinded routing requests.
+func (r *router) HandleInbound(msg service.DIDCommMsg, ctx service.DIDCommContext) (string, error) {
+	switch msg.Type() {
+	case didDocRequestMsgType, didDocRequestMsgTypeV2:
+		return "", r.handleDIDDocRequest(msg, ctx)
+	case registerRouteRequestMsgType, registerRouteRequestMsgTypeV2:
+		return "", r.handleRegisterRouteRequest(msg, ctx)
+	default:
+		return "", fmt.Errorf("unsupported message type: %s", msg.Type())
+	}
+}
+
+// handleDIDDocRequest issues routing DID for the requester and opens session for registering route,
+// DID doc response is sent in the thread of the request, so the session is keyed by the request thread.
+func (r *router) handleDIDDocRequest(msg service.DIDCommMsg, ctx service.DIDCommContext) error {
+	thID, err := msg.ThreadID()
+	if err != nil {
+		return fmt.Errorf("failed to get thread ID: %w", err)
+	}
+
+	r.removeExpiredSessions()
+
+	doc, err := r.createRoutingDID(msgVersion(msg.Type()))
+	if err != nil {
+		logger.Errorf("failed to create routing DID: %s", err)
+
+		return r.reply(msg, didDocResponseMsgType, &routingMsgData{ErrorMsg: "failed to create routing DID"})
+	}
+
+	sessionBytes, err := json.Marshal(&routerSession{
+		ThreadID:  thID,
+		RouterDID: doc.ID,
+		MyDID:     ctx.MyDID(),
+		TheirDID:  ctx.TheirDID(),
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal router session: %w", err)
+	}
+
+	err = r.store.Put(thID, sessionBytes, storage.Tag{Name: routerSessionTag})
+	if err != nil {
+		return fmt.Errorf("failed to save router session: %w", err)
+	}
+
+	docBytes, err := doc.JSONBytes()
+	if err != nil {
+		return fmt.Errorf("failed to marshal routing DID document: %w", err)
+	}
+
+	return r.reply(msg, didDocResponseMsgType, &routingMsgData{DIDDoc: docBytes})
+}
+
+func (r *router) handleRegisterRouteRequest(msg service.DIDCommMsg, ctx service.DIDCommContext) error {
+	connID, err := r.registerRoute(msg, ctx)
+	if err != nil {
+		logger.Errorf("failed to register route: %s", err)
+
+		return r.reply(msg, registerRouteResponseMsgType, &routingMsgData{ErrorMsg: err.Error()})
+	}
+
+	logger.Infof("registered blinded route, connection ID: %s", connID)
+
+	return r.reply(msg, registerRouteResponseMsgType, &routingMsgData{})
+}
+
+// registerRoute creates connection with requester's DID and registers its keys with mediator service,
+// returns ID of the new connection.
+func (r *router) registerRoute(msg service.DIDCommMsg, ctx service.DIDCommContext) (string, error) {
+	session, err := r.session(msg.ParentThreadID(), ctx)
+	if err != nil {
+		return "", err
+	}
+
+	request, err := decodeRoutingMsg(msg)
+	if err != nil || request.Data == nil || len(request.Data.DIDDoc) == 0 {
+		return "", errors.New("DID document missing in register route request")
+	}
+
+	theirDoc, err := did.ParseDocument(request.Data.DIDDoc)
+	if err != nil {
+		return "", fmt.Errorf("invalid DID document in register route request: %w", err)
+	}
+
+	if strings.HasPrefix(theirDoc.ID, peerDIDPrefix) {
+		_, err = r.vdrRegistry.Create(peer.DIDMethod, theirDoc, vdr.WithOption(storeDIDOption, true))
+		if err != nil {
+			return "", fmt.Errorf("failed to store DID document: %w", err)
+		}
+	}
+
+	record := &connection.Record{
+		ConnectionID:   uuid.New().String(),
+		State:          didexchangeSvc.StateIDCompleted,
+		MyDID:          session.RouterDID,
+		TheirDID:       theirDoc.ID,
+		Namespace:      connection.TheirNSPrefix,
+		DIDCommVersion: msgVersion(msg.Type()),
+	}
+
+	err = r.connections.SaveConnectionRecord(record)
+	if err != nil {
+		return "", fmt.Errorf("failed to save connection record: %w", err)
+	}
+
+	err = r.addKeys(record, theirDoc)
+	if err != nil {
+		return "", err
+	}
+
+	r.removeSession(session.ThreadID)
+
+	return record.ConnectionID, nil
+}
+
+// session returns open session of given thread of DID doc response, the session must not be expired and
+// must have been opened over the connection of given context.
+func (r *router) session(thID string, ctx service.DIDCommContext) (*routerSession, error) {
+	if thID == "" {
+		return nil, errors.New("register route request is not nested in thread of DID doc response")
+	}
+
+	sessionBytes, err := r.store.Get(thID)
+	if err != nil {
+		return nil, fmt.Errorf(errUnknownRoutingSession, thID)
+	}
+
+	session := &routerSession{}
+
+	err = json.Unmarshal(sessionBytes, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal router session: %w", err)
+	}
+
+	if session.expired() {
+		r.removeSession(thID)
+
+		return nil, fmt.Errorf(errExpiredRoutingSession, thID)
+	}
+
+	if session.MyDID != ctx.MyDID() || session.TheirDID != ctx.TheirDID() {
+		return nil, fmt.Errorf(errSessionConnection, thID)
+	}
+
+	return session, nil
+}
+
+// removeExpiredSessions removes sessions of requesters who did not register route in time.
+func (r *router) removeExpiredSessions() {
+	iter, err := r.store.Query(routerSessionTag)
+	if err != nil {
+		logger.Warnf("failed to query router sessions: %s", err)
+
+		return
+	}
+
+	defer func() {
+		if e := iter.Close(); e != nil {
+			logger.Warnf("failed to close iterator: %s", e)
+		}
+	}()
+
+	more, err := iter.Next()
+
+	for ; more && err == nil; more, err = iter.Next() {
+		thID, e := iter.Key()
+		if e != nil {
+			logger.Warnf("failed to read router session: %s", e)
+
+			continue
+		}
+
+		sessionBytes, e := iter.Value()
+		if e != nil {
+			logger.Warnf("failed to read router session %s: %s", thID, e)
+
+			continue
+		}
+
+		session := &routerSession{}
+
+		if e = json.Unmarshal(sessionBytes, session); e != nil || session.expired() {
+			r.removeSession(thID)
+		}
+	}
+
+	if err != nil {
+		logger.Warnf("failed to iterate router sessions: %s", err)
+	}
+}
+
+func (r *router) removeSession(thID string) {
+	if err := r.store.Delete(thID); err != nil {
+		logger.Warnf("failed to delete router session %s: %s", thID, err)
+	}
+}
+
+// addKeys registers keys of given DID document with mediator service of this agent, so that messages
+// forwarded to those keys are routed over given connection.
+func (r *router) addKeys(record *connection.Record, theirDoc *did.Doc) error {
+	keys := didKeys(theirDoc)
+	if len(keys) == 0 {
+		return fmt.Errorf("no keys found in DID document %s", theirDoc.ID)
+	}
+
+	err := r.routes.addRoutes(record, keys)
+	if err != nil {
+		return fmt.Errorf("failed to register keys with mediator: %w", err)
+	}
+
+	return nil
+}
+
+func (s *routerSession) expired() bool {
+	return time.Since(s.CreatedAt) > routerSessionTTL
+}
+
+// createRoutingDID creates peer DID to be used by requester for routing messages through this agent, with
+// DIDComm service of given version.
+func (r *router) createRoutingDID(version service.Version) (*did.Doc, error) {
+	return createPeerDID(r.kms, r.vdrRegistry, version, r.endpoint, nil)
+}
+
+// reply replies to given request with message of given type, in format of the request.
+func (r *router) reply(request service.DIDCommMsg, msgType string, data *routingMsgData) error {
+	err := r.messenger.ReplyTo(request.ID(), newRoutingMsg(msgVersion(request.Type()), msgType, data))
+	if err != nil {
+		return fmt.Errorf("failed to reply to %s: %w", request.ID(), err)
+	}
+
+	return nil
+}
+
+// didKeys returns keys of given DID document which can be registered with mediator,
+// i.e. key agreement key IDs and recipient keys of DIDComm services.
+func didKeys(doc *did.Doc) []string {
+	var keys []string
+
+	for _, ka := range doc.KeyAgreement {
+		keys = append(keys, ka.VerificationMethod.ID)
+	}
+
+	for _, svc := range doc.Service {
+		if svc.Type == didCommServiceType || svc.Type == didCommV2ServiceType {
+			keys = append(keys, svc.RecipientKeys...)
+		}
+	}
+
+	return keys
+}
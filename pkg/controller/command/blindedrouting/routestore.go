@@ -0,0 +1,159 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blindedrouting
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+)
+
+const (
+	// RouteStatusRequested DID doc request was sent to router.
+	RouteStatusRequested = "requested"
+	// RouteStatusDIDDocReceived router responded with DID document to be used for routing.
+	RouteStatusDIDDocReceived = "diddoc-received"
+	// RouteStatusDIDCreated peer DID to be registered with router was created.
+	RouteStatusDIDCreated = "did-created"
+	// RouteStatusRegistering register route request was sent to router.
+	RouteStatusRegistering = "registering"
+	// RouteStatusRegistered route was registered with router.
+	RouteStatusRegistered = "registered"
+	// RouteStatusFailed blinded routing exchange failed.
+	RouteStatusFailed = "failed"
+
+	// RouteTopic is the notifier topic on which progress of blinded routing exchanges is published.
+	RouteTopic = "blindedrouting-route"
+
+	// store name and tags for route records.
+	routeStoreName           = "blindedrouting_route"
+	routeTag                 = "route"
+	routeConnectionIDTag     = "connectionID"
+	routeDIDDocResponseIDTag = "didDocResponseID"
+)
+
+// routeStore persists blinded routing exchanges with routers.
+type routeStore struct {
+	store storage.Store
+}
+
+func newRouteStore(p storage.Provider) (*routeStore, error) {
+	store, err := p.OpenStore(routeStoreName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open route store: %w", err)
+	}
+
+	return &routeStore{store: store}, nil
+}
+
+func (s *routeStore) save(record *RouteRecord) error {
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal route record: %w", err)
+	}
+
+	tags := []storage.Tag{{Name: routeTag}}
+
+	if record.ConnectionID != "" {
+		tags = append(tags, storage.Tag{Name: routeConnectionIDTag, Value: record.ConnectionID})
+	}
+
+	if record.DIDDocResponseID != "" {
+		tags = append(tags, storage.Tag{Name: routeDIDDocResponseIDTag, Value: record.DIDDocResponseID})
+	}
+
+	return s.store.Put(record.ID, recordBytes, tags...)
+}
+
+func (s *routeStore) get(id string) (*RouteRecord, error) {
+	recordBytes, err := s.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &RouteRecord{}
+
+	err = json.Unmarshal(recordBytes, record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal route record: %w", err)
+	}
+
+	return record, nil
+}
+
+// getByDIDDocResponseID returns route record of the exchange in which router responded with given message,
+// storage.ErrDataNotFound is returned if no such exchange was persisted.
+func (s *routeStore) getByDIDDocResponseID(msgID string) (*RouteRecord, error) {
+	records, err := s.query(routeDIDDocResponseIDTag + ":" + msgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, storage.ErrDataNotFound
+	}
+
+	return records[0], nil
+}
+
+// list returns persisted route records, only records of given connection are returned if connection ID is provided.
+func (s *routeStore) list(connID string) ([]*RouteRecord, error) {
+	if connID != "" {
+		return s.query(routeConnectionIDTag + ":" + connID)
+	}
+
+	return s.query(routeTag)
+}
+
+func (s *routeStore) remove(id string) error {
+	_, err := s.store.Get(id)
+	if err != nil {
+		return err
+	}
+
+	return s.store.Delete(id)
+}
+
+func (s *routeStore) query(expression string) ([]*RouteRecord, error) {
+	iter, err := s.store.Query(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if e := iter.Close(); e != nil {
+			logger.Warnf("failed to close iterator: %s", e)
+		}
+	}()
+
+	var records []*RouteRecord
+
+	more, err := iter.Next()
+
+	for ; more && err == nil; more, err = iter.Next() {
+		value, e := iter.Value()
+		if e != nil {
+			return nil, e
+		}
+
+		record := &RouteRecord{}
+
+		if e = json.Unmarshal(value, record); e != nil {
+			return nil, fmt.Errorf("failed to unmarshal route record: %w", e)
+		}
+
+		records = append(records, record)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
@@ -0,0 +1,133 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+)
+
+// ErrorDefinition describes an error code of a command in the error catalog.
+type ErrorDefinition struct {
+	Code command.Code `json:"code"`
+	// Name is stable machine-readable name of the error, e.g. 'STORE_CONFLICT'.
+	Name    string `json:"name"`
+	Command string `json:"command,omitempty"`
+	// Description of the error for humans.
+	Description string `json:"description"`
+	// Retryable errors are typically caused by temporary conditions, e.g. unreachable peers, so that the same
+	// request may succeed later. Validation errors are never retryable.
+	Retryable bool `json:"retryable"`
+	// Conflict errors are caused by records changed by other requests, e.g. conditional writes whose versions
+	// do not match, REST handlers report them with status 409.
+	Conflict bool `json:"conflict,omitempty"`
+}
+
+// ErrorDetail describes a field-level cause of an error.
+type ErrorDetail struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// ErrorInfo is the machine-readable form of a command error.
+type ErrorInfo struct {
+	Code      command.Code  `json:"code"`
+	Name      string        `json:"name,omitempty"`
+	Message   string        `json:"message"`
+	Details   []ErrorDetail `json:"details,omitempty"`
+	Retryable bool          `json:"retryable,omitempty"`
+}
+
+// detailer is implemented by errors carrying field-level details, e.g. SchemaError.
+type detailer interface {
+	ErrorDetails() []ErrorDetail
+}
+
+var catalog = struct { //nolint:gochecknoglobals
+	mu          sync.RWMutex
+	definitions map[command.Code]ErrorDefinition
+}{
+	definitions: map[command.Code]ErrorDefinition{
+		UnknownStatus: {Code: UnknownStatus, Name: "UNKNOWN", Description: "unknown error"},
+	},
+}
+
+// RegisterErrors adds given definitions to the error catalog. Registering the same definition again has no effect,
+// an error is returned for codes already defined differently.
+func RegisterErrors(definitions ...ErrorDefinition) error {
+	catalog.mu.Lock()
+	defer catalog.mu.Unlock()
+
+	for _, definition := range definitions {
+		if definition.Name == "" {
+			return fmt.Errorf("name of error code %d is mandatory", definition.Code)
+		}
+
+		if registered, ok := catalog.definitions[definition.Code]; ok && registered != definition {
+			return fmt.Errorf("error code %d is already defined as %s", definition.Code, registered.Name)
+		}
+	}
+
+	for _, definition := range definitions {
+		catalog.definitions[definition.Code] = definition
+	}
+
+	return nil
+}
+
+// ErrorCatalog returns definitions of all registered error codes sorted by code.
+func ErrorCatalog() []ErrorDefinition {
+	catalog.mu.RLock()
+	defer catalog.mu.RUnlock()
+
+	definitions := make([]ErrorDefinition, 0, len(catalog.definitions))
+
+	for _, definition := range catalog.definitions {
+		definitions = append(definitions, definition)
+	}
+
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].Code < definitions[j].Code })
+
+	return definitions
+}
+
+// LookupError returns definition of given error code.
+func LookupError(code command.Code) (ErrorDefinition, bool) {
+	catalog.mu.RLock()
+	defer catalog.mu.RUnlock()
+
+	definition, ok := catalog.definitions[code]
+
+	return definition, ok
+}
+
+// DescribeError returns machine-readable form of given error with given code, named by the error catalog.
+// Field-level details are taken from errors providing them, e.g. requests not matching schema.
+func DescribeError(code command.Code, err error) ErrorInfo {
+	info := ErrorInfo{Code: code, Message: err.Error()}
+
+	if definition, ok := LookupError(code); ok {
+		info.Name = definition.Name
+		info.Retryable = definition.Retryable
+	}
+
+	var cmdErr command.Error
+	if errors.As(err, &cmdErr) && cmdErr.Type() == command.ValidationError {
+		info.Retryable = false
+	}
+
+	var d detailer
+	if errors.As(err, &d) {
+		info.Details = d.ErrorDetails()
+	}
+
+	return info
+}
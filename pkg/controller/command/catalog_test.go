@@ -0,0 +1,88 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	agentcmd "github.com/trustbloc/agent-sdk/pkg/controller/command"
+)
+
+func TestRegisterErrors(t *testing.T) {
+	definition := agentcmd.ErrorDefinition{
+		Code: 9801, Name: "TEST_REGISTERED", Command: "test", Description: "registered error", Retryable: true,
+	}
+
+	require.NoError(t, agentcmd.RegisterErrors(definition))
+	require.NoError(t, agentcmd.RegisterErrors(definition))
+
+	registered, ok := agentcmd.LookupError(definition.Code)
+	require.True(t, ok)
+	require.Equal(t, definition, registered)
+	require.Contains(t, agentcmd.ErrorCatalog(), definition)
+
+	t.Run("conflicting definition", func(t *testing.T) {
+		err := agentcmd.RegisterErrors(agentcmd.ErrorDefinition{Code: definition.Code, Name: "TEST_OTHER"})
+		require.EqualError(t, err, "error code 9801 is already defined as TEST_REGISTERED")
+	})
+
+	t.Run("missing name", func(t *testing.T) {
+		err := agentcmd.RegisterErrors(agentcmd.ErrorDefinition{Code: 9802})
+		require.EqualError(t, err, "name of error code 9802 is mandatory")
+
+		_, ok := agentcmd.LookupError(9802)
+		require.False(t, ok)
+	})
+
+	t.Run("catalog is sorted by code", func(t *testing.T) {
+		definitions := agentcmd.ErrorCatalog()
+		require.Equal(t, agentcmd.UnknownStatus, definitions[0].Code)
+
+		for i := 1; i < len(definitions); i++ {
+			require.Less(t, definitions[i-1].Code, definitions[i].Code)
+		}
+	})
+}
+
+func TestDetailedError(t *testing.T) {
+	require.NoError(t, agentcmd.RegisterErrors(agentcmd.ErrorDefinition{
+		Code: 9811, Name: "TEST_DETAILED", Description: "detailed error", Retryable: true,
+	}))
+
+	t.Run("execute error", func(t *testing.T) {
+		cause := errors.New("unavailable")
+
+		var e agentcmd.DetailedError
+
+		require.True(t, errors.As(agentcmd.NewExecuteError(9811, cause), &e))
+		require.Equal(t, "TEST_DETAILED", e.Name())
+		require.True(t, e.Retryable())
+		require.Empty(t, e.Details())
+		require.True(t, errors.Is(e, cause))
+	})
+
+	t.Run("validation error", func(t *testing.T) {
+		cause := &agentcmd.SchemaError{Violations: []agentcmd.SchemaViolation{
+			{Field: "$.id", Description: "expected string"},
+		}}
+
+		var e agentcmd.DetailedError
+
+		require.True(t, errors.As(agentcmd.NewValidationError(9811, cause), &e))
+		require.Equal(t, "TEST_DETAILED", e.Name())
+		require.False(t, e.Retryable())
+		require.Equal(t, []agentcmd.ErrorDetail{{Field: "$.id", Description: "expected string"}}, e.Details())
+	})
+
+	t.Run("unregistered code", func(t *testing.T) {
+		info := agentcmd.DescribeError(9812, agentcmd.NewExecuteError(9812, errors.New("failed")))
+		require.Equal(t, agentcmd.ErrorInfo{Code: 9812, Message: "failed"}, info)
+	})
+}
@@ -9,6 +9,7 @@ SPDX-License-Identifier: Apache-2.0
 package didclient
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/ed25519"
 	"crypto/elliptic"
@@ -29,6 +30,7 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
 	"github.com/hyperledger/aries-framework-go/pkg/crypto"
 	"github.com/hyperledger/aries-framework-go/pkg/crypto/primitive/bbs12381g2pub"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	mediatorservice "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/mediator"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
 	jwk2 "github.com/hyperledger/aries-framework-go/pkg/doc/jose/jwk"
@@ -36,6 +38,7 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
 	"github.com/hyperledger/aries-framework-go/pkg/kms"
 	"github.com/hyperledger/aries-framework-go/pkg/vdr/peer"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
 	"github.com/trustbloc/edge-core/pkg/log"
 	diddoctransformer "github.com/trustbloc/orb/pkg/orbclient/doctransformer"
 	"github.com/trustbloc/sidetree-core-go/pkg/document"
@@ -43,6 +46,7 @@ import (
 	agentcmd "github.com/trustbloc/agent-sdk/pkg/controller/command"
 	"github.com/trustbloc/agent-sdk/pkg/controller/internal/cmdutil"
 	"github.com/trustbloc/agent-sdk/pkg/controller/internal/logutil"
+	"github.com/trustbloc/agent-sdk/pkg/controller/internal/mediation"
 )
 
 var logger = log.New("agent-sdk-didclient")
@@ -60,11 +64,10 @@ const (
 	VerifyWebDIDFromOrbDIDCommandMethod = "VerifyWebDIDFromOrbDID"
 	// CreatePeerDIDCommandMethod command method.
 	CreatePeerDIDCommandMethod = "CreatePeerDID"
-	// log constants.
-	successString = "success"
 
 	didCommServiceType   = "did-communication"
 	didCommV2ServiceType = "DIDCommMessaging"
+	didCommV2Profile     = "didcomm/v2"
 
 	// ed25519KeyType defines ed25119 key type.
 	ed25519KeyType = "ed25519"
@@ -101,6 +104,7 @@ const (
 	errInvalidRouterConnectionID = "invalid router connection ID"
 	errMissingDIDCommServiceType = "did document missing '%s' service type"
 	errFailedToRegisterDIDRecKey = "failed to register did doc recipient key : %w"
+	errMissingRoutingDID         = "router connection %s has no routing DID"
 )
 
 // Provider describes dependencies for the client.
@@ -113,6 +117,9 @@ type Provider interface {
 type ProviderWithMediator interface {
 	Provider
 	Service(id string) (interface{}, error)
+	StorageProvider() storage.Provider
+	ProtocolStateStorageProvider() storage.Provider
+	Messenger() service.Messenger
 }
 
 type didBlocClient interface {
@@ -126,9 +133,46 @@ type mediatorClient interface {
 	GetConfig(connID string) (*mediatorservice.Config, error)
 }
 
+// mediationClient is client of coordinate mediation 2.0 used with DIDComm V2 routers.
+type mediationClient interface {
+	Get(connID string) (*mediation.Record, error)
+	UpdateRecipients(ctx context.Context, connID string, recipients []string,
+		action string) ([]mediation.UpdateResult, error)
+	UpdateKeylist(connID string, results []mediation.UpdateResult) error
+}
+
+type options struct {
+	msgHandler command.MessageHandler
+	mediations *mediation.Client
+}
+
+// Opt represents a DID client command option.
+type Opt func(opts *options)
+
+// WithMessageHandler enables registering DIDs with DIDComm V2 routers, replies of routers are received
+// through message services registered with given message handler.
+func WithMessageHandler(msgHandler command.MessageHandler) Opt {
+	return func(opts *options) {
+		opts.msgHandler = msgHandler
+	}
+}
+
+// WithMediationClient sets coordinate mediation 2.0 client shared with other commands receiving replies of routers
+// through the message handler, it is created by the command if not set.
+func WithMediationClient(client *mediation.Client) Opt {
+	return func(opts *options) {
+		opts.mediations = client
+	}
+}
+
 func newCommand(domain, didAnchorOrigin, token string, unanchoredDIDMaxLifeTime int,
 	p Provider, mediatorClient mediatorClient, mediatorSvc mediatorservice.ProtocolService,
+	mediations mediationClient,
 ) (*Command, error) {
+	if err := agentcmd.RegisterErrors(Errors()...); err != nil {
+		return nil, err
+	}
+
 	orbOpts := make([]orb.Option, 0)
 
 	if unanchoredDIDMaxLifeTime > 0 {
@@ -148,6 +192,7 @@ func newCommand(domain, didAnchorOrigin, token string, unanchoredDIDMaxLifeTime
 		vdrRegistry:     p.VDRegistry(),
 		mediatorClient:  mediatorClient,
 		mediatorSvc:     mediatorSvc,
+		mediations:      mediations,
 		keyManager:      p.KMS(),
 		didAnchorOrigin: didAnchorOrigin,
 	}, nil
@@ -155,13 +200,19 @@ func newCommand(domain, didAnchorOrigin, token string, unanchoredDIDMaxLifeTime
 
 // New returns new DID Exchange controller command instance.
 func New(domain, didAnchorOrigin, token string, unanchoredDIDMaxLifeTime int, p Provider) (*Command, error) {
-	return newCommand(domain, didAnchorOrigin, token, unanchoredDIDMaxLifeTime, p, nil, nil)
+	return newCommand(domain, didAnchorOrigin, token, unanchoredDIDMaxLifeTime, p, nil, nil, nil)
 }
 
-// NewWithMediator returns new DID Exchange controller command instance.
+// NewWithMediator returns new DID Exchange controller command instance registering created DIDs with routers.
 func NewWithMediator(domain, didAnchorOrigin, token string, unanchoredDIDMaxLifeTime int,
-	p ProviderWithMediator,
+	p ProviderWithMediator, opts ...Opt,
 ) (*Command, error) {
+	cmdOpts := &options{}
+
+	for _, opt := range opts {
+		opt(cmdOpts)
+	}
+
 	mClient, err := mediator.New(p)
 	if err != nil {
 		return nil, err
@@ -179,7 +230,21 @@ func NewWithMediator(domain, didAnchorOrigin, token string, unanchoredDIDMaxLife
 		return nil, errors.New("cast service to route service failed")
 	}
 
-	return newCommand(domain, didAnchorOrigin, token, unanchoredDIDMaxLifeTime, p, mClient, mediatorSvc)
+	// DIDs are registered only with DIDComm V1 routers unless replies of DIDComm V2 routers can be received.
+	var mediations mediationClient
+
+	if cmdOpts.msgHandler != nil {
+		mediations = cmdOpts.mediations
+
+		if cmdOpts.mediations == nil {
+			mediations, err = mediation.New(p, cmdOpts.msgHandler)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create mediation client: %w", err)
+			}
+		}
+	}
+
+	return newCommand(domain, didAnchorOrigin, token, unanchoredDIDMaxLifeTime, p, mClient, mediatorSvc, mediations)
 }
 
 // Command is controller command for DID Exchange.
@@ -189,6 +254,7 @@ type Command struct {
 	vdrRegistry     vdr.Registry
 	mediatorClient  mediatorClient
 	mediatorSvc     mediatorservice.ProtocolService
+	mediations      mediationClient
 	keyManager      kms.KeyManager
 	didAnchorOrigin string
 }
@@ -210,15 +276,43 @@ func (c *Command) GetHandlers() []command.Handler {
 	return handlers
 }
 
+// Schemas returns JSON schemas of request and response models of DID client command methods.
+func Schemas() []agentcmd.MethodSchema {
+	return []agentcmd.MethodSchema{
+		agentcmd.NewMethodSchema(CommandName, CreateOrbDIDCommandMethod, &CreateOrbDIDRequest{}, &did.DocResolution{}),
+		agentcmd.NewMethodSchema(CommandName, ResolveOrbDIDCommandMethod, &ResolveOrbDIDRequest{}, &did.DocResolution{}),
+		agentcmd.NewMethodSchema(CommandName, ResolveWebDIDFromOrbDIDCommandMethod, &ResolveOrbDIDRequest{},
+			&did.DocResolution{}),
+		agentcmd.NewMethodSchema(CommandName, VerifyWebDIDFromOrbDIDCommandMethod, &VerifyWebDIDFromOrbDIDRequest{}, nil),
+		agentcmd.NewMethodSchema(CommandName, CreatePeerDIDCommandMethod, &CreatePeerDIDRequest{}, &did.DocResolution{}),
+	}
+}
+
+// Errors returns definitions of DID client error codes for the error catalog.
+func Errors() []agentcmd.ErrorDefinition {
+	return []agentcmd.ErrorDefinition{
+		{
+			Code: InvalidRequestErrorCode, Name: "DIDCLIENT_INVALID_REQUEST", Command: CommandName,
+			Description: "invalid request",
+		},
+		{
+			Code: CreateDIDErrorCode, Name: "DIDCLIENT_CREATE_DID_FAILED", Command: CommandName,
+			Description: "failed to create DID", Retryable: true,
+		},
+		{
+			Code: ResolveDIDErrorCode, Name: "DIDCLIENT_RESOLVE_DID_FAILED", Command: CommandName,
+			Description: "failed to resolve DID", Retryable: true,
+		},
+	}
+}
+
 // ResolveWebDIDFromOrbDID resolve web DID from orb DID.
 func (c *Command) ResolveWebDIDFromOrbDID(rw io.Writer, req io.Reader) command.Error {
 	var request ResolveOrbDIDRequest
 
-	err := json.NewDecoder(req).Decode(&request)
+	err := agentcmd.DecodeRequest(req, &request)
 	if err != nil {
-		logutil.LogError(logger, CommandName, ResolveWebDIDFromOrbDIDCommandMethod, err.Error())
-
-		return command.NewValidationError(InvalidRequestErrorCode, err)
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
 	}
 
 	didWeb := strings.ReplaceAll(request.DID, "orb:https", "web")
@@ -226,20 +320,14 @@ func (c *Command) ResolveWebDIDFromOrbDID(rw io.Writer, req io.Reader) command.E
 
 	didWebResolution, errRead := c.vdrRegistry.Resolve(didWeb)
 	if errRead != nil {
-		logutil.LogError(logger, CommandName, ResolveWebDIDFromOrbDIDCommandMethod, errRead.Error())
-
-		return command.NewExecuteError(ResolveDIDErrorCode, errRead)
+		return agentcmd.NewExecuteError(ResolveDIDErrorCode, errRead)
 	}
 
 	bytes, err := didWebResolution.JSONBytes()
 	if err != nil {
-		logutil.LogError(logger, CommandName, ResolveWebDIDFromOrbDIDCommandMethod, err.Error())
-
-		return command.NewExecuteError(ResolveDIDErrorCode, err)
+		return agentcmd.NewExecuteError(ResolveDIDErrorCode, err)
 	}
 
-	logutil.LogDebug(logger, CommandName, ResolveWebDIDFromOrbDIDCommandMethod, successString)
-
 	if _, err := rw.Write(bytes); err != nil {
 		logger.Errorf(err.Error())
 	}
@@ -251,50 +339,36 @@ func (c *Command) ResolveWebDIDFromOrbDID(rw io.Writer, req io.Reader) command.E
 func (c *Command) VerifyWebDIDFromOrbDID(rw io.Writer, req io.Reader) command.Error {
 	var request VerifyWebDIDFromOrbDIDRequest
 
-	err := json.NewDecoder(req).Decode(&request)
+	err := agentcmd.DecodeRequest(req, &request)
 	if err != nil {
-		logutil.LogError(logger, CommandName, VerifyWebDIDFromOrbDIDCommandMethod, err.Error())
-
-		return command.NewValidationError(InvalidRequestErrorCode, err)
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
 	}
 
 	didWebResolution, errRead := c.vdrRegistry.Resolve(request.DID)
 	if errRead != nil {
-		logutil.LogError(logger, CommandName, VerifyWebDIDFromOrbDIDCommandMethod, errRead.Error())
-
-		return command.NewExecuteError(ResolveDIDErrorCode, errRead)
+		return agentcmd.NewExecuteError(ResolveDIDErrorCode, errRead)
 	}
 
 	didOrbResolution, errRead := c.didBlocClient.Read(didWebResolution.DIDDocument.AlsoKnownAs[0])
 	if errRead != nil {
-		logutil.LogError(logger, CommandName, VerifyWebDIDFromOrbDIDCommandMethod, errRead.Error())
-
-		return command.NewExecuteError(ResolveDIDErrorCode, errRead)
+		return agentcmd.NewExecuteError(ResolveDIDErrorCode, errRead)
 	}
 
 	didWebResolutionResult, err := transformToResolutionResult(didWebResolution)
 	if err != nil {
-		logutil.LogError(logger, CommandName, VerifyWebDIDFromOrbDIDCommandMethod, err.Error())
-
-		return command.NewExecuteError(ResolveDIDErrorCode, err)
+		return agentcmd.NewExecuteError(ResolveDIDErrorCode, err)
 	}
 
 	didOrbResolutionResult, err := transformToResolutionResult(didOrbResolution)
 	if err != nil {
-		logutil.LogError(logger, CommandName, VerifyWebDIDFromOrbDIDCommandMethod, err.Error())
-
-		return command.NewExecuteError(ResolveDIDErrorCode, err)
+		return agentcmd.NewExecuteError(ResolveDIDErrorCode, err)
 	}
 
 	if err := diddoctransformer.VerifyWebDocumentFromOrbDocument(didWebResolutionResult,
 		didOrbResolutionResult); err != nil {
-		logutil.LogError(logger, CommandName, VerifyWebDIDFromOrbDIDCommandMethod, err.Error())
-
-		return command.NewExecuteError(ResolveDIDErrorCode, err)
+		return agentcmd.NewExecuteError(ResolveDIDErrorCode, err)
 	}
 
-	logutil.LogDebug(logger, CommandName, VerifyWebDIDFromOrbDIDCommandMethod, successString)
-
 	return nil
 }
 
@@ -333,11 +407,9 @@ func transformToResolutionResult(docResolution *did.DocResolution) (*document.Re
 func (c *Command) ResolveOrbDID(rw io.Writer, req io.Reader) command.Error {
 	var request ResolveOrbDIDRequest
 
-	err := json.NewDecoder(req).Decode(&request)
+	err := agentcmd.DecodeRequest(req, &request)
 	if err != nil {
-		logutil.LogError(logger, CommandName, ResolveOrbDIDCommandMethod, err.Error())
-
-		return command.NewValidationError(InvalidRequestErrorCode, err)
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
 	}
 
 	if strings.Contains(request.DID, "did:web") {
@@ -347,20 +419,14 @@ func (c *Command) ResolveOrbDID(rw io.Writer, req io.Reader) command.Error {
 
 	docResolution, errRead := c.didBlocClient.Read(request.DID)
 	if errRead != nil {
-		logutil.LogError(logger, CommandName, ResolveOrbDIDCommandMethod, errRead.Error())
-
-		return command.NewExecuteError(ResolveDIDErrorCode, errRead)
+		return agentcmd.NewExecuteError(ResolveDIDErrorCode, errRead)
 	}
 
 	bytes, err := docResolution.JSONBytes()
 	if err != nil {
-		logutil.LogError(logger, CommandName, ResolveOrbDIDCommandMethod, err.Error())
-
-		return command.NewExecuteError(ResolveDIDErrorCode, err)
+		return agentcmd.NewExecuteError(ResolveDIDErrorCode, err)
 	}
 
-	logutil.LogDebug(logger, CommandName, ResolveOrbDIDCommandMethod, successString)
-
 	if _, err := rw.Write(bytes); err != nil {
 		logger.Errorf(err.Error())
 	}
@@ -372,11 +438,9 @@ func (c *Command) ResolveOrbDID(rw io.Writer, req io.Reader) command.Error {
 func (c *Command) CreateOrbDID(rw io.Writer, req io.Reader) command.Error { //nolint: funlen,gocyclo,gocognit,maintidx
 	var request CreateOrbDIDRequest
 
-	err := json.NewDecoder(req).Decode(&request)
+	err := agentcmd.DecodeRequest(req, &request)
 	if err != nil {
-		logutil.LogError(logger, CommandName, CreateOrbDIDCommandMethod, err.Error())
-
-		return command.NewValidationError(InvalidRequestErrorCode, err)
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
 	}
 
 	didDoc := did.Doc{}
@@ -421,16 +485,12 @@ func (c *Command) CreateOrbDID(rw io.Writer, req io.Reader) command.Error { //no
 	for _, v := range request.PublicKeys {
 		value, decodeErr := base64.RawURLEncoding.DecodeString(v.Value)
 		if decodeErr != nil {
-			logutil.LogError(logger, CommandName, CreateOrbDIDCommandMethod, decodeErr.Error())
-
-			return command.NewExecuteError(CreateDIDErrorCode, decodeErr)
+			return agentcmd.NewExecuteError(CreateDIDErrorCode, decodeErr)
 		}
 
 		k, errGet := getKey(v.KeyType, value)
 		if errGet != nil {
-			logutil.LogError(logger, CommandName, CreateOrbDIDCommandMethod, errGet.Error())
-
-			return command.NewExecuteError(CreateDIDErrorCode, errGet)
+			return agentcmd.NewExecuteError(CreateDIDErrorCode, errGet)
 		}
 
 		if v.Recovery {
@@ -454,18 +514,13 @@ func (c *Command) CreateOrbDID(rw io.Writer, req io.Reader) command.Error { //no
 		if strings.EqualFold(v.KeyType, x25519ECDHKW) {
 			jwk, errJWK = jwksupport.JWKFromX25519Key(k.(*crypto.PublicKey).X)
 			if errJWK != nil {
-				logutil.LogError(logger, CommandName, CreateOrbDIDCommandMethod, errJWK.Error())
-
-				return command.NewExecuteError(CreateDIDErrorCode, errJWK)
+				return agentcmd.NewExecuteError(CreateDIDErrorCode, errJWK)
 			}
 		} else if strings.EqualFold(v.KeyType, p256ecdhkw) || strings.EqualFold(v.KeyType, p384ecdhkw) ||
 			strings.EqualFold(v.KeyType, p521ecdhkw) {
 			pubKey, ok := k.(*crypto.PublicKey)
 			if !ok {
-				logutil.LogError(logger, CommandName, CreateOrbDIDCommandMethod,
-					fmt.Sprintf("key '%+v' is not NIST P ECDH KW type", k))
-
-				return command.NewExecuteError(CreateDIDErrorCode, fmt.Errorf("key '%+v' is not NIST P ECDH KW type", k))
+				return agentcmd.NewExecuteError(CreateDIDErrorCode, fmt.Errorf("key '%+v' is not NIST P ECDH KW type", k))
 			}
 
 			ecdsaKey := &ecdsa.PublicKey{
@@ -476,25 +531,19 @@ func (c *Command) CreateOrbDID(rw io.Writer, req io.Reader) command.Error { //no
 
 			jwk, errJWK = jwksupport.JWKFromKey(ecdsaKey)
 			if errJWK != nil {
-				logutil.LogError(logger, CommandName, CreateOrbDIDCommandMethod, errJWK.Error())
-
-				return command.NewExecuteError(CreateDIDErrorCode, fmt.Errorf("JWKFromKey() jwk: %+v, ecdsa key: "+
+				return agentcmd.NewExecuteError(CreateDIDErrorCode, fmt.Errorf("JWKFromKey() jwk: %+v, ecdsa key: "+
 					"%+v, error: %w", jwk, ecdsaKey, errJWK))
 			}
 		} else {
 			jwk, errJWK = jwksupport.JWKFromKey(k)
 			if errJWK != nil {
-				logutil.LogError(logger, CommandName, CreateOrbDIDCommandMethod, errJWK.Error())
-
-				return command.NewExecuteError(CreateDIDErrorCode, errJWK)
+				return agentcmd.NewExecuteError(CreateDIDErrorCode, errJWK)
 			}
 		}
 
 		vm, errVM := did.NewVerificationMethodFromJWK(v.ID, v.Type, "", jwk)
 		if errVM != nil {
-			logutil.LogError(logger, CommandName, CreateOrbDIDCommandMethod, errVM.Error())
-
-			return command.NewExecuteError(CreateDIDErrorCode, errVM)
+			return agentcmd.NewExecuteError(CreateDIDErrorCode, errVM)
 		}
 
 		for _, p := range v.Purposes {
@@ -515,10 +564,7 @@ func (c *Command) CreateOrbDID(rw io.Writer, req io.Reader) command.Error { //no
 				didDoc.CapabilityInvocation = append(didDoc.CapabilityInvocation,
 					*did.NewReferencedVerification(vm, did.CapabilityInvocation))
 			default:
-				logutil.LogError(logger, CommandName, CreateOrbDIDCommandMethod,
-					fmt.Sprintf("public key purpose %s not supported", p))
-
-				return command.NewExecuteError(CreateDIDErrorCode,
+				return agentcmd.NewExecuteError(CreateDIDErrorCode,
 					fmt.Errorf("public key purpose %s not supported", p))
 			}
 		}
@@ -531,39 +577,34 @@ func (c *Command) CreateOrbDID(rw io.Writer, req io.Reader) command.Error { //no
 
 	docResolution, err := c.didBlocClient.Create(&didDoc, didMethodOpt...)
 	if err != nil {
-		logutil.LogError(logger, CommandName, CreateOrbDIDCommandMethod, err.Error())
-
-		return command.NewExecuteError(CreateDIDErrorCode, err)
+		return agentcmd.NewExecuteError(CreateDIDErrorCode, err)
 	}
 
 	logutil.LogDebug(logger, CommandName, CreateOrbDIDCommandMethod, fmt.Sprintf("ORB DID Doc crated: %+v",
 		docResolution.DIDDocument))
 
-	// add all keyAgreements to router connections
-	for _, val := range docResolution.DIDDocument.KeyAgreement {
-		for _, rConn := range request.RouterConnections {
-			err = mediatorservice.AddKeyToRouter(c.mediatorSvc, rConn, val.VerificationMethod.ID)
-			if err != nil {
-				logutil.LogError(logger, CommandName, CreateOrbDIDCommandMethod, err.Error())
-
-				return command.NewExecuteError(CreateDIDErrorCode, fmt.Errorf(errFailedToRegisterDIDRecKey+
-					" for KeyAgreement ID %v, connection: %v", err, val.VerificationMethod.ID, rConn))
-			}
+	keyAgreements := make([]string, len(docResolution.DIDDocument.KeyAgreement))
+	for i, val := range docResolution.DIDDocument.KeyAgreement {
+		keyAgreements[i] = val.VerificationMethod.ID
+	}
 
-			logutil.LogDebug(logger, CommandName, CreateOrbDIDCommandMethod, fmt.Sprintf("added keyAgreements ID %v"+
-				" to router connection: %+v", val.VerificationMethod.ID, rConn))
+	// register DID with router connections, DIDComm V1 routers are given all keyAgreements
+	for _, rConn := range request.RouterConnections {
+		err = c.registerWithRouter(rConn, docResolution.DIDDocument.ID, keyAgreements)
+		if err != nil {
+			return agentcmd.NewExecuteError(CreateDIDErrorCode, fmt.Errorf(errFailedToRegisterDIDRecKey+
+				", connection: %v", err, rConn))
 		}
+
+		logutil.LogDebug(logger, CommandName, CreateOrbDIDCommandMethod, fmt.Sprintf("registered DID %s"+
+			" with router connection: %+v", docResolution.DIDDocument.ID, rConn))
 	}
 
 	bytes, err := docResolution.JSONBytes()
 	if err != nil {
-		logutil.LogError(logger, CommandName, CreateOrbDIDCommandMethod, err.Error())
-
-		return command.NewExecuteError(CreateDIDErrorCode, err)
+		return agentcmd.NewExecuteError(CreateDIDErrorCode, err)
 	}
 
-	logutil.LogDebug(logger, CommandName, CreateOrbDIDCommandMethod, successString)
-
 	if _, err := rw.Write(bytes); err != nil {
 		logger.Errorf(err.Error())
 	}
@@ -617,41 +658,32 @@ func getKey(keyType string, value []byte) (interface{}, error) {
 func (c *Command) CreatePeerDID(rw io.Writer, req io.Reader) command.Error { //nolint: funlen,gocyclo
 	var request CreatePeerDIDRequest
 
-	err := json.NewDecoder(req).Decode(&request)
+	err := agentcmd.DecodeRequest(req, &request)
 	if err != nil {
-		logutil.LogError(logger, CommandName, CreatePeerDIDCommandMethod, err.Error())
-
-		return command.NewValidationError(InvalidRequestErrorCode, err)
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
 	}
 
 	if request.RouterConnectionID == "" {
-		logutil.LogError(logger, CommandName, CreatePeerDIDCommandMethod, errInvalidRouterConnectionID)
-
-		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errInvalidRouterConnectionID))
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errInvalidRouterConnectionID))
 	}
 
-	config, err := c.mediatorClient.GetConfig(request.RouterConnectionID)
+	endpoint, err := c.routerEndpoint(request.RouterConnectionID)
 	if err != nil {
-		logutil.LogError(logger, CommandName, CreatePeerDIDCommandMethod, err.Error())
-
-		return command.NewExecuteError(CreateDIDErrorCode, err)
+		return agentcmd.NewExecuteError(CreateDIDErrorCode, err)
 	}
 
 	// TODO - key type should be configurable
 	keyID, keyBytes, err := c.keyManager.CreateAndExportPubKeyBytes(kms.ED25519Type)
 	if err != nil {
-		logutil.LogError(logger, CommandName, CreatePeerDIDCommandMethod, err.Error())
-
-		return command.NewExecuteError(CreateDIDErrorCode, err)
+		return agentcmd.NewExecuteError(CreateDIDErrorCode, err)
 	}
 
 	docResolution, err := c.vdrRegistry.Create(
 		peer.DIDMethod,
 		&did.Doc{
 			Service: []did.Service{{
-				Type: didCommV2ServiceType,
-				ServiceEndpoint: model.NewDIDCommV2Endpoint(
-					[]model.DIDCommV2Endpoint{{URI: config.Endpoint(), RoutingKeys: config.Keys()}}),
+				Type:            didCommV2ServiceType,
+				ServiceEndpoint: endpoint,
 			}},
 			VerificationMethod: []did.VerificationMethod{*did.NewVerificationMethodFromBytes(
 				"#"+keyID,
@@ -662,43 +694,138 @@ func (c *Command) CreatePeerDID(rw io.Writer, req io.Reader) command.Error { //n
 		},
 	)
 	if err != nil {
-		logutil.LogError(logger, CommandName, CreatePeerDIDCommandMethod, err.Error())
-
-		return command.NewExecuteError(CreateDIDErrorCode, err)
+		return agentcmd.NewExecuteError(CreateDIDErrorCode, err)
 	}
 
 	didSvc, ok := did.LookupService(docResolution.DIDDocument, didCommServiceType)
 	if !ok {
 		didSvc, ok = did.LookupService(docResolution.DIDDocument, didCommV2ServiceType)
 		if !ok {
-			logutil.LogError(logger, CommandName, CreatePeerDIDCommandMethod, errMissingDIDCommServiceType)
+			return agentcmd.NewExecuteError(CreateDIDErrorCode, fmt.Errorf(errMissingDIDCommServiceType, didCommServiceType))
+		}
+	}
+
+	err = c.registerWithRouter(request.RouterConnectionID, docResolution.DIDDocument.ID, didSvc.RecipientKeys)
+	if err != nil {
+		return agentcmd.NewExecuteError(CreateDIDErrorCode, fmt.Errorf(errFailedToRegisterDIDRecKey, err))
+	}
 
-			return command.NewExecuteError(CreateDIDErrorCode, fmt.Errorf(errMissingDIDCommServiceType, didCommServiceType))
+	bytes, err := docResolution.JSONBytes()
+	if err != nil {
+		return agentcmd.NewExecuteError(CreateDIDErrorCode, err)
+	}
+
+	if _, err := rw.Write(bytes); err != nil {
+		logger.Errorf(err.Error())
+	}
+
+	return nil
+}
+
+// routerEndpoint returns DIDComm V2 service endpoint routing messages through router over given connection.
+// Endpoint of DIDComm V2 router is its routing DID, DIDComm V1 routers are reached by their routing keys.
+func (c *Command) routerEndpoint(connID string) (model.Endpoint, error) {
+	record, isV2, err := c.routerMediation(connID)
+	if err != nil {
+		return model.Endpoint{}, err
+	}
+
+	if isV2 {
+		if len(record.RoutingDIDs) == 0 {
+			return model.Endpoint{}, fmt.Errorf(errMissingRoutingDID, connID)
 		}
+
+		return model.NewDIDCommV2Endpoint([]model.DIDCommV2Endpoint{{
+			URI:    record.RoutingDIDs[0],
+			Accept: []string{didCommV2Profile},
+		}}), nil
 	}
 
-	for _, val := range didSvc.RecipientKeys {
-		err = mediatorservice.AddKeyToRouter(c.mediatorSvc, request.RouterConnectionID, val)
+	config, err := c.mediatorClient.GetConfig(connID)
+	if err != nil {
+		return model.Endpoint{}, err
+	}
+
+	return model.NewDIDCommV2Endpoint(
+		[]model.DIDCommV2Endpoint{{URI: config.Endpoint(), RoutingKeys: config.Keys()}}), nil
+}
 
+// registerWithRouter registers DID with router over given connection. DIDComm V2 routers are sent recipient
+// update adding the DID, DIDComm V1 routers are given recipient keys of the DID which are then recorded in
+// the local keylist.
+func (c *Command) registerWithRouter(connID, didID string, recipientKeys []string) error {
+	_, isV2, err := c.routerMediation(connID)
+	if err != nil {
+		return err
+	}
+
+	if isV2 {
+		return c.addRecipient(connID, didID)
+	}
+
+	var added []mediation.UpdateResult
+
+	for _, key := range recipientKeys {
+		err = mediatorservice.AddKeyToRouter(c.mediatorSvc, connID, key)
 		if err != nil {
-			logutil.LogError(logger, CommandName, CreatePeerDIDCommandMethod, err.Error())
+			err = fmt.Errorf("recipient key %s: %w", key, err)
 
-			return command.NewExecuteError(CreateDIDErrorCode, fmt.Errorf(errFailedToRegisterDIDRecKey, err))
+			break
 		}
+
+		added = append(added, mediation.UpdateResult{
+			RecipientDID: key, Action: mediation.ActionAdd, Result: mediation.ResultSuccess,
+		})
 	}
 
-	bytes, err := docResolution.JSONBytes()
+	// keys accepted by router are recorded in local keylist of the router connection.
+	if c.mediations != nil && len(added) > 0 {
+		if e := c.mediations.UpdateKeylist(connID, added); e != nil {
+			return e
+		}
+	}
+
+	return err
+}
+
+// addRecipient adds recipient DID to DIDComm V2 router and waits for the router to confirm it.
+func (c *Command) addRecipient(connID, didID string) error {
+	results, err := c.mediations.UpdateRecipients(context.Background(), connID, []string{didID},
+		mediation.ActionAdd)
 	if err != nil {
-		logutil.LogError(logger, CommandName, CreatePeerDIDCommandMethod, err.Error())
+		return fmt.Errorf("recipient DID %s: %w", didID, err)
+	}
+
+	for _, result := range results {
+		if result.RecipientDID != didID {
+			continue
+		}
+
+		if result.Result != mediation.ResultSuccess {
+			return fmt.Errorf("router failed to add recipient DID %s: %s", didID, result.Result)
+		}
 
-		return command.NewExecuteError(CreateDIDErrorCode, err)
+		return nil
 	}
 
-	logutil.LogDebug(logger, CommandName, CreatePeerDIDCommandMethod, successString)
+	return fmt.Errorf("router did not confirm recipient DID %s", didID)
+}
 
-	if _, err := rw.Write(bytes); err != nil {
-		logger.Errorf(err.Error())
+// routerMediation returns mediation granted by router over given connection and whether the router
+// uses coordinate mediation 2.0.
+func (c *Command) routerMediation(connID string) (*mediation.Record, bool, error) {
+	if c.mediations == nil {
+		return nil, false, nil
 	}
 
-	return nil
+	record, err := c.mediations.Get(connID)
+
+	switch {
+	case errors.Is(err, storage.ErrDataNotFound):
+		return nil, false, nil
+	case err != nil:
+		return nil, false, fmt.Errorf("failed to get mediation of router connection %s: %w", connID, err)
+	default:
+		return record, true, nil
+	}
 }
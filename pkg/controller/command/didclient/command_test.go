@@ -9,6 +9,7 @@ package didclient //nolint:testpackage // uses internal implementation details
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"crypto/ed25519"
 	"crypto/elliptic"
@@ -25,17 +26,24 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/common/model"
 	cryptoapi "github.com/hyperledger/aries-framework-go/pkg/crypto"
 	"github.com/hyperledger/aries-framework-go/pkg/crypto/primitive/bbs12381g2pub"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	mediatorsvc "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/mediator"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/jose/jwk"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
+	mockmsghandler "github.com/hyperledger/aries-framework-go/pkg/mock/didcomm/msghandler"
 	mockprotocol "github.com/hyperledger/aries-framework-go/pkg/mock/didcomm/protocol"
 	mockroute "github.com/hyperledger/aries-framework-go/pkg/mock/didcomm/protocol/mediator"
 	mockkms "github.com/hyperledger/aries-framework-go/pkg/mock/kms"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
 	mockvdr "github.com/hyperledger/aries-framework-go/pkg/mock/vdr"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
 	"github.com/stretchr/testify/require"
 
 	"github.com/trustbloc/agent-sdk/pkg/controller/command"
+	"github.com/trustbloc/agent-sdk/pkg/controller/internal/mediation"
+	sdkmockprotocol "github.com/trustbloc/agent-sdk/pkg/controller/internal/mocks/protocol"
 )
 
 //nolint:lll
@@ -75,8 +83,10 @@ func TestNew(t *testing.T) {
 	})
 
 	t.Run("test no coordination service error", func(t *testing.T) {
-		c, err := NewWithMediator("domain", "origin", "", 0, &mockprotocol.MockProvider{
-			ServiceErr: fmt.Errorf("sample-error"),
+		c, err := NewWithMediator("domain", "origin", "", 0, &sdkmockprotocol.MockProvider{
+			MockProvider: &mockprotocol.MockProvider{
+				ServiceErr: fmt.Errorf("sample-error"),
+			},
 		})
 		require.Error(t, err)
 		require.Nil(t, c)
@@ -84,15 +94,49 @@ func TestNew(t *testing.T) {
 	})
 
 	t.Run("test invalid coordination service error", func(t *testing.T) {
-		c, err := NewWithMediator("domain", "origin", "", 0, &mockprotocol.MockProvider{
-			ServiceMap: map[string]interface{}{
-				mediatorsvc.Coordination: "xyz",
+		c, err := NewWithMediator("domain", "origin", "", 0, &sdkmockprotocol.MockProvider{
+			MockProvider: &mockprotocol.MockProvider{
+				ServiceMap: map[string]interface{}{
+					mediatorsvc.Coordination: "xyz",
+				},
 			},
 		})
 		require.Error(t, err)
 		require.Nil(t, c)
 		require.EqualError(t, err, "cast service to route service failed")
 	})
+
+	t.Run("test with message handler", func(t *testing.T) {
+		c, err := NewWithMediator("domain", "origin", "", 0, newMockProviderWithRouterConnection(t, "sample-conn"),
+			WithMessageHandler(mockmsghandler.NewMockMsgServiceProvider()))
+		require.NoError(t, err)
+		require.NotNil(t, c.mediations)
+	})
+
+	t.Run("test with shared mediation client", func(t *testing.T) {
+		prov := newMockProviderWithRouterConnection(t, "sample-conn")
+		registrar := mockmsghandler.NewMockMsgServiceProvider()
+
+		mediations, err := mediation.New(prov, registrar)
+		require.NoError(t, err)
+
+		c, err := NewWithMediator("domain", "origin", "", 0, prov, WithMessageHandler(registrar),
+			WithMediationClient(mediations))
+		require.NoError(t, err)
+		require.Equal(t, mediations, c.mediations)
+		require.Len(t, registrar.Services(), 1)
+	})
+
+	t.Run("test mediation store error", func(t *testing.T) {
+		prov := newMockProviderWithRouterConnection(t, "sample-conn")
+		prov.StoreProvider = &mockstorage.MockStoreProvider{ErrOpenStoreHandle: fmt.Errorf("sample-error")}
+
+		c, err := NewWithMediator("domain", "origin", "", 0, prov,
+			WithMessageHandler(mockmsghandler.NewMockMsgServiceProvider()))
+		require.Error(t, err)
+		require.Nil(t, c)
+		require.Contains(t, err.Error(), "failed to create mediation client")
+	})
 }
 
 func TestCommand_ResolveOrbDID(t *testing.T) {
@@ -988,6 +1032,9 @@ func TestCommand_CreatePeerDID(t *testing.T) {
 			},
 		}
 
+		mediations := &mockMediations{}
+		c.mediations = mediations
+
 		var b bytes.Buffer
 
 		cmdErr := c.CreatePeerDID(&b, bytes.NewBufferString(`{
@@ -999,6 +1046,7 @@ func TestCommand_CreatePeerDID(t *testing.T) {
 		require.NoError(t, err)
 		require.NotEmpty(t, resp.DIDDocument)
 		require.NotEmpty(t, resp.Context)
+		require.Equal(t, []string{"1ert5", "x5356s"}, mediations.keylist)
 	})
 
 	t.Run("success (default)", func(t *testing.T) {
@@ -1151,6 +1199,221 @@ func TestCommand_CreatePeerDID(t *testing.T) {
 	})
 }
 
+func TestCommand_CreatePeerDIDWithV2Router(t *testing.T) {
+	const (
+		connID     = "router-connection"
+		routingDID = "did:peer:2.Ez6LSrouter"
+		sampleDID  = "did:peer:1zQmRouted"
+		request    = `{"routerConnectionID" : "router-connection"}`
+	)
+
+	newV2Command := func(t *testing.T, mediations *mockMediations) *Command {
+		t.Helper()
+
+		c, err := NewWithMediator("domain", "origin", "", 0, getMockProvider())
+		require.NoError(t, err)
+
+		c.mediations = mediations
+		c.keyManager = &mockkms.KeyManager{CrAndExportPubKeyID: "key-1", CrAndExportPubKeyValue: []byte("key")}
+		c.vdrRegistry = &mockvdr.MockVDRegistry{CreateFunc: echoPeerDID(sampleDID)}
+		c.mediatorClient = &mockMediatorClient{
+			GetConfigFunc: func(connID string) (*mediatorsvc.Config, error) {
+				return nil, fmt.Errorf("router config of DIDComm V2 router should not be used")
+			},
+		}
+
+		return c
+	}
+
+	t.Run("test success", func(t *testing.T) {
+		mediations := &mockMediations{
+			record: &mediation.Record{ConnectionID: connID, RoutingDIDs: []string{routingDID}},
+			updated: []mediation.UpdateResult{{
+				RecipientDID: sampleDID, Action: mediation.ActionAdd, Result: mediation.ResultSuccess,
+			}},
+		}
+
+		c := newV2Command(t, mediations)
+
+		var b bytes.Buffer
+
+		cmdErr := c.CreatePeerDID(&b, bytes.NewBufferString(request))
+		require.NoError(t, cmdErr)
+
+		resp, err := did.ParseDocumentResolution(b.Bytes())
+		require.NoError(t, err)
+
+		uri, err := resp.DIDDocument.Service[0].ServiceEndpoint.URI()
+		require.NoError(t, err)
+		require.Equal(t, routingDID, uri)
+		require.Equal(t, []string{sampleDID}, mediations.recipients)
+	})
+
+	t.Run("test mediation without routing DID", func(t *testing.T) {
+		c := newV2Command(t, &mockMediations{record: &mediation.Record{ConnectionID: connID}})
+
+		var b bytes.Buffer
+
+		cmdErr := c.CreatePeerDID(&b, bytes.NewBufferString(request))
+		require.Error(t, cmdErr)
+		require.Equal(t, CreateDIDErrorCode, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), "has no routing DID")
+	})
+
+	t.Run("test get mediation error", func(t *testing.T) {
+		c := newV2Command(t, &mockMediations{getErr: fmt.Errorf("sample-error")})
+
+		var b bytes.Buffer
+
+		cmdErr := c.CreatePeerDID(&b, bytes.NewBufferString(request))
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), "failed to get mediation of router connection")
+	})
+
+	t.Run("test recipient update error", func(t *testing.T) {
+		c := newV2Command(t, &mockMediations{
+			record:    &mediation.Record{ConnectionID: connID, RoutingDIDs: []string{routingDID}},
+			updateErr: fmt.Errorf("timeout waiting for reply from router"),
+		})
+
+		var b bytes.Buffer
+
+		cmdErr := c.CreatePeerDID(&b, bytes.NewBufferString(request))
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), "failed to register did doc recipient key")
+		require.Contains(t, cmdErr.Error(), "timeout waiting for reply from router")
+	})
+
+	t.Run("test recipient not added by router", func(t *testing.T) {
+		c := newV2Command(t, &mockMediations{
+			record: &mediation.Record{ConnectionID: connID, RoutingDIDs: []string{routingDID}},
+			updated: []mediation.UpdateResult{{
+				RecipientDID: sampleDID, Action: mediation.ActionAdd, Result: "server_error",
+			}},
+		})
+
+		var b bytes.Buffer
+
+		cmdErr := c.CreatePeerDID(&b, bytes.NewBufferString(request))
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), "router failed to add recipient DID did:peer:1zQmRouted: server_error")
+	})
+
+	t.Run("test recipient not confirmed by router", func(t *testing.T) {
+		c := newV2Command(t, &mockMediations{
+			record: &mediation.Record{ConnectionID: connID, RoutingDIDs: []string{routingDID}},
+		})
+
+		var b bytes.Buffer
+
+		cmdErr := c.CreatePeerDID(&b, bytes.NewBufferString(request))
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), "router did not confirm recipient DID")
+	})
+}
+
+func TestCommand_CreateOrbDIDWithV2Router(t *testing.T) {
+	didDoc, err := did.ParseDocument([]byte(sampleDoc))
+	require.NoError(t, err)
+
+	didDoc.KeyAgreement = []did.Verification{{
+		VerificationMethod: didDoc.VerificationMethod[2],
+		Relationship:       did.KeyAgreement,
+	}}
+
+	c, err := NewWithMediator("domain", "origin", "", 0, getMockProviderWithMediator(&mockroute.MockMediatorSvc{
+		AddKeyErr: fmt.Errorf("keys should not be added to DIDComm V2 router"),
+	}))
+	require.NoError(t, err)
+
+	mediations := &mockMediations{
+		record: &mediation.Record{ConnectionID: "12345", RoutingDIDs: []string{"did:peer:2.Ez6LSrouter"}},
+		updated: []mediation.UpdateResult{{
+			RecipientDID: didDoc.ID, Action: mediation.ActionAdd, Result: mediation.ResultSuccess,
+		}},
+	}
+
+	c.mediations = mediations
+	c.didBlocClient = &mockDIDClient{createDIDValue: &did.DocResolution{DIDDocument: didDoc}}
+
+	r, err := json.Marshal(CreateOrbDIDRequest{
+		PublicKeys: []PublicKey{{
+			KeyType:  ed25519KeyType,
+			Value:    base64.RawURLEncoding.EncodeToString([]byte("ed25519-public-key-value-32bytes")),
+			Recovery: true,
+		}},
+		RouterConnections: []string{"12345"},
+	})
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+
+	cmdErr := c.CreateOrbDID(&b, bytes.NewBuffer(r))
+	require.NoError(t, cmdErr)
+	require.Equal(t, []string{didDoc.ID}, mediations.recipients)
+}
+
+func TestCommand_RegisterPeerDIDWithV2Router(t *testing.T) {
+	const (
+		connID      = "router-connection"
+		routingDID  = "did:peer:2.Ez6LSrouter"
+		sampleDID   = "did:peer:1zQmRouted"
+		grantMsgStr = `{
+		"id": "123456781",
+		"type": "https://didcomm.org/coordinate-mediation/2.0/mediate-grant",
+		"thid": "%s",
+		"body": {"routing_did": ["did:peer:2.Ez6LSrouter"]}
+		}`
+		updateResponseMsgStr = `{
+		"id": "123456782",
+		"type": "https://didcomm.org/coordinate-mediation/2.0/recipient-update-response",
+		"thid": "%s",
+		"body": {"updated": [{"recipient_did": "did:peer:1zQmRouted", "action": "add", "result": "success"}]}
+		}`
+	)
+
+	prov := newMockProviderWithRouterConnection(t, connID)
+	registrar := mockmsghandler.NewMockMsgServiceProvider()
+
+	// router grants mediation to the agent.
+	grantMessenger := sdkmockprotocol.NewMockMessenger()
+	prov.CustomMessenger = grantMessenger
+
+	mediations, err := mediation.New(prov, registrar)
+	require.NoError(t, err)
+
+	go replyFromRouter(t, registrar, grantMessenger, mediation.MediateGrantMsgType, grantMsgStr)
+
+	_, err = mediations.RequestMediation(context.Background(), connID)
+	require.NoError(t, err)
+
+	// peer DID created by the agent is registered with router as recipient.
+	updateMessenger := sdkmockprotocol.NewMockMessenger()
+	prov.CustomMessenger = updateMessenger
+
+	c, err := NewWithMediator("domain", "origin", "", 0, prov, WithMessageHandler(registrar))
+	require.NoError(t, err)
+
+	c.vdrRegistry = &mockvdr.MockVDRegistry{CreateFunc: echoPeerDID(sampleDID)}
+
+	go replyFromRouter(t, registrar, updateMessenger, mediation.RecipientUpdateResponseMsgType, updateResponseMsgStr)
+
+	var b bytes.Buffer
+
+	cmdErr := c.CreatePeerDID(&b, bytes.NewBufferString(`{"routerConnectionID" : "router-connection"}`))
+	require.NoError(t, cmdErr)
+
+	resp, err := did.ParseDocumentResolution(b.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, sampleDID, resp.DIDDocument.ID)
+
+	uri, err := resp.DIDDocument.Service[0].ServiceEndpoint.URI()
+	require.NoError(t, err)
+	require.Equal(t, routingDID, uri)
+
+	require.Len(t, registrar.Services(), 1)
+}
+
 type mockDIDClient struct {
 	createDIDValue  *did.DocResolution
 	createDIDErr    error
@@ -1191,9 +1454,120 @@ func getMockProvider() ProviderWithMediator {
 }
 
 func getMockProviderWithMediator(mediator interface{}) ProviderWithMediator {
-	return &mockprotocol.MockProvider{
-		ServiceMap: map[string]interface{}{
-			mediatorsvc.Coordination: mediator,
+	return &sdkmockprotocol.MockProvider{
+		MockProvider: &mockprotocol.MockProvider{
+			ServiceMap: map[string]interface{}{
+				mediatorsvc.Coordination: mediator,
+			},
 		},
 	}
 }
+
+// mockMediations mock coordinate mediation 2.0 client.
+type mockMediations struct {
+	record     *mediation.Record
+	getErr     error
+	updated    []mediation.UpdateResult
+	updateErr  error
+	recipients []string
+	keylist    []string
+}
+
+// Get returns mediation granted by router.
+func (m *mockMediations) Get(string) (*mediation.Record, error) {
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+
+	if m.record == nil {
+		return nil, storage.ErrDataNotFound
+	}
+
+	return m.record, nil
+}
+
+// UpdateRecipients records recipients sent to router.
+func (m *mockMediations) UpdateRecipients(_ context.Context, _ string, recipients []string,
+	_ string,
+) ([]mediation.UpdateResult, error) {
+	m.recipients = append(m.recipients, recipients...)
+
+	if m.updateErr != nil {
+		return nil, m.updateErr
+	}
+
+	return m.updated, nil
+}
+
+// UpdateKeylist records keys added to local keylist.
+func (m *mockMediations) UpdateKeylist(_ string, results []mediation.UpdateResult) error {
+	for _, result := range results {
+		m.keylist = append(m.keylist, result.RecipientDID)
+	}
+
+	return nil
+}
+
+// echoPeerDID returns VDR create function resolving requested DID document under given DID.
+func echoPeerDID(didID string) func(string, *did.Doc, ...vdr.DIDMethodOption) (*did.DocResolution, error) {
+	return func(_ string, didDoc *did.Doc, _ ...vdr.DIDMethodOption) (*did.DocResolution, error) {
+		didDoc.ID = didID
+		didDoc.Context = []string{"https://w3id.org/did/v1"}
+
+		return &did.DocResolution{Context: []string{"https://w3id.org/did/v1"}, DIDDocument: didDoc}, nil
+	}
+}
+
+// newMockProviderWithRouterConnection returns provider with given connection to router.
+func newMockProviderWithRouterConnection(t *testing.T, connID string) *sdkmockprotocol.MockProvider {
+	t.Helper()
+
+	connBytes, err := json.Marshal(&connection.Record{
+		ConnectionID: connID,
+		State:        "completed", MyDID: "mydid", TheirDID: "theirDID-001",
+	})
+	require.NoError(t, err)
+
+	mockStore := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
+	require.NoError(t, mockStore.Put("conn_"+connID, connBytes))
+
+	prov := sdkmockprotocol.NewMockProvider()
+	prov.ServiceMap = map[string]interface{}{
+		mediatorsvc.Coordination: &mockroute.MockMediatorSvc{},
+	}
+	prov.StoreProvider = mockstorage.NewCustomMockStoreProvider(mockStore)
+	prov.ProtocolStateStoreProvider = mockstorage.NewMockStoreProvider()
+	prov.CustomKMS = &mockkms.KeyManager{CrAndExportPubKeyID: "key-1", CrAndExportPubKeyValue: []byte("key")}
+
+	return prov
+}
+
+// replyFromRouter delivers reply of given type to the last message sent, once reply handler is registered.
+func replyFromRouter(t *testing.T, registrar *mockmsghandler.MockMsgSvcProvider,
+	messenger *sdkmockprotocol.MockMessenger, msgType, replyFormat string,
+) {
+	t.Helper()
+
+	for {
+		if messenger.GetLastID() == "" {
+			continue
+		}
+
+		for _, svc := range registrar.Services() {
+			if !svc.Accept(msgType, nil) {
+				continue
+			}
+
+			replyMsg, e := service.ParseDIDCommMsgMap([]byte(fmt.Sprintf(replyFormat, messenger.GetLastID())))
+			require.NoError(t, e)
+
+			_, e = svc.HandleInbound(replyMsg, &sdkmockprotocol.MockDIDCommContext{
+				MyDIDValue:    "mydid",
+				TheirDIDValue: "theirDID-001",
+			})
+			require.NoError(t, e)
+
+			return
+		}
+	}
+}
@@ -33,8 +33,25 @@ const (
 
 	// Store error group for Store command errors.
 	Store Group = 3000
+
+	// Schema error group for Schema command errors.
+	Schema Group = 4000
+
+	// BlindedRouting error group for blinded routing command errors.
+	BlindedRouting Group = 5000
+
+	// Webhook error group for webhook command errors.
+	Webhook Group = 6000
 )
 
+// DetailedError is command error with stable name, field-level details and retryable flag.
+type DetailedError interface {
+	command.Error
+	Name() string
+	Details() []ErrorDetail
+	Retryable() bool
+}
+
 // NewExecuteError returns new command execute error.
 func NewExecuteError(code command.Code, err error) command.Error {
 	return &commandError{err, code, ExecuteError}
@@ -45,7 +62,7 @@ func NewValidationError(code command.Code, err error) command.Error {
 	return &commandError{err, code, ValidationError}
 }
 
-// commandError implements basic command Error.
+// commandError implements basic command Error, described by the error catalog.
 type commandError struct {
 	error
 	code    command.Code
@@ -59,3 +76,23 @@ func (c *commandError) Code() command.Code {
 func (c *commandError) Type() command.Type {
 	return c.errType
 }
+
+// Unwrap returns the cause of the error.
+func (c *commandError) Unwrap() error {
+	return c.error
+}
+
+// Name returns stable name of the error code in the error catalog, or empty string if it is not registered.
+func (c *commandError) Name() string {
+	return DescribeError(c.code, c).Name
+}
+
+// Details returns field-level details of the error, if any.
+func (c *commandError) Details() []ErrorDetail {
+	return DescribeError(c.code, c).Details
+}
+
+// Retryable checks whether the error code is retryable and the error is not a validation error.
+func (c *commandError) Retryable() bool {
+	return DescribeError(c.code, c).Retryable
+}
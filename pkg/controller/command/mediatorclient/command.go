@@ -11,9 +11,12 @@ package mediatorclient
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,14 +30,17 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	didexchangeSvc "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/didexchange"
 	oobv2 "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/outofbandv2"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
 	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+	"github.com/hyperledger/aries-framework-go/pkg/vdr/peer"
 	"github.com/hyperledger/aries-framework-go/spi/storage"
 	"github.com/trustbloc/edge-core/pkg/log"
 
 	agentcmd "github.com/trustbloc/agent-sdk/pkg/controller/command"
 	"github.com/trustbloc/agent-sdk/pkg/controller/internal/cmdutil"
-	"github.com/trustbloc/agent-sdk/pkg/controller/internal/logutil"
+	"github.com/trustbloc/agent-sdk/pkg/controller/internal/mediation"
 	"github.com/trustbloc/agent-sdk/pkg/controller/internal/msghandler"
 )
 
@@ -49,6 +55,20 @@ const (
 	CreateInvitation = "CreateInvitation"
 	// SendCreateConnectionRequest command name.
 	SendCreateConnectionRequest = "SendCreateConnectionRequest"
+	// GetOperation command name.
+	GetOperation = "GetOperation"
+	// CancelOperation command name.
+	CancelOperation = "CancelOperation"
+	// QueryKeylist command name.
+	QueryKeylist = "QueryKeylist"
+	// RemoveKeys command name.
+	RemoveKeys = "RemoveKeys"
+	// DecodeInvitation command name.
+	DecodeInvitation = "DecodeInvitation"
+	// ListInvitations command name.
+	ListInvitations = "ListInvitations"
+	// RevokeInvitation command name.
+	RevokeInvitation = "RevokeInvitation"
 )
 
 const (
@@ -61,13 +81,32 @@ const (
 	CreateInvitationError
 	// SendCreateConnectionRequestError is typically a code for mediator send create connection request command errors.
 	SendCreateConnectionRequestError
+	// GetOperationError is typically a code for get operation command errors.
+	GetOperationError
+	// CancelOperationError is typically a code for cancel operation command errors.
+	CancelOperationError
+	// QueryKeylistError is typically a code for keylist query command errors.
+	QueryKeylistError
+	// RemoveKeysError is typically a code for remove keys command errors.
+	RemoveKeysError
+	// DecodeInvitationError is typically a code for decode invitation command errors.
+	DecodeInvitationError
+	// ListInvitationsError is typically a code for list invitations command errors.
+	ListInvitationsError
+	// RevokeInvitationError is typically a code for revoke invitation command errors.
+	RevokeInvitationError
 
 	// errors.
-	errInvalidConnectionRequest = "invitation missing in connection request"
-	errNoConnectionFound        = "no connection found to create invitation"
-
-	// log constants.
-	successString = "success"
+	errInvalidConnectionRequest  = "invitation missing in connection request"
+	errInvalidInvitationURL      = "invitation URL missing in request"
+	errInvalidInvitationID       = "invalid invitation ID"
+	errV2InvitationLimits        = "expiresIn and maxUses are not supported for DIDComm V2 invitations"
+	errNoConnectionFound         = "no connection found to create invitation"
+	errInvalidOperationID        = "invalid operation ID"
+	errOperationNotFound         = "operation not found: %s"
+	errInvalidRemoveKeysRequest  = "either keys or DID is required to remove keys"
+	errNoKeysFoundForDID         = "no keys found for DID %s"
+	errInvalidCreateConnResponse = "invalid create connection response, DID document missing"
 
 	// messaging & notifications.
 	stateCompleteTopic = "state-complete-topic"
@@ -80,8 +119,27 @@ const (
 	msgEventBufferSize = 10
 
 	// message types.
-	createConnRequestMsgType  = "https://trustbloc.dev/blinded-routing/1.0/create-conn-req"
-	createConnResponseMsgType = "https://trustbloc.dev/blinded-routing/1.0/create-conn-resp"
+	createConnRequestMsgType     = "https://trustbloc.dev/blinded-routing/1.0/create-conn-req"
+	createConnResponseMsgType    = "https://trustbloc.dev/blinded-routing/1.0/create-conn-resp"
+	keylistQueryMsgType          = "https://didcomm.org/coordinatemediation/1.0/keylist-query"
+	keylistMsgType               = "https://didcomm.org/coordinatemediation/1.0/keylist"
+	keylistUpdateMsgType         = "https://didcomm.org/coordinatemediation/1.0/keylist-update"
+	keylistUpdateResponseMsgType = "https://didcomm.org/coordinatemediation/1.0/keylist-update-response"
+
+	// DIDComm V2 create connection message types.
+	createConnRequestMsgTypeV2  = "https://trustbloc.dev/blinded-routing/2.0/create-conn-req"
+	createConnResponseMsgTypeV2 = "https://trustbloc.dev/blinded-routing/2.0/create-conn-resp"
+
+	// keylist update actions.
+	keylistUpdateActionRemove = "remove"
+
+	// peer DID documents returned by router are stored in peer VDR.
+	peerDIDPrefix  = "did:peer:"
+	storeDIDOption = "store"
+
+	// DID document service types carrying recipient keys.
+	didCommServiceType   = "did-communication"
+	didCommV2ServiceType = "DIDCommMessaging"
 )
 
 // Provider describes dependencies for this command.
@@ -107,10 +165,62 @@ type Command struct {
 	messenger      *messaging.Client
 	didExchTimeout time.Duration
 	msgHandler     command.MessageHandler
+	operations     *operations
+	vdrRegistry    vdr.Registry
+	httpClient     *http.Client
+	inviteHosts    []string
+	endpoint       string
+	invitations    *invitationStore
+	mediations     *mediation.Client
+	connections    *connection.Recorder
+}
+
+type options struct {
+	inviteHosts []string
+	mediations  *mediation.Client
+	guard       *InvitationGuard
+}
+
+// Opt represents a mediator client command option.
+type Opt func(opts *options)
+
+// WithInvitationURLHosts enables resolving shortened invitation URLs of given hosts, a host starting with '*.'
+// allows all its subdomains and '*' allows any host. Hosts with private, loopback or link-local addresses are
+// refused. Only invitation URLs with invitation in query are decoded if not set.
+func WithInvitationURLHosts(hosts ...string) Opt {
+	return func(opts *options) {
+		opts.inviteHosts = hosts
+	}
+}
+
+// WithInvitationGuard sets guard of invitations shared with other commands of the agent, a guard is created
+// for the command if not set, see NewInvitationGuard.
+func WithInvitationGuard(guard *InvitationGuard) Opt {
+	return func(opts *options) {
+		opts.guard = guard
+	}
+}
+
+// WithMediationClient sets coordinate mediation 2.0 client shared with other commands receiving replies of routers
+// through the message handler, it is created by the command if not set.
+func WithMediationClient(client *mediation.Client) Opt {
+	return func(opts *options) {
+		opts.mediations = client
+	}
 }
 
 // New returns new mediator client controller command instance.
-func New(p Provider, msgHandler command.MessageHandler, notifier command.Notifier) (*Command, error) {
+func New(p Provider, msgHandler command.MessageHandler, notifier command.Notifier, opts ...Opt) (*Command, error) {
+	if err := agentcmd.RegisterErrors(Errors()...); err != nil {
+		return nil, err
+	}
+
+	cmdOpts := &options{}
+
+	for _, opt := range opts {
+		opt(cmdOpts)
+	}
+
 	mediatorClient, err := mediator.New(p)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create mediator client : %w", err)
@@ -126,17 +236,22 @@ func New(p Provider, msgHandler command.MessageHandler, notifier command.Notifie
 		return nil, fmt.Errorf("failed to create did-exchange client : %w", err)
 	}
 
-	outOfBandClient, err := outofband.New(p)
+	outOfBandClient, outOfBandClientV2, err := newOutOfBandClients(p)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create out-of-band client : %w", err)
+		return nil, err
 	}
 
-	outOfBandClientV2, err := outofbandv2.New(p)
+	guard, mediations, err := sharedClients(p, msgHandler, notifier, cmdOpts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create out-of-band v2 client : %w", err)
+		return nil, err
 	}
 
-	return &Command{
+	connectionRecorder, err := connection.NewRecorder(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection recorder: %w", err)
+	}
+
+	c := &Command{
 		didExchange:    didExchangeClient,
 		outOfBand:      outOfBandClient,
 		outOfBandV2:    outOfBandClientV2,
@@ -144,7 +259,56 @@ func New(p Provider, msgHandler command.MessageHandler, notifier command.Notifie
 		messenger:      messengerClient,
 		didExchTimeout: didExchangeTimeOut,
 		msgHandler:     msgHandler,
-	}, nil
+		operations:     newOperations(notifier),
+		vdrRegistry:    p.VDRegistry(),
+		httpClient:     newInvitationHTTPClient(),
+		inviteHosts:    cmdOpts.inviteHosts,
+		endpoint:       p.ServiceEndpoint(),
+		invitations:    guard.invitations,
+		mediations:     mediations,
+		connections:    connectionRecorder,
+	}
+
+	return c, nil
+}
+
+func newOutOfBandClients(p Provider) (*outofband.Client, *outofbandv2.Client, error) {
+	outOfBandClient, err := outofband.New(p)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create out-of-band client : %w", err)
+	}
+
+	outOfBandClientV2, err := outofbandv2.New(p)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create out-of-band v2 client : %w", err)
+	}
+
+	return outOfBandClient, outOfBandClientV2, nil
+}
+
+// sharedClients returns invitation guard and mediation client set by options, creating those not set.
+func sharedClients(p Provider, msgHandler command.MessageHandler, notifier command.Notifier,
+	cmdOpts *options,
+) (*InvitationGuard, *mediation.Client, error) {
+	var err error
+
+	guard := cmdOpts.guard
+	if guard == nil {
+		guard, err = NewInvitationGuard(p, notifier)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	mediations := cmdOpts.mediations
+	if mediations == nil {
+		mediations, err = mediation.New(p, msgHandler)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create mediation client: %w", err)
+		}
+	}
+
+	return guard, mediations, nil
 }
 
 // GetHandlers returns list of all commands supported by this controller command.
@@ -153,73 +317,199 @@ func (c *Command) GetHandlers() []command.Handler {
 		cmdutil.NewCommandHandler(CommandName, Connect, c.Connect),
 		cmdutil.NewCommandHandler(CommandName, CreateInvitation, c.CreateInvitation),
 		cmdutil.NewCommandHandler(CommandName, SendCreateConnectionRequest, c.SendCreateConnectionRequest),
+		cmdutil.NewCommandHandler(CommandName, GetOperation, c.GetOperation),
+		cmdutil.NewCommandHandler(CommandName, CancelOperation, c.CancelOperation),
+		cmdutil.NewCommandHandler(CommandName, QueryKeylist, c.QueryKeylist),
+		cmdutil.NewCommandHandler(CommandName, RemoveKeys, c.RemoveKeys),
+		cmdutil.NewCommandHandler(CommandName, DecodeInvitation, c.DecodeInvitation),
+		cmdutil.NewCommandHandler(CommandName, ListInvitations, c.ListInvitations),
+		cmdutil.NewCommandHandler(CommandName, RevokeInvitation, c.RevokeInvitation),
+	}
+}
+
+// Schemas returns JSON schemas of request and response models of mediator client command methods.
+func Schemas() []agentcmd.MethodSchema {
+	return []agentcmd.MethodSchema{
+		agentcmd.NewMethodSchema(CommandName, Connect, &ConnectionRequest{}, &ConnectionResponse{}),
+		agentcmd.NewMethodSchema(CommandName, CreateInvitation, &CreateInvitationRequest{}, &CreateInvitationResponse{}),
+		agentcmd.NewMethodSchema(CommandName, SendCreateConnectionRequest, &CreateConnectionRequest{},
+			&CreateConnectionResponse{}),
+		agentcmd.NewMethodSchema(CommandName, GetOperation, &OperationRequest{}, &OperationResponse{}),
+		agentcmd.NewMethodSchema(CommandName, CancelOperation, &OperationRequest{}, &OperationResponse{}),
+		agentcmd.NewMethodSchema(CommandName, QueryKeylist, &KeylistQueryRequest{}, &KeylistQueryResponse{}),
+		agentcmd.NewMethodSchema(CommandName, RemoveKeys, &RemoveKeysRequest{}, &RemoveKeysResponse{}),
+		agentcmd.NewMethodSchema(CommandName, DecodeInvitation, &DecodeInvitationRequest{}, &DecodeInvitationResponse{}),
+		agentcmd.NewMethodSchema(CommandName, ListInvitations, &ListInvitationsRequest{}, &ListInvitationsResponse{}),
+		agentcmd.NewMethodSchema(CommandName, RevokeInvitation, &RevokeInvitationRequest{}, &RevokeInvitationResponse{}),
+	}
+}
+
+// Errors returns definitions of mediator client error codes for the error catalog.
+func Errors() []agentcmd.ErrorDefinition {
+	return []agentcmd.ErrorDefinition{
+		{
+			Code: InvalidRequestErrorCode, Name: "MEDIATORCLIENT_INVALID_REQUEST", Command: CommandName,
+			Description: "invalid request",
+		},
+		{
+			Code: ConnectMediatorError, Name: "MEDIATORCLIENT_CONNECT_FAILED", Command: CommandName,
+			Description: "failed to connect to mediator", Retryable: true,
+		},
+		{
+			Code: CreateInvitationError, Name: "MEDIATORCLIENT_CREATE_INVITATION_FAILED", Command: CommandName,
+			Description: "failed to create invitation", Retryable: true,
+		},
+		{
+			Code: SendCreateConnectionRequestError, Name: "MEDIATORCLIENT_CREATE_CONNECTION_FAILED", Command: CommandName,
+			Description: "failed to send create connection request to mediator", Retryable: true,
+		},
+		{
+			Code: GetOperationError, Name: "MEDIATORCLIENT_GET_OPERATION_FAILED", Command: CommandName,
+			Description: "failed to get operation",
+		},
+		{
+			Code: CancelOperationError, Name: "MEDIATORCLIENT_CANCEL_OPERATION_FAILED", Command: CommandName,
+			Description: "failed to cancel operation",
+		},
+		{
+			Code: QueryKeylistError, Name: "MEDIATORCLIENT_QUERY_KEYLIST_FAILED", Command: CommandName,
+			Description: "failed to query keylist of mediator", Retryable: true,
+		},
+		{
+			Code: RemoveKeysError, Name: "MEDIATORCLIENT_REMOVE_KEYS_FAILED", Command: CommandName,
+			Description: "failed to remove keys from mediator", Retryable: true,
+		},
+		{
+			Code: DecodeInvitationError, Name: "MEDIATORCLIENT_DECODE_INVITATION_FAILED", Command: CommandName,
+			Description: "failed to decode invitation",
+		},
+		{
+			Code: ListInvitationsError, Name: "MEDIATORCLIENT_LIST_INVITATIONS_FAILED", Command: CommandName,
+			Description: "failed to list invitations",
+		},
+		{
+			Code: RevokeInvitationError, Name: "MEDIATORCLIENT_REVOKE_INVITATION_FAILED", Command: CommandName,
+			Description: "failed to revoke invitation",
+		},
 	}
 }
 
 // Connect connects agent to given router endpoint.
+// If request is asynchronous, then connection is performed in background and operation ID is returned.
+// Routers inviting with DIDComm V2 accept profile are connected using coordinate mediation 2.0.
 func (c *Command) Connect(rw io.Writer, req io.Reader) command.Error {
-	var (
-		request ConnectionRequest
-		connID  string
-		isV2    bool
-	)
+	var request ConnectionRequest
 
-	err := json.NewDecoder(req).Decode(&request)
+	err := agentcmd.DecodeRequest(req, &request)
 	if err != nil {
-		logutil.LogError(logger, CommandName, Connect, err.Error())
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if request.Invitation == nil && request.InvitationURL == "" {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errInvalidConnectionRequest))
+	}
+
+	// shortened invitation URLs are resolved when connecting, so that asynchronous requests do not wait for them.
+	if request.Invitation == nil {
+		request.Invitation, _, err = c.checkInvitationURL(request.InvitationURL)
+		if err != nil {
+			return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+		}
+	}
+
+	if request.Async {
+		operationID := c.operations.start(Connect, func(ctx context.Context) (interface{}, command.Error) {
+			return c.resolveAndConnect(ctx, &request)
+		})
+
+		command.WriteNillableResponse(rw, &ConnectionResponse{OperationID: operationID}, logger)
+
+		return nil
+	}
 
-		return command.NewValidationError(InvalidRequestErrorCode, err)
+	response, cmdErr := c.resolveAndConnect(context.Background(), &request)
+	if cmdErr != nil {
+		return cmdErr
 	}
 
+	command.WriteNillableResponse(rw, response, logger)
+
+	return nil
+}
+
+// resolveAndConnect resolves shortened invitation URL of given request, if any, and connects to the router.
+func (c *Command) resolveAndConnect(ctx context.Context, request *ConnectionRequest) (*ConnectionResponse,
+	command.Error,
+) {
 	if request.Invitation == nil {
-		logutil.LogError(logger, CommandName, Connect, errInvalidConnectionRequest)
+		invitation, err := c.resolveInvitationURL(ctx, request.InvitationURL)
+		if err != nil {
+			return nil, agentcmd.NewExecuteError(ConnectMediatorError, err)
+		}
 
-		return command.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errInvalidConnectionRequest))
+		request.Invitation = invitation
 	}
 
+	return c.connect(ctx, request)
+}
+
+func (c *Command) connect(ctx context.Context, request *ConnectionRequest) (*ConnectionResponse, command.Error) {
+	var (
+		connID      string
+		mediationV2 bool
+	)
+
 	//nolint:nestif
-	if isV2, err = service.IsDIDCommV2(request.Invitation); isV2 && err == nil {
+	if isV2, err := service.IsDIDCommV2(request.Invitation); isV2 && err == nil {
 		inv := &oobv2.Invitation{}
 
 		err = request.Invitation.Decode(inv)
 		if err != nil {
-			return command.NewExecuteError(ConnectMediatorError, err)
+			return nil, agentcmd.NewExecuteError(ConnectMediatorError, err)
 		}
 
 		connID, err = c.outOfBandV2.AcceptInvitation(inv)
 		if err != nil {
-			logutil.LogError(logger, CommandName, Connect, err.Error())
-
-			return command.NewExecuteError(ConnectMediatorError, err)
+			return nil, agentcmd.NewExecuteError(ConnectMediatorError, err)
 		}
+
+		mediationV2 = mediation.Supported(inv)
 	} else {
 		inv := &outofband.Invitation{}
+
 		err = request.Invitation.Decode(inv)
 		if err != nil {
-			return command.NewExecuteError(ConnectMediatorError, err)
+			return nil, agentcmd.NewExecuteError(ConnectMediatorError, err)
 		}
 
-		connID, err = c.createOOBInvitation(inv, request.MyLabel, request.StateCompleteMessageType)
+		connID, err = c.createOOBInvitation(ctx, inv, request.MyLabel, request.StateCompleteMessageType)
 		if err != nil {
-			return command.NewExecuteError(ConnectMediatorError, err)
+			return nil, agentcmd.NewExecuteError(ConnectMediatorError, err)
 		}
 	}
 
-	err = c.mediator.Register(connID)
-	if err != nil {
-		logutil.LogError(logger, CommandName, Connect, err.Error())
-
-		return command.NewExecuteError(ConnectMediatorError, err)
+	if err := ctx.Err(); err != nil {
+		return nil, agentcmd.NewExecuteError(ConnectMediatorError, err)
 	}
 
-	command.WriteNillableResponse(rw, &ConnectionResponse{ConnectionID: connID}, logger)
+	if mediationV2 {
+		record, err := c.mediations.RequestMediation(ctx, connID)
+		if err != nil {
+			return nil, agentcmd.NewExecuteError(ConnectMediatorError, err)
+		}
 
-	logutil.LogDebug(logger, CommandName, Connect, successString)
+		return &ConnectionResponse{ConnectionID: connID, RoutingDIDs: record.RoutingDIDs}, nil
+	}
 
-	return nil
+	err := c.mediator.Register(connID)
+	if err != nil {
+		return nil, agentcmd.NewExecuteError(ConnectMediatorError, err)
+	}
+
+	return &ConnectionResponse{ConnectionID: connID}, nil
 }
 
-func (c *Command) createOOBInvitation(inv *outofband.Invitation,
+func (c *Command) createOOBInvitation(ctx context.Context, inv *outofband.Invitation,
 	myLabel, stateCompleteMessageType string,
 ) (string, error) {
 	var notificationCh chan messaging.NotificationPayload
@@ -232,8 +522,6 @@ func (c *Command) createOOBInvitation(inv *outofband.Invitation,
 		err := c.msgHandler.Register(msghandler.NewMessageService(stateCompleteTopic, stateCompleteMessageType,
 			nil, messaging.NewNotifier(notificationCh, nil)))
 		if err != nil {
-			logutil.LogError(logger, CommandName, Connect, err.Error())
-
 			return "", err
 		}
 
@@ -248,8 +536,6 @@ func (c *Command) createOOBInvitation(inv *outofband.Invitation,
 
 		err := c.didExchange.RegisterMsgEvent(statusCh)
 		if err != nil {
-			logutil.LogError(logger, CommandName, Connect, err.Error())
-
 			return "", err
 		}
 
@@ -263,15 +549,11 @@ func (c *Command) createOOBInvitation(inv *outofband.Invitation,
 
 	connID, err := c.outOfBand.AcceptInvitation(inv, myLabel)
 	if err != nil {
-		logutil.LogError(logger, CommandName, Connect, err.Error())
-
 		return "", err
 	}
 
-	err = c.waitForConnect(statusCh, notificationCh, connID)
+	err = c.waitForConnect(ctx, statusCh, notificationCh, connID)
 	if err != nil {
-		logutil.LogError(logger, CommandName, Connect, err.Error())
-
 		return "", err
 	}
 
@@ -280,28 +562,27 @@ func (c *Command) createOOBInvitation(inv *outofband.Invitation,
 
 // CreateInvitation creates out-of-band invitation from one of the mediator connections.
 //
-//nolint:funlen
+//nolint:funlen,gocyclo
 func (c *Command) CreateInvitation(rw io.Writer, req io.Reader) command.Error {
 	connections, err := c.mediator.GetConnections()
 	if err != nil {
-		logutil.LogError(logger, CommandName, CreateInvitation, err.Error())
-
-		return command.NewExecuteError(CreateInvitationError, err)
+		return agentcmd.NewExecuteError(CreateInvitationError, err)
 	}
 
 	if len(connections) == 0 {
-		logutil.LogError(logger, CommandName, CreateInvitation, errNoConnectionFound)
-
-		return command.NewExecuteError(CreateInvitationError, fmt.Errorf(errNoConnectionFound))
+		return agentcmd.NewExecuteError(CreateInvitationError, fmt.Errorf(errNoConnectionFound))
 	}
 
 	var request CreateInvitationRequest
 
-	err = json.NewDecoder(req).Decode(&request)
+	err = agentcmd.DecodeRequest(req, &request)
 	if err != nil {
-		logutil.LogError(logger, CommandName, CreateInvitation, err.Error())
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
 
-		return command.NewValidationError(InvalidRequestErrorCode, err)
+	// DIDComm V2 invitations are accepted without did exchange request on which limits are enforced.
+	if request.From != "" && (request.ExpiresIn > 0 || request.MaxUses > 0) {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errV2InvitationLimits))
 	}
 
 	var (
@@ -318,12 +599,23 @@ func (c *Command) CreateInvitation(rw io.Writer, req io.Reader) command.Error {
 		)
 
 		if err != nil {
-			logutil.LogError(logger, CommandName, CreateInvitation, fmt.Sprintf("oob v2 error: %s", err.Error()))
+			return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+		}
+
+		err = c.saveInvitation(&request, invitationV2.ID, invitationVersionV2, invitationV2)
+		if err != nil {
+			return agentcmd.NewExecuteError(CreateInvitationError, err)
+		}
+
+		response := &CreateInvitationResponse{InvitationV2: invitationV2}
 
-			return command.NewValidationError(InvalidRequestErrorCode, err)
+		response.InvitationURL, err = EncodeInvitationURL(c.invitationBaseURL(request.BaseURL), oobV2QueryParam,
+			invitationV2)
+		if err != nil {
+			return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
 		}
 
-		command.WriteNillableResponse(rw, &CreateInvitationResponse{InvitationV2: invitationV2}, logger)
+		command.WriteNillableResponse(rw, response, logger)
 	} else {
 		invitation, err = c.outOfBand.CreateInvitation(
 			request.Service,
@@ -333,84 +625,671 @@ func (c *Command) CreateInvitation(rw io.Writer, req io.Reader) command.Error {
 			outofband.WithAccept("didcomm/aip2;env=rfc19", "didcomm/aip1"),
 			outofband.WithRouterConnections(connections[rand.Intn(len(connections))])) //nolint: gosec
 		if err != nil {
-			logutil.LogError(logger, CommandName, CreateInvitation, fmt.Sprintf("oob v1 error: %s", err.Error()))
+			return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+		}
 
-			return command.NewValidationError(InvalidRequestErrorCode, err)
+		err = c.saveInvitation(&request, invitation.ID, invitationVersionV1, invitation)
+		if err != nil {
+			return agentcmd.NewExecuteError(CreateInvitationError, err)
 		}
 
-		command.WriteNillableResponse(rw, &CreateInvitationResponse{Invitation: invitation}, logger)
-	}
+		response := &CreateInvitationResponse{Invitation: invitation}
 
-	logutil.LogDebug(logger, CommandName, CreateInvitation, fmt.Sprintf("%s for %s", successString, request.Label))
+		response.InvitationURL, err = EncodeInvitationURL(c.invitationBaseURL(request.BaseURL), oobQueryParam,
+			invitation)
+		if err != nil {
+			return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+		}
+
+		response.LegacyInvitationURL, err = EncodeInvitationURL(c.invitationBaseURL(request.BaseURL),
+			legacyQueryParam, invitation)
+		if err != nil {
+			return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+		}
+
+		command.WriteNillableResponse(rw, response, logger)
+	}
 
 	return nil
 }
 
 // SendCreateConnectionRequest sends create connection request to mediator.
+// Router DID document returned in response is stored and connection between the DIDs is created.
 func (c *Command) SendCreateConnectionRequest(rw io.Writer, req io.Reader) command.Error {
 	connections, err := c.mediator.GetConnections()
 	if err != nil {
-		logutil.LogError(logger, CommandName, SendCreateConnectionRequest, err.Error())
-
-		return command.NewExecuteError(SendCreateConnectionRequestError, err)
+		return agentcmd.NewExecuteError(SendCreateConnectionRequestError, err)
 	}
 
 	if len(connections) == 0 {
-		logutil.LogError(logger, CommandName, SendCreateConnectionRequest, errNoConnectionFound)
-
-		return command.NewExecuteError(SendCreateConnectionRequestError, fmt.Errorf(errNoConnectionFound))
+		return agentcmd.NewExecuteError(SendCreateConnectionRequestError, fmt.Errorf(errNoConnectionFound))
 	}
 
 	var request CreateConnectionRequest
 
-	err = json.NewDecoder(req).Decode(&request)
+	err = agentcmd.DecodeRequest(req, &request)
 	if err != nil {
-		logutil.LogError(logger, CommandName, SendCreateConnectionRequest, err.Error())
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
 
-		return command.NewValidationError(SendCreateConnectionRequestError, err)
+	myDoc, err := did.ParseDocument(request.DIDDocument)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode,
+			fmt.Errorf("invalid DID document in request: %w", err))
 	}
 
-	msgBytes, err := json.Marshal(map[string]interface{}{
+	connID := connections[rand.Intn(len(connections))] //nolint: gosec
+
+	if request.Async {
+		operationID := c.operations.start(SendCreateConnectionRequest,
+			func(ctx context.Context) (interface{}, command.Error) {
+				return c.sendCreateConnectionRequest(ctx, &request, myDoc, connID)
+			})
+
+		command.WriteNillableResponse(rw, &CreateConnectionResponse{OperationID: operationID}, logger)
+
+		return nil
+	}
+
+	response, cmdErr := c.sendCreateConnectionRequest(context.Background(), &request, myDoc, connID)
+	if cmdErr != nil {
+		return cmdErr
+	}
+
+	command.WriteNillableResponse(rw, response, logger)
+
+	return nil
+}
+
+func (c *Command) sendCreateConnectionRequest(ctx context.Context, request *CreateConnectionRequest,
+	myDoc *did.Doc, connID string,
+) (*CreateConnectionResponse, command.Error) {
+	connRecord, err := c.connections.GetConnectionRecord(connID)
+	if err != nil {
+		return nil, agentcmd.NewExecuteError(SendCreateConnectionRequestError,
+			fmt.Errorf("failed to get router connection: %w", err))
+	}
+
+	msg, responseType := newCreateConnRequest(connRecord.DIDCommVersion, request.DIDDocument)
+
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		return nil, agentcmd.NewExecuteError(SendCreateConnectionRequestError, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendMsgTimeOut)
+	defer cancel()
+
+	res, err := c.messenger.Send(json.RawMessage(msgBytes),
+		messaging.SendByConnectionID(connID),
+		messaging.WaitForResponse(ctx, responseType))
+	if err != nil {
+		return nil, agentcmd.NewExecuteError(SendCreateConnectionRequestError, err)
+	}
+
+	theirDoc, err := parseCreateConnResponse(res)
+	if err != nil {
+		return nil, agentcmd.NewExecuteError(SendCreateConnectionRequestError, err)
+	}
+
+	newConnID, err := c.saveRouterConnection(myDoc, theirDoc)
+	if err != nil {
+		return nil, agentcmd.NewExecuteError(SendCreateConnectionRequestError, err)
+	}
+
+	theirDocBytes, err := theirDoc.JSONBytes()
+	if err != nil {
+		return nil, agentcmd.NewExecuteError(SendCreateConnectionRequestError, err)
+	}
+
+	return &CreateConnectionResponse{
+		Payload:      res,
+		ConnectionID: newConnID,
+		DIDDocument:  theirDocBytes,
+	}, nil
+}
+
+// newCreateConnRequest returns create connection request in format of given DIDComm version
+// along with type of the expected response.
+func newCreateConnRequest(version service.Version, didDoc json.RawMessage) (map[string]interface{}, string) {
+	data := map[string]interface{}{
+		"didDoc": didDoc,
+	}
+
+	if version == service.V2 {
+		return map[string]interface{}{
+			"id":   uuid.New().String(),
+			"type": createConnRequestMsgTypeV2,
+			"body": data,
+		}, createConnResponseMsgTypeV2
+	}
+
+	return map[string]interface{}{
 		"@id":   uuid.New().String(),
 		"@type": createConnRequestMsgType,
-		"data": map[string]interface{}{
-			"didDoc": request.DIDDocument,
-		},
-	})
+		"data":  data,
+	}, createConnResponseMsgType
+}
+
+// parseCreateConnResponse parses create connection response from router, in either DIDComm V1 or V2 format,
+// and returns router's DID document.
+func parseCreateConnResponse(res json.RawMessage) (*did.Doc, error) {
+	var resp createConnResp
+
+	err := json.Unmarshal(res, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse create connection response: %w", err)
+	}
+
+	if resp.Data == nil {
+		resp.Data = resp.Body
+	}
+
+	if resp.Data == nil {
+		return nil, fmt.Errorf(errInvalidCreateConnResponse)
+	}
+
+	if resp.Data.ErrorMsg != "" {
+		return nil, fmt.Errorf("router failed to create connection: %s", resp.Data.ErrorMsg)
+	}
+
+	if len(resp.Data.DIDDoc) == 0 {
+		return nil, fmt.Errorf(errInvalidCreateConnResponse)
+	}
+
+	doc, err := did.ParseDocument(resp.Data.DIDDoc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DID document in create connection response: %w", err)
+	}
+
+	return doc, nil
+}
+
+// saveRouterConnection stores router's DID document and creates connection between given DIDs,
+// returns ID of the new connection.
+func (c *Command) saveRouterConnection(myDoc, theirDoc *did.Doc) (string, error) {
+	// public DIDs are resolvable, only peer DID documents need to be stored.
+	if strings.HasPrefix(theirDoc.ID, peerDIDPrefix) {
+		_, err := c.vdrRegistry.Create(peer.DIDMethod, theirDoc, vdr.WithOption(storeDIDOption, true))
+		if err != nil {
+			return "", fmt.Errorf("failed to store router DID document: %w", err)
+		}
+	}
+
+	didCommVersion := service.V1
+	if _, ok := did.LookupService(theirDoc, didCommV2ServiceType); ok {
+		didCommVersion = service.V2
+	}
+
+	record := &connection.Record{
+		ConnectionID:   uuid.New().String(),
+		State:          didexchangeSvc.StateIDCompleted,
+		MyDID:          myDoc.ID,
+		TheirDID:       theirDoc.ID,
+		Namespace:      connection.MyNSPrefix,
+		DIDCommVersion: didCommVersion,
+	}
+
+	err := c.connections.SaveConnectionRecord(record)
 	if err != nil {
-		logutil.LogError(logger, CommandName, SendCreateConnectionRequest, err.Error())
+		return "", fmt.Errorf("failed to save connection record: %w", err)
+	}
+
+	return record.ConnectionID, nil
+}
+
+// GetOperation returns status and result of an asynchronous operation.
+func (c *Command) GetOperation(rw io.Writer, req io.Reader) command.Error {
+	var request OperationRequest
 
-		return command.NewValidationError(SendCreateConnectionRequestError, err)
+	err := agentcmd.DecodeRequest(req, &request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if request.OperationID == "" {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errInvalidOperationID))
+	}
+
+	op, err := c.operations.get(request.OperationID)
+	if err != nil {
+		return agentcmd.NewExecuteError(GetOperationError, err)
 	}
 
+	command.WriteNillableResponse(rw, &OperationResponse{Operation: op}, logger)
+
+	return nil
+}
+
+// CancelOperation aborts an asynchronous operation which is still in progress.
+func (c *Command) CancelOperation(rw io.Writer, req io.Reader) command.Error {
+	var request OperationRequest
+
+	err := agentcmd.DecodeRequest(req, &request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if request.OperationID == "" {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errInvalidOperationID))
+	}
+
+	op, err := c.operations.cancel(request.OperationID)
+	if err != nil {
+		return agentcmd.NewExecuteError(CancelOperationError, err)
+	}
+
+	command.WriteNillableResponse(rw, &OperationResponse{Operation: op}, logger)
+
+	return nil
+}
+
+// DecodeInvitation decodes out-of-band invitation from invitation URL, shortened URLs of allowed hosts are
+// resolved by following HTTP redirects.
+func (c *Command) DecodeInvitation(rw io.Writer, req io.Reader) command.Error {
+	var request DecodeInvitationRequest
+
+	err := agentcmd.DecodeRequest(req, &request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if request.InvitationURL == "" {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errInvalidInvitationURL))
+	}
+
+	invitation, err := c.decodeInvitationURL(context.Background(), request.InvitationURL)
+	if err != nil {
+		return agentcmd.NewExecuteError(DecodeInvitationError, err)
+	}
+
+	command.WriteNillableResponse(rw, &DecodeInvitationResponse{Invitation: invitation}, logger)
+
+	return nil
+}
+
+// ListInvitations lists invitations created through mediator client along with their status.
+func (c *Command) ListInvitations(rw io.Writer, req io.Reader) command.Error {
+	var request ListInvitationsRequest
+
+	err := agentcmd.DecodeRequest(req, &request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	records, err := c.invitations.list()
+	if err != nil {
+		return agentcmd.NewExecuteError(ListInvitationsError, err)
+	}
+
+	response := &ListInvitationsResponse{Invitations: []*InvitationRecord{}}
+
+	for _, record := range records {
+		if request.Status == "" || request.Status == record.Status {
+			response.Invitations = append(response.Invitations, record)
+		}
+	}
+
+	command.WriteNillableResponse(rw, response, logger)
+
+	return nil
+}
+
+// RevokeInvitation revokes invitation created through mediator client, connection requests against
+// revoked invitation are stopped. DIDComm V2 invitations can't be revoked.
+func (c *Command) RevokeInvitation(rw io.Writer, req io.Reader) command.Error {
+	var request RevokeInvitationRequest
+
+	err := agentcmd.DecodeRequest(req, &request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if request.InvitationID == "" {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errInvalidInvitationID))
+	}
+
+	record, err := c.invitations.revoke(request.InvitationID)
+	if errors.Is(err, errRevokeV2Invitation) {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if err != nil {
+		return agentcmd.NewExecuteError(RevokeInvitationError, err)
+	}
+
+	command.WriteNillableResponse(rw, &RevokeInvitationResponse{Invitation: record}, logger)
+
+	return nil
+}
+
+func (c *Command) saveInvitation(request *CreateInvitationRequest, id, version string, invitation interface{}) error {
+	invBytes, err := json.Marshal(invitation)
+	if err != nil {
+		return fmt.Errorf("failed to marshal invitation: %w", err)
+	}
+
+	record := &InvitationRecord{
+		ID:         id,
+		Version:    version,
+		Label:      request.Label,
+		CreatedAt:  time.Now().UTC(),
+		MaxUses:    request.MaxUses,
+		Invitation: invBytes,
+	}
+
+	if request.ExpiresIn > 0 {
+		expiresAt := record.CreatedAt.Add(time.Duration(request.ExpiresIn) * time.Second)
+		record.ExpiresAt = &expiresAt
+	}
+
+	return c.invitations.save(record)
+}
+
+// invitationBaseURL returns base URL to be used for invitation URLs.
+func (c *Command) invitationBaseURL(baseURL string) string {
+	if baseURL != "" {
+		return baseURL
+	}
+
+	if strings.HasPrefix(c.endpoint, invitationURLHTTPPrefix) {
+		return c.endpoint
+	}
+
+	return defaultInvitationBaseURL
+}
+
+// QueryKeylist queries recipient keys registered with the router over given connection or all router connections.
+func (c *Command) QueryKeylist(rw io.Writer, req io.Reader) command.Error {
+	var request KeylistQueryRequest
+
+	err := agentcmd.DecodeRequest(req, &request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	connections, err := c.routerConnections(request.ConnectionID)
+	if err != nil {
+		return agentcmd.NewExecuteError(QueryKeylistError, err)
+	}
+
+	response := &KeylistQueryResponse{Keylists: make([]*Keylist, 0, len(connections))}
+
+	for _, connID := range connections {
+		keylist, e := c.queryKeylist(connID, &request)
+		if e != nil {
+			return agentcmd.NewExecuteError(QueryKeylistError, e)
+		}
+
+		response.Keylists = append(response.Keylists, keylist)
+	}
+
+	command.WriteNillableResponse(rw, response, logger)
+
+	return nil
+}
+
+// queryKeylist queries recipient keys registered with the router over given connection.
+func (c *Command) queryKeylist(connID string, request *KeylistQueryRequest) (*Keylist, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), sendMsgTimeOut)
 	defer cancel()
 
+	isV2, err := c.mediations.IsMediated(connID)
+	if err != nil {
+		return nil, err
+	}
+
+	if isV2 {
+		return c.queryKeylistV2(ctx, connID, request)
+	}
+
+	query := map[string]interface{}{
+		"@id":   uuid.New().String(),
+		"@type": keylistQueryMsgType,
+	}
+
+	if paginate := request.paginate(); paginate != nil {
+		query["paginate"] = paginate
+	}
+
+	msgBytes, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
 	res, err := c.messenger.Send(json.RawMessage(msgBytes),
-		messaging.SendByConnectionID(connections[rand.Intn(len(connections))]), //nolint: gosec
-		messaging.WaitForResponse(ctx, createConnResponseMsgType))
+		messaging.SendByConnectionID(connID),
+		messaging.WaitForResponse(ctx, keylistMsgType))
 	if err != nil {
-		logutil.LogError(logger, CommandName, SendCreateConnectionRequest, err.Error())
+		return nil, err
+	}
 
-		return command.NewExecuteError(SendCreateConnectionRequestError, err)
+	var msg keylistMsg
+
+	err = json.Unmarshal(res, &msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse keylist response: %w", err)
+	}
+
+	keylist := &Keylist{
+		ConnectionID: connID,
+		Keys:         make([]string, 0, len(msg.Keys)),
+		Pagination:   msg.Pagination,
+	}
+
+	for _, key := range msg.Keys {
+		keylist.Keys = append(keylist.Keys, key.RecipientKey)
+	}
+
+	return keylist, nil
+}
+
+// queryKeylistV2 queries recipient DIDs registered with DIDComm V2 router using coordinate mediation 2.0.
+func (c *Command) queryKeylistV2(ctx context.Context, connID string, request *KeylistQueryRequest) (*Keylist, error) {
+	recipients, err := c.mediations.QueryRecipients(ctx, connID, request.recipientsPaginate())
+	if err != nil {
+		return nil, err
+	}
+
+	keylist := &Keylist{
+		ConnectionID: connID,
+		Keys:         make([]string, 0, len(recipients.DIDs)),
+	}
+
+	if recipients.Pagination != nil {
+		keylist.Pagination = &Pagination{
+			Count:     recipients.Pagination.Count,
+			Offset:    recipients.Pagination.Offset,
+			Remaining: recipients.Pagination.Remaining,
+		}
+	}
+
+	for _, recipient := range recipients.DIDs {
+		keylist.Keys = append(keylist.Keys, recipient.RecipientDID)
+	}
+
+	return keylist, nil
+}
+
+// RemoveKeys sends keylist update request to the router to remove given recipient keys
+// or all keys belonging to given DID, waits for the router to report results of the update
+// and removes keys removed by the router from the local keylist.
+func (c *Command) RemoveKeys(rw io.Writer, req io.Reader) command.Error {
+	var request RemoveKeysRequest
+
+	err := agentcmd.DecodeRequest(req, &request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if len(request.Keys) == 0 && request.DID == "" {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, fmt.Errorf(errInvalidRemoveKeysRequest))
 	}
 
-	command.WriteNillableResponse(rw, &CreateConnectionResponse{res}, logger)
+	keys := append([]string{}, request.Keys...)
 
-	logutil.LogDebug(logger, CommandName, SendCreateConnectionRequest, successString)
+	if request.DID != "" {
+		didKeys, e := c.getDIDKeys(request.DID)
+		if e != nil {
+			return agentcmd.NewExecuteError(RemoveKeysError, e)
+		}
+
+		keys = append(keys, didKeys...)
+	}
+
+	connections, err := c.routerConnections(request.ConnectionID)
+	if err != nil {
+		return agentcmd.NewExecuteError(RemoveKeysError, err)
+	}
+
+	response := &RemoveKeysResponse{Keys: keys}
+
+	for _, connID := range connections {
+		results, e := c.removeKeys(connID, request.DID, keys)
+		if e != nil {
+			return agentcmd.NewExecuteError(RemoveKeysError, e)
+		}
+
+		updated := &KeylistUpdateResult{ConnectionID: connID, Results: make([]*KeyUpdateResult, len(results))}
+		for i, result := range results {
+			updated.Results[i] = &KeyUpdateResult{Key: result.RecipientDID, Result: result.Result}
+		}
+
+		response.Updates = append(response.Updates, updated)
+	}
+
+	command.WriteNillableResponse(rw, response, logger)
 
 	return nil
 }
 
-func (c *Command) waitForConnect(didStateMsgs chan service.StateMsg, //nolint: gocyclo
-	notificationCh chan messaging.NotificationPayload, connID string,
+// removeKeys removes given keys from the router over given connection and returns results reported by router.
+func (c *Command) removeKeys(connID, didID string, keys []string) ([]mediation.UpdateResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sendMsgTimeOut)
+	defer cancel()
+
+	isV2, err := c.mediations.IsMediated(connID)
+	if err != nil {
+		return nil, err
+	}
+
+	if isV2 {
+		// DIDComm V2 routers may have the DID itself registered as recipient.
+		recipients := keys
+		if didID != "" {
+			recipients = append([]string{didID}, keys...)
+		}
+
+		return c.mediations.UpdateRecipients(ctx, connID, recipients, mediation.ActionRemove)
+	}
+
+	updates := make([]keylistUpdate, len(keys))
+	for i, key := range keys {
+		updates[i] = keylistUpdate{RecipientKey: key, Action: keylistUpdateActionRemove}
+	}
+
+	msgBytes, err := json.Marshal(map[string]interface{}{
+		"@id":     uuid.New().String(),
+		"@type":   keylistUpdateMsgType,
+		"updates": updates,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.messenger.Send(json.RawMessage(msgBytes),
+		messaging.SendByConnectionID(connID),
+		messaging.WaitForResponse(ctx, keylistUpdateResponseMsgType))
+	if err != nil {
+		return nil, err
+	}
+
+	var updateResponse keylistUpdateResponseMsg
+
+	err = json.Unmarshal(res, &updateResponse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse keylist update response: %w", err)
+	}
+
+	results := make([]mediation.UpdateResult, len(updateResponse.Updated))
+	for i, updated := range updateResponse.Updated {
+		results[i] = mediation.UpdateResult{
+			RecipientDID: updated.RecipientKey, Action: updated.Action, Result: updated.Result,
+		}
+	}
+
+	return results, c.mediations.UpdateKeylist(connID, results)
+}
+
+// routerConnections returns given router connection or all router connections if none given,
+// including connections to DIDComm V2 routers which granted mediation.
+func (c *Command) routerConnections(connID string) ([]string, error) {
+	if connID != "" {
+		return []string{connID}, nil
+	}
+
+	connections, err := c.mediator.GetConnections()
+	if err != nil {
+		return nil, err
+	}
+
+	v2Connections, err := c.mediations.Connections()
+	if err != nil {
+		return nil, err
+	}
+
+	connections = append(connections, v2Connections...)
+
+	if len(connections) == 0 {
+		return nil, fmt.Errorf(errNoConnectionFound)
+	}
+
+	return connections, nil
+}
+
+// getDIDKeys returns keys of given DID which may have been registered with router,
+// i.e. key agreement key IDs and recipient keys of DIDComm services.
+func (c *Command) getDIDKeys(didID string) ([]string, error) {
+	docResolution, err := c.vdrRegistry.Resolve(didID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve DID %s: %w", didID, err)
+	}
+
+	var keys []string
+
+	for _, ka := range docResolution.DIDDocument.KeyAgreement {
+		keys = append(keys, ka.VerificationMethod.ID)
+	}
+
+	for _, svc := range docResolution.DIDDocument.Service {
+		if svc.Type == didCommServiceType || svc.Type == didCommV2ServiceType {
+			keys = append(keys, svc.RecipientKeys...)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf(errNoKeysFoundForDID, didID)
+	}
+
+	return keys, nil
+}
+
+func (c *Command) waitForConnect(ctx context.Context, //nolint: gocyclo
+	didStateMsgs chan service.StateMsg, notificationCh chan messaging.NotificationPayload, connID string,
 ) error {
+	ctx, cancel := context.WithTimeout(ctx, c.didExchTimeout)
+	defer cancel()
+
 	if notificationCh != nil {
 		select {
 		case <-notificationCh:
 			// TODO correlate connection ID
 			return nil
-		case <-time.After(c.didExchTimeout):
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return fmt.Errorf("cancelled waiting for state completed message from mediator: %w", ctx.Err())
+			}
+
 			return fmt.Errorf("timeout waiting for state completed message from mediator")
 		}
 	}
@@ -449,7 +1328,11 @@ func (c *Command) waitForConnect(didStateMsgs chan service.StateMsg, //nolint: g
 	select {
 	case <-done:
 		return nil
-	case <-time.After(c.didExchTimeout):
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return fmt.Errorf("cancelled waiting for did exchange state 'completed': %w", ctx.Err())
+		}
+
 		return fmt.Errorf("time out waiting for did exchange state 'completed'")
 	}
 }
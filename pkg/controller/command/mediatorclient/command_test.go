@@ -9,8 +9,13 @@ package mediatorclient //nolint:testpackage // uses internal implementation deta
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -19,18 +24,23 @@ import (
 	mediatorsvc "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/mediator"
 	outofbandsvc "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/outofband"
 	outofbandv2svc "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/outofbandv2"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/did"
+	vdrapi "github.com/hyperledger/aries-framework-go/pkg/framework/aries/api/vdr"
 	mockmsghandler "github.com/hyperledger/aries-framework-go/pkg/mock/didcomm/msghandler"
 	mockdidexchange "github.com/hyperledger/aries-framework-go/pkg/mock/didcomm/protocol/didexchange"
 	mockroute "github.com/hyperledger/aries-framework-go/pkg/mock/didcomm/protocol/mediator"
 	mockoob "github.com/hyperledger/aries-framework-go/pkg/mock/didcomm/protocol/outofband"
 	mockkms "github.com/hyperledger/aries-framework-go/pkg/mock/kms"
 	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	mockvdr "github.com/hyperledger/aries-framework-go/pkg/mock/vdr"
 	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
 	"github.com/stretchr/testify/require"
 
 	"github.com/trustbloc/agent-sdk/pkg/controller/command"
+	"github.com/trustbloc/agent-sdk/pkg/controller/internal/mediation"
 	"github.com/trustbloc/agent-sdk/pkg/controller/internal/mocks"
 	sdkmockprotocol "github.com/trustbloc/agent-sdk/pkg/controller/internal/mocks/protocol"
+	"github.com/trustbloc/agent-sdk/pkg/controller/internal/netguard"
 )
 
 const sampleErr = "sample-error"
@@ -90,7 +100,7 @@ func TestNew(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, c)
 		require.NotEmpty(t, c.GetHandlers())
-		require.Len(t, c.GetHandlers(), 3)
+		require.Len(t, c.GetHandlers(), 10)
 	})
 
 	t.Run("test failure while creating mediator client", func(t *testing.T) {
@@ -143,6 +153,40 @@ func TestNew(t *testing.T) {
 		require.Nil(t, c)
 		require.Contains(t, err.Error(), "failed to create messenger client")
 	})
+
+	t.Run("test failure while opening invitation store", func(t *testing.T) {
+		prov := newMockProvider(nil)
+		prov.StoreProvider = &mockstorage.MockStoreProvider{
+			Store:         &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)},
+			FailNamespace: invitationStoreName,
+		}
+
+		c, err := New(prov, mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.Error(t, err)
+		require.Nil(t, c)
+		require.Contains(t, err.Error(), "failed to open invitation store")
+	})
+
+	t.Run("test failure while registering mediation reply handler", func(t *testing.T) {
+		c, err := New(newMockProvider(nil), &mockmsghandler.MockMsgSvcProvider{RegisterErr: fmt.Errorf(sampleErr)},
+			mocks.NewMockNotifier())
+		require.Error(t, err)
+		require.Nil(t, c)
+		require.Contains(t, err.Error(), "failed to create mediation client")
+	})
+
+	t.Run("test with shared mediation client", func(t *testing.T) {
+		prov := newMockProvider(nil)
+		registrar := mockmsghandler.NewMockMsgServiceProvider()
+
+		mediations, err := mediation.New(prov, registrar)
+		require.NoError(t, err)
+
+		c, err := New(prov, registrar, mocks.NewMockNotifier(), WithMediationClient(mediations))
+		require.NoError(t, err)
+		require.Same(t, mediations, c.mediations)
+		require.Len(t, registrar.Services(), 1)
+	})
 }
 
 func TestCommand_Connect(t *testing.T) {
@@ -195,7 +239,6 @@ func TestCommand_Connect(t *testing.T) {
 			"from": "did:orb:EiCNPdiZlyRPsx1BpgDqepdh28ujp3LAGnKnQMXdgxJyWA",
 			"body": {
 				"accept": [
-					"didcomm/v2",
 					"didcomm/aip2;env=rfc19"
 				]}
 			}
@@ -274,8 +317,8 @@ func TestCommand_Connect(t *testing.T) {
 
 		go func() {
 			for {
-				if len(mockMsgRegistrar.Services()) > 0 {
-					_, e := mockMsgRegistrar.Services()[0].HandleInbound(
+				if len(mockMsgRegistrar.Services()) > 1 {
+					_, e := mockMsgRegistrar.Services()[1].HandleInbound(
 						&service.DIDCommMsgMap{},
 						&sdkmockprotocol.MockDIDCommContext{},
 					)
@@ -465,12 +508,14 @@ func TestCommand_Connect(t *testing.T) {
 			outofbandv2svc.Name:        &sdkmockprotocol.MockOobServiceV2{},
 		})
 
-		c, err := New(prov, &mockmsghandler.MockMsgSvcProvider{
-			RegisterErr: fmt.Errorf(sampleErr),
-		}, mocks.NewMockNotifier())
+		registrar := mockmsghandler.NewMockMsgServiceProvider()
+
+		c, err := New(prov, registrar, mocks.NewMockNotifier())
 		require.NoError(t, err)
 		require.NotNil(t, c)
 
+		registrar.RegisterErr = fmt.Errorf(sampleErr)
+
 		// reduce timeout
 		c.didExchTimeout = 10 * time.Millisecond
 
@@ -517,6 +562,116 @@ func TestCommand_Connect(t *testing.T) {
 	})
 }
 
+func TestCommand_ConnectMediationV2(t *testing.T) {
+	const (
+		sampleRoutingDID   = "did:peer:2.Ez6LSrouter"
+		sampleV2Invitation = `{
+		"invitation": {
+			"id": "0196218f-cd7f-485e-a427-724835bb2941",
+			"type": "https://didcomm.org/out-of-band/2.0/invitation",
+			"label": "hub-router",
+			"from": "did:orb:EiCNPdiZlyRPsx1BpgDqepdh28ujp3LAGnKnQMXdgxJyWA",
+			"body": {"accept": ["didcomm/v2", "didcomm/aip2;env=rfc19"]}
+		}
+		}`
+		grantMsgStr = `{
+		"id": "123456781",
+		"type": "https://didcomm.org/coordinate-mediation/2.0/mediate-grant",
+		"thid": "%s",
+		"body": {"routing_did": ["did:peer:2.Ez6LSrouter"]}
+		}`
+		denyMsgStr = `{
+		"id": "123456781",
+		"type": "https://didcomm.org/coordinate-mediation/2.0/mediate-deny",
+		"thid": "%s",
+		"body": {}
+		}`
+	)
+
+	t.Run("test mediation granted", func(t *testing.T) {
+		prov, registrar, messenger := newMockProviderWithV2Router(t)
+
+		go replyFromRouter(t, registrar, messenger, mediation.MediateGrantMsgType, grantMsgStr)
+
+		c, err := New(prov, registrar, mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		cmdErr := c.Connect(&b, bytes.NewBufferString(sampleV2Invitation))
+		require.NoError(t, cmdErr)
+
+		resp := &ConnectionResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(resp))
+		require.Equal(t, "sample-connection", resp.ConnectionID)
+		require.Equal(t, []string{sampleRoutingDID}, resp.RoutingDIDs)
+
+		record, err := c.mediations.Get("sample-connection")
+		require.NoError(t, err)
+		require.Equal(t, []string{sampleRoutingDID}, record.RoutingDIDs)
+
+		connections, err := c.routerConnections("")
+		require.NoError(t, err)
+		require.Equal(t, []string{"sample-connection"}, connections)
+
+		require.Len(t, registrar.Services(), 1)
+	})
+
+	t.Run("test mediation denied", func(t *testing.T) {
+		prov, registrar, messenger := newMockProviderWithV2Router(t)
+
+		go replyFromRouter(t, registrar, messenger, mediation.MediateDenyMsgType, denyMsgStr)
+
+		c, err := New(prov, registrar, mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		cmdErr := c.Connect(&b, bytes.NewBufferString(sampleV2Invitation))
+		require.Error(t, cmdErr)
+		require.Equal(t, ConnectMediatorError, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), mediation.ErrDenied.Error())
+
+		isV2, err := c.mediations.IsMediated("sample-connection")
+		require.NoError(t, err)
+		require.False(t, isV2)
+	})
+
+	t.Run("test mediation request cancelled", func(t *testing.T) {
+		prov, registrar, _ := newMockProviderWithV2Router(t)
+
+		c, err := New(prov, registrar, mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err = c.mediations.RequestMediation(ctx, "sample-connection")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "cancelled waiting for reply from router")
+	})
+
+	t.Run("test failure while sending mediation request", func(t *testing.T) {
+		prov, registrar, _ := newMockProviderWithV2Router(t)
+
+		c, err := New(prov, registrar, mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		_, err = c.mediations.RequestMediation(context.Background(), "unknown-connection")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to request mediation")
+		require.Len(t, registrar.Services(), 1)
+	})
+
+	t.Run("test selection based on accept profiles", func(t *testing.T) {
+		require.True(t, mediation.Supported(&outofbandv2svc.Invitation{
+			Body: &outofbandv2svc.InvitationBody{Accept: []string{"didcomm/v2"}},
+		}))
+		require.False(t, mediation.Supported(&outofbandv2svc.Invitation{
+			Body: &outofbandv2svc.InvitationBody{Accept: []string{"didcomm/aip2;env=rfc19"}},
+		}))
+		require.False(t, mediation.Supported(&outofbandv2svc.Invitation{}))
+	})
+}
+
 func TestCommand_CreateInvitation(t *testing.T) {
 	t.Run("test with empty connections", func(t *testing.T) {
 		c, err := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
@@ -584,8 +739,39 @@ func TestCommand_CreateInvitation(t *testing.T) {
 		require.NotNil(t, c)
 
 		var b bytes.Buffer
-		cmdErr := c.CreateInvitation(&b, bytes.NewBufferString("{}"))
+		cmdErr := c.CreateInvitation(&b, bytes.NewBufferString(`{"baseURL":"https://example.com/invite"}`))
 		require.NoError(t, cmdErr)
+
+		resp := &CreateInvitationResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(resp))
+		require.NotNil(t, resp.Invitation)
+		require.True(t, strings.HasPrefix(resp.InvitationURL, "https://example.com/invite?oob="))
+		require.True(t, strings.HasPrefix(resp.LegacyInvitationURL, "https://example.com/invite?c_i="))
+	})
+
+	t.Run("test limits of DIDComm V2 invitation", func(t *testing.T) {
+		prov := newMockProvider(map[string]interface{}{
+			mediatorsvc.Coordination: &mockroute.MockMediatorSvc{
+				Connections: []string{"sample-connection"},
+			},
+			didexchangesvc.DIDExchange: &sdkmockprotocol.MockDIDExchangeSvc{},
+			outofbandsvc.Name:          &sdkmockprotocol.MockOobService{},
+			outofbandv2svc.Name:        &sdkmockprotocol.MockOobServiceV2{},
+		})
+
+		c, err := New(prov, mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+		require.NotNil(t, c)
+
+		var b bytes.Buffer
+		cmdErr := c.CreateInvitation(&b, bytes.NewBufferString(`{"from":"did:example:123","maxUses":1}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), errV2InvitationLimits)
+
+		cmdErr = c.CreateInvitation(&b, bytes.NewBufferString(`{"from":"did:example:123","expiresIn":60}`))
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), errV2InvitationLimits)
 	})
 
 	t.Run("test failure while saving invitation", func(t *testing.T) {
@@ -613,167 +799,1365 @@ func TestCommand_CreateInvitation(t *testing.T) {
 	})
 }
 
-func TestCommand_SendCreateConnectionRequest(t *testing.T) {
-	const replyMsgStr = `{
-							"@id": "123456781",
-							"@type": "https://trustbloc.dev/blinded-routing/1.0/create-conn-resp",
-							"~thread" : {"thid": "%s"}
-					}`
+func TestCommand_Invitations(t *testing.T) {
+	newGuardedCommand := func(t *testing.T, notifier command.Notifier) (*Command, *InvitationGuard) {
+		t.Helper()
 
-	t.Run("test success", func(t *testing.T) {
 		prov := newMockProvider(map[string]interface{}{
 			mediatorsvc.Coordination: &mockroute.MockMediatorSvc{
 				Connections: []string{"sample-connection"},
 			},
-			didexchangesvc.DIDExchange: &sdkmockprotocol.MockDIDExchangeSvc{},
+			didexchangesvc.DIDExchange: &mockdidexchange.MockDIDExchangeSvc{},
 			outofbandsvc.Name:          &sdkmockprotocol.MockOobService{},
 			outofbandv2svc.Name:        &sdkmockprotocol.MockOobServiceV2{},
 		})
 
-		record := &connection.Record{
-			ConnectionID: "sample-connection",
-			State:        "completed", MyDID: "mydid", TheirDID: "theirDID-001",
-		}
-		mockStore := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
-		connBytes, err := json.Marshal(record)
+		guard, err := NewInvitationGuard(prov, notifier)
 		require.NoError(t, err)
-		require.NoError(t, mockStore.Put("conn_sample-connection", connBytes))
-		prov.StoreProvider = mockstorage.NewCustomMockStoreProvider(mockStore)
 
-		registrar := mockmsghandler.NewMockMsgServiceProvider()
-		mockMessenger := sdkmockprotocol.NewMockMessenger()
+		c, err := New(prov, mockmsghandler.NewMockMsgServiceProvider(), notifier, WithInvitationGuard(guard))
+		require.NoError(t, err)
+		require.NotNil(t, c)
 
-		prov.CustomMessenger = mockMessenger
+		return c, guard
+	}
 
-		go func() {
-			for {
-				if len(registrar.Services()) > 0 && mockMessenger.GetLastID() != "" { //nolint: gocritic
-					replyMsg, e := service.ParseDIDCommMsgMap([]byte(fmt.Sprintf(replyMsgStr, mockMessenger.GetLastID())))
-					require.NoError(t, e)
+	newCommand := func(t *testing.T, notifier command.Notifier) *Command {
+		t.Helper()
 
-					_, e = registrar.Services()[0].HandleInbound(replyMsg, &sdkmockprotocol.MockDIDCommContext{
-						MyDIDValue:    "sampleDID",
-						TheirDIDValue: "sampleTheirDID",
-					})
-					require.NoError(t, e)
+		c, _ := newGuardedCommand(t, notifier)
 
-					break
-				}
-			}
-		}()
+		return c
+	}
 
-		c, err := New(prov, registrar, mocks.NewMockNotifier())
-		require.NoError(t, err)
-		require.NotNil(t, c)
+	createInvitation := func(t *testing.T, c *Command, request string) string {
+		t.Helper()
 
-		request := CreateConnectionRequest{
-			DIDDocument: json.RawMessage([]byte(sampleDIDDoc)),
-		}
+		var b bytes.Buffer
+		cmdErr := c.CreateInvitation(&b, bytes.NewBufferString(request))
+		require.NoError(t, cmdErr)
 
-		rqstBytes, err := json.Marshal(request)
-		require.NoError(t, err)
+		resp := &CreateInvitationResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(resp))
+		require.NotNil(t, resp.Invitation)
+
+		return resp.Invitation.ID
+	}
+
+	listInvitations := func(t *testing.T, c *Command, request string) []*InvitationRecord {
+		t.Helper()
 
 		var b bytes.Buffer
-		err = c.SendCreateConnectionRequest(&b, bytes.NewBuffer(rqstBytes))
+		cmdErr := c.ListInvitations(&b, bytes.NewBufferString(request))
+		require.NoError(t, cmdErr)
+
+		resp := &ListInvitationsResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(resp))
+
+		return resp.Invitations
+	}
+
+	t.Run("test list invitations", func(t *testing.T) {
+		c := newCommand(t, mocks.NewMockNotifier())
+
+		id := createInvitation(t, c, `{"label":"sample-label","expiresIn":3600,"maxUses":2}`)
+		createInvitation(t, c, `{}`)
+
+		invitations := listInvitations(t, c, `{}`)
+		require.Len(t, invitations, 2)
+
+		invitations = listInvitations(t, c, `{"status":"revoked"}`)
+		require.Empty(t, invitations)
+
+		record, err := c.invitations.get(id)
 		require.NoError(t, err)
+		require.Equal(t, invitationVersionV1, record.Version)
+		require.Equal(t, "sample-label", record.Label)
+		require.Equal(t, 2, record.MaxUses)
+		require.NotNil(t, record.ExpiresAt)
+		require.Equal(t, InvitationStatusActive, record.Status)
+		require.NotEmpty(t, record.Invitation)
 	})
 
-	t.Run("test with empty connections", func(t *testing.T) {
-		c, err := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
-		require.NoError(t, err)
-		require.NotNil(t, c)
+	t.Run("test revoke invitation", func(t *testing.T) {
+		c := newCommand(t, mocks.NewMockNotifier())
 
-		request := CreateConnectionRequest{
-			DIDDocument: json.RawMessage([]byte(sampleDIDDoc)),
-		}
+		id := createInvitation(t, c, `{}`)
 
-		rqstBytes, err := json.Marshal(request)
+		var b bytes.Buffer
+		cmdErr := c.RevokeInvitation(&b, bytes.NewBufferString(fmt.Sprintf(`{"invitationID":%q}`, id)))
+		require.NoError(t, cmdErr)
+
+		resp := &RevokeInvitationResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(resp))
+		require.Equal(t, id, resp.Invitation.ID)
+		require.Equal(t, InvitationStatusRevoked, resp.Invitation.Status)
+		require.NotNil(t, resp.Invitation.RevokedAt)
+
+		invitations := listInvitations(t, c, `{"status":"revoked"}`)
+		require.Len(t, invitations, 1)
+		require.Equal(t, id, invitations[0].ID)
+	})
+
+	t.Run("test revoke invitation failures", func(t *testing.T) {
+		c := newCommand(t, mocks.NewMockNotifier())
+
+		var b bytes.Buffer
+		cmdErr := c.RevokeInvitation(&b, bytes.NewBufferString("="))
+		require.Error(t, cmdErr)
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+
+		cmdErr = c.RevokeInvitation(&b, bytes.NewBufferString(`{}`))
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), errInvalidInvitationID)
+
+		cmdErr = c.RevokeInvitation(&b, bytes.NewBufferString(`{"invitationID":"unknown"}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, RevokeInvitationError, cmdErr.Code())
+
+		require.NoError(t, c.invitations.save(&InvitationRecord{ID: "v2-invitation", Version: invitationVersionV2}))
+
+		cmdErr = c.RevokeInvitation(&b, bytes.NewBufferString(`{"invitationID":"v2-invitation"}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), errRevokeV2Invitation.Error())
+
+		record, err := c.invitations.get("v2-invitation")
 		require.NoError(t, err)
+		require.Equal(t, InvitationStatusActive, record.Status)
+	})
+
+	t.Run("test list invitations failures", func(t *testing.T) {
+		c := newCommand(t, mocks.NewMockNotifier())
 
 		var b bytes.Buffer
-		err = c.SendCreateConnectionRequest(&b, bytes.NewBuffer(rqstBytes))
+		cmdErr := c.ListInvitations(&b, bytes.NewBufferString("="))
+		require.Error(t, cmdErr)
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
 
-		require.Error(t, err)
-		require.Contains(t, err.Error(), "no connection found to create invitation")
+		c.invitations.store = &mockstorage.MockStore{ErrQuery: fmt.Errorf(sampleErr)}
+
+		cmdErr = c.ListInvitations(&b, bytes.NewBufferString(`{}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, ListInvitationsError, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), sampleErr)
 	})
 
-	t.Run("test failure while getting connections", func(t *testing.T) {
-		prov := newMockProvider(map[string]interface{}{
-			mediatorsvc.Coordination: &mockroute.MockMediatorSvc{
-				GetConnectionsErr: fmt.Errorf(sampleErr),
-			},
-			didexchangesvc.DIDExchange: &sdkmockprotocol.MockDIDExchangeSvc{},
-			outofbandsvc.Name:          &sdkmockprotocol.MockOobService{},
-			outofbandv2svc.Name:        &sdkmockprotocol.MockOobServiceV2{},
-		})
+	t.Run("test usage limits and expiry", func(t *testing.T) {
+		c := newCommand(t, mocks.NewMockNotifier())
 
-		c, err := New(prov, mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
-		require.NoError(t, err)
-		require.NotNil(t, c)
+		id := createInvitation(t, c, `{"maxUses":1}`)
 
-		request := CreateConnectionRequest{
-			DIDDocument: json.RawMessage([]byte(sampleDIDDoc)),
-		}
+		record, err := c.invitations.use(id)
+		require.NoError(t, err)
+		require.Equal(t, InvitationStatusActive, record.Status)
+		require.Equal(t, 1, record.UseCount)
 
-		rqstBytes, err := json.Marshal(request)
+		record, err = c.invitations.use(id)
 		require.NoError(t, err)
+		require.Equal(t, InvitationStatusExhausted, record.Status)
+		require.Equal(t, 1, record.UseCount)
 
-		var b bytes.Buffer
-		err = c.SendCreateConnectionRequest(&b, bytes.NewBuffer(rqstBytes))
+		expired := time.Now().Add(-time.Minute)
+		require.NoError(t, c.invitations.save(&InvitationRecord{ID: "expired-invitation", ExpiresAt: &expired}))
 
-		require.Error(t, err)
-		require.Contains(t, err.Error(), sampleErr)
+		record, err = c.invitations.use("expired-invitation")
+		require.NoError(t, err)
+		require.Equal(t, InvitationStatusExpired, record.Status)
+		require.Equal(t, 0, record.UseCount)
 	})
 
-	t.Run("test failure while sending message", func(t *testing.T) {
-		prov := newMockProvider(map[string]interface{}{
-			mediatorsvc.Coordination: &mockroute.MockMediatorSvc{
-				Connections: []string{"sample-connection"},
-			},
-			didexchangesvc.DIDExchange: &sdkmockprotocol.MockDIDExchangeSvc{},
-			outofbandsvc.Name:          &sdkmockprotocol.MockOobService{},
-			outofbandv2svc.Name:        &sdkmockprotocol.MockOobServiceV2{},
+	t.Run("test connection request against active invitation is continued", func(t *testing.T) {
+		c, guard := newGuardedCommand(t, mocks.NewMockNotifier())
+
+		id := createInvitation(t, c, `{"maxUses":1}`)
+
+		continued := make(chan interface{}, 1)
+
+		guard.handleAction(service.DIDCommAction{
+			Message:    service.DIDCommMsgMap{"@type": didexchangesvc.RequestMsgType},
+			Properties: &mockDIDExchangeEvent{connectionID: "conn-1", invitationID: id},
+			Continue:   func(args interface{}) { continued <- args },
+			Stop:       func(err error) { require.NoError(t, err) },
 		})
 
-		c, err := New(prov, mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.Len(t, continued, 1)
+
+		record, err := c.invitations.get(id)
 		require.NoError(t, err)
-		require.NotNil(t, c)
+		require.Equal(t, 1, record.UseCount)
+		require.Equal(t, InvitationStatusExhausted, record.Status)
+	})
 
-		request := CreateConnectionRequest{
-			DIDDocument: json.RawMessage([]byte(sampleDIDDoc)),
-		}
+	t.Run("test commands sharing invitation guard", func(t *testing.T) {
+		prov := newMockProvider(nil)
 
-		rqstBytes, err := json.Marshal(request)
+		guard, err := NewInvitationGuard(prov, mocks.NewMockNotifier())
 		require.NoError(t, err)
 
-		var b bytes.Buffer
-		err = c.SendCreateConnectionRequest(&b, bytes.NewBuffer(rqstBytes))
+		// registering again would fail, commands sharing the guard don't register with did exchange service.
+		svc := prov.ServiceMap[didexchangesvc.DIDExchange]
+		svc.(*mockdidexchange.MockDIDExchangeSvc).RegisterActionEventErr = fmt.Errorf(sampleErr)
+
+		for i := 0; i < 2; i++ {
+			c, e := New(prov, mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier(),
+				WithInvitationGuard(guard))
+			require.NoError(t, e)
+			require.Same(t, guard.invitations, c.invitations)
+		}
+	})
+
+	t.Run("test did exchange service without action events", func(t *testing.T) {
+		prov := newMockProvider(nil)
+		prov.ServiceMap[didexchangesvc.DIDExchange] = "xyz"
 
+		guard, err := NewInvitationGuard(prov, mocks.NewMockNotifier())
 		require.Error(t, err)
+		require.Nil(t, guard)
+		require.Contains(t, err.Error(), "does not support action events")
 	})
 
-	t.Run("test failure due to invalid request", func(t *testing.T) {
-		prov := newMockProvider(map[string]interface{}{
-			mediatorsvc.Coordination: &mockroute.MockMediatorSvc{
-				Connections: []string{"sample-connection"},
-			},
-			didexchangesvc.DIDExchange: &sdkmockprotocol.MockDIDExchangeSvc{},
-			outofbandsvc.Name:          &sdkmockprotocol.MockOobService{},
-			outofbandv2svc.Name:        &sdkmockprotocol.MockOobServiceV2{},
-		})
+	t.Run("test register action event error", func(t *testing.T) {
+		prov := newMockProvider(nil)
+		prov.ServiceMap[didexchangesvc.DIDExchange] = &mockdidexchange.MockDIDExchangeSvc{
+			RegisterActionEventErr: fmt.Errorf(sampleErr),
+		}
 
 		c, err := New(prov, mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
-		require.NoError(t, err)
-		require.NotNil(t, c)
-
-		var b bytes.Buffer
-		err = c.SendCreateConnectionRequest(&b, bytes.NewBufferString("---"))
 		require.Error(t, err)
-		require.Contains(t, err.Error(), "invalid character")
+		require.Nil(t, c)
+		require.Contains(t, err.Error(), "failed to register for did exchange actions")
 	})
-}
 
-func newMockProvider(serviceMap map[string]interface{}) *sdkmockprotocol.MockProvider {
+	t.Run("test connection request against revoked invitation", func(t *testing.T) {
+		notifications := make(chan []byte, 10)
+
+		notifier := mocks.NewMockNotifier()
+		notifier.NotifyFunc = func(topic string, message []byte) error {
+			if topic == InvitationTopic {
+				notifications <- message
+			}
+
+			return nil
+		}
+
+		c, guard := newGuardedCommand(t, notifier)
+
+		id := createInvitation(t, c, `{}`)
+		_, err := c.invitations.revoke(id)
+		require.NoError(t, err)
+
+		next := make(chan service.DIDCommAction, 1)
+		guard.next = next
+
+		stopped := make(chan error, 3)
+		newAction := func(msgType, connID, invitationID string) service.DIDCommAction {
+			return service.DIDCommAction{
+				Message:    service.DIDCommMsgMap{"@type": msgType},
+				Properties: &mockDIDExchangeEvent{connectionID: connID, invitationID: invitationID},
+				Continue:   func(interface{}) {},
+				Stop:       func(err error) { stopped <- err },
+			}
+		}
+
+		actions := make(chan service.DIDCommAction, 3)
+		actions <- newAction(didexchangesvc.ResponseMsgType, "conn-1", id)
+		actions <- newAction(didexchangesvc.RequestMsgType, "conn-2", "unknown")
+		actions <- newAction(didexchangesvc.RequestMsgType, "conn-3", id)
+		close(actions)
+
+		guard.handleActions(actions)
+
+		require.Len(t, stopped, 1)
+		require.EqualError(t, <-stopped, fmt.Sprintf("invitation %s is %s", id, InvitationStatusRevoked))
+
+		// actions other than requests against inactive invitations are passed on to previous handler.
+		require.Len(t, next, 1)
+		require.Equal(t, "conn-1", (<-next).Properties.(*mockDIDExchangeEvent).connectionID)
+
+		require.Len(t, notifications, 1)
+
+		notification := &RejectedConnectionNotification{}
+		require.NoError(t, json.Unmarshal(<-notifications, notification))
+		require.Equal(t, id, notification.InvitationID)
+		require.Equal(t, "conn-3", notification.ConnectionID)
+		require.Equal(t, InvitationStatusRevoked, notification.Status)
+	})
+}
+
+func TestCommand_SendCreateConnectionRequest(t *testing.T) {
+	const replyMsgStr = `{
+							"@id": "123456781",
+							"@type": "https://trustbloc.dev/blinded-routing/1.0/create-conn-resp",
+							"~thread" : {"thid": "%s"},
+							"data": %s
+					}`
+
+	sendWithReply := func(t *testing.T, replyData string,
+		setup func(c *Command),
+	) (*sdkmockprotocol.MockProvider, *CreateConnectionResponse, command.Error) {
+		t.Helper()
+
+		prov := newMockProviderWithConnection(t)
+
+		registrar := mockmsghandler.NewMockMsgServiceProvider()
+		mockMessenger := sdkmockprotocol.NewMockMessenger()
+
+		prov.CustomMessenger = mockMessenger
+
+		go func() {
+			for {
+				if len(registrar.Services()) > 1 && mockMessenger.GetLastID() != "" { //nolint: gocritic
+					replyMsg, e := service.ParseDIDCommMsgMap(
+						[]byte(fmt.Sprintf(replyMsgStr, mockMessenger.GetLastID(), replyData)))
+					require.NoError(t, e)
+
+					_, e = registrar.Services()[1].HandleInbound(replyMsg, &sdkmockprotocol.MockDIDCommContext{
+						MyDIDValue:    "sampleDID",
+						TheirDIDValue: "sampleTheirDID",
+					})
+					require.NoError(t, e)
+
+					break
+				}
+			}
+		}()
+
+		c, err := New(prov, registrar, mocks.NewMockNotifier())
+		require.NoError(t, err)
+		require.NotNil(t, c)
+
+		if setup != nil {
+			setup(c)
+		}
+
+		request := CreateConnectionRequest{
+			DIDDocument: json.RawMessage([]byte(sampleDIDDoc)),
+		}
+
+		rqstBytes, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		cmdErr := c.SendCreateConnectionRequest(&b, bytes.NewBuffer(rqstBytes))
+		if cmdErr != nil {
+			return prov, nil, cmdErr
+		}
+
+		resp := &CreateConnectionResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(resp))
+
+		return prov, resp, nil
+	}
+
+	t.Run("test success", func(t *testing.T) {
+		routerDoc := strings.ReplaceAll(sampleDIDDoc, "did:example:21tDAKCERh95uGgKbJNHYp", "did:example:router")
+
+		prov, resp, cmdErr := sendWithReply(t, fmt.Sprintf(`{"didDoc": %s}`, routerDoc), nil)
+		require.NoError(t, cmdErr)
+		require.NotEmpty(t, resp.Payload)
+		require.NotEmpty(t, resp.ConnectionID)
+
+		doc, err := did.ParseDocument(resp.DIDDocument)
+		require.NoError(t, err)
+		require.Equal(t, "did:example:router", doc.ID)
+
+		lookup, err := connection.NewLookup(prov)
+		require.NoError(t, err)
+
+		record, err := lookup.GetConnectionRecord(resp.ConnectionID)
+		require.NoError(t, err)
+		require.Equal(t, "did:example:21tDAKCERh95uGgKbJNHYp", record.MyDID)
+		require.Equal(t, "did:example:router", record.TheirDID)
+		require.Equal(t, didexchangesvc.StateIDCompleted, record.State)
+		require.Equal(t, service.V1, record.DIDCommVersion)
+	})
+
+	t.Run("test success with DIDComm V2 router", func(t *testing.T) {
+		routerDoc := strings.ReplaceAll(sampleDIDDoc, "did:example:21tDAKCERh95uGgKbJNHYp", "did:example:router")
+
+		prov := newMockProviderWithConnection(t)
+
+		registrar := mockmsghandler.NewMockMsgServiceProvider()
+		mockMessenger := sdkmockprotocol.NewMockMessenger()
+		prov.CustomMessenger = mockMessenger
+
+		c, err := New(prov, registrar, mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		require.NoError(t, c.connections.SaveConnectionRecord(&connection.Record{
+			ConnectionID: "sample-connection",
+			State:        didexchangesvc.StateIDCompleted, MyDID: "mydid", TheirDID: "theirDID-001",
+			DIDCommVersion: service.V2,
+		}))
+
+		go func() {
+			for {
+				if len(registrar.Services()) > 1 && mockMessenger.GetLastID() != "" { //nolint: gocritic
+					replyMsg, e := service.ParseDIDCommMsgMap([]byte(fmt.Sprintf(`{
+						"id": "123456781",
+						"type": "https://trustbloc.dev/blinded-routing/2.0/create-conn-resp",
+						"thid": %q,
+						"body": {"didDoc": %s}
+					}`, mockMessenger.GetLastID(), routerDoc)))
+					require.NoError(t, e)
+
+					_, e = registrar.Services()[1].HandleInbound(replyMsg, &sdkmockprotocol.MockDIDCommContext{
+						MyDIDValue:    "sampleDID",
+						TheirDIDValue: "sampleTheirDID",
+					})
+					require.NoError(t, e)
+
+					break
+				}
+			}
+		}()
+
+		rqstBytes, err := json.Marshal(CreateConnectionRequest{DIDDocument: json.RawMessage(sampleDIDDoc)})
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		require.NoError(t, c.SendCreateConnectionRequest(&b, bytes.NewBuffer(rqstBytes)))
+
+		resp := &CreateConnectionResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(resp))
+
+		doc, err := did.ParseDocument(resp.DIDDocument)
+		require.NoError(t, err)
+		require.Equal(t, "did:example:router", doc.ID)
+	})
+
+	t.Run("test V1 and V2 create connection request formats", func(t *testing.T) {
+		msg, responseType := newCreateConnRequest(service.V1, json.RawMessage(`{"id":"did:example:alice"}`))
+		require.Equal(t, createConnRequestMsgType, msg["@type"])
+		require.NotEmpty(t, msg["@id"])
+		require.Contains(t, msg, "data")
+		require.Equal(t, createConnResponseMsgType, responseType)
+
+		msg, responseType = newCreateConnRequest(service.V2, json.RawMessage(`{"id":"did:example:alice"}`))
+		require.Equal(t, createConnRequestMsgTypeV2, msg["type"])
+		require.NotEmpty(t, msg["id"])
+		require.Contains(t, msg, "body")
+		require.Equal(t, createConnResponseMsgTypeV2, responseType)
+	})
+
+	t.Run("test failure while getting router connection", func(t *testing.T) {
+		prov := newMockProvider(map[string]interface{}{
+			mediatorsvc.Coordination: &mockroute.MockMediatorSvc{
+				Connections: []string{"unknown-connection"},
+			},
+			didexchangesvc.DIDExchange: &sdkmockprotocol.MockDIDExchangeSvc{},
+			outofbandsvc.Name:          &sdkmockprotocol.MockOobService{},
+			outofbandv2svc.Name:        &sdkmockprotocol.MockOobServiceV2{},
+		})
+
+		c, err := New(prov, mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		rqstBytes, err := json.Marshal(CreateConnectionRequest{DIDDocument: json.RawMessage(sampleDIDDoc)})
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		err = c.SendCreateConnectionRequest(&b, bytes.NewBuffer(rqstBytes))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get router connection")
+	})
+
+	t.Run("test success with peer DID", func(t *testing.T) {
+		routerDoc := strings.ReplaceAll(sampleDIDDoc, "did:example:21tDAKCERh95uGgKbJNHYp", "did:peer:router")
+
+		var stored *did.Doc
+
+		_, resp, cmdErr := sendWithReply(t, fmt.Sprintf(`{"didDoc": %s}`, routerDoc), func(c *Command) {
+			c.vdrRegistry = &mockvdr.MockVDRegistry{
+				CreateFunc: func(method string, doc *did.Doc, _ ...vdrapi.DIDMethodOption) (*did.DocResolution, error) {
+					require.Equal(t, "peer", method)
+					stored = doc
+
+					return &did.DocResolution{DIDDocument: doc}, nil
+				},
+			}
+		})
+		require.NoError(t, cmdErr)
+		require.NotEmpty(t, resp.ConnectionID)
+		require.NotNil(t, stored)
+		require.Equal(t, "did:peer:router", stored.ID)
+	})
+
+	t.Run("test failure while storing peer DID", func(t *testing.T) {
+		routerDoc := strings.ReplaceAll(sampleDIDDoc, "did:example:21tDAKCERh95uGgKbJNHYp", "did:peer:router")
+
+		_, _, cmdErr := sendWithReply(t, fmt.Sprintf(`{"didDoc": %s}`, routerDoc), func(c *Command) {
+			c.vdrRegistry = &mockvdr.MockVDRegistry{CreateErr: fmt.Errorf(sampleErr)}
+		})
+		require.Error(t, cmdErr)
+		require.Equal(t, SendCreateConnectionRequestError, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), "failed to store router DID document")
+	})
+
+	t.Run("test router error in response", func(t *testing.T) {
+		_, _, cmdErr := sendWithReply(t, `{"errorMsg": "sample-router-error"}`, nil)
+		require.Error(t, cmdErr)
+		require.Equal(t, SendCreateConnectionRequestError, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), "router failed to create connection: sample-router-error")
+	})
+
+	t.Run("test invalid response", func(t *testing.T) {
+		_, _, cmdErr := sendWithReply(t, `{}`, nil)
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), errInvalidCreateConnResponse)
+
+		_, _, cmdErr = sendWithReply(t, `null`, nil)
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), errInvalidCreateConnResponse)
+
+		_, _, cmdErr = sendWithReply(t, `{"didDoc": {"id": 1}}`, nil)
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), "invalid DID document in create connection response")
+	})
+
+	t.Run("test invalid DID document in request", func(t *testing.T) {
+		c, err := New(newMockProviderWithConnection(t), mockmsghandler.NewMockMsgServiceProvider(),
+			mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		cmdErr := c.SendCreateConnectionRequest(&b, bytes.NewBufferString(`{"didDoc":{"id":1}}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, command.ValidationError, cmdErr.Type())
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), "invalid DID document in request")
+
+		// asynchronous requests are validated before operation is started.
+		cmdErr = c.SendCreateConnectionRequest(&b, bytes.NewBufferString(`{"didDoc":{"id":1},"async":true}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, command.ValidationError, cmdErr.Type())
+		require.Contains(t, cmdErr.Error(), "invalid DID document in request")
+		require.Empty(t, b.Bytes())
+	})
+
+	t.Run("test with empty connections", func(t *testing.T) {
+		c, err := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+		require.NotNil(t, c)
+
+		request := CreateConnectionRequest{
+			DIDDocument: json.RawMessage([]byte(sampleDIDDoc)),
+		}
+
+		rqstBytes, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		err = c.SendCreateConnectionRequest(&b, bytes.NewBuffer(rqstBytes))
+
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no connection found to create invitation")
+	})
+
+	t.Run("test failure while getting connections", func(t *testing.T) {
+		prov := newMockProvider(map[string]interface{}{
+			mediatorsvc.Coordination: &mockroute.MockMediatorSvc{
+				GetConnectionsErr: fmt.Errorf(sampleErr),
+			},
+			didexchangesvc.DIDExchange: &sdkmockprotocol.MockDIDExchangeSvc{},
+			outofbandsvc.Name:          &sdkmockprotocol.MockOobService{},
+			outofbandv2svc.Name:        &sdkmockprotocol.MockOobServiceV2{},
+		})
+
+		c, err := New(prov, mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+		require.NotNil(t, c)
+
+		request := CreateConnectionRequest{
+			DIDDocument: json.RawMessage([]byte(sampleDIDDoc)),
+		}
+
+		rqstBytes, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		err = c.SendCreateConnectionRequest(&b, bytes.NewBuffer(rqstBytes))
+
+		require.Error(t, err)
+		require.Contains(t, err.Error(), sampleErr)
+	})
+
+	t.Run("test failure while sending message", func(t *testing.T) {
+		prov := newMockProvider(map[string]interface{}{
+			mediatorsvc.Coordination: &mockroute.MockMediatorSvc{
+				Connections: []string{"sample-connection"},
+			},
+			didexchangesvc.DIDExchange: &sdkmockprotocol.MockDIDExchangeSvc{},
+			outofbandsvc.Name:          &sdkmockprotocol.MockOobService{},
+			outofbandv2svc.Name:        &sdkmockprotocol.MockOobServiceV2{},
+		})
+
+		c, err := New(prov, mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+		require.NotNil(t, c)
+
+		request := CreateConnectionRequest{
+			DIDDocument: json.RawMessage([]byte(sampleDIDDoc)),
+		}
+
+		rqstBytes, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		err = c.SendCreateConnectionRequest(&b, bytes.NewBuffer(rqstBytes))
+
+		require.Error(t, err)
+	})
+
+	t.Run("test failure due to invalid request", func(t *testing.T) {
+		prov := newMockProvider(map[string]interface{}{
+			mediatorsvc.Coordination: &mockroute.MockMediatorSvc{
+				Connections: []string{"sample-connection"},
+			},
+			didexchangesvc.DIDExchange: &sdkmockprotocol.MockDIDExchangeSvc{},
+			outofbandsvc.Name:          &sdkmockprotocol.MockOobService{},
+			outofbandv2svc.Name:        &sdkmockprotocol.MockOobServiceV2{},
+		})
+
+		c, err := New(prov, mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+		require.NotNil(t, c)
+
+		var b bytes.Buffer
+		err = c.SendCreateConnectionRequest(&b, bytes.NewBufferString("---"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid character")
+	})
+}
+
+func TestCommand_Operations(t *testing.T) {
+	const (
+		sampleConnID       = "sample-conn-id"
+		sampleV2Invitation = `{
+		"invitation": {
+			"id": "0196218f-cd7f-485e-a427-724835bb2941",
+			"type": "https://didcomm.org/out-of-band/2.0/invitation",
+			"label": "hub-router",
+			"from": "did:orb:EiCNPdiZlyRPsx1BpgDqepdh28ujp3LAGnKnQMXdgxJyWA",
+			"body": {"accept": ["didcomm/aip2;env=rfc19"]}
+		},
+		"async": true
+		}`
+		sampleInvitation = `{
+		"invitation": {
+			"@id": "3ae3d2cb-83bf-429f-93ea-0802f92ecf42",
+			"@type": "https://didcomm.org/out-of-band/1.0/invitation",
+			"label": "hub-router",
+			"protocols": ["https://didcomm.org/didexchange/1.0"]
+		},
+		"async": true
+		}`
+	)
+
+	getOperation := func(t *testing.T, c *Command, id string) *Operation {
+		t.Helper()
+
+		var b bytes.Buffer
+		cmdErr := c.GetOperation(&b, bytes.NewBufferString(fmt.Sprintf(`{"operationID":%q}`, id)))
+		require.NoError(t, cmdErr)
+
+		resp := &OperationResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(resp))
+
+		return resp.Operation
+	}
+
+	waitForStatus := func(t *testing.T, c *Command, id string) *Operation {
+		t.Helper()
+
+		var op *Operation
+
+		require.Eventually(t, func() bool {
+			op = getOperation(t, c, id)
+
+			return isOperationFinished(op.Status)
+		}, time.Second, 5*time.Millisecond)
+
+		return op
+	}
+
+	t.Run("test successful async connect", func(t *testing.T) {
+		topics := make(chan string, 10)
+
+		notifier := mocks.NewMockNotifier()
+		notifier.NotifyFunc = func(topic string, message []byte) error {
+			select {
+			case topics <- topic:
+			default:
+			}
+
+			return nil
+		}
+
+		prov := newMockProvider(map[string]interface{}{
+			mediatorsvc.Coordination:   &mockroute.MockMediatorSvc{},
+			didexchangesvc.DIDExchange: &mockdidexchange.MockDIDExchangeSvc{},
+			outofbandsvc.Name:          &sdkmockprotocol.MockOobService{},
+			outofbandv2svc.Name: &sdkmockprotocol.MockOobServiceV2{
+				AcceptInvitationHandle: func(_ *outofbandv2svc.Invitation) (string, error) {
+					return sampleConnID, nil
+				},
+			},
+		})
+
+		c, err := New(prov, mockmsghandler.NewMockMsgServiceProvider(), notifier)
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		require.NoError(t, c.Connect(&b, bytes.NewBufferString(sampleV2Invitation)))
+
+		resp := &ConnectionResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(resp))
+		require.Empty(t, resp.ConnectionID)
+		require.NotEmpty(t, resp.OperationID)
+
+		op := waitForStatus(t, c, resp.OperationID)
+		require.Equal(t, OperationStatusCompleted, op.Status)
+		require.Equal(t, Connect, op.Method)
+
+		result := &ConnectionResponse{}
+		require.NoError(t, json.Unmarshal(op.Result, result))
+		require.Equal(t, sampleConnID, result.ConnectionID)
+
+		require.Equal(t, OperationTopic, <-topics)
+	})
+
+	t.Run("test failed async connect", func(t *testing.T) {
+		prov := newMockProvider(map[string]interface{}{
+			mediatorsvc.Coordination:   &mockroute.MockMediatorSvc{},
+			didexchangesvc.DIDExchange: &mockdidexchange.MockDIDExchangeSvc{},
+			outofbandsvc.Name:          &sdkmockprotocol.MockOobService{},
+			outofbandv2svc.Name: &sdkmockprotocol.MockOobServiceV2{
+				AcceptInvitationHandle: func(_ *outofbandv2svc.Invitation) (string, error) {
+					return "", fmt.Errorf(sampleErr)
+				},
+			},
+		})
+
+		c, err := New(prov, mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		require.NoError(t, c.Connect(&b, bytes.NewBufferString(sampleV2Invitation)))
+
+		resp := &ConnectionResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(resp))
+
+		op := waitForStatus(t, c, resp.OperationID)
+		require.Equal(t, OperationStatusFailed, op.Status)
+		require.Contains(t, op.Error, sampleErr)
+		require.Equal(t, ConnectMediatorError, op.ErrorCode)
+	})
+
+	t.Run("test cancel async connect", func(t *testing.T) {
+		var (
+			mutex     sync.Mutex
+			cancelled int
+		)
+
+		notifier := mocks.NewMockNotifier()
+		notifier.NotifyFunc = func(topic string, message []byte) error {
+			var op Operation
+			require.NoError(t, json.Unmarshal(message, &op))
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if op.Status == OperationStatusCancelled {
+				cancelled++
+			}
+
+			return nil
+		}
+
+		notifications := func() int {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			return cancelled
+		}
+
+		c, err := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), notifier)
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		require.NoError(t, c.Connect(&b, bytes.NewBufferString(sampleInvitation)))
+
+		resp := &ConnectionResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(resp))
+
+		b.Reset()
+		cmdErr := c.CancelOperation(&b, bytes.NewBufferString(fmt.Sprintf(`{"operationID":%q}`, resp.OperationID)))
+		require.NoError(t, cmdErr)
+
+		cancelResp := &OperationResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(cancelResp))
+		require.Equal(t, OperationStatusCancelled, cancelResp.Operation.Status)
+
+		op := waitForStatus(t, c, resp.OperationID)
+		require.Equal(t, OperationStatusCancelled, op.Status)
+
+		// cancelled operation is published once, not again when its function returns.
+		require.Never(t, func() bool {
+			return notifications() > 1
+		}, 200*time.Millisecond, 10*time.Millisecond)
+		require.Equal(t, 1, notifications())
+	})
+
+	t.Run("test operation not found", func(t *testing.T) {
+		c, err := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		cmdErr := c.GetOperation(&b, bytes.NewBufferString(`{"operationID":"unknown"}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, GetOperationError, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), "operation not found")
+
+		cmdErr = c.CancelOperation(&b, bytes.NewBufferString(`{"operationID":"unknown"}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, CancelOperationError, cmdErr.Code())
+	})
+
+	t.Run("test invalid operation request", func(t *testing.T) {
+		c, err := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		cmdErr := c.GetOperation(&b, bytes.NewBufferString(`{}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, command.ValidationError, cmdErr.Type())
+		require.Contains(t, cmdErr.Error(), errInvalidOperationID)
+
+		cmdErr = c.CancelOperation(&b, bytes.NewBufferString(`--`))
+		require.Error(t, cmdErr)
+		require.Equal(t, command.ValidationError, cmdErr.Type())
+	})
+}
+
+func TestCommand_QueryKeylist(t *testing.T) {
+	const keylistMsgStr = `{
+		"@id": "123456781",
+		"@type": "https://didcomm.org/coordinatemediation/1.0/keylist",
+		"keys": [{"recipient_key": "key-1"}, {"recipient_key": "key-2"}],
+		"pagination": {"count": 2, "offset": 2, "remaining": 3},
+		"~thread" : {"thid": "%s"}
+	}`
+
+	t.Run("test success", func(t *testing.T) {
+		prov := newMockProviderWithConnection(t)
+
+		registrar := mockmsghandler.NewMockMsgServiceProvider()
+		mockMessenger := sdkmockprotocol.NewMockMessenger()
+		prov.CustomMessenger = mockMessenger
+
+		go func() {
+			for {
+				if len(registrar.Services()) > 1 && mockMessenger.GetLastID() != "" { //nolint: gocritic
+					replyMsg, e := service.ParseDIDCommMsgMap([]byte(fmt.Sprintf(keylistMsgStr, mockMessenger.GetLastID())))
+					require.NoError(t, e)
+
+					_, e = registrar.Services()[1].HandleInbound(replyMsg, &sdkmockprotocol.MockDIDCommContext{
+						MyDIDValue:    "sampleDID",
+						TheirDIDValue: "sampleTheirDID",
+					})
+					require.NoError(t, e)
+
+					break
+				}
+			}
+		}()
+
+		c, err := New(prov, registrar, mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		cmdErr := c.QueryKeylist(&b, bytes.NewBufferString(`{"limit":2}`))
+		require.NoError(t, cmdErr)
+
+		resp := &KeylistQueryResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(resp))
+		require.Len(t, resp.Keylists, 1)
+		require.Equal(t, []string{"key-1", "key-2"}, resp.Keylists[0].Keys)
+		require.Equal(t, "sample-connection", resp.Keylists[0].ConnectionID)
+		require.NotNil(t, resp.Keylists[0].Pagination)
+		require.Equal(t, 3, resp.Keylists[0].Pagination.Remaining)
+	})
+
+	t.Run("test with empty connections", func(t *testing.T) {
+		c, err := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		cmdErr := c.QueryKeylist(&b, bytes.NewBufferString(`{}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, QueryKeylistError, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), errNoConnectionFound)
+	})
+
+	t.Run("test failure while sending message", func(t *testing.T) {
+		c, err := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		cmdErr := c.QueryKeylist(&b, bytes.NewBufferString(`{"connectionID":"unknown-connection"}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, QueryKeylistError, cmdErr.Code())
+	})
+
+	t.Run("test invalid request", func(t *testing.T) {
+		c, err := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		cmdErr := c.QueryKeylist(&b, bytes.NewBufferString(`--`))
+		require.Error(t, cmdErr)
+		require.Equal(t, command.ValidationError, cmdErr.Type())
+	})
+}
+
+func TestCommand_QueryKeylistV2(t *testing.T) {
+	const recipientMsgStr = `{
+		"id": "123456781",
+		"type": "https://didcomm.org/coordinate-mediation/2.0/recipient",
+		"thid": "%s",
+		"body": {
+			"dids": [{"recipient_did": "did:peer:alice"}, {"recipient_did": "did:peer:bob"}],
+			"pagination": {"count": 2, "offset": 0, "remaining": 0}
+		}
+	}`
+
+	prov, registrar, messenger := newMockProviderWithV2Router(t)
+
+	go replyFromRouter(t, registrar, messenger, mediation.RecipientMsgType, recipientMsgStr)
+
+	c, err := New(prov, registrar, mocks.NewMockNotifier())
+	require.NoError(t, err)
+
+	saveMediationRecord(t, c, "sample-connection")
+
+	var b bytes.Buffer
+	cmdErr := c.QueryKeylist(&b, bytes.NewBufferString(`{"limit":2}`))
+	require.NoError(t, cmdErr)
+
+	resp := &KeylistQueryResponse{}
+	require.NoError(t, json.NewDecoder(&b).Decode(resp))
+	require.Len(t, resp.Keylists, 1)
+	require.Equal(t, []string{"did:peer:alice", "did:peer:bob"}, resp.Keylists[0].Keys)
+	require.Equal(t, "sample-connection", resp.Keylists[0].ConnectionID)
+	require.NotNil(t, resp.Keylists[0].Pagination)
+	require.Equal(t, 2, resp.Keylists[0].Pagination.Count)
+}
+
+func TestCommand_RemoveKeys(t *testing.T) {
+	const updateResponseMsgStr = `{
+		"@id": "123456781",
+		"@type": "https://didcomm.org/coordinatemediation/1.0/keylist-update-response",
+		"updated": [
+			{"recipient_key": "key-1", "action": "remove", "result": "success"},
+			{"recipient_key": "key-2", "action": "remove", "result": "client_error"}
+		],
+		"~thread" : {"thid": "%s"}
+	}`
+
+	t.Run("test success with keys", func(t *testing.T) {
+		prov := newMockProviderWithConnection(t)
+		registrar := mockmsghandler.NewMockMsgServiceProvider()
+		messenger := sdkmockprotocol.NewMockMessenger()
+		prov.CustomMessenger = messenger
+
+		c, err := New(prov, registrar, mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		require.NoError(t, c.mediations.UpdateKeylist("sample-connection", []mediation.UpdateResult{
+			{RecipientDID: "key-1", Action: mediation.ActionAdd, Result: mediation.ResultSuccess},
+			{RecipientDID: "key-2", Action: mediation.ActionAdd, Result: mediation.ResultSuccess},
+		}))
+
+		go replyFromRouter(t, registrar, messenger, keylistUpdateResponseMsgType, updateResponseMsgStr)
+
+		var b bytes.Buffer
+		cmdErr := c.RemoveKeys(&b, bytes.NewBufferString(`{"keys":["key-1","key-2"]}`))
+		require.NoError(t, cmdErr)
+
+		resp := &RemoveKeysResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(resp))
+		require.Equal(t, []string{"key-1", "key-2"}, resp.Keys)
+		require.Len(t, resp.Updates, 1)
+		require.Equal(t, "sample-connection", resp.Updates[0].ConnectionID)
+		require.Equal(t, []*KeyUpdateResult{
+			{Key: "key-1", Result: "success"},
+			{Key: "key-2", Result: "client_error"},
+		}, resp.Updates[0].Results)
+
+		keys, err := c.mediations.Keylist("sample-connection")
+		require.NoError(t, err)
+		require.Equal(t, []string{"key-2"}, keys)
+	})
+
+	t.Run("test success with DID", func(t *testing.T) {
+		prov := newMockProviderWithConnection(t)
+		registrar := mockmsghandler.NewMockMsgServiceProvider()
+		messenger := sdkmockprotocol.NewMockMessenger()
+		prov.CustomMessenger = messenger
+
+		c, err := New(prov, registrar, mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		c.vdrRegistry = &mockvdr.MockVDRegistry{ResolveValue: &did.Doc{
+			ID: "did:peer:123",
+			Service: []did.Service{{
+				Type:          didCommV2ServiceType,
+				RecipientKeys: []string{"did:key:123"},
+			}},
+		}}
+
+		go replyFromRouter(t, registrar, messenger, keylistUpdateResponseMsgType, updateResponseMsgStr)
+
+		var b bytes.Buffer
+		cmdErr := c.RemoveKeys(&b, bytes.NewBufferString(`{"did":"did:peer:123"}`))
+		require.NoError(t, cmdErr)
+
+		resp := &RemoveKeysResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(resp))
+		require.Equal(t, []string{"did:key:123"}, resp.Keys)
+	})
+
+	t.Run("test success with DIDComm V2 router", func(t *testing.T) {
+		const recipientUpdateResponseMsgStr = `{
+			"id": "123456781",
+			"type": "https://didcomm.org/coordinate-mediation/2.0/recipient-update-response",
+			"thid": "%s",
+			"body": {"updated": [{"recipient_did": "did:peer:alice", "action": "remove", "result": "success"}]}
+		}`
+
+		prov, registrar, messenger := newMockProviderWithV2Router(t)
+
+		c, err := New(prov, registrar, mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		saveMediationRecord(t, c, "sample-connection")
+
+		require.NoError(t, c.mediations.UpdateKeylist("sample-connection", []mediation.UpdateResult{
+			{RecipientDID: "did:peer:alice", Action: mediation.ActionAdd, Result: mediation.ResultSuccess},
+		}))
+
+		go replyFromRouter(t, registrar, messenger, mediation.RecipientUpdateResponseMsgType,
+			recipientUpdateResponseMsgStr)
+
+		var b bytes.Buffer
+		cmdErr := c.RemoveKeys(&b, bytes.NewBufferString(`{"keys":["did:peer:alice"]}`))
+		require.NoError(t, cmdErr)
+
+		resp := &RemoveKeysResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(resp))
+		require.Len(t, resp.Updates, 1)
+		require.Equal(t, "sample-connection", resp.Updates[0].ConnectionID)
+		require.Equal(t, []*KeyUpdateResult{{Key: "did:peer:alice", Result: "success"}}, resp.Updates[0].Results)
+
+		keys, err := c.mediations.Keylist("sample-connection")
+		require.NoError(t, err)
+		require.Empty(t, keys)
+	})
+
+	t.Run("test failure while sending message", func(t *testing.T) {
+		c, err := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		cmdErr := c.RemoveKeys(&b, bytes.NewBufferString(`{"connectionID":"unknown-connection","keys":["key-1"]}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, RemoveKeysError, cmdErr.Code())
+	})
+
+	t.Run("test failure while resolving DID", func(t *testing.T) {
+		c, err := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		c.vdrRegistry = &mockvdr.MockVDRegistry{ResolveErr: fmt.Errorf(sampleErr)}
+
+		var b bytes.Buffer
+		cmdErr := c.RemoveKeys(&b, bytes.NewBufferString(`{"did":"did:peer:123"}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, RemoveKeysError, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), sampleErr)
+
+		c.vdrRegistry = &mockvdr.MockVDRegistry{ResolveValue: &did.Doc{ID: "did:peer:123"}}
+
+		cmdErr = c.RemoveKeys(&b, bytes.NewBufferString(`{"did":"did:peer:123"}`))
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), "no keys found for DID")
+	})
+
+	t.Run("test with empty connections", func(t *testing.T) {
+		c, err := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		cmdErr := c.RemoveKeys(&b, bytes.NewBufferString(`{"keys":["key-1"]}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, RemoveKeysError, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), errNoConnectionFound)
+	})
+
+	t.Run("test invalid request", func(t *testing.T) {
+		c, err := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+		cmdErr := c.RemoveKeys(&b, bytes.NewBufferString(`{}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, command.ValidationError, cmdErr.Type())
+		require.Contains(t, cmdErr.Error(), errInvalidRemoveKeysRequest)
+
+		cmdErr = c.RemoveKeys(&b, bytes.NewBufferString(`--`))
+		require.Error(t, cmdErr)
+		require.Equal(t, command.ValidationError, cmdErr.Type())
+	})
+}
+
+func TestCommand_DecodeInvitation(t *testing.T) {
+	invitation := map[string]interface{}{
+		"@id":       "3ae3d2cb-83bf-429f-93ea-0802f92ecf42",
+		"@type":     "https://didcomm.org/out-of-band/1.0/invitation",
+		"label":     "hub-router",
+		"protocols": []string{"https://didcomm.org/didexchange/1.0"},
+	}
+
+	decode := func(t *testing.T, c *Command, invitationURL string) (*DecodeInvitationResponse, error) {
+		t.Helper()
+
+		reqBytes, err := json.Marshal(&DecodeInvitationRequest{InvitationURL: invitationURL})
+		require.NoError(t, err)
+
+		var b bytes.Buffer
+
+		cmdErr := c.DecodeInvitation(&b, bytes.NewBuffer(reqBytes))
+		if cmdErr != nil {
+			return nil, cmdErr
+		}
+
+		resp := &DecodeInvitationResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(resp))
+
+		return resp, nil
+	}
+
+	c, err := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+	require.NoError(t, err)
+
+	t.Run("test decode invitation from query parameters", func(t *testing.T) {
+		for _, param := range []string{oobQueryParam, oobV2QueryParam, legacyQueryParam} {
+			invitationURL, e := EncodeInvitationURL("https://example.com/invite?lang=en", param, invitation)
+			require.NoError(t, e)
+			require.Contains(t, invitationURL, param+"=")
+			require.Contains(t, invitationURL, "lang=en")
+
+			resp, e := decode(t, c, invitationURL)
+			require.NoError(t, e)
+			require.Equal(t, "3ae3d2cb-83bf-429f-93ea-0802f92ecf42", resp.Invitation.ID())
+		}
+	})
+
+	t.Run("test decode invitation from shortened URL", func(t *testing.T) {
+		invitationURL, e := EncodeInvitationURL("https://example.com/invite", oobQueryParam, invitation)
+		require.NoError(t, e)
+
+		invBytes, e := json.Marshal(invitation)
+		require.NoError(t, e)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, invitationURL, http.StatusFound)
+		})
+		mux.HandleFunc("/body", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write(invBytes) //nolint: errcheck
+		})
+		mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		})
+
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+
+		cmd, e := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier(),
+			WithInvitationURLHosts("127.0.0.1"))
+		require.NoError(t, e)
+
+		// test server listens on loopback address, which is refused by default transport.
+		cmd.httpClient.Transport = http.DefaultTransport
+
+		resp, e := decode(t, cmd, srv.URL+"/redirect")
+		require.NoError(t, e)
+		require.Equal(t, "3ae3d2cb-83bf-429f-93ea-0802f92ecf42", resp.Invitation.ID())
+
+		resp, e = decode(t, cmd, srv.URL+"/body")
+		require.NoError(t, e)
+		require.Equal(t, "3ae3d2cb-83bf-429f-93ea-0802f92ecf42", resp.Invitation.ID())
+
+		_, e = decode(t, cmd, srv.URL+"/loop")
+		require.Error(t, e)
+		require.Contains(t, e.Error(), "too many redirects")
+
+		_, e = decode(t, cmd, srv.URL+"/not-found")
+		require.Error(t, e)
+		require.Contains(t, e.Error(), "status code: 404")
+	})
+
+	t.Run("test shortened URL of host not allowed", func(t *testing.T) {
+		_, e := decode(t, c, "https://example.com/invite")
+		require.Error(t, e)
+		require.Contains(t, e.Error(), "resolving invitation URLs of host example.com is not allowed")
+
+		cmd, e := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier(),
+			WithInvitationURLHosts("*.example.com"))
+		require.NoError(t, e)
+
+		_, e = decode(t, cmd, "https://example.org/invite")
+		require.Error(t, e)
+		require.Contains(t, e.Error(), "is not allowed")
+
+		require.True(t, cmd.isInvitationHostAllowed("links.example.com"))
+		require.False(t, cmd.isInvitationHostAllowed("example.org"))
+	})
+
+	t.Run("test shortened URL of host with private address", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("unexpected request to private address")
+		}))
+		defer srv.Close()
+
+		cmd, e := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier(),
+			WithInvitationURLHosts("*"))
+		require.NoError(t, e)
+
+		_, e = decode(t, cmd, srv.URL+"/invite")
+		require.Error(t, e)
+		require.ErrorIs(t, e, netguard.ErrPrivateAddress)
+	})
+
+	t.Run("test shortened URL resolved by asynchronous connect", func(t *testing.T) {
+		release := make(chan struct{})
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+			http.NotFound(w, r)
+		}))
+		defer srv.Close()
+
+		cmd, e := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier(),
+			WithInvitationURLHosts("127.0.0.1"))
+		require.NoError(t, e)
+
+		cmd.httpClient.Transport = http.DefaultTransport
+
+		reqBytes, e := json.Marshal(&ConnectionRequest{InvitationURL: srv.URL + "/invite", Async: true})
+		require.NoError(t, e)
+
+		// response is returned while invitation URL is still being resolved.
+		var b bytes.Buffer
+		require.NoError(t, cmd.Connect(&b, bytes.NewBuffer(reqBytes)))
+		close(release)
+
+		resp := &ConnectionResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(resp))
+		require.NotEmpty(t, resp.OperationID)
+	})
+
+	t.Run("test decode failures", func(t *testing.T) {
+		_, e := decode(t, c, "")
+		require.Error(t, e)
+		require.Contains(t, e.Error(), errInvalidInvitationURL)
+
+		_, e = decode(t, c, "didcomm://invite?oob=%%%%")
+		require.Error(t, e)
+
+		_, e = decode(t, c, "didcomm://invite?oob=invalid")
+		require.Error(t, e)
+		require.Contains(t, e.Error(), "failed to")
+
+		_, e = decode(t, c, "didcomm://invite")
+		require.Error(t, e)
+		require.Contains(t, e.Error(), "invitation not found in URL")
+
+		var b bytes.Buffer
+		cmdErr := c.DecodeInvitation(&b, bytes.NewBufferString(`--`))
+		require.Error(t, cmdErr)
+		require.Equal(t, command.ValidationError, cmdErr.Type())
+	})
+
+	t.Run("test connect with invitation URL", func(t *testing.T) {
+		invitationURL, e := EncodeInvitationURL("https://example.com", oobV2QueryParam, map[string]interface{}{
+			"id":    "0196218f-cd7f-485e-a427-724835bb2941",
+			"type":  "https://didcomm.org/out-of-band/2.0/invitation",
+			"label": "hub-router",
+			"from":  "did:orb:EiCNPdiZlyRPsx1BpgDqepdh28ujp3LAGnKnQMXdgxJyWA",
+			"body":  map[string]interface{}{"accept": []string{"didcomm/aip2;env=rfc19"}},
+		})
+		require.NoError(t, e)
+
+		prov := newMockProvider(map[string]interface{}{
+			mediatorsvc.Coordination:   &mockroute.MockMediatorSvc{},
+			didexchangesvc.DIDExchange: &mockdidexchange.MockDIDExchangeSvc{},
+			outofbandsvc.Name:          &sdkmockprotocol.MockOobService{},
+			outofbandv2svc.Name: &sdkmockprotocol.MockOobServiceV2{
+				AcceptInvitationHandle: func(_ *outofbandv2svc.Invitation) (string, error) {
+					return "sample-conn-id", nil
+				},
+			},
+		})
+
+		cmd, e := New(prov, mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, e)
+
+		reqBytes, e := json.Marshal(&ConnectionRequest{InvitationURL: invitationURL})
+		require.NoError(t, e)
+
+		var b bytes.Buffer
+		require.NoError(t, cmd.Connect(&b, bytes.NewBuffer(reqBytes)))
+
+		resp := &ConnectionResponse{}
+		require.NoError(t, json.NewDecoder(&b).Decode(resp))
+		require.Equal(t, "sample-conn-id", resp.ConnectionID)
+
+		cmdErr := cmd.Connect(&b, bytes.NewBufferString(`{"invitationURL":"didcomm://invite"}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, command.ValidationError, cmdErr.Type())
+	})
+}
+
+func newMockProviderWithConnection(t *testing.T) *sdkmockprotocol.MockProvider {
+	t.Helper()
+
+	prov := newMockProvider(map[string]interface{}{
+		mediatorsvc.Coordination: &mockroute.MockMediatorSvc{
+			Connections: []string{"sample-connection"},
+		},
+		didexchangesvc.DIDExchange: &sdkmockprotocol.MockDIDExchangeSvc{},
+		outofbandsvc.Name:          &sdkmockprotocol.MockOobService{},
+		outofbandv2svc.Name:        &sdkmockprotocol.MockOobServiceV2{},
+	})
+
+	record := &connection.Record{
+		ConnectionID: "sample-connection",
+		State:        "completed", MyDID: "mydid", TheirDID: "theirDID-001",
+	}
+	mockStore := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
+	connBytes, err := json.Marshal(record)
+	require.NoError(t, err)
+	require.NoError(t, mockStore.Put("conn_sample-connection", connBytes))
+	prov.StoreProvider = mockstorage.NewCustomMockStoreProvider(mockStore)
+
+	return prov
+}
+
+func newMockProvider(serviceMap map[string]interface{}) *sdkmockprotocol.MockProvider {
 	if serviceMap == nil {
 		serviceMap = map[string]interface{}{
 			mediatorsvc.Coordination:   &mockroute.MockMediatorSvc{},
@@ -792,3 +2176,79 @@ func newMockProvider(serviceMap map[string]interface{}) *sdkmockprotocol.MockPro
 
 	return prov
 }
+
+type mockDIDExchangeEvent struct {
+	connectionID string
+	invitationID string
+}
+
+func (e *mockDIDExchangeEvent) ConnectionID() string {
+	return e.connectionID
+}
+
+func (e *mockDIDExchangeEvent) InvitationID() string {
+	return e.invitationID
+}
+
+func (e *mockDIDExchangeEvent) All() map[string]interface{} {
+	return map[string]interface{}{
+		"connectionID": e.connectionID,
+		"invitationID": e.invitationID,
+	}
+}
+
+// newMockProviderWithV2Router returns provider with connection to router which accepts DIDComm V2 invitations.
+func newMockProviderWithV2Router(t *testing.T) (*sdkmockprotocol.MockProvider,
+	*mockmsghandler.MockMsgSvcProvider, *sdkmockprotocol.MockMessenger,
+) {
+	t.Helper()
+
+	prov := newMockProviderWithConnection(t)
+	prov.ServiceMap[mediatorsvc.Coordination] = &mockroute.MockMediatorSvc{}
+	prov.ServiceMap[outofbandv2svc.Name] = &sdkmockprotocol.MockOobServiceV2{
+		AcceptInvitationHandle: func(_ *outofbandv2svc.Invitation) (string, error) {
+			return "sample-connection", nil
+		},
+	}
+
+	messenger := sdkmockprotocol.NewMockMessenger()
+	prov.CustomMessenger = messenger
+
+	return prov, mockmsghandler.NewMockMsgServiceProvider(), messenger
+}
+
+// replyFromRouter delivers reply of given type to the last message sent, once reply handler is registered.
+func replyFromRouter(t *testing.T, registrar *mockmsghandler.MockMsgSvcProvider,
+	messenger *sdkmockprotocol.MockMessenger, msgType, replyFormat string,
+) {
+	t.Helper()
+
+	for {
+		if messenger.GetLastID() == "" {
+			continue
+		}
+
+		for _, svc := range registrar.Services() {
+			if !svc.Accept(msgType, nil) {
+				continue
+			}
+
+			replyMsg, e := service.ParseDIDCommMsgMap([]byte(fmt.Sprintf(replyFormat, messenger.GetLastID())))
+			require.NoError(t, e)
+
+			_, e = svc.HandleInbound(replyMsg, &sdkmockprotocol.MockDIDCommContext{
+				MyDIDValue:    "sampleDID",
+				TheirDIDValue: "sampleTheirDID",
+			})
+			require.NoError(t, e)
+
+			return
+		}
+	}
+}
+
+func saveMediationRecord(t *testing.T, c *Command, connID string) {
+	t.Helper()
+
+	require.NoError(t, c.mediations.Save(&mediation.Record{ConnectionID: connID}))
+}
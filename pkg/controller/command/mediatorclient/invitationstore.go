@@ -0,0 +1,330 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package mediatorclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/client/didexchange"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	didexchangeSvc "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/didexchange"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+)
+
+const (
+	// InvitationStatusActive invitation can be used to connect.
+	InvitationStatusActive = "active"
+	// InvitationStatusExpired invitation is past its expiry time.
+	InvitationStatusExpired = "expired"
+	// InvitationStatusRevoked invitation was revoked.
+	InvitationStatusRevoked = "revoked"
+	// InvitationStatusExhausted invitation was used maximum number of times.
+	InvitationStatusExhausted = "exhausted"
+
+	// InvitationTopic is the notifier topic on which rejected connection requests are published.
+	InvitationTopic = "mediatorclient-invitation"
+
+	// invitation versions.
+	invitationVersionV1 = "v1"
+	invitationVersionV2 = "v2"
+
+	// store name and tag for invitation records.
+	invitationStoreName = "mediatorclient_invitation"
+	invitationTag       = "invitation"
+)
+
+// errRevokeV2Invitation is returned when revoking DIDComm V2 invitation, which is accepted without did exchange
+// and so can't be revoked.
+var errRevokeV2Invitation = errors.New("DIDComm V2 invitations can't be revoked")
+
+// invitationStore persists invitations created through mediator client and keeps track of their usage.
+type invitationStore struct {
+	store storage.Store
+	lock  sync.Mutex
+}
+
+func newInvitationStore(p storage.Provider) (*invitationStore, error) {
+	store, err := p.OpenStore(invitationStoreName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open invitation store: %w", err)
+	}
+
+	return &invitationStore{store: store}, nil
+}
+
+func (s *invitationStore) save(record *InvitationRecord) error {
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal invitation record: %w", err)
+	}
+
+	return s.store.Put(record.ID, recordBytes, storage.Tag{Name: invitationTag})
+}
+
+func (s *invitationStore) get(id string) (*InvitationRecord, error) {
+	recordBytes, err := s.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &InvitationRecord{}
+
+	err = json.Unmarshal(recordBytes, record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal invitation record: %w", err)
+	}
+
+	record.Status = record.status(time.Now())
+
+	return record, nil
+}
+
+func (s *invitationStore) list() ([]*InvitationRecord, error) {
+	iter, err := s.store.Query(invitationTag)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if e := iter.Close(); e != nil {
+			logger.Warnf("failed to close iterator: %s", e)
+		}
+	}()
+
+	now := time.Now()
+
+	var records []*InvitationRecord
+
+	more, err := iter.Next()
+
+	for ; more && err == nil; more, err = iter.Next() {
+		value, e := iter.Value()
+		if e != nil {
+			return nil, e
+		}
+
+		record := &InvitationRecord{}
+
+		if e = json.Unmarshal(value, record); e != nil {
+			return nil, fmt.Errorf("failed to unmarshal invitation record: %w", e)
+		}
+
+		record.Status = record.status(now)
+		records = append(records, record)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func (s *invitationStore) revoke(id string) (*InvitationRecord, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	record, err := s.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if record.Version == invitationVersionV2 {
+		return nil, errRevokeV2Invitation
+	}
+
+	if record.RevokedAt == nil {
+		now := time.Now().UTC()
+		record.RevokedAt = &now
+	}
+
+	record.Status = InvitationStatusRevoked
+
+	return record, s.save(record)
+}
+
+// use records usage of given invitation, returned record status tells whether invitation could be used.
+// storage.ErrDataNotFound is returned if invitation was not created through mediator client.
+func (s *invitationStore) use(id string) (*InvitationRecord, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	record, err := s.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if record.Status != InvitationStatusActive {
+		return record, nil
+	}
+
+	record.UseCount++
+	record.Status = record.status(time.Now())
+
+	// last allowed use is still a valid use.
+	if record.Status == InvitationStatusExhausted {
+		record.Status = InvitationStatusActive
+	}
+
+	return record, s.save(record)
+}
+
+func (r *InvitationRecord) status(now time.Time) string {
+	switch {
+	case r.RevokedAt != nil:
+		return InvitationStatusRevoked
+	case r.ExpiresAt != nil && now.After(*r.ExpiresAt):
+		return InvitationStatusExpired
+	case r.MaxUses > 0 && r.UseCount >= r.MaxUses:
+		return InvitationStatusExhausted
+	default:
+		return InvitationStatusActive
+	}
+}
+
+// actionEventSource is implemented by did exchange service, returning handler of its action events.
+type actionEventSource interface {
+	ActionEvent() chan<- service.DIDCommAction
+}
+
+// actionEventRegistrar is implemented by did exchange service, registering handler of its action events.
+type actionEventRegistrar interface {
+	RegisterActionEvent(ch chan<- service.DIDCommAction) error
+}
+
+// InvitationGuard enforces expiry, revocation and usage limits of invitations created through mediator client
+// on did exchange requests received by the agent. Requests against invitations which are no longer active are
+// stopped and a notification is published, other actions are passed on to the handler registered before the guard.
+//
+// Guard is registered with did exchange service of the agent when created, after other handlers of did exchange
+// actions (e.g. did exchange command accepting requests automatically) are registered. Create one guard per agent
+// and share it with mediator client commands using WithInvitationGuard.
+type InvitationGuard struct {
+	invitations *invitationStore
+	notifier    command.Notifier
+	next        chan<- service.DIDCommAction
+}
+
+// NewInvitationGuard creates guard of invitations and registers it with did exchange service of given provider.
+// Limits of DIDComm V2 invitations are not enforced, as they are accepted without did exchange.
+func NewInvitationGuard(p Provider, notifier command.Notifier) (*InvitationGuard, error) {
+	svc, err := p.Service(didexchangeSvc.DIDExchange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup did exchange service: %w", err)
+	}
+
+	registrar, ok := svc.(actionEventRegistrar)
+	if !ok {
+		return nil, errors.New("did exchange service does not support action events")
+	}
+
+	invitations, err := newInvitationStore(p.StorageProvider())
+	if err != nil {
+		return nil, err
+	}
+
+	guard := &InvitationGuard{invitations: invitations, notifier: notifier}
+
+	if source, ok := svc.(actionEventSource); ok {
+		guard.next = source.ActionEvent()
+	}
+
+	actions := make(chan service.DIDCommAction, msgEventBufferSize)
+
+	err = registrar.RegisterActionEvent(actions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register for did exchange actions : %w", err)
+	}
+
+	go guard.handleActions(actions)
+
+	return guard, nil
+}
+
+func (g *InvitationGuard) handleActions(actions chan service.DIDCommAction) {
+	for action := range actions {
+		g.handleAction(action)
+	}
+}
+
+func (g *InvitationGuard) handleAction(action service.DIDCommAction) {
+	record, connID := g.inactiveInvitation(action)
+	if record != nil {
+		logger.Infof("stopping connection request %s against %s invitation %s", connID, record.Status, record.ID)
+
+		action.Stop(fmt.Errorf("invitation %s is %s", record.ID, record.Status))
+		g.notifyRejectedConnection(record, connID)
+
+		return
+	}
+
+	if g.next != nil {
+		g.next <- action
+
+		return
+	}
+
+	action.Continue(service.Empty{})
+}
+
+// inactiveInvitation records use of invitation requested by given did exchange action and returns the
+// invitation along with requested connection ID if the invitation is no longer active.
+func (g *InvitationGuard) inactiveInvitation(action service.DIDCommAction) (*InvitationRecord, string) {
+	if action.Message == nil || action.Message.Type() != didexchangeSvc.RequestMsgType {
+		return nil, ""
+	}
+
+	event, ok := action.Properties.(didexchange.Event)
+	if !ok || event.InvitationID() == "" {
+		return nil, ""
+	}
+
+	record, err := g.invitations.use(event.InvitationID())
+	if errors.Is(err, storage.ErrDataNotFound) {
+		// not an invitation created by mediator client.
+		return nil, ""
+	}
+
+	if err != nil {
+		logger.Warnf("failed to record use of invitation %s: %s", event.InvitationID(), err)
+
+		return nil, ""
+	}
+
+	if record.Status == InvitationStatusActive {
+		return nil, ""
+	}
+
+	return record, event.ConnectionID()
+}
+
+func (g *InvitationGuard) notifyRejectedConnection(record *InvitationRecord, connID string) {
+	if g.notifier == nil {
+		return
+	}
+
+	msg, err := json.Marshal(&RejectedConnectionNotification{
+		InvitationID: record.ID,
+		ConnectionID: connID,
+		Status:       record.Status,
+	})
+	if err != nil {
+		logger.Warnf("failed to marshal rejected connection notification: %s", err)
+
+		return
+	}
+
+	if err = g.notifier.Notify(InvitationTopic, msg); err != nil {
+		logger.Warnf("failed to publish rejected connection notification: %s", err)
+	}
+}
@@ -0,0 +1,230 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package mediatorclient
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+
+	"github.com/trustbloc/agent-sdk/pkg/controller/internal/netguard"
+)
+
+const (
+	// invitation URL query parameters.
+	oobQueryParam    = "oob"
+	oobV2QueryParam  = "_oob"
+	legacyQueryParam = "c_i"
+
+	// base URL used for invitation URLs if neither request nor agent provide one.
+	defaultInvitationBaseURL = "didcomm://invite"
+
+	// limits for resolving shortened invitation URLs.
+	maxInvitationRedirects  = 5
+	maxInvitationBodySize   = 64 * 1024
+	invitationFetchTimeOut  = 10 * time.Second
+	invitationAcceptHeader  = "application/json"
+	invitationURLHTTPPrefix = "http"
+
+	// invitationURLAnyHost allows resolving invitation URLs of any host with public addresses.
+	invitationURLAnyHost = "*"
+)
+
+var errInvitationNotFoundInURL = errors.New("invitation not found in URL")
+
+// EncodeInvitationURL returns invitation URL with given base URL and invitation encoded in given query parameter.
+func EncodeInvitationURL(baseURL, param string, invitation interface{}) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid invitation base URL: %w", err)
+	}
+
+	invBytes, err := json.Marshal(invitation)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal invitation: %w", err)
+	}
+
+	query := u.Query()
+	query.Set(param, base64.RawURLEncoding.EncodeToString(invBytes))
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// newInvitationHTTPClient returns client which refuses to connect to private, loopback and link-local addresses.
+func newInvitationHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+	transport.DialContext = netguard.NewDialer(invitationFetchTimeOut).DialContext
+	transport.Proxy = nil
+
+	return &http.Client{
+		Timeout:   invitationFetchTimeOut,
+		Transport: transport,
+		// redirects are followed manually, since invitation may be found in query of redirect location.
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// decodeInvitationURL decodes invitation from given URL, resolving shortened URLs.
+func (c *Command) decodeInvitationURL(ctx context.Context, rawURL string) (*service.DIDCommMsgMap, error) {
+	invitation, shortened, err := c.checkInvitationURL(rawURL)
+	if err != nil || !shortened {
+		return invitation, err
+	}
+
+	return c.resolveInvitationURL(ctx, rawURL)
+}
+
+// checkInvitationURL decodes invitation from query of given URL without sending any request, or tells that
+// the URL is shortened and may be resolved.
+func (c *Command) checkInvitationURL(rawURL string) (*service.DIDCommMsgMap, bool, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid invitation URL: %w", err)
+	}
+
+	invitation, err := invitationFromQuery(u.Query())
+	if !errors.Is(err, errInvitationNotFoundInURL) {
+		return invitation, false, err
+	}
+
+	if !strings.HasPrefix(u.Scheme, invitationURLHTTPPrefix) {
+		return nil, false, err
+	}
+
+	if !c.isInvitationHostAllowed(u.Hostname()) {
+		return nil, false, fmt.Errorf("%w, resolving invitation URLs of host %s is not allowed", err, u.Hostname())
+	}
+
+	return nil, true, nil
+}
+
+// resolveInvitationURL resolves shortened invitation URL by following HTTP redirects to allowed hosts until
+// a URL with invitation query parameter is found or invitation is returned as response body.
+func (c *Command) resolveInvitationURL(ctx context.Context, rawURL string) (*service.DIDCommMsgMap, error) {
+	for i := 0; i <= maxInvitationRedirects; i++ {
+		invitation, shortened, err := c.checkInvitationURL(rawURL)
+		if err != nil || !shortened {
+			return invitation, err
+		}
+
+		invitation, location, err := c.fetchInvitation(ctx, strings.TrimSpace(rawURL))
+		if err != nil || invitation != nil {
+			return invitation, err
+		}
+
+		rawURL = location
+	}
+
+	return nil, fmt.Errorf("too many redirects while resolving invitation URL")
+}
+
+// isInvitationHostAllowed tells whether invitation URLs of given host may be resolved, a host starting with '*.'
+// allows all its subdomains.
+func (c *Command) isInvitationHostAllowed(host string) bool {
+	for _, allowed := range c.inviteHosts {
+		if allowed == invitationURLAnyHost {
+			return true
+		}
+	}
+
+	return netguard.MatchesHost(c.inviteHosts, host)
+}
+
+// fetchInvitation gets given URL and returns either invitation from response body or redirect location.
+func (c *Command) fetchInvitation(ctx context.Context, rawURL string) (*service.DIDCommMsgMap, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, http.NoBody)
+	if err != nil {
+		return nil, "", err
+	}
+
+	req.Header.Set("Accept", invitationAcceptHeader)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to resolve invitation URL: %w", err)
+	}
+
+	defer func() {
+		if e := resp.Body.Close(); e != nil {
+			logger.Warnf("failed to close response body: %s", e)
+		}
+	}()
+
+	switch {
+	case resp.StatusCode >= http.StatusMultipleChoices && resp.StatusCode < http.StatusBadRequest:
+		location, e := resp.Location()
+		if e != nil {
+			return nil, "", fmt.Errorf("invalid redirect while resolving invitation URL: %w", e)
+		}
+
+		return nil, location.String(), nil
+	case resp.StatusCode == http.StatusOK:
+		body, e := io.ReadAll(io.LimitReader(resp.Body, maxInvitationBodySize))
+		if e != nil {
+			return nil, "", fmt.Errorf("failed to read invitation: %w", e)
+		}
+
+		invitation, e := service.ParseDIDCommMsgMap(body)
+		if e != nil {
+			return nil, "", fmt.Errorf("failed to parse invitation: %w", e)
+		}
+
+		return &invitation, "", nil
+	default:
+		return nil, "", fmt.Errorf("failed to resolve invitation URL, status code: %d", resp.StatusCode)
+	}
+}
+
+func invitationFromQuery(query url.Values) (*service.DIDCommMsgMap, error) {
+	for _, param := range []string{oobQueryParam, oobV2QueryParam, legacyQueryParam} {
+		encoded := query.Get(param)
+		if encoded == "" {
+			continue
+		}
+
+		invBytes, err := decodeBase64(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode invitation from '%s' query parameter: %w", param, err)
+		}
+
+		invitation, err := service.ParseDIDCommMsgMap(invBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse invitation from '%s' query parameter: %w", param, err)
+		}
+
+		return &invitation, nil
+	}
+
+	return nil, errInvitationNotFoundInURL
+}
+
+// decodeBase64 decodes base64url with or without padding, falling back to standard encoding
+// since some agents use it in invitation URLs.
+func decodeBase64(encoded string) ([]byte, error) {
+	// '+' of standard encoding may have been decoded as space in query.
+	encoded = strings.TrimRight(strings.ReplaceAll(encoded, " ", "+"), "=")
+
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err == nil {
+		return decoded, nil
+	}
+
+	return base64.RawStdEncoding.DecodeString(encoded)
+}
@@ -9,10 +9,14 @@ package mediatorclient
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/hyperledger/aries-framework-go/pkg/client/outofband"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
 	"github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/outofbandv2"
+
+	"github.com/trustbloc/agent-sdk/pkg/controller/internal/mediation"
 )
 
 // ConnectionRequest model
@@ -20,7 +24,12 @@ import (
 // This is used for connecting to given router.
 type ConnectionRequest struct {
 	// Invitation is out-of-band (V1 or V2) invitation from mediator.
-	Invitation *service.DIDCommMsgMap `json:"invitation"`
+	Invitation *service.DIDCommMsgMap `json:"invitation,omitempty"`
+
+	// InvitationURL is out-of-band invitation URL ('oob', '_oob' or legacy 'c_i' query parameter) from mediator,
+	// shortened URLs of hosts allowed by agent are resolved by following HTTP redirects when connecting.
+	// Optional: used only if invitation is not provided.
+	InvitationURL string `json:"invitationURL,omitempty"`
 
 	// MyLabel is custom label to be used as receiver label of this invitation
 	// Optional: if missing, agent default label will be used.
@@ -32,11 +41,22 @@ type ConnectionRequest struct {
 	// If not provided, then this agent will go ahead with mediator registration once did exchange state is
 	// completed at invitee.
 	StateCompleteMessageType string `json:"stateCompleteMessageType,omitempty"`
+
+	// Async if true, then connection is performed in background and ID of the operation is returned
+	// immediately. Progress and result of the operation are published on notifier topic
+	// 'mediatorclient-operation' and can be queried using GetOperation command.
+	Async bool `json:"async,omitempty"`
 }
 
 // ConnectionResponse contains response.
 type ConnectionResponse struct {
-	ConnectionID string `json:"connectionID"`
+	ConnectionID string `json:"connectionID,omitempty"`
+
+	// RoutingDIDs granted by DIDComm V2 router using coordinate mediation 2.0.
+	RoutingDIDs []string `json:"routingDIDs,omitempty"`
+
+	// OperationID is ID of the operation started for asynchronous connection request.
+	OperationID string `json:"operationID,omitempty"`
 }
 
 // CreateInvitationRequest model
@@ -49,6 +69,18 @@ type CreateInvitationRequest struct {
 	Service   []interface{} `json:"service"`
 	Protocols []string      `json:"protocols"`
 	From      string        `json:"from"`
+
+	// BaseURL is base URL of invitation URLs returned in response.
+	// Optional: if missing, agent's HTTP service endpoint or 'didcomm://invite' will be used.
+	BaseURL string `json:"baseURL,omitempty"`
+
+	// ExpiresIn is number of seconds after which invitation expires.
+	// Optional: if missing, invitation never expires. Not supported for DIDComm V2 invitations.
+	ExpiresIn int `json:"expiresIn,omitempty"`
+
+	// MaxUses is maximum number of connection requests accepted for this invitation, 1 for single-use invitation.
+	// Optional: if missing, invitation can be used any number of times. Not supported for DIDComm V2 invitations.
+	MaxUses int `json:"maxUses,omitempty"`
 }
 
 // CreateInvitationResponse model
@@ -59,6 +91,28 @@ type CreateInvitationResponse struct {
 	Invitation *outofband.Invitation `json:"invitation"`
 
 	InvitationV2 *outofbandv2.Invitation `json:"invitation-v2"`
+
+	// InvitationURL is invitation encoded in URL, 'oob' query parameter for V1 and '_oob' for V2 invitations.
+	InvitationURL string `json:"invitationURL,omitempty"`
+
+	// LegacyInvitationURL is V1 invitation encoded in legacy 'c_i' query parameter of URL.
+	LegacyInvitationURL string `json:"legacyInvitationURL,omitempty"`
+}
+
+// DecodeInvitationRequest model
+//
+// This is used for decoding out-of-band invitation from invitation URL.
+type DecodeInvitationRequest struct {
+	// InvitationURL to be decoded.
+	InvitationURL string `json:"invitationURL"`
+}
+
+// DecodeInvitationResponse model
+//
+// Response of decoding out-of-band invitation from invitation URL.
+type DecodeInvitationResponse struct {
+	// Invitation is decoded out-of-band (V1 or V2) invitation.
+	Invitation *service.DIDCommMsgMap `json:"invitation"`
 }
 
 // CreateConnectionRequest model
@@ -66,11 +120,279 @@ type CreateInvitationResponse struct {
 // This is used for sending create connection request.
 type CreateConnectionRequest struct {
 	DIDDocument json.RawMessage `json:"didDoc"`
+
+	// Async if true, then request is sent in background and ID of the operation is returned immediately.
+	Async bool `json:"async,omitempty"`
 }
 
 // CreateConnectionResponse model
 //
 // This is used for getting create connection response.
 type CreateConnectionResponse struct {
-	Payload json.RawMessage `json:"payload"`
+	// Payload is create connection response message received from router.
+	Payload json.RawMessage `json:"payload,omitempty"`
+
+	// ConnectionID of the connection created with router's DID.
+	ConnectionID string `json:"connectionID,omitempty"`
+
+	// DIDDocument of the router returned in create connection response.
+	DIDDocument json.RawMessage `json:"didDoc,omitempty"`
+
+	// OperationID is ID of the operation started for asynchronous create connection request.
+	OperationID string `json:"operationID,omitempty"`
+}
+
+// OperationRequest model
+//
+// This is used for getting status of or cancelling an asynchronous operation.
+type OperationRequest struct {
+	// OperationID returned by asynchronous command.
+	OperationID string `json:"operationID"`
+}
+
+// OperationResponse model
+//
+// Response containing current state of an asynchronous operation.
+type OperationResponse struct {
+	Operation *Operation `json:"operation"`
+}
+
+// Operation represents state of an asynchronous command execution.
+type Operation struct {
+	// ID of the operation.
+	ID string `json:"id"`
+
+	// Method is name of the command method which started this operation.
+	Method string `json:"method"`
+
+	// Status of the operation, one of 'pending', 'running', 'completed', 'failed' or 'cancelled'.
+	Status string `json:"status"`
+
+	// Result of the command, available once operation is completed.
+	Result json.RawMessage `json:"result,omitempty"`
+
+	// Error message, available if operation failed or was cancelled.
+	Error string `json:"error,omitempty"`
+
+	// ErrorCode is command error code, available if operation failed or was cancelled.
+	ErrorCode command.Code `json:"errorCode,omitempty"`
+
+	// CreatedAt is the time when the operation was created.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// UpdatedAt is the time of the last status change of the operation.
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// KeylistQueryRequest model
+//
+// This is used for querying recipient keys registered with the router.
+type KeylistQueryRequest struct {
+	// ConnectionID of the router connection to be queried.
+	// Optional: if missing, all router connections will be queried.
+	ConnectionID string `json:"connectionID,omitempty"`
+
+	// Limit is maximum number of keys to be returned by router.
+	Limit int `json:"limit,omitempty"`
+
+	// Offset is number of keys to be skipped by router.
+	Offset int `json:"offset,omitempty"`
+}
+
+func (r *KeylistQueryRequest) paginate() *Paginate {
+	if r.Limit > 0 || r.Offset > 0 {
+		return &Paginate{Limit: r.Limit, Offset: r.Offset}
+	}
+
+	return nil
+}
+
+func (r *KeylistQueryRequest) recipientsPaginate() *mediation.Paginate {
+	if paginate := r.paginate(); paginate != nil {
+		return &mediation.Paginate{Limit: paginate.Limit, Offset: paginate.Offset}
+	}
+
+	return nil
+}
+
+// KeylistQueryResponse model
+//
+// Response of keylist query.
+type KeylistQueryResponse struct {
+	// Keylists contains keys registered with queried router connections.
+	Keylists []*Keylist `json:"keylists"`
+}
+
+// Keylist contains keys registered with the router of a router connection.
+type Keylist struct {
+	// ConnectionID of the router connection which was queried.
+	ConnectionID string `json:"connectionID"`
+
+	// Keys registered with the router.
+	Keys []string `json:"keys"`
+
+	// Pagination details returned by router, if any.
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Paginate contains pagination parameters of keylist query.
+type Paginate struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// Pagination contains pagination details of keylist query response.
+type Pagination struct {
+	Count     int `json:"count"`
+	Offset    int `json:"offset"`
+	Remaining int `json:"remaining"`
+}
+
+// RemoveKeysRequest model
+//
+// This is used for removing recipient keys registered with the router.
+type RemoveKeysRequest struct {
+	// ConnectionID of the router connection from which keys to be removed.
+	// Optional: if missing, keys will be removed from all router connections.
+	ConnectionID string `json:"connectionID,omitempty"`
+
+	// Keys to be removed.
+	Keys []string `json:"keys,omitempty"`
+
+	// DID whose keys (key agreement and DIDComm service recipient keys) to be removed.
+	DID string `json:"did,omitempty"`
+}
+
+// RemoveKeysResponse model
+//
+// Response of remove keys command.
+type RemoveKeysResponse struct {
+	// Keys requested to be removed.
+	Keys []string `json:"keys"`
+
+	// Updates contains results of keylist updates reported by router connections.
+	Updates []*KeylistUpdateResult `json:"updates"`
+}
+
+// KeylistUpdateResult contains results of keylist update reported by a router connection.
+type KeylistUpdateResult struct {
+	ConnectionID string             `json:"connectionID"`
+	Results      []*KeyUpdateResult `json:"results"`
+}
+
+// KeyUpdateResult is result of removing a key reported by router, key is removed only if result is 'success'.
+type KeyUpdateResult struct {
+	Key    string `json:"key"`
+	Result string `json:"result"`
+}
+
+// keylistMsg is keylist message sent by router in response to keylist query.
+type keylistMsg struct {
+	Keys []struct {
+		RecipientKey string `json:"recipient_key"`
+	} `json:"keys"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// keylistUpdate is a single update entry of keylist update message.
+type keylistUpdate struct {
+	RecipientKey string `json:"recipient_key"`
+	Action       string `json:"action"`
+}
+
+// keylistUpdateResponseMsg is keylist update response message sent by router in response to keylist update.
+type keylistUpdateResponseMsg struct {
+	Updated []struct {
+		RecipientKey string `json:"recipient_key"`
+		Action       string `json:"action"`
+		Result       string `json:"result"`
+	} `json:"updated"`
+}
+
+// ListInvitationsRequest model
+//
+// This is used for listing invitations created through mediator client.
+type ListInvitationsRequest struct {
+	// Status filter, one of 'active', 'expired', 'revoked' or 'exhausted'.
+	// Optional: if missing, all invitations are returned.
+	Status string `json:"status,omitempty"`
+}
+
+// ListInvitationsResponse model
+//
+// Response of list invitations command.
+type ListInvitationsResponse struct {
+	Invitations []*InvitationRecord `json:"invitations"`
+}
+
+// RevokeInvitationRequest model
+//
+// This is used for revoking invitation created through mediator client.
+type RevokeInvitationRequest struct {
+	// InvitationID of the invitation to be revoked.
+	InvitationID string `json:"invitationID"`
+}
+
+// RevokeInvitationResponse model
+//
+// Response of revoke invitation command.
+type RevokeInvitationResponse struct {
+	Invitation *InvitationRecord `json:"invitation"`
+}
+
+// InvitationRecord is persisted invitation created through mediator client.
+type InvitationRecord struct {
+	// ID of the invitation.
+	ID string `json:"id"`
+
+	// Version of the out-of-band invitation, 'v1' or 'v2'.
+	Version string `json:"version"`
+
+	// Label of the invitation.
+	Label string `json:"label,omitempty"`
+
+	// CreatedAt is the time when the invitation was created.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// ExpiresAt is the time after which the invitation expires, if any.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+
+	// MaxUses is maximum number of uses of the invitation, 0 if unlimited.
+	MaxUses int `json:"maxUses,omitempty"`
+
+	// UseCount is number of connection requests received for the invitation.
+	UseCount int `json:"useCount"`
+
+	// RevokedAt is the time when the invitation was revoked, if any.
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+
+	// Status of the invitation, one of 'active', 'expired', 'revoked' or 'exhausted'.
+	Status string `json:"status,omitempty"`
+
+	// Invitation is the out-of-band invitation.
+	Invitation json.RawMessage `json:"invitation"`
+}
+
+// RejectedConnectionNotification is published when connection request against an invitation
+// which is no longer active is ignored.
+type RejectedConnectionNotification struct {
+	InvitationID string `json:"invitationID"`
+	ConnectionID string `json:"connectionID"`
+	Status       string `json:"status"`
+}
+
+// createConnResp is create connection response message received from router, fields of both
+// DIDComm V1 ('@id', '@type', 'data') and DIDComm V2 ('id', 'type', 'body') formats are included.
+type createConnResp struct {
+	ID     string              `json:"@id,omitempty"`
+	Type   string              `json:"@type,omitempty"`
+	Data   *createConnRespData `json:"data,omitempty"`
+	IDV2   string              `json:"id,omitempty"`
+	TypeV2 string              `json:"type,omitempty"`
+	Body   *createConnRespData `json:"body,omitempty"`
+}
+
+type createConnRespData struct {
+	ErrorMsg string          `json:"errorMsg,omitempty"`
+	DIDDoc   json.RawMessage `json:"didDoc,omitempty"`
 }
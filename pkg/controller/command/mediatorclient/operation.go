@@ -0,0 +1,253 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package mediatorclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+)
+
+const (
+	// OperationStatusPending operation is created but not yet started.
+	OperationStatusPending = "pending"
+	// OperationStatusRunning operation is in progress.
+	OperationStatusRunning = "running"
+	// OperationStatusCompleted operation completed successfully.
+	OperationStatusCompleted = "completed"
+	// OperationStatusFailed operation failed.
+	OperationStatusFailed = "failed"
+	// OperationStatusCancelled operation was cancelled by the client.
+	OperationStatusCancelled = "cancelled"
+
+	// OperationTopic is the notifier topic on which operation progress is published.
+	OperationTopic = "mediatorclient-operation"
+
+	// finished operations are kept around for this long so that clients can still query their results.
+	operationRetention = time.Hour
+)
+
+// asyncFunc is a long-running command function executed as an asynchronous operation.
+type asyncFunc func(ctx context.Context) (interface{}, command.Error)
+
+// operation is an in-memory record of an asynchronous command execution.
+type operation struct {
+	Operation
+	cancel context.CancelFunc
+}
+
+// operations keeps track of asynchronous operations started by this command.
+type operations struct {
+	notifier command.Notifier
+	items    map[string]*operation
+	lock     sync.RWMutex
+}
+
+func newOperations(notifier command.Notifier) *operations {
+	return &operations{
+		notifier: notifier,
+		items:    make(map[string]*operation),
+	}
+}
+
+// start launches given function in background and returns ID of the operation created for it.
+func (o *operations) start(method string, fn asyncFunc) string {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	op := &operation{
+		Operation: Operation{
+			ID:        uuid.New().String(),
+			Method:    method,
+			Status:    OperationStatusPending,
+			CreatedAt: time.Now().UTC(),
+		},
+		cancel: cancel,
+	}
+	op.UpdatedAt = op.CreatedAt
+
+	o.lock.Lock()
+	o.evictExpired()
+	o.items[op.ID] = op
+	o.lock.Unlock()
+
+	o.publish(op.ID)
+
+	go func() {
+		defer cancel()
+
+		o.update(op.ID, func(op *Operation) bool {
+			// operation may have been cancelled before it started.
+			if op.Status != OperationStatusPending {
+				return false
+			}
+
+			op.Status = OperationStatusRunning
+
+			return true
+		})
+
+		result, cmdErr := fn(ctx)
+		if cmdErr != nil {
+			logger.Errorf("command=[%s] action=[%s] operation=[%s] code=[%d] errMsg=[%s]", CommandName, method,
+				op.ID, cmdErr.Code(), cmdErr)
+		}
+
+		o.update(op.ID, func(op *Operation) bool {
+			// operation cancelled by the client was published when cancelled.
+			if op.Status == OperationStatusCancelled {
+				return false
+			}
+
+			finishOperation(op, errors.Is(ctx.Err(), context.Canceled), result, cmdErr)
+
+			return true
+		})
+	}()
+
+	return op.ID
+}
+
+// finishOperation records outcome of the operation, an operation cancelled by the client stays cancelled
+// even if its function completed before noticing the cancellation.
+func finishOperation(op *Operation, cancelled bool, result interface{}, cmdErr command.Error) {
+	switch {
+	case cancelled:
+		op.Status = OperationStatusCancelled
+	case cmdErr != nil:
+		op.Status = OperationStatusFailed
+	default:
+		op.Status = OperationStatusCompleted
+		op.Result = marshalResult(result)
+	}
+
+	if cmdErr != nil {
+		op.Error = cmdErr.Error()
+		op.ErrorCode = cmdErr.Code()
+	}
+}
+
+// get returns a snapshot of the operation with given ID.
+func (o *operations) get(id string) (*Operation, error) {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+
+	op, ok := o.items[id]
+	if !ok {
+		return nil, fmt.Errorf(errOperationNotFound, id)
+	}
+
+	snapshot := op.Operation
+
+	return &snapshot, nil
+}
+
+// cancel aborts the context of the operation with given ID and returns the operation marked as cancelled,
+// operations already finished are returned unchanged.
+func (o *operations) cancel(id string) (*Operation, error) {
+	o.lock.Lock()
+
+	op, ok := o.items[id]
+	if !ok {
+		o.lock.Unlock()
+
+		return nil, fmt.Errorf(errOperationNotFound, id)
+	}
+
+	cancelled := !isOperationFinished(op.Status)
+	if cancelled {
+		op.cancel()
+
+		op.Status = OperationStatusCancelled
+		op.UpdatedAt = time.Now().UTC()
+	}
+
+	snapshot := op.Operation
+
+	o.lock.Unlock()
+
+	if cancelled {
+		o.publish(id)
+	}
+
+	return &snapshot, nil
+}
+
+// update changes the operation with given ID by given function and publishes it, unless the function reports
+// that the operation was left unchanged.
+func (o *operations) update(id string, fn func(op *Operation) bool) {
+	o.lock.Lock()
+
+	op, ok := o.items[id]
+	if !ok || !fn(&op.Operation) {
+		o.lock.Unlock()
+
+		return
+	}
+
+	op.UpdatedAt = time.Now().UTC()
+
+	o.lock.Unlock()
+
+	o.publish(id)
+}
+
+func (o *operations) publish(id string) {
+	if o.notifier == nil {
+		return
+	}
+
+	op, err := o.get(id)
+	if err != nil {
+		return
+	}
+
+	msg, err := json.Marshal(op)
+	if err != nil {
+		logger.Warnf("failed to marshal operation %s: %s", id, err)
+
+		return
+	}
+
+	if err = o.notifier.Notify(OperationTopic, msg); err != nil {
+		logger.Warnf("failed to publish operation %s: %s", id, err)
+	}
+}
+
+// evictExpired removes finished operations past retention period, caller must hold the lock.
+func (o *operations) evictExpired() {
+	for id, op := range o.items {
+		if isOperationFinished(op.Status) && time.Since(op.UpdatedAt) > operationRetention {
+			delete(o.items, id)
+		}
+	}
+}
+
+func isOperationFinished(status string) bool {
+	return status == OperationStatusCompleted || status == OperationStatusFailed || status == OperationStatusCancelled
+}
+
+func marshalResult(result interface{}) json.RawMessage {
+	if result == nil {
+		return nil
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		logger.Warnf("failed to marshal operation result: %s", err)
+
+		return nil
+	}
+
+	return resultBytes
+}
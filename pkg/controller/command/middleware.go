@@ -0,0 +1,161 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	"github.com/trustbloc/edge-core/pkg/log"
+)
+
+// Invocation describes a command method call seen by middlewares.
+type Invocation struct {
+	Name    string
+	Method  string
+	Request []byte
+	// Response is the writer the command method writes its response to as it is produced, responses are not
+	// buffered so that streamed responses stay streamed. Middlewares may replace it with a wrapping writer.
+	Response io.Writer
+}
+
+// Next invokes the rest of the middleware chain and the command method, returning its error.
+type Next func(inv *Invocation) command.Error
+
+// Middleware intercepts command method calls, it may inspect or replace request, response writer and error,
+// or return without calling next.
+type Middleware func(inv *Invocation, next Next) command.Error
+
+var logger = log.New("agent-sdk-command")
+
+var middlewares = struct { //nolint:gochecknoglobals
+	mu    sync.RWMutex
+	chain []Middleware
+}{chain: defaultMiddlewares()}
+
+// defaultMiddlewares returns middlewares applied unless reset, command methods are logged by LoggingMiddleware
+// only, so that each call is logged once.
+func defaultMiddlewares() []Middleware {
+	return []Middleware{LoggingMiddleware(logger)}
+}
+
+// UseMiddlewares appends given middlewares to the chain applied to command methods of all command handlers,
+// REST and wasm/mobile dispatchers of this SDK. Middlewares are called in the order they were added, after
+// the default LoggingMiddleware.
+func UseMiddlewares(mw ...Middleware) {
+	middlewares.mu.Lock()
+	defer middlewares.mu.Unlock()
+
+	middlewares.chain = append(middlewares.chain, mw...)
+}
+
+// ResetMiddlewares removes all middlewares added so far, restoring the default chain.
+func ResetMiddlewares() {
+	middlewares.mu.Lock()
+	defer middlewares.mu.Unlock()
+
+	middlewares.chain = defaultMiddlewares()
+}
+
+func middlewareChain() []Middleware {
+	middlewares.mu.RLock()
+	defer middlewares.mu.RUnlock()
+
+	return append([]Middleware{}, middlewares.chain...)
+}
+
+// Intercept returns execute function calling given command method through the middleware chain. The request is
+// buffered for middlewares, the response is written through to the writer of the caller.
+func Intercept(name, method string, exec command.Exec) command.Exec {
+	return func(rw io.Writer, req io.Reader) command.Error {
+		chain := middlewareChain()
+		if len(chain) == 0 {
+			return exec(rw, req)
+		}
+
+		var request []byte
+
+		if req != nil {
+			var err error
+
+			request, err = io.ReadAll(req)
+			if err != nil {
+				return NewValidationError(UnknownStatus, fmt.Errorf("failed to read request: %w", err))
+			}
+		}
+
+		next := func(inv *Invocation) command.Error {
+			return exec(inv.Response, bytes.NewReader(inv.Request))
+		}
+
+		for i := len(chain) - 1; i >= 0; i-- {
+			mw, inner := chain[i], next
+			next = func(inv *Invocation) command.Error {
+				return mw(inv, inner)
+			}
+		}
+
+		return next(&Invocation{Name: name, Method: method, Request: request, Response: rw})
+	}
+}
+
+type middlewareLogger interface {
+	Debugf(string, ...interface{})
+	Errorf(string, ...interface{})
+}
+
+// LoggingMiddleware logs command method calls, failed calls are logged as errors.
+func LoggingMiddleware(l middlewareLogger) Middleware {
+	return func(inv *Invocation, next Next) command.Error {
+		l.Debugf("command=[%s] action=[%s] msg=[request received]", inv.Name, inv.Method)
+
+		if err := next(inv); err != nil {
+			l.Errorf("command=[%s] action=[%s] code=[%d] errMsg=[%s]", inv.Name, inv.Method, err.Code(), err)
+
+			return err
+		}
+
+		l.Debugf("command=[%s] action=[%s] msg=[success]", inv.Name, inv.Method)
+
+		return nil
+	}
+}
+
+// MetricsRecorder records duration of a command method call and its error, if any.
+type MetricsRecorder func(name, method string, duration time.Duration, err command.Error)
+
+// MetricsMiddleware passes duration of command method calls to given recorder.
+func MetricsMiddleware(record MetricsRecorder) Middleware {
+	return func(inv *Invocation, next Next) command.Error {
+		start := time.Now()
+
+		err := next(inv)
+
+		record(inv.Name, inv.Method, time.Since(start), err)
+
+		return err
+	}
+}
+
+// RecoveryMiddleware turns panics of command methods, and of middlewares added after it, into execute errors.
+func RecoveryMiddleware(l middlewareLogger) Middleware {
+	return func(inv *Invocation, next Next) (err command.Error) {
+		defer func() {
+			if r := recover(); r != nil {
+				l.Errorf("command=[%s] action=[%s] errMsg=[panic: %v]", inv.Name, inv.Method, r)
+
+				err = NewExecuteError(UnknownStatus, fmt.Errorf("command %s %s panicked: %v", inv.Name, inv.Method, r))
+			}
+		}()
+
+		return next(inv)
+	}
+}
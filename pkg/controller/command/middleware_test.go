@@ -0,0 +1,198 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/edge-core/pkg/log"
+
+	agentcmd "github.com/trustbloc/agent-sdk/pkg/controller/command"
+)
+
+func echo(rw io.Writer, req io.Reader) command.Error {
+	if _, err := io.Copy(rw, req); err != nil {
+		return agentcmd.NewExecuteError(agentcmd.UnknownStatus, err)
+	}
+
+	return nil
+}
+
+func TestIntercept(t *testing.T) {
+	t.Run("default middlewares", func(t *testing.T) {
+		t.Cleanup(agentcmd.ResetMiddlewares)
+
+		var rw bytes.Buffer
+
+		require.Nil(t, agentcmd.Intercept("foo", "Bar", echo)(&rw, bytes.NewBufferString("request")))
+		require.Equal(t, "request", rw.String())
+	})
+
+	t.Run("middlewares are called in order", func(t *testing.T) {
+		t.Cleanup(agentcmd.ResetMiddlewares)
+
+		var calls []string
+
+		mw := func(id string) agentcmd.Middleware {
+			return func(inv *agentcmd.Invocation, next agentcmd.Next) command.Error {
+				calls = append(calls, id+" "+inv.Name+" "+inv.Method)
+
+				inv.Request = append(inv.Request, id...)
+
+				if err := next(inv); err != nil {
+					return err
+				}
+
+				_, err := inv.Response.Write([]byte(id))
+				require.NoError(t, err)
+
+				return nil
+			}
+		}
+
+		agentcmd.UseMiddlewares(mw("1"), mw("2"))
+
+		var rw bytes.Buffer
+
+		require.Nil(t, agentcmd.Intercept("foo", "Bar", echo)(&rw, bytes.NewBufferString("request")))
+		require.Equal(t, "request1221", rw.String())
+		require.Equal(t, []string{"1 foo Bar", "2 foo Bar"}, calls)
+	})
+
+	t.Run("middleware short-circuits command", func(t *testing.T) {
+		t.Cleanup(agentcmd.ResetMiddlewares)
+
+		agentcmd.UseMiddlewares(func(*agentcmd.Invocation, agentcmd.Next) command.Error {
+			return agentcmd.NewValidationError(agentcmd.UnknownStatus, errors.New("access denied"))
+		})
+
+		var rw bytes.Buffer
+
+		err := agentcmd.Intercept("foo", "Bar", func(io.Writer, io.Reader) command.Error {
+			t.Fatal("command must not be called")
+
+			return nil
+		})(&rw, bytes.NewBufferString("request"))
+		require.EqualError(t, err, "access denied")
+		require.Equal(t, command.ValidationError, err.Type())
+		require.Empty(t, rw.String())
+	})
+
+	t.Run("response is written through", func(t *testing.T) {
+		t.Cleanup(agentcmd.ResetMiddlewares)
+
+		agentcmd.UseMiddlewares(agentcmd.LoggingMiddleware(log.New("middleware-test")))
+
+		var rw bytes.Buffer
+
+		err := agentcmd.Intercept("foo", "Bar", func(w io.Writer, _ io.Reader) command.Error {
+			_, e := w.Write([]byte("partial"))
+			require.NoError(t, e)
+			require.Equal(t, "partial", rw.String())
+
+			return agentcmd.NewExecuteError(agentcmd.UnknownStatus, errors.New("failed"))
+		})(&rw, bytes.NewBufferString("request"))
+		require.EqualError(t, err, "failed")
+		require.Equal(t, "partial", rw.String())
+	})
+
+	t.Run("middleware wraps response writer", func(t *testing.T) {
+		t.Cleanup(agentcmd.ResetMiddlewares)
+
+		counter := &countingWriter{}
+
+		agentcmd.UseMiddlewares(func(inv *agentcmd.Invocation, next agentcmd.Next) command.Error {
+			counter.w = inv.Response
+			inv.Response = counter
+
+			return next(inv)
+		})
+
+		var rw bytes.Buffer
+
+		require.Nil(t, agentcmd.Intercept("foo", "Bar", echo)(&rw, bytes.NewBufferString("request")))
+		require.Equal(t, "request", rw.String())
+		require.Equal(t, len("request"), counter.n)
+	})
+
+	t.Run("failed to read request", func(t *testing.T) {
+		t.Cleanup(agentcmd.ResetMiddlewares)
+
+		agentcmd.UseMiddlewares(agentcmd.LoggingMiddleware(log.New("middleware-test")))
+
+		err := agentcmd.Intercept("foo", "Bar", echo)(&bytes.Buffer{}, &mockReader{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to read request")
+	})
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	t.Cleanup(agentcmd.ResetMiddlewares)
+
+	var (
+		recorded []string
+		errs     []command.Error
+	)
+
+	agentcmd.UseMiddlewares(agentcmd.MetricsMiddleware(
+		func(name, method string, duration time.Duration, err command.Error) {
+			require.True(t, duration >= 0)
+
+			recorded = append(recorded, name+"."+method)
+			errs = append(errs, err)
+		}))
+
+	require.Nil(t, agentcmd.Intercept("foo", "Bar", echo)(&bytes.Buffer{}, bytes.NewBufferString("request")))
+
+	cmdErr := agentcmd.NewExecuteError(agentcmd.UnknownStatus, errors.New("failed"))
+
+	require.Equal(t, cmdErr, agentcmd.Intercept("foo", "Baz", func(io.Writer, io.Reader) command.Error {
+		return cmdErr
+	})(&bytes.Buffer{}, bytes.NewBufferString("request")))
+
+	require.Equal(t, []string{"foo.Bar", "foo.Baz"}, recorded)
+	require.Equal(t, []command.Error{nil, cmdErr}, errs)
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	t.Cleanup(agentcmd.ResetMiddlewares)
+
+	agentcmd.UseMiddlewares(agentcmd.RecoveryMiddleware(log.New("middleware-test")))
+
+	var rw bytes.Buffer
+
+	err := agentcmd.Intercept("foo", "Bar", func(io.Writer, io.Reader) command.Error {
+		panic("boom")
+	})(&rw, bytes.NewBufferString("request"))
+	require.EqualError(t, err, "command foo Bar panicked: boom")
+	require.Equal(t, command.ExecuteError, err.Type())
+	require.Empty(t, rw.String())
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+
+	return n, err
+}
+
+type mockReader struct{}
+
+func (m *mockReader) Read([]byte) (int, error) {
+	return 0, errors.New("read error")
+}
@@ -0,0 +1,462 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// schemaVersion is the JSON Schema draft of published schemas.
+const schemaVersion = "http://json-schema.org/draft-07/schema#"
+
+// sdkPackagePath is the path prefix of packages whose models are strictly validated. Models of other packages,
+// e.g. DIDComm messages of aries framework, are only checked to be JSON objects.
+const sdkPackagePath = "github.com/trustbloc/agent-sdk/"
+
+//nolint:gochecknoglobals
+var (
+	rawMessageType  = reflect.TypeOf(json.RawMessage{})
+	timeType        = reflect.TypeOf(time.Time{})
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	schemaCache     sync.Map
+)
+
+// SchemaViolation describes a part of request not matching the schema of the request model.
+type SchemaViolation struct {
+	// Field is the path of the violating field, e.g. '$.publicKeys[0].purposes'.
+	Field string `json:"field"`
+	// Description of the violation.
+	Description string `json:"description"`
+}
+
+// SchemaError is returned for requests not matching the schema of the request model.
+type SchemaError struct {
+	Violations []SchemaViolation `json:"violations"`
+}
+
+func (e *SchemaError) Error() string {
+	violations := make([]string, len(e.Violations))
+
+	for i, v := range e.Violations {
+		violations[i] = v.Field + ": " + v.Description
+	}
+
+	return "request does not match schema: " + strings.Join(violations, "; ")
+}
+
+// ErrorDetails returns violations as field-level error details.
+func (e *SchemaError) ErrorDetails() []ErrorDetail {
+	details := make([]ErrorDetail, len(e.Violations))
+
+	for i, v := range e.Violations {
+		details[i] = ErrorDetail(v)
+	}
+
+	return details
+}
+
+// MethodSchema contains JSON schemas of request and response models of a command method. Schema of a method
+// without request or response model is omitted.
+type MethodSchema struct {
+	Command  string                 `json:"command"`
+	Method   string                 `json:"method"`
+	Request  map[string]interface{} `json:"request,omitempty"`
+	Response map[string]interface{} `json:"response,omitempty"`
+}
+
+// NewMethodSchema returns schemas of given request and response models of a command method, either may be nil.
+func NewMethodSchema(name, method string, request, response interface{}) MethodSchema {
+	schema := MethodSchema{Command: name, Method: method}
+
+	if request != nil {
+		schema.Request = Schema(request)
+	}
+
+	if response != nil {
+		schema.Response = Schema(response)
+	}
+
+	return schema
+}
+
+// Schema returns JSON schema of the type of given model. Objects of agent SDK models do not allow unknown
+// properties, JSON field names are matched case-insensitively as encoding/json does.
+func Schema(model interface{}) map[string]interface{} {
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	cached, ok := schemaCache.Load(t)
+	if !ok {
+		cached, _ = schemaCache.LoadOrStore(t, typeSchema(t, map[reflect.Type]bool{}))
+	}
+
+	schema := map[string]interface{}{"$schema": schemaVersion, "title": t.Name()}
+
+	for k, v := range cached.(map[string]interface{}) { //nolint:forcetypeassert
+		schema[k] = v
+	}
+
+	return schema
+}
+
+// DecodeRequest decodes JSON request into given model, rejecting requests with unknown fields or values
+// not matching the schema of the model with SchemaError.
+func DecodeRequest(req io.Reader, model interface{}) error {
+	var raw json.RawMessage
+
+	if err := json.NewDecoder(req).Decode(&raw); err != nil {
+		return err
+	}
+
+	var doc interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&doc); err != nil {
+		return err
+	}
+
+	if violations := validate("$", doc, reflect.TypeOf(model).Elem()); len(violations) > 0 {
+		return &SchemaError{Violations: violations}
+	}
+
+	return json.Unmarshal(raw, model)
+}
+
+// isAny checks whether any JSON value can be decoded into values of given type by encoding/json, or by custom
+// unmarshaller of the type.
+func isAny(t reflect.Type) bool {
+	return t == rawMessageType || t.Kind() == reflect.Interface ||
+		t != timeType && reflect.PtrTo(t).Implements(unmarshalerType)
+}
+
+func isBytes(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+//nolint:gocyclo,cyclop
+func typeSchema(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	switch {
+	case isAny(t):
+		return map[string]interface{}{}
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case isBytes(t):
+		return map[string]interface{}{"type": []string{"string", "null"}, "contentEncoding": "base64"}
+	}
+
+	switch t.Kind() { //nolint:exhaustive
+	case reflect.Ptr:
+		return nullable(typeSchema(t.Elem(), visiting))
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": []string{"array", "null"}, "items": typeSchema(t.Elem(), visiting)}
+	case reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), visiting)}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 []string{"object", "null"},
+			"additionalProperties": typeSchema(t.Elem(), visiting),
+		}
+	case reflect.Struct:
+		return structSchema(t, visiting)
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func structSchema(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	if !strings.HasPrefix(t.PkgPath(), sdkPackagePath) || visiting[t] {
+		return map[string]interface{}{"type": "object"}
+	}
+
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	properties := map[string]interface{}{}
+
+	for _, field := range jsonFields(t) {
+		properties[field.name] = typeSchema(field.typ, visiting)
+	}
+
+	return map[string]interface{}{"type": "object", "properties": properties, "additionalProperties": false}
+}
+
+func nullable(schema map[string]interface{}) map[string]interface{} {
+	typeName, ok := schema["type"].(string)
+	if !ok {
+		return schema
+	}
+
+	result := map[string]interface{}{"type": []string{typeName, "null"}}
+
+	for k, v := range schema {
+		if k != "type" {
+			result[k] = v
+		}
+	}
+
+	return result
+}
+
+type jsonField struct {
+	name string
+	typ  reflect.Type
+}
+
+// jsonFields returns JSON fields of given struct type, including fields of embedded structs.
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+
+		if name == "-" && tag == "-" {
+			continue
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			fields = append(fields, jsonFields(fieldType)...)
+
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		fields = append(fields, jsonField{name: name, typ: field.Type})
+	}
+
+	return fields
+}
+
+// validate returns violations of the schema of given type by given JSON value decoded with numbers.
+//
+//nolint:gocyclo,cyclop
+func validate(path string, value interface{}, t reflect.Type) []SchemaViolation {
+	if isAny(t) {
+		return nil
+	}
+
+	if value == nil {
+		switch t.Kind() { //nolint:exhaustive
+		case reflect.Ptr, reflect.Slice, reflect.Map:
+			return nil
+		default:
+			return violation(path, "must not be null")
+		}
+	}
+
+	switch {
+	case t == timeType:
+		s, ok := value.(string)
+		if !ok {
+			return violation(path, "expected date-time string")
+		}
+
+		if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+			return violation(path, "invalid date-time")
+		}
+
+		return nil
+	case isBytes(t):
+		s, ok := value.(string)
+		if !ok {
+			return violation(path, "expected base64 string")
+		}
+
+		if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+			return violation(path, "invalid base64")
+		}
+
+		return nil
+	}
+
+	switch t.Kind() { //nolint:exhaustive
+	case reflect.Ptr:
+		return validate(path, value, t.Elem())
+	case reflect.String:
+		return expect(path, value, "string")
+	case reflect.Bool:
+		return expect(path, value, "boolean")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return validateInteger(path, value, t.Kind() >= reflect.Uint)
+	case reflect.Float32, reflect.Float64:
+		return expect(path, value, "number")
+	case reflect.Slice, reflect.Array:
+		return validateArray(path, value, t.Elem())
+	case reflect.Map:
+		return validateMap(path, value, t.Elem())
+	case reflect.Struct:
+		return validateStruct(path, value, t)
+	default:
+		return nil
+	}
+}
+
+func violation(path, description string) []SchemaViolation {
+	return []SchemaViolation{{Field: path, Description: description}}
+}
+
+func expect(path string, value interface{}, typeName string) []SchemaViolation {
+	var ok bool
+
+	switch typeName {
+	case "string":
+		_, ok = value.(string)
+	case "boolean":
+		_, ok = value.(bool)
+	case "number":
+		_, ok = value.(json.Number)
+	}
+
+	if !ok {
+		return violation(path, "expected "+typeName)
+	}
+
+	return nil
+}
+
+func validateInteger(path string, value interface{}, unsigned bool) []SchemaViolation {
+	number, ok := value.(json.Number)
+	if !ok {
+		return violation(path, "expected integer")
+	}
+
+	i, err := number.Int64()
+	if err != nil {
+		return violation(path, "expected integer")
+	}
+
+	if unsigned && i < 0 {
+		return violation(path, "must not be negative")
+	}
+
+	return nil
+}
+
+func validateArray(path string, value interface{}, elem reflect.Type) []SchemaViolation {
+	items, ok := value.([]interface{})
+	if !ok {
+		return violation(path, "expected array")
+	}
+
+	var violations []SchemaViolation
+
+	for i, item := range items {
+		violations = append(violations, validate(fmt.Sprintf("%s[%d]", path, i), item, elem)...)
+	}
+
+	return violations
+}
+
+func validateMap(path string, value interface{}, elem reflect.Type) []SchemaViolation {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return violation(path, "expected object")
+	}
+
+	var violations []SchemaViolation
+
+	for _, key := range sortedKeys(object) {
+		violations = append(violations, validate(path+"."+key, object[key], elem)...)
+	}
+
+	return violations
+}
+
+func validateStruct(path string, value interface{}, t reflect.Type) []SchemaViolation {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return violation(path, "expected object")
+	}
+
+	if !strings.HasPrefix(t.PkgPath(), sdkPackagePath) {
+		return nil
+	}
+
+	fields := jsonFields(t)
+
+	var violations []SchemaViolation
+
+	for _, key := range sortedKeys(object) {
+		field, found := lookupField(fields, key)
+		if !found {
+			violations = append(violations, violation(path+"."+key, "unknown field")...)
+
+			continue
+		}
+
+		violations = append(violations, validate(path+"."+key, object[key], field.typ)...)
+	}
+
+	return violations
+}
+
+// lookupField finds field with given name, preferring exact match over case-insensitive one like encoding/json.
+func lookupField(fields []jsonField, name string) (jsonField, bool) {
+	for _, field := range fields {
+		if field.name == name {
+			return field, true
+		}
+	}
+
+	for _, field := range fields {
+		if strings.EqualFold(field.name, name) {
+			return field, true
+		}
+	}
+
+	return jsonField{}, false
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+
+	for key := range object {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
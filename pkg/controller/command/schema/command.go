@@ -0,0 +1,118 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package schema provides command exporting JSON schemas of command request and response models
+// for client code generation, and the catalog of command error codes.
+package schema
+
+import (
+	"io"
+
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	"github.com/trustbloc/edge-core/pkg/log"
+
+	agentcmd "github.com/trustbloc/agent-sdk/pkg/controller/command"
+	"github.com/trustbloc/agent-sdk/pkg/controller/internal/cmdutil"
+)
+
+var logger = log.New("agent-sdk-schema")
+
+const (
+	// CommandName package command name.
+	CommandName = "schema"
+	// GetSchemasCommandMethod command method.
+	GetSchemasCommandMethod = "GetSchemas"
+	// GetErrorsCommandMethod command method.
+	GetErrorsCommandMethod = "GetErrors"
+)
+
+const (
+	// InvalidRequestErrorCode is typically a code for validation errors.
+	InvalidRequestErrorCode = command.Code(iota + agentcmd.Schema)
+)
+
+// Command exports JSON schemas of command methods.
+type Command struct {
+	schemas []agentcmd.MethodSchema
+}
+
+// New returns new schema command exporting given schemas, along with its own ones.
+func New(schemas []agentcmd.MethodSchema) *Command {
+	if err := agentcmd.RegisterErrors(Errors()...); err != nil {
+		logger.Errorf("failed to register schema errors: %s", err)
+	}
+
+	return &Command{schemas: append(append([]agentcmd.MethodSchema{}, schemas...), Schemas()...)}
+}
+
+// GetHandlers returns list of all commands supported by this controller command.
+func (c *Command) GetHandlers() []command.Handler {
+	return []command.Handler{
+		cmdutil.NewCommandHandler(CommandName, GetSchemasCommandMethod, c.GetSchemas),
+		cmdutil.NewCommandHandler(CommandName, GetErrorsCommandMethod, c.GetErrors),
+	}
+}
+
+// Schemas returns JSON schemas of request and response models of schema command methods.
+func Schemas() []agentcmd.MethodSchema {
+	return []agentcmd.MethodSchema{
+		agentcmd.NewMethodSchema(CommandName, GetSchemasCommandMethod, &GetSchemasRequest{}, &GetSchemasResponse{}),
+		agentcmd.NewMethodSchema(CommandName, GetErrorsCommandMethod, &GetErrorsRequest{}, &GetErrorsResponse{}),
+	}
+}
+
+// Errors returns definitions of schema error codes for the error catalog.
+func Errors() []agentcmd.ErrorDefinition {
+	return []agentcmd.ErrorDefinition{
+		{Code: InvalidRequestErrorCode, Name: "SCHEMA_INVALID_REQUEST", Command: CommandName, Description: "invalid request"},
+	}
+}
+
+// GetSchemas returns JSON schemas of request and response models of command methods, optionally of given
+// command or method only.
+func (c *Command) GetSchemas(rw io.Writer, req io.Reader) command.Error {
+	var request GetSchemasRequest
+
+	err := agentcmd.DecodeRequest(req, &request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	response := &GetSchemasResponse{Schemas: []agentcmd.MethodSchema{}}
+
+	for _, schema := range c.schemas {
+		if (request.Command == "" || schema.Command == request.Command) &&
+			(request.Method == "" || schema.Method == request.Method) {
+			response.Schemas = append(response.Schemas, schema)
+		}
+	}
+
+	agentcmd.WriteNillableResponse(rw, response, logger)
+
+	return nil
+}
+
+// GetErrors returns definitions of registered error codes, optionally of given command only.
+func (c *Command) GetErrors(rw io.Writer, req io.Reader) command.Error {
+	var request GetErrorsRequest
+
+	err := agentcmd.DecodeRequest(req, &request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	response := &GetErrorsResponse{Errors: []agentcmd.ErrorDefinition{}}
+
+	for _, definition := range agentcmd.ErrorCatalog() {
+		if request.Command == "" || definition.Command == request.Command {
+			response.Errors = append(response.Errors, definition)
+		}
+	}
+
+	agentcmd.WriteNillableResponse(rw, response, logger)
+
+	return nil
+}
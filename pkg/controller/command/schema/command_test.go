@@ -0,0 +1,112 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package schema_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	"github.com/stretchr/testify/require"
+
+	agentcmd "github.com/trustbloc/agent-sdk/pkg/controller/command"
+	"github.com/trustbloc/agent-sdk/pkg/controller/command/schema"
+	"github.com/trustbloc/agent-sdk/pkg/controller/command/store"
+)
+
+func TestCommand_GetSchemas(t *testing.T) {
+	cmd := schema.New(store.Schemas())
+	require.Len(t, cmd.GetHandlers(), 2)
+
+	getSchemas := func(t *testing.T, request string) []agentcmd.MethodSchema {
+		t.Helper()
+
+		var rw bytes.Buffer
+
+		require.Nil(t, cmd.GetSchemas(&rw, bytes.NewBufferString(request)))
+
+		var response schema.GetSchemasResponse
+
+		require.NoError(t, json.Unmarshal(rw.Bytes(), &response))
+
+		return response.Schemas
+	}
+
+	t.Run("all schemas", func(t *testing.T) {
+		schemas := getSchemas(t, `{}`)
+		require.Len(t, schemas, len(store.Schemas())+2)
+		require.Equal(t, schema.CommandName, schemas[len(schemas)-1].Command)
+	})
+
+	t.Run("schemas of command method", func(t *testing.T) {
+		schemas := getSchemas(t, `{"command":"store","method":"Put"}`)
+		require.Len(t, schemas, 1)
+		require.Equal(t, "PutRequest", schemas[0].Request["title"])
+		require.Equal(t, "PutResponse", schemas[0].Response["title"])
+		require.Equal(t, false, schemas[0].Request["additionalProperties"])
+
+		require.Empty(t, getSchemas(t, `{"command":"unknown"}`))
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		err := cmd.GetSchemas(&bytes.Buffer{}, bytes.NewBufferString(`{"commandName":"store"}`))
+		require.EqualError(t, err, "request does not match schema: $.commandName: unknown field")
+		require.Equal(t, command.ValidationError, err.Type())
+		require.Equal(t, schema.InvalidRequestErrorCode, err.Code())
+	})
+}
+
+func TestCommand_GetErrors(t *testing.T) {
+	require.NoError(t, agentcmd.RegisterErrors(store.Errors()...))
+	require.NoError(t, agentcmd.RegisterErrors(schema.Errors()...))
+
+	cmd := schema.New(nil)
+
+	getErrors := func(t *testing.T, request string) []agentcmd.ErrorDefinition {
+		t.Helper()
+
+		var rw bytes.Buffer
+
+		require.Nil(t, cmd.GetErrors(&rw, bytes.NewBufferString(request)))
+
+		var response schema.GetErrorsResponse
+
+		require.NoError(t, json.Unmarshal(rw.Bytes(), &response))
+
+		return response.Errors
+	}
+
+	t.Run("all errors", func(t *testing.T) {
+		definitions := getErrors(t, `{}`)
+		require.Equal(t, agentcmd.ErrorCatalog(), definitions)
+		require.Equal(t, "UNKNOWN", definitions[0].Name)
+	})
+
+	t.Run("errors of command", func(t *testing.T) {
+		definitions := getErrors(t, `{"command":"store"}`)
+		require.ElementsMatch(t, store.Errors(), definitions)
+
+		definitions = getErrors(t, `{"command":"schema"}`)
+		require.Len(t, definitions, 1)
+		require.Equal(t, "SCHEMA_INVALID_REQUEST", definitions[0].Name)
+		require.False(t, definitions[0].Retryable)
+
+		require.Empty(t, getErrors(t, `{"command":"unknown"}`))
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		err := cmd.GetErrors(&bytes.Buffer{}, bytes.NewBufferString(`{"command":1}`))
+		require.Error(t, err)
+		require.Equal(t, command.ValidationError, err.Type())
+
+		info := agentcmd.DescribeError(err.Code(), err)
+		require.Equal(t, "SCHEMA_INVALID_REQUEST", info.Name)
+		require.Len(t, info.Details, 1)
+		require.Equal(t, "$.command", info.Details[0].Field)
+	})
+}
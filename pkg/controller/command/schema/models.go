@@ -0,0 +1,47 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package schema
+
+import (
+	agentcmd "github.com/trustbloc/agent-sdk/pkg/controller/command"
+)
+
+// GetSchemasRequest model
+//
+// This is used for getting JSON schemas of command request and response models.
+type GetSchemasRequest struct {
+	// Command name to get schemas of.
+	// Optional: if missing, schemas of all commands are returned.
+	Command string `json:"command,omitempty"`
+
+	// Method name to get schema of.
+	// Optional: if missing, schemas of all methods of the command are returned.
+	Method string `json:"method,omitempty"`
+}
+
+// GetSchemasResponse model
+//
+// This is used for returning JSON schemas of command request and response models.
+type GetSchemasResponse struct {
+	Schemas []agentcmd.MethodSchema `json:"schemas"`
+}
+
+// GetErrorsRequest model
+//
+// This is used for getting the catalog of command error codes.
+type GetErrorsRequest struct {
+	// Command name to get error codes of.
+	// Optional: if missing, all error codes are returned.
+	Command string `json:"command,omitempty"`
+}
+
+// GetErrorsResponse model
+//
+// This is used for returning the catalog of command error codes sorted by code.
+type GetErrorsResponse struct {
+	Errors []agentcmd.ErrorDefinition `json:"errors"`
+}
@@ -0,0 +1,145 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package command_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	agentcmd "github.com/trustbloc/agent-sdk/pkg/controller/command"
+)
+
+type embedded struct {
+	Label string `json:"label,omitempty"`
+}
+
+type item struct {
+	Name  string `json:"name"`
+	Count uint   `json:"count,omitempty"`
+}
+
+type testRequest struct {
+	embedded
+	ID        string            `json:"id"`
+	Enabled   bool              `json:"enabled,omitempty"`
+	Limit     int               `json:"limit,omitempty"`
+	Ratio     float64           `json:"ratio,omitempty"`
+	Value     []byte            `json:"value,omitempty"`
+	Items     []item            `json:"items,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Doc       json.RawMessage   `json:"doc,omitempty"`
+	CreatedAt *time.Time        `json:"createdAt,omitempty"`
+	Ignored   string            `json:"-"`
+	internal  string
+}
+
+func TestDecodeRequest(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var request testRequest
+
+		require.NoError(t, agentcmd.DecodeRequest(bytes.NewBufferString(`{
+			"id": "1", "label": "label", "Enabled": true, "limit": 2, "ratio": 0.5, "value": "dmFsdWU=",
+			"items": [{"name": "item", "count": 3}], "labels": {"a": "b"}, "doc": {"any": ["thing", 1]},
+			"createdAt": "2022-01-02T03:04:05Z"
+		}`), &request))
+
+		require.Equal(t, "1", request.ID)
+		require.Equal(t, "label", request.Label)
+		require.True(t, request.Enabled)
+		require.Equal(t, []byte("value"), request.Value)
+		require.Equal(t, []item{{Name: "item", Count: 3}}, request.Items)
+		require.Equal(t, time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC), request.CreatedAt.UTC())
+		require.Empty(t, request.internal)
+	})
+
+	t.Run("null values", func(t *testing.T) {
+		var request testRequest
+
+		require.NoError(t, agentcmd.DecodeRequest(bytes.NewBufferString(
+			`{"id": "1", "items": null, "labels": null, "doc": null, "createdAt": null}`), &request))
+	})
+
+	t.Run("violations", func(t *testing.T) {
+		var request testRequest
+
+		err := agentcmd.DecodeRequest(bytes.NewBufferString(`{
+			"id": 1, "unknown": true, "enabled": "yes", "limit": 1.5, "ratio": "half", "value": "%",
+			"items": [{"name": "item", "count": -1, "extra": 1}, "item"], "labels": {"a": 1},
+			"createdAt": "yesterday", "label": null, "Ignored": "x"
+		}`), &request)
+
+		var schemaErr *agentcmd.SchemaError
+
+		require.True(t, errors.As(err, &schemaErr))
+		require.Equal(t, []agentcmd.SchemaViolation{
+			{Field: "$.Ignored", Description: "unknown field"},
+			{Field: "$.createdAt", Description: "invalid date-time"},
+			{Field: "$.enabled", Description: "expected boolean"},
+			{Field: "$.id", Description: "expected string"},
+			{Field: "$.items[0].count", Description: "must not be negative"},
+			{Field: "$.items[0].extra", Description: "unknown field"},
+			{Field: "$.items[1]", Description: "expected object"},
+			{Field: "$.label", Description: "must not be null"},
+			{Field: "$.labels.a", Description: "expected string"},
+			{Field: "$.limit", Description: "expected integer"},
+			{Field: "$.ratio", Description: "expected number"},
+			{Field: "$.unknown", Description: "unknown field"},
+			{Field: "$.value", Description: "invalid base64"},
+		}, schemaErr.Violations)
+		require.Contains(t, err.Error(), "request does not match schema: $.Ignored: unknown field; ")
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		var request testRequest
+
+		require.EqualError(t, agentcmd.DecodeRequest(bytes.NewBufferString(``), &request), io.EOF.Error())
+		require.Contains(t, agentcmd.DecodeRequest(bytes.NewBufferString(`}`), &request).Error(),
+			"invalid character")
+		require.EqualError(t, agentcmd.DecodeRequest(bytes.NewBufferString(`[]`), &request),
+			"request does not match schema: $: expected object")
+	})
+}
+
+func TestSchema(t *testing.T) {
+	schema := agentcmd.Schema(&testRequest{})
+
+	schemaBytes, err := json.Marshal(schema)
+	require.NoError(t, err)
+
+	require.JSONEq(t, `{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"title": "testRequest",
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"label": {"type": "string"},
+			"id": {"type": "string"},
+			"enabled": {"type": "boolean"},
+			"limit": {"type": "integer"},
+			"ratio": {"type": "number"},
+			"value": {"type": ["string", "null"], "contentEncoding": "base64"},
+			"items": {"type": ["array", "null"], "items": {
+				"type": "object",
+				"additionalProperties": false,
+				"properties": {"name": {"type": "string"}, "count": {"type": "integer", "minimum": 0}}
+			}},
+			"labels": {"type": ["object", "null"], "additionalProperties": {"type": "string"}},
+			"doc": {},
+			"createdAt": {"type": ["string", "null"], "format": "date-time"}
+		}
+	}`, string(schemaBytes))
+
+	methodSchema := agentcmd.NewMethodSchema("test", "Method", nil, &item{})
+	require.Nil(t, methodSchema.Request)
+	require.Equal(t, "item", methodSchema.Response["title"])
+}
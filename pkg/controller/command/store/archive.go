@@ -0,0 +1,591 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package store
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	cryptoapi "github.com/hyperledger/aries-framework-go/pkg/crypto"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/packer"
+	ariesjose "github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/square/go-jose/v3"
+)
+
+const (
+	// ImportModeMerge puts archived records into stores, keeping records which are not in the archive.
+	ImportModeMerge = "merge"
+	// ImportModeReplace replaces records of stores with archived records.
+	ImportModeReplace = "replace"
+
+	// archiveVersion is the version of the archive format written by ExportStores.
+	archiveVersion = 1
+)
+
+var (
+	errEmptyStoreNames   = errors.New("store names are mandatory")
+	errArchiveEncryption = errors.New("exactly one of passphrase, key or key ID is mandatory")
+	errNoKeyManager      = errors.New("key IDs can't be used, as key manager of the store command is not set")
+	errInvalidImportMode = errors.New("import mode must be either merge or replace")
+	errInvalidArchive    = errors.New("invalid archive")
+)
+
+// storeArchive is the decrypted form of the archive returned by ExportStores.
+type storeArchive struct {
+	Version   int             `json:"version"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Stores    []archivedStore `json:"stores"`
+}
+
+type archivedStore struct {
+	Name    string                      `json:"name"`
+	Config  *storage.StoreConfiguration `json:"config,omitempty"`
+	Records []archivedRecord            `json:"records"`
+}
+
+type archivedRecord struct {
+	Key   string        `json:"key"`
+	Value []byte        `json:"value"`
+	Tags  []storage.Tag `json:"tags,omitempty"`
+}
+
+// tagNames returns names of the tags of archived records.
+func (s *archivedStore) tagNames() []string {
+	var names []string
+
+	for _, record := range s.Records {
+		for _, tag := range record.Tags {
+			names = append(names, tag.Name)
+		}
+	}
+
+	return names
+}
+
+// validate checks the archive before anything is written to stores.
+func (a *storeArchive) validate() error {
+	if a.Version != archiveVersion {
+		return fmt.Errorf("%w: unsupported version %d", errInvalidArchive, a.Version)
+	}
+
+	names := map[string]bool{}
+
+	for _, s := range a.Stores {
+		if s.Name == "" || names[s.Name] {
+			return fmt.Errorf("%w: missing or duplicate store name '%s'", errInvalidArchive, s.Name)
+		}
+
+		names[s.Name] = true
+
+		for _, record := range s.Records {
+			if record.Key == "" || record.Value == nil {
+				return fmt.Errorf("%w: record without key or value in store %s", errInvalidArchive, s.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// archiveSecret is what an archive is encrypted to: a passphrase, a JWK or ID of a key of the key manager.
+// Exactly one of them is set.
+type archiveSecret struct {
+	passphrase string
+	key        json.RawMessage
+	keyID      string
+}
+
+// validate checks that exactly one secret is set.
+func (s *archiveSecret) validate() error {
+	set := 0
+
+	for _, isSet := range []bool{s.passphrase != "", len(s.key) > 0, s.keyID != ""} {
+		if isSet {
+			set++
+		}
+	}
+
+	if set != 1 {
+		return errArchiveEncryption
+	}
+
+	return nil
+}
+
+// archiveKeys encrypts archives to keys of the key manager and decrypts them, so that private keys never leave
+// the key manager.
+type archiveKeys struct {
+	keyManager kms.KeyManager
+	crypto     cryptoapi.Crypto
+}
+
+// encrypt encrypts given archive bytes as a compact JWE to the key agreement key with given ID,
+// e.g. a key of NISTP256ECDHKW type.
+func (k *archiveKeys) encrypt(archiveBytes []byte, keyID string) (string, error) {
+	pubKeyBytes, _, err := k.keyManager.ExportPubKeyBytes(keyID)
+	if err != nil {
+		return "", fmt.Errorf("failed to export public key %s: %w", keyID, err)
+	}
+
+	pubKey := &cryptoapi.PublicKey{}
+
+	if err = json.Unmarshal(pubKeyBytes, pubKey); err != nil {
+		return "", fmt.Errorf("key %s is not a key agreement key: %w", keyID, err)
+	}
+
+	pubKey.KID = keyID
+
+	encrypter, err := ariesjose.NewJWEEncrypt(ariesjose.A256GCM, packer.EnvelopeEncodingTypeV2, "", "", nil,
+		[]*cryptoapi.PublicKey{pubKey}, k.crypto)
+	if err != nil {
+		return "", fmt.Errorf("failed to create encrypter: %w", err)
+	}
+
+	jwe, err := encrypter.Encrypt(archiveBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt archive: %w", err)
+	}
+
+	return jwe.CompactSerialize(json.Marshal)
+}
+
+// decrypt decrypts given compact JWE with the key with given ID, the archive must be encrypted to that key.
+func (k *archiveKeys) decrypt(compact, keyID string) ([]byte, error) {
+	jwe, err := ariesjose.Deserialize(compact)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidArchive, err)
+	}
+
+	if kid, _ := jwe.ProtectedHeaders.KeyID(); kid != keyID {
+		return nil, fmt.Errorf("%w: archive is not encrypted to key %s", errInvalidArchive, keyID)
+	}
+
+	archiveBytes, err := ariesjose.NewJWEDecrypt(nil, k.crypto, k.keyManager).Decrypt(jwe)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidArchive, err)
+	}
+
+	return archiveBytes, nil
+}
+
+// encryptArchive encrypts given archive as a compact JWE to given secret. Archives are encrypted to key IDs
+// with given keys.
+func encryptArchive(archive *storeArchive, secret *archiveSecret, keys *archiveKeys) (string, error) {
+	archiveBytes, err := json.Marshal(archive)
+	if err != nil {
+		return "", err
+	}
+
+	if secret.keyID != "" {
+		return keys.encrypt(archiveBytes, secret.keyID)
+	}
+
+	recipient, err := archiveRecipient(secret.passphrase, secret.key)
+	if err != nil {
+		return "", err
+	}
+
+	encrypter, err := jose.NewEncrypter(jose.A256GCM, *recipient, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create encrypter: %w", err)
+	}
+
+	jwe, err := encrypter.Encrypt(archiveBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt archive: %w", err)
+	}
+
+	return jwe.CompactSerialize()
+}
+
+func archiveRecipient(passphrase string, key json.RawMessage) (*jose.Recipient, error) {
+	if passphrase != "" {
+		return &jose.Recipient{Algorithm: jose.PBES2_HS512_A256KW, Key: []byte(passphrase)}, nil
+	}
+
+	jwk, err := parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm := jose.KeyAlgorithm(jwk.Algorithm)
+
+	if algorithm == "" {
+		switch jwk.Key.(type) {
+		case *ecdsa.PublicKey:
+			algorithm = jose.ECDH_ES_A256KW
+		case *rsa.PublicKey:
+			algorithm = jose.RSA_OAEP_256
+		case []byte:
+			algorithm = jose.A256KW
+		default:
+			return nil, fmt.Errorf("unsupported key type %T", jwk.Key)
+		}
+	}
+
+	return &jose.Recipient{Algorithm: algorithm, Key: jwk.Key, KeyID: jwk.KeyID}, nil
+}
+
+// decryptArchive decrypts and validates archive encrypted by encryptArchive to given secret. Archives encrypted
+// to key IDs are decrypted with given keys.
+func decryptArchive(compact string, secret *archiveSecret, keys *archiveKeys) (*storeArchive, error) {
+	var (
+		archiveBytes []byte
+		err          error
+	)
+
+	if secret.keyID != "" {
+		archiveBytes, err = keys.decrypt(compact, secret.keyID)
+	} else {
+		archiveBytes, err = decryptJWE(compact, secret.passphrase, secret.key)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &storeArchive{}
+
+	if err = json.Unmarshal(archiveBytes, archive); err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidArchive, err)
+	}
+
+	if err = archive.validate(); err != nil {
+		return nil, err
+	}
+
+	return archive, nil
+}
+
+// decryptJWE decrypts compact JWE either with given passphrase or with given private JWK.
+func decryptJWE(compact, passphrase string, key json.RawMessage) ([]byte, error) {
+	jwe, err := jose.ParseEncrypted(compact)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidArchive, err)
+	}
+
+	var decryptionKey interface{}
+
+	if passphrase != "" {
+		// passphrase must not be used as a plain symmetric key
+		if jwe.Header.Algorithm != string(jose.PBES2_HS512_A256KW) {
+			return nil, fmt.Errorf("%w: archive is not encrypted to a passphrase", errInvalidArchive)
+		}
+
+		decryptionKey = []byte(passphrase)
+	} else {
+		jwk, e := parseKey(key)
+		if e != nil {
+			return nil, e
+		}
+
+		decryptionKey = jwk.Key
+	}
+
+	archiveBytes, err := jwe.Decrypt(decryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidArchive, err)
+	}
+
+	return archiveBytes, nil
+}
+
+func parseKey(key json.RawMessage) (*jose.JSONWebKey, error) {
+	if len(key) == 0 {
+		return nil, errArchiveEncryption
+	}
+
+	jwk := &jose.JSONWebKey{}
+
+	if err := jwk.UnmarshalJSON(key); err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+
+	return jwk, nil
+}
+
+// storeConfig returns configuration of the store with given name, stores which were not configured
+// have empty configuration.
+func storeConfig(provider storage.Provider, name string) (storage.StoreConfiguration, error) {
+	config, err := provider.GetStoreConfig(name)
+	if errors.Is(err, storage.ErrStoreNotFound) {
+		return storage.StoreConfiguration{}, nil
+	}
+
+	return config, err
+}
+
+// queryRecords returns records having any of the tags with given names, expired records are skipped.
+// Storage does not support listing all records, so records without such tags cannot be found.
+func queryRecords(store storage.Store, tagNames []string) ([]archivedRecord, error) {
+	var records []archivedRecord
+
+	queried, seen := map[string]bool{}, map[string]bool{}
+
+	for _, tagName := range tagNames {
+		if queried[tagName] {
+			continue
+		}
+
+		queried[tagName] = true
+
+		iterator, err := store.Query(tagName)
+		if err != nil {
+			return nil, err
+		}
+
+		records, err = appendRecords(records, iterator, seen)
+
+		if errClose := iterator.Close(); errClose != nil {
+			logger.Warnf("failed to close iterator: %s", errClose)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return records, nil
+}
+
+// appendRecords appends records of given iterator with keys which were not seen yet to given records.
+func appendRecords(records []archivedRecord, iterator storage.Iterator, seen map[string]bool) ([]archivedRecord,
+	error) {
+	more, err := iterator.Next()
+
+	for ; more && err == nil; more, err = iterator.Next() {
+		record, expired, e := readRecord(iterator)
+		if e != nil {
+			return nil, e
+		}
+
+		if expired || seen[record.Key] {
+			continue
+		}
+
+		seen[record.Key] = true
+
+		tags, e := iterator.Tags()
+		if e != nil {
+			return nil, e
+		}
+
+		// raw tags are archived, so that expiry of the record is kept
+		records = append(records, archivedRecord{Key: record.Key, Value: record.Value, Tags: tags})
+	}
+
+	return records, err
+}
+
+// archiveTagNames returns names of tags records of the store with given name are looked up by: the record tag
+// of records written through the store command, tags from the store configuration, index tags and given tags.
+func (c *Command) archiveTagNames(name string, tagNames []string) ([]string, storage.StoreConfiguration, error) {
+	config, err := storeConfig(c.provider, name)
+	if err != nil {
+		return nil, config, fmt.Errorf("failed to get configuration of store %s: %w", name, err)
+	}
+
+	definitions, _, err := c.indexes.get(name)
+	if err != nil {
+		return nil, config, err
+	}
+
+	names := append(append(append([]string{recordTag}, config.TagNames...), definitions.names()...), tagNames...)
+
+	return names, config, nil
+}
+
+// exportStore reads records and configuration of given store. Records written through the store command
+// are exported, along with records having tags from the store configuration, index tags or any of given tags.
+func (c *Command) exportStore(store storage.Store, name string, tagNames []string) (*archivedStore, error) {
+	names, config, err := c.archiveTagNames(name, tagNames)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := queryRecords(store, names)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read records of store %s: %w", name, err)
+	}
+
+	archived := &archivedStore{Name: name, Records: records}
+
+	if len(config.TagNames) > 0 {
+		archived.Config = &config
+	}
+
+	return archived, nil
+}
+
+// storeImport is an import of an archived store prepared before anything is written, along with operations
+// restoring the store if importing a later store of the archive fails.
+type storeImport struct {
+	store      storage.Store
+	name       string
+	config     *storage.StoreConfiguration
+	prevConfig storage.StoreConfiguration
+	operations []storage.Operation
+	undo       []storage.Operation
+	changes    []*change
+}
+
+// prepareImport prepares writing archived records to given store. Index tags of records are derived by indexes
+// of the store. In replace mode, existing records which are found the same way as exported records and are not
+// in the archive are deleted.
+func (c *Command) prepareImport(store storage.Store, archived *archivedStore, mode string) (*storeImport, error) {
+	names, config, err := c.archiveTagNames(archived.Name, archived.tagNames())
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &storeImport{store: store, name: archived.Name, prevConfig: config}
+
+	if mode == ImportModeReplace {
+		if err = imp.deleteMissing(names, archived); err != nil {
+			return nil, err
+		}
+
+		config = storage.StoreConfiguration{}
+	}
+
+	if archived.Config != nil {
+		config.TagNames = mergeTagNames(config.TagNames, archived.Config.TagNames)
+		imp.config = &config
+	}
+
+	puts := make([]storage.Operation, 0, len(archived.Records))
+
+	for _, record := range archived.Records {
+		puts = append(puts, storage.Operation{Key: record.Key, Value: record.Value, Tags: withRecordTag(record.Tags)})
+	}
+
+	if err = c.indexOperations(archived.Name, puts); err != nil {
+		return nil, err
+	}
+
+	for _, op := range puts {
+		undo, e := previousRecord(store, op.Key)
+		if e != nil {
+			return nil, fmt.Errorf("failed to read records of store %s: %w", archived.Name, e)
+		}
+
+		imp.undo = append(imp.undo, *undo)
+	}
+
+	imp.operations = append(imp.operations, puts...)
+	imp.changes = c.changes.batch(store, archived.Name, imp.operations)
+
+	return imp, nil
+}
+
+// deleteMissing adds operations deleting existing records found by tags with given names which are not
+// in the archive.
+func (i *storeImport) deleteMissing(tagNames []string, archived *archivedStore) error {
+	existing, err := queryRecords(i.store, tagNames)
+	if err != nil {
+		return fmt.Errorf("failed to read records of store %s: %w", archived.Name, err)
+	}
+
+	keys := map[string]bool{}
+
+	for _, record := range archived.Records {
+		keys[record.Key] = true
+	}
+
+	for _, record := range existing {
+		if !keys[record.Key] {
+			i.operations = append(i.operations, storage.Operation{Key: record.Key})
+			i.undo = append(i.undo, storage.Operation{Key: record.Key, Value: record.Value, Tags: record.Tags})
+		}
+	}
+
+	return nil
+}
+
+// previousRecord returns operation restoring the record with given key as it is now.
+func previousRecord(store storage.Store, key string) (*storage.Operation, error) {
+	value, err := store.Get(key)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return &storage.Operation{Key: key}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := store.GetTags(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storage.Operation{Key: key, Value: value, Tags: tags}, nil
+}
+
+// apply writes the configuration and records of the store in a single batch.
+func (i *storeImport) apply(provider storage.Provider) error {
+	if i.config != nil {
+		if err := provider.SetStoreConfig(i.name, *i.config); err != nil {
+			return fmt.Errorf("failed to set configuration of store %s: %w", i.name, err)
+		}
+	}
+
+	if len(i.operations) == 0 {
+		return nil
+	}
+
+	if err := i.store.Batch(i.operations); err != nil {
+		return fmt.Errorf("failed to write records of store %s: %w", i.name, err)
+	}
+
+	return nil
+}
+
+// rollback restores configuration and records of the store changed by apply. Failures are logged only,
+// as the import failed already.
+func (i *storeImport) rollback(provider storage.Provider) {
+	if i.config != nil {
+		if err := provider.SetStoreConfig(i.name, i.prevConfig); err != nil {
+			logger.Errorf("failed to restore configuration of store %s: %s", i.name, err)
+		}
+	}
+
+	if len(i.undo) == 0 {
+		return
+	}
+
+	if err := i.store.Batch(i.undo); err != nil {
+		logger.Errorf("failed to restore records of store %s: %s", i.name, err)
+	}
+}
+
+func mergeTagNames(names, other []string) []string {
+	merged := append([]string{}, names...)
+
+	for _, name := range other {
+		found := false
+
+		for _, existing := range merged {
+			if existing == name {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			merged = append(merged, name)
+		}
+	}
+
+	return merged
+}
@@ -9,15 +9,20 @@ package store
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	cryptoapi "github.com/hyperledger/aries-framework-go/pkg/crypto"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
 	"github.com/hyperledger/aries-framework-go/spi/storage"
 	"github.com/trustbloc/edge-core/pkg/log"
 
 	agentcmd "github.com/trustbloc/agent-sdk/pkg/controller/command"
 	"github.com/trustbloc/agent-sdk/pkg/controller/internal/cmdutil"
-	"github.com/trustbloc/agent-sdk/pkg/controller/internal/logutil"
 )
 
 const (
@@ -29,12 +34,41 @@ const (
 	GetCommandMethod = "Get"
 	// QueryCommandMethod command method.
 	QueryCommandMethod = "Query"
+	// QueryRecordsCommandMethod command method.
+	QueryRecordsCommandMethod = "QueryRecords"
 	// DeleteCommandMethod command method.
 	DeleteCommandMethod = "Delete"
 	// FlushCommandMethod command method.
 	FlushCommandMethod = "Flush"
-
-	successString = "success"
+	// ListStoresCommandMethod command method.
+	ListStoresCommandMethod = "ListStores"
+	// GetTagsCommandMethod command method.
+	GetTagsCommandMethod = "GetTags"
+	// GetBulkCommandMethod command method.
+	GetBulkCommandMethod = "GetBulk"
+	// BatchCommandMethod command method.
+	BatchCommandMethod = "Batch"
+	// IncrementCommandMethod command method.
+	IncrementCommandMethod = "Increment"
+	// SweeperStatsCommandMethod command method.
+	SweeperStatsCommandMethod = "SweeperStats"
+	// ExportStoresCommandMethod command method.
+	ExportStoresCommandMethod = "ExportStores"
+	// ImportStoresCommandMethod command method.
+	ImportStoresCommandMethod = "ImportStores"
+	// SubscribeCommandMethod command method.
+	SubscribeCommandMethod = "Subscribe"
+	// UnsubscribeCommandMethod command method.
+	UnsubscribeCommandMethod = "Unsubscribe"
+	// ConfigureIndexesCommandMethod command method.
+	ConfigureIndexesCommandMethod = "ConfigureIndexes"
+	// GetIndexesCommandMethod command method.
+	GetIndexesCommandMethod = "GetIndexes"
+	// RebuildIndexesCommandMethod command method.
+	RebuildIndexesCommandMethod = "RebuildIndexes"
+
+	// DefaultStoreName is the name of the store used by requests not carrying a store name.
+	DefaultStoreName = "store"
 )
 
 const (
@@ -50,29 +84,186 @@ const (
 	DeleteErrorCode
 	// FlushErrorCode is typically a code for Flush errors.
 	FlushErrorCode
+	// StoreNotAllowedErrorCode is for requests to stores not matching the allowlist.
+	StoreNotAllowedErrorCode
+	// GetTagsErrorCode is typically a code for GetTags errors.
+	GetTagsErrorCode
+	// GetBulkErrorCode is typically a code for GetBulk errors.
+	GetBulkErrorCode
+	// BatchErrorCode is typically a code for Batch errors.
+	BatchErrorCode
+	// ConflictErrorCode is for conditional writes to records whose version does not match.
+	ConflictErrorCode
+	// IncrementErrorCode is typically a code for Increment errors.
+	IncrementErrorCode
+	// ExportStoresErrorCode is typically a code for ExportStores errors.
+	ExportStoresErrorCode
+	// ImportStoresErrorCode is typically a code for ImportStores errors.
+	ImportStoresErrorCode
+	// InvalidArchiveErrorCode is for archives which cannot be decrypted or are malformed.
+	InvalidArchiveErrorCode
+	// SubscribeErrorCode is typically a code for Subscribe errors.
+	SubscribeErrorCode
+	// UnsubscribeErrorCode is typically a code for Unsubscribe errors.
+	UnsubscribeErrorCode
+	// ConfigureIndexesErrorCode is typically a code for ConfigureIndexes errors.
+	ConfigureIndexesErrorCode
+	// GetIndexesErrorCode is typically a code for GetIndexes errors.
+	GetIndexesErrorCode
+	// RebuildIndexesErrorCode is typically a code for RebuildIndexes errors.
+	RebuildIndexesErrorCode
+	// ListStoresErrorCode is typically a code for ListStores errors.
+	ListStoresErrorCode
 )
 
 var logger = log.New("agent-sdk-store")
 
+var (
+	errEmptyKey          = errors.New("key is mandatory")
+	errEmptyKeys         = errors.New("keys are mandatory")
+	errEmptyOperations   = errors.New("operations are mandatory")
+	errEmptyOperationKey = errors.New("key of batch operation is mandatory")
+	errEmptyFilterTag    = errors.New("tag name of filter is mandatory")
+)
+
 // Provider describes dependencies for the client.
 type Provider interface {
 	StorageProvider() storage.Provider
 }
 
+type options struct {
+	allowedStores  []string
+	sweepInterval  time.Duration
+	sweepBatchSize int
+	notifier       command.Notifier
+	keys           *archiveKeys
+	state          *SharedState
+}
+
+// Opt represents a store command option.
+type Opt func(opts *options)
+
+// WithAllowedStores allows requests to stores with given names in addition to the default store.
+// A name ending with '*' allows all stores with given prefix, e.g. 'myapp_*'.
+func WithAllowedStores(names ...string) Opt {
+	return func(opts *options) {
+		opts.allowedStores = append(opts.allowedStores, names...)
+	}
+}
+
+// WithSweepInterval sets interval between runs of the sweeper deleting expired records,
+// zero or negative interval disables the sweeper. Defaults to DefaultSweepInterval. Only applies
+// to the first command of a shared state, as its commands share the sweeper.
+func WithSweepInterval(interval time.Duration) Opt {
+	return func(opts *options) {
+		opts.sweepInterval = interval
+	}
+}
+
+// WithSweepBatchSize sets maximum number of expired records deleted by the sweeper at once.
+// Defaults to DefaultSweepBatchSize. Only applies to the first command of a shared state.
+func WithSweepBatchSize(size int) Opt {
+	return func(opts *options) {
+		opts.sweepBatchSize = size
+	}
+}
+
+// WithNotifier sets notifier on which changes of records are published to subscriptions.
+func WithNotifier(notifier command.Notifier) Opt {
+	return func(opts *options) {
+		opts.notifier = notifier
+	}
+}
+
+// WithKeyManager sets key manager and crypto which encrypt archives of ExportStores and ImportStores requests
+// to IDs of key agreement keys, so that private keys are not passed in requests.
+func WithKeyManager(keyManager kms.KeyManager, crypto cryptoapi.Crypto) Opt {
+	return func(opts *options) {
+		opts.keys = &archiveKeys{keyManager: keyManager, crypto: crypto}
+	}
+}
+
+// WithSharedState sets state shared with other store commands created with it, so that they share opened stores
+// and a single sweeper deleting expired records, and their writes are serialized. Each command has its own state
+// if not set.
+func WithSharedState(state *SharedState) Opt {
+	return func(opts *options) {
+		opts.state = state
+	}
+}
+
 // Command is controller command for store.
 type Command struct {
 	provider storage.Provider
-	store    storage.Store
+	stores   *stores
+	notifier command.Notifier
+	keys     *archiveKeys
+	changes  *changeNotifier
+	indexes  *indexRegistry
+	// state is shared by store commands created with the same shared state.
+	state     *SharedState
+	closeOnce sync.Once
 }
 
-// New returns new store controller command instance.
-func New(p Provider) (*Command, error) {
-	store, err := p.StorageProvider().OpenStore(CommandName)
+// New returns new store controller command instance. Commands created with the same shared state share
+// opened stores and a single sweeper deleting expired records, and their writes are serialized. The sweeper
+// is started by the first of the commands with its options, and stopped when all of them are closed. Error
+// codes of the command are registered in the error catalog, so that errors are named for any host.
+func New(p Provider, opts ...Opt) (*Command, error) {
+	if err := agentcmd.RegisterErrors(Errors()...); err != nil {
+		return nil, err
+	}
+
+	cmdOpts := &options{sweepInterval: DefaultSweepInterval, sweepBatchSize: DefaultSweepBatchSize}
+
+	for _, opt := range opts {
+		opt(cmdOpts)
+	}
+
+	store, err := p.StorageProvider().OpenStore(DefaultStoreName)
+	if err != nil {
+		return nil, err
+	}
+
+	indexesStore, err := p.StorageProvider().OpenStore(indexesStoreName)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Command{provider: p.StorageProvider(), store: store}, nil
+	namesStore, err := p.StorageProvider().OpenStore(storeNamesStoreName)
+	if err != nil {
+		return nil, err
+	}
+
+	if cmdOpts.sweepBatchSize <= 0 {
+		cmdOpts.sweepBatchSize = DefaultSweepBatchSize
+	}
+
+	state := cmdOpts.state
+	if state == nil {
+		state = NewSharedState()
+	}
+
+	state.acquire(p.StorageProvider(), indexesStore, namesStore, cmdOpts)
+	state.stores.add(DefaultStoreName, store)
+
+	return &Command{
+		provider: p.StorageProvider(),
+		stores:   newStores(state.stores, append([]string{DefaultStoreName}, cmdOpts.allowedStores...)),
+		notifier: cmdOpts.notifier,
+		keys:     cmdOpts.keys,
+		changes:  state.changes,
+		indexes:  state.indexes,
+		state:    state,
+	}, nil
+}
+
+// Close releases state shared with other store commands. The sweeper deleting
+// expired records is stopped when the last of the commands is closed.
+func (c *Command) Close() error {
+	c.closeOnce.Do(c.state.release)
+
+	return nil
 }
 
 // GetHandlers returns list of all commands supported by this controller command.
@@ -81,31 +272,175 @@ func (c *Command) GetHandlers() []command.Handler {
 		cmdutil.NewCommandHandler(CommandName, PutCommandMethod, c.Put),
 		cmdutil.NewCommandHandler(CommandName, GetCommandMethod, c.Get),
 		cmdutil.NewCommandHandler(CommandName, QueryCommandMethod, c.Query),
+		cmdutil.NewCommandHandler(CommandName, QueryRecordsCommandMethod, c.QueryRecords),
 		cmdutil.NewCommandHandler(CommandName, DeleteCommandMethod, c.Delete),
 		cmdutil.NewCommandHandler(CommandName, FlushCommandMethod, c.Flush),
+		cmdutil.NewCommandHandler(CommandName, ListStoresCommandMethod, c.ListStores),
+		cmdutil.NewCommandHandler(CommandName, GetTagsCommandMethod, c.GetTags),
+		cmdutil.NewCommandHandler(CommandName, GetBulkCommandMethod, c.GetBulk),
+		cmdutil.NewCommandHandler(CommandName, BatchCommandMethod, c.Batch),
+		cmdutil.NewCommandHandler(CommandName, IncrementCommandMethod, c.Increment),
+		cmdutil.NewCommandHandler(CommandName, SweeperStatsCommandMethod, c.SweeperStats),
+		cmdutil.NewCommandHandler(CommandName, ExportStoresCommandMethod, c.ExportStores),
+		cmdutil.NewCommandHandler(CommandName, ImportStoresCommandMethod, c.ImportStores),
+		cmdutil.NewCommandHandler(CommandName, SubscribeCommandMethod, c.Subscribe),
+		cmdutil.NewCommandHandler(CommandName, UnsubscribeCommandMethod, c.Unsubscribe),
+		cmdutil.NewCommandHandler(CommandName, ConfigureIndexesCommandMethod, c.ConfigureIndexes),
+		cmdutil.NewCommandHandler(CommandName, GetIndexesCommandMethod, c.GetIndexes),
+		cmdutil.NewCommandHandler(CommandName, RebuildIndexesCommandMethod, c.RebuildIndexes),
+	}
+}
+
+// Schemas returns JSON schemas of request and response models of store command methods.
+func Schemas() []agentcmd.MethodSchema {
+	return []agentcmd.MethodSchema{
+		agentcmd.NewMethodSchema(CommandName, PutCommandMethod, &PutRequest{}, &PutResponse{}),
+		agentcmd.NewMethodSchema(CommandName, GetCommandMethod, &GetRequest{}, &GetResponse{}),
+		agentcmd.NewMethodSchema(CommandName, QueryCommandMethod, &QueryRequest{}, &QueryResponse{}),
+		agentcmd.NewMethodSchema(CommandName, QueryRecordsCommandMethod, &QueryRequest{}, &QueryRecordsResponse{}),
+		agentcmd.NewMethodSchema(CommandName, DeleteCommandMethod, &DeleteRequest{}, nil),
+		agentcmd.NewMethodSchema(CommandName, FlushCommandMethod, nil, nil),
+		agentcmd.NewMethodSchema(CommandName, ListStoresCommandMethod, nil, &ListStoresResponse{}),
+		agentcmd.NewMethodSchema(CommandName, GetTagsCommandMethod, &GetTagsRequest{}, &GetTagsResponse{}),
+		agentcmd.NewMethodSchema(CommandName, GetBulkCommandMethod, &GetBulkRequest{}, &GetBulkResponse{}),
+		agentcmd.NewMethodSchema(CommandName, BatchCommandMethod, &BatchRequest{}, nil),
+		agentcmd.NewMethodSchema(CommandName, IncrementCommandMethod, &IncrementRequest{}, &IncrementResponse{}),
+		agentcmd.NewMethodSchema(CommandName, SweeperStatsCommandMethod, nil, &SweeperStatsResponse{}),
+		agentcmd.NewMethodSchema(CommandName, ExportStoresCommandMethod, &ExportStoresRequest{}, &ExportStoresResponse{}),
+		agentcmd.NewMethodSchema(CommandName, ImportStoresCommandMethod, &ImportStoresRequest{}, &ImportStoresResponse{}),
+		agentcmd.NewMethodSchema(CommandName, SubscribeCommandMethod, &SubscribeRequest{}, &SubscribeResponse{}),
+		agentcmd.NewMethodSchema(CommandName, UnsubscribeCommandMethod, &UnsubscribeRequest{}, nil),
+		agentcmd.NewMethodSchema(CommandName, ConfigureIndexesCommandMethod, &ConfigureIndexesRequest{}, nil),
+		agentcmd.NewMethodSchema(CommandName, GetIndexesCommandMethod, &GetIndexesRequest{}, &GetIndexesResponse{}),
+		agentcmd.NewMethodSchema(CommandName, RebuildIndexesCommandMethod, &RebuildIndexesRequest{},
+			&RebuildIndexesResponse{}),
+	}
+}
+
+// Errors returns definitions of store error codes for the error catalog.
+func Errors() []agentcmd.ErrorDefinition {
+	return []agentcmd.ErrorDefinition{
+		{
+			Code: InvalidRequestErrorCode, Name: "STORE_INVALID_REQUEST", Command: CommandName,
+			Description: "invalid request",
+		},
+		{Code: PutErrorCode, Name: "STORE_PUT_FAILED", Command: CommandName, Description: "failed to put record"},
+		{Code: GetErrorCode, Name: "STORE_GET_FAILED", Command: CommandName, Description: "failed to get record"},
+		{
+			Code: QueryErrorCode, Name: "STORE_QUERY_FAILED", Command: CommandName,
+			Description: "failed to query records",
+		},
+		{
+			Code: DeleteErrorCode, Name: "STORE_DELETE_FAILED", Command: CommandName,
+			Description: "failed to delete record",
+		},
+		{Code: FlushErrorCode, Name: "STORE_FLUSH_FAILED", Command: CommandName, Description: "failed to flush store"},
+		{
+			Code: StoreNotAllowedErrorCode, Name: "STORE_NOT_ALLOWED", Command: CommandName,
+			Description: "store does not match the allowlist",
+		},
+		{
+			Code: GetTagsErrorCode, Name: "STORE_GET_TAGS_FAILED", Command: CommandName,
+			Description: "failed to get tags of record",
+		},
+		{
+			Code: GetBulkErrorCode, Name: "STORE_GET_BULK_FAILED", Command: CommandName,
+			Description: "failed to get records",
+		},
+		{
+			Code: BatchErrorCode, Name: "STORE_BATCH_FAILED", Command: CommandName,
+			Description: "failed to perform batch operations",
+		},
+		{
+			Code: ConflictErrorCode, Name: "STORE_CONFLICT", Command: CommandName,
+			Description: "version of record does not match the expected one", Conflict: true,
+		},
+		{
+			Code: IncrementErrorCode, Name: "STORE_INCREMENT_FAILED", Command: CommandName,
+			Description: "failed to increment counter",
+		},
+		{
+			Code: ExportStoresErrorCode, Name: "STORE_EXPORT_FAILED", Command: CommandName,
+			Description: "failed to export stores",
+		},
+		{
+			Code: ImportStoresErrorCode, Name: "STORE_IMPORT_FAILED", Command: CommandName,
+			Description: "failed to import stores",
+		},
+		{
+			Code: InvalidArchiveErrorCode, Name: "STORE_INVALID_ARCHIVE", Command: CommandName,
+			Description: "archive cannot be decrypted or is malformed",
+		},
+		{
+			Code: SubscribeErrorCode, Name: "STORE_SUBSCRIBE_FAILED", Command: CommandName,
+			Description: "failed to subscribe to changes",
+		},
+		{
+			Code: UnsubscribeErrorCode, Name: "STORE_UNSUBSCRIBE_FAILED", Command: CommandName,
+			Description: "failed to unsubscribe from changes",
+		},
+		{
+			Code: ConfigureIndexesErrorCode, Name: "STORE_CONFIGURE_INDEXES_FAILED", Command: CommandName,
+			Description: "failed to configure indexes",
+		},
+		{
+			Code: GetIndexesErrorCode, Name: "STORE_GET_INDEXES_FAILED", Command: CommandName,
+			Description: "failed to get indexes",
+		},
+		{
+			Code: RebuildIndexesErrorCode, Name: "STORE_REBUILD_INDEXES_FAILED", Command: CommandName,
+			Description: "failed to rebuild indexes",
+		},
+		{
+			Code: ListStoresErrorCode, Name: "STORE_LIST_STORES_FAILED", Command: CommandName,
+			Description: "failed to list stores",
+		},
 	}
 }
 
-// Put stores the key, value and (optional) tags.
+// Put stores the key, value and (optional) tags. Records put with TTL expire after given number of seconds.
+// Tags of indexes configured for the store are derived from JSON value, replacing given tags of the same names.
 func (c *Command) Put(rw io.Writer, req io.Reader) command.Error {
 	var request PutRequest
 
-	err := json.NewDecoder(req).Decode(&request)
+	err := agentcmd.DecodeRequest(req, &request)
 	if err != nil {
-		logutil.LogError(logger, CommandName, PutCommandMethod, err.Error())
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
 
-		return command.NewValidationError(InvalidRequestErrorCode, err)
+	tags, err := withExpiry(request.Tags, request.TTL)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
 	}
 
-	if err = c.store.Put(request.Key, request.Value, request.Tags...); err != nil {
-		logutil.LogError(logger, CommandName, PutCommandMethod, err.Error())
+	store, cmdErr := c.openStore(request.StoreName, PutErrorCode)
+	if cmdErr != nil {
+		return cmdErr
+	}
 
-		return command.NewExecuteError(PutErrorCode, err)
+	c.state.writeMu.Lock()
+	defer c.state.writeMu.Unlock()
+
+	if request.IfMatch != "" {
+		if err = checkVersion(store, request.Key, request.IfMatch); err != nil {
+			return versionError(PutErrorCode, err)
+		}
 	}
 
-	command.WriteNillableResponse(rw, nil, logger)
+	tags, err = c.indexTags(storeNameOrDefault(request.StoreName), request.Value, tags)
+	if err != nil {
+		return agentcmd.NewExecuteError(PutErrorCode, err)
+	}
 
-	logutil.LogDebug(logger, CommandName, PutCommandMethod, successString)
+	if err = store.Put(request.Key, request.Value, tags...); err != nil {
+		return agentcmd.NewExecuteError(PutErrorCode, err)
+	}
+
+	c.changes.notify(storeNameOrDefault(request.StoreName), &change{
+		key: request.Key, operation: ChangeOperationPut, value: request.Value, tags: tags,
+	})
+
+	command.WriteNillableResponse(rw, &PutResponse{Version: recordVersion(request.Value)}, logger)
 
 	return nil
 }
@@ -114,92 +449,177 @@ func (c *Command) Put(rw io.Writer, req io.Reader) command.Error {
 func (c *Command) Get(rw io.Writer, req io.Reader) command.Error {
 	var request GetRequest
 
-	err := json.NewDecoder(req).Decode(&request)
+	err := agentcmd.DecodeRequest(req, &request)
 	if err != nil {
-		logutil.LogError(logger, CommandName, GetCommandMethod, err.Error())
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
 
-		return command.NewValidationError(InvalidRequestErrorCode, err)
+	store, cmdErr := c.openStore(request.StoreName, GetErrorCode)
+	if cmdErr != nil {
+		return cmdErr
 	}
 
-	result, err := c.store.Get(request.Key)
+	result, _, err := getRecord(store, request.Key)
 	if err != nil {
-		logutil.LogError(logger, CommandName, GetCommandMethod, err.Error())
-
-		return command.NewExecuteError(GetErrorCode, err)
+		return agentcmd.NewExecuteError(GetErrorCode, err)
 	}
 
 	command.WriteNillableResponse(rw, &GetResponse{
-		Result: result,
+		Result:  result,
+		Version: recordVersion(result),
 	}, logger)
 
-	logutil.LogDebug(logger, CommandName, GetCommandMethod, successString)
-
 	return nil
 }
 
-// Query retrieves data according to given expression.
+// Query retrieves values of records matching given expression. At most limit values are returned, along with
+// a cursor for retrieving the next ones if more records match. Values are written to the response one by one,
+// so that large results need not be held in memory; if reading records fails after some of them were written,
+// the response ends with the error instead of the cursor and the error is returned. Expired records and records
+// not passing the filter are skipped. The filter is evaluated on records matching the expression, which are all
+// read from storage, so that filtering by prefix or range of a tag reads all records carrying the tag.
 func (c *Command) Query(rw io.Writer, req io.Reader) command.Error {
+	return c.query(rw, req, resultsField)
+}
+
+// QueryRecords retrieves records matching given expression along with their keys, tags and versions, the same
+// way as Query retrieves their values.
+func (c *Command) QueryRecords(rw io.Writer, req io.Reader) command.Error {
+	return c.query(rw, req, recordsField)
+}
+
+// query writes records matching the query of given request to the response field with given name.
+func (c *Command) query(rw io.Writer, req io.Reader, field string) command.Error { //nolint: funlen
 	var request QueryRequest
 
-	err := json.NewDecoder(req).Decode(&request)
+	err := agentcmd.DecodeRequest(req, &request)
 	if err != nil {
-		logutil.LogError(logger, CommandName, QueryCommandMethod, err.Error())
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
 
-		return command.NewValidationError(InvalidRequestErrorCode, err)
+	err = validateQuery(&request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	scan, err := newQueryScan(&request, &queryResponseWriter{w: rw, field: field})
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
 	}
 
-	var options []storage.QueryOption
+	store, cmdErr := c.openStore(request.StoreName, QueryErrorCode)
+	if cmdErr != nil {
+		return cmdErr
+	}
 
-	if request.PageSize > 0 {
-		options = append(options, storage.WithPageSize(request.PageSize))
+	if request.Filter != nil {
+		scan.indexed, err = c.isIndex(storeNameOrDefault(request.StoreName), request.Filter.TagName)
+		if err != nil {
+			return agentcmd.NewExecuteError(QueryErrorCode, err)
+		}
 	}
 
-	records, err := c.store.Query(request.Expression, options...)
+	iterator, err := store.Query(request.Expression, queryOptions(&request)...)
 	if err != nil {
-		logutil.LogError(logger, CommandName, QueryCommandMethod, err.Error())
-
-		return command.NewExecuteError(QueryErrorCode, err)
+		return agentcmd.NewExecuteError(QueryErrorCode, err)
 	}
 
 	defer func() {
-		errClose := records.Close()
+		errClose := iterator.Close()
 		if errClose != nil {
-			logutil.LogError(logger, CommandName, QueryCommandMethod, errClose.Error())
+			logger.Warnf("failed to close iterator: %s", errClose)
 		}
 	}()
 
-	values, cmdErr := getValuesFromIterator(records)
-	if cmdErr != nil {
-		return cmdErr
+	err = scan.run(iterator)
+	if err != nil {
+		return queryError(scan.writer, err)
+	}
+
+	var totalItems *int
+
+	if request.WithTotal {
+		totalItems = &scan.total
 	}
 
-	command.WriteNillableResponse(rw, &QueryResponse{Results: values}, logger)
+	next, err := scan.nextCursor()
+	if err != nil {
+		return queryError(scan.writer, err)
+	}
 
-	logutil.LogDebug(logger, CommandName, QueryCommandMethod, successString)
+	if err = scan.writer.close(next, totalItems); err != nil {
+		return agentcmd.NewExecuteError(QueryErrorCode, err)
+	}
 
 	return nil
 }
 
+// validateQuery validates query request and defaults its expression.
+func validateQuery(request *QueryRequest) error {
+	if request.Limit < 0 {
+		return errInvalidLimit
+	}
+
+	if request.Filter != nil {
+		if request.Filter.TagName == "" {
+			return errEmptyFilterTag
+		}
+
+		if request.Expression == "" {
+			request.Expression = request.Filter.TagName
+		}
+	}
+
+	return nil
+}
+
+// queryError returns error of a query. If records were written already, the response is ended with the error
+// first, so that it stays valid JSON for clients which receive the response as it is written.
+func queryError(writer *queryResponseWriter, err error) command.Error {
+	if errors.Is(err, errInvalidCursor) {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if writer.count > 0 {
+		// ending the response is best effort, the returned error reports the failure either way.
+		_ = writer.abort(err) //nolint:errcheck
+	}
+
+	return agentcmd.NewExecuteError(QueryErrorCode, err)
+}
+
 // Delete deletes a record with a given key.
 func (c *Command) Delete(rw io.Writer, req io.Reader) command.Error {
 	var request DeleteRequest
 
-	err := json.NewDecoder(req).Decode(&request)
+	err := agentcmd.DecodeRequest(req, &request)
 	if err != nil {
-		logutil.LogError(logger, CommandName, DeleteCommandMethod, err.Error())
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
 
-		return command.NewValidationError(InvalidRequestErrorCode, err)
+	store, cmdErr := c.openStore(request.StoreName, DeleteErrorCode)
+	if cmdErr != nil {
+		return cmdErr
 	}
 
-	if err = c.store.Delete(request.Key); err != nil {
-		logutil.LogError(logger, CommandName, DeleteCommandMethod, err.Error())
+	c.state.writeMu.Lock()
+	defer c.state.writeMu.Unlock()
 
-		return command.NewExecuteError(DeleteErrorCode, err)
+	if request.IfMatch != "" {
+		if err = checkVersion(store, request.Key, request.IfMatch); err != nil {
+			return versionError(DeleteErrorCode, err)
+		}
 	}
 
-	command.WriteNillableResponse(rw, nil, logger)
+	deleted := c.changes.deleted(store, storeNameOrDefault(request.StoreName), request.Key)
+
+	if err = store.Delete(request.Key); err != nil {
+		return agentcmd.NewExecuteError(DeleteErrorCode, err)
+	}
+
+	c.changes.notify(storeNameOrDefault(request.StoreName), deleted)
 
-	logutil.LogDebug(logger, CommandName, DeleteCommandMethod, successString)
+	command.WriteNillableResponse(rw, nil, logger)
 
 	return nil
 }
@@ -211,48 +631,498 @@ func (c *Command) Flush(rw io.Writer, _ io.Reader) command.Error {
 	for _, openStore := range openStores {
 		err := openStore.Flush()
 		if err != nil {
-			logutil.LogError(logger, CommandName, FlushCommandMethod, err.Error())
-
-			return command.NewExecuteError(FlushErrorCode, err)
+			return agentcmd.NewExecuteError(FlushErrorCode, err)
 		}
 	}
 
 	command.WriteNillableResponse(rw, &GetResponse{}, logger)
 
-	logutil.LogDebug(logger, CommandName, FlushCommandMethod, successString)
+	return nil
+}
+
+// GetTags fetches tags of the record based on key.
+func (c *Command) GetTags(rw io.Writer, req io.Reader) command.Error {
+	var request GetTagsRequest
+
+	err := agentcmd.DecodeRequest(req, &request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	store, cmdErr := c.openStore(request.StoreName, GetTagsErrorCode)
+	if cmdErr != nil {
+		return cmdErr
+	}
+
+	tags, err := getTags(store, request.Key)
+	if err != nil {
+		return agentcmd.NewExecuteError(GetTagsErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, &GetTagsResponse{Tags: tags}, logger)
+
+	return nil
+}
+
+// GetBulk fetches records based on keys, results are in the same order as keys. Missing and expired
+// records are returned as nil.
+func (c *Command) GetBulk(rw io.Writer, req io.Reader) command.Error {
+	var request GetBulkRequest
+
+	err := agentcmd.DecodeRequest(req, &request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if len(request.Keys) == 0 {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, errEmptyKeys)
+	}
+
+	store, cmdErr := c.openStore(request.StoreName, GetBulkErrorCode)
+	if cmdErr != nil {
+		return cmdErr
+	}
+
+	results, err := store.GetBulk(request.Keys...)
+	if err == nil {
+		err = hideExpired(store, request.Keys, results)
+	}
+
+	if err != nil {
+		return agentcmd.NewExecuteError(GetBulkErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, &GetBulkResponse{Results: results}, logger)
+
+	return nil
+}
+
+// Batch performs put and delete operations atomically. Tags of indexes configured for the store are derived
+// from JSON values of put records.
+func (c *Command) Batch(rw io.Writer, req io.Reader) command.Error {
+	var request BatchRequest
+
+	err := agentcmd.DecodeRequest(req, &request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if len(request.Operations) == 0 {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, errEmptyOperations)
+	}
+
+	operations := make([]storage.Operation, len(request.Operations))
+
+	for i, op := range request.Operations {
+		if op.Key == "" {
+			return agentcmd.NewValidationError(InvalidRequestErrorCode, errEmptyOperationKey)
+		}
+
+		tags, e := withExpiry(op.Tags, op.TTL)
+		if e != nil {
+			return agentcmd.NewValidationError(InvalidRequestErrorCode, e)
+		}
+
+		operations[i] = storage.Operation{Key: op.Key, Value: op.Value, Tags: tags}
+	}
+
+	store, cmdErr := c.openStore(request.StoreName, BatchErrorCode)
+	if cmdErr != nil {
+		return cmdErr
+	}
+
+	c.state.writeMu.Lock()
+	defer c.state.writeMu.Unlock()
+
+	if err = c.indexOperations(storeNameOrDefault(request.StoreName), operations); err != nil {
+		return agentcmd.NewExecuteError(BatchErrorCode, err)
+	}
+
+	changes := c.changes.batch(store, storeNameOrDefault(request.StoreName), operations)
+
+	if err = store.Batch(operations); err != nil {
+		return agentcmd.NewExecuteError(BatchErrorCode, err)
+	}
+
+	c.changes.notify(storeNameOrDefault(request.StoreName), changes...)
+
+	command.WriteNillableResponse(rw, nil, logger)
+
+	return nil
+}
+
+// Increment atomically adds delta to the integer counter stored in the record with given key.
+func (c *Command) Increment(rw io.Writer, req io.Reader) command.Error {
+	var request IncrementRequest
+
+	err := agentcmd.DecodeRequest(req, &request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if request.Key == "" {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, errEmptyKey)
+	}
+
+	store, cmdErr := c.openStore(request.StoreName, IncrementErrorCode)
+	if cmdErr != nil {
+		return cmdErr
+	}
+
+	c.state.writeMu.Lock()
+	defer c.state.writeMu.Unlock()
+
+	counter, tags, err := increment(store, request.Key, request.Delta)
+	if err != nil {
+		return agentcmd.NewExecuteError(IncrementErrorCode, err)
+	}
+
+	value := []byte(strconv.FormatInt(counter, 10))
+
+	c.changes.notify(storeNameOrDefault(request.StoreName), &change{
+		key: request.Key, operation: ChangeOperationPut, value: value, tags: tags,
+	})
+
+	command.WriteNillableResponse(rw, &IncrementResponse{
+		Value:   counter,
+		Version: recordVersion(value),
+	}, logger)
+
+	return nil
+}
+
+// ListStores lists allowed stores ever opened through store commands of the storage provider, including those
+// opened before restarts, and names of stores allowed to be opened.
+func (c *Command) ListStores(rw io.Writer, _ io.Reader) command.Error {
+	names, err := c.stores.names()
+	if err != nil {
+		return agentcmd.NewExecuteError(ListStoresErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, &ListStoresResponse{
+		Stores:  names,
+		Allowed: c.stores.allowed,
+	}, logger)
+
+	return nil
+}
+
+// SweeperStats returns statistics of the sweeper deleting expired records.
+func (c *Command) SweeperStats(rw io.Writer, _ io.Reader) command.Error {
+	command.WriteNillableResponse(rw, c.state.sweeper.getStats(), logger)
+
+	return nil
+}
+
+// ExportStores exports records and configurations of given stores into an archive encrypted as a JWE,
+// to a passphrase, a public key or a key of the key manager. Records written through the store command are
+// exported, found by their internal record tag. Records written to the storage otherwise are only exported if
+// they have tags from the store configuration, index tags or any of requested tags, as storage does not support
+// listing all records. Numbers of exported records are returned, so that missing records can be noticed.
+func (c *Command) ExportStores(rw io.Writer, req io.Reader) command.Error {
+	var request ExportStoresRequest
+
+	err := agentcmd.DecodeRequest(req, &request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if len(request.StoreNames) == 0 {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, errEmptyStoreNames)
+	}
+
+	secret, cmdErr := c.archiveSecret(request.Passphrase, request.Key, request.KeyID)
+	if cmdErr != nil {
+		return cmdErr
+	}
+
+	archive := &storeArchive{Version: archiveVersion, CreatedAt: time.Now().UTC()}
+	response := &ExportStoresResponse{Stores: []ExportedStore{}}
+
+	for _, name := range request.StoreNames {
+		name = storeNameOrDefault(name)
+
+		store, openErr := c.openStore(name, ExportStoresErrorCode)
+		if openErr != nil {
+			return openErr
+		}
+
+		archived, e := c.exportStore(store, name, request.TagNames)
+		if e != nil {
+			return agentcmd.NewExecuteError(ExportStoresErrorCode, e)
+		}
+
+		archive.Stores = append(archive.Stores, *archived)
+		response.Stores = append(response.Stores, ExportedStore{Name: name, Records: len(archived.Records)})
+	}
+
+	encrypted, err := encryptArchive(archive, secret, c.keys)
+	if err != nil {
+		return agentcmd.NewExecuteError(ExportStoresErrorCode, err)
+	}
+
+	response.Archive = encrypted
+
+	command.WriteNillableResponse(rw, response, logger)
 
 	return nil
 }
 
-func getValuesFromIterator(iterator storage.Iterator) ([][]byte, command.Error) {
-	var values [][]byte
+// ImportStores imports records and configurations of stores from an archive created by ExportStores.
+// The archive is decrypted and verified and writes to all stores are prepared before anything is written.
+// Records of each store are written in a single batch; if writing a store fails, stores imported before it
+// are restored to their previous state. Index tags of imported records are derived by indexes of the stores.
+func (c *Command) ImportStores(rw io.Writer, req io.Reader) command.Error { //nolint: funlen,gocyclo
+	var request ImportStoresRequest
 
-	moreRecords, err := iterator.Next()
+	err := agentcmd.DecodeRequest(req, &request)
 	if err != nil {
-		logutil.LogError(logger, CommandName, QueryCommandMethod, err.Error())
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
 
-		return nil, command.NewExecuteError(QueryErrorCode, err)
+	if request.Mode == "" {
+		request.Mode = ImportModeMerge
 	}
 
-	for moreRecords {
-		value, errValue := iterator.Value()
-		if errValue != nil {
-			logutil.LogError(logger, CommandName, QueryCommandMethod, errValue.Error())
+	if request.Mode != ImportModeMerge && request.Mode != ImportModeReplace {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, errInvalidImportMode)
+	}
 
-			return nil, command.NewExecuteError(QueryErrorCode, errValue)
+	secret, cmdErr := c.archiveSecret(request.Passphrase, request.Key, request.KeyID)
+	if cmdErr != nil {
+		return cmdErr
+	}
+
+	archive, err := decryptArchive(request.Archive, secret, c.keys)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidArchiveErrorCode, err)
+	}
+
+	targets := make([]storage.Store, len(archive.Stores))
+
+	for i := range archive.Stores {
+		store, openErr := c.openStore(archive.Stores[i].Name, ImportStoresErrorCode)
+		if openErr != nil {
+			return openErr
+		}
+
+		targets[i] = store
+	}
+
+	c.state.writeMu.Lock()
+	defer c.state.writeMu.Unlock()
+
+	imports := make([]*storeImport, len(archive.Stores))
+
+	for i := range archive.Stores {
+		imports[i], err = c.prepareImport(targets[i], &archive.Stores[i], request.Mode)
+		if err != nil {
+			return agentcmd.NewExecuteError(ImportStoresErrorCode, err)
+		}
+	}
+
+	for i := range imports {
+		if err = imports[i].apply(c.provider); err != nil {
+			for j := i; j >= 0; j-- {
+				imports[j].rollback(c.provider)
+			}
+
+			return agentcmd.NewExecuteError(ImportStoresErrorCode, err)
 		}
+	}
+
+	response := &ImportStoresResponse{Stores: []ImportedStore{}}
+
+	for i, imp := range imports {
+		c.changes.notify(imp.name, imp.changes...)
+
+		response.Stores = append(response.Stores, ImportedStore{
+			Name:    imp.name,
+			Records: len(archive.Stores[i].Records),
+		})
+	}
+
+	command.WriteNillableResponse(rw, response, logger)
+
+	return nil
+}
+
+// archiveSecret returns secret of an export or import request, exactly one of given secrets must be set.
+func (c *Command) archiveSecret(passphrase string, key json.RawMessage, keyID string) (*archiveSecret,
+	command.Error) {
+	secret := &archiveSecret{passphrase: passphrase, key: key, keyID: keyID}
+
+	if err := secret.validate(); err != nil {
+		return nil, agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if keyID != "" && c.keys == nil {
+		return nil, agentcmd.NewValidationError(InvalidRequestErrorCode, errNoKeyManager)
+	}
+
+	return secret, nil
+}
+
+// Subscribe subscribes to changes of records in the store, optionally only of records with tags matching
+// given expression. Changes are published on the ChangeTopic of the store as ChangeEvent messages carrying
+// ID of the subscription.
+func (c *Command) Subscribe(rw io.Writer, req io.Reader) command.Error {
+	var request SubscribeRequest
+
+	err := agentcmd.DecodeRequest(req, &request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if c.notifier == nil {
+		return agentcmd.NewExecuteError(SubscribeErrorCode, errNotificationsDisabled)
+	}
+
+	if _, cmdErr := c.openStore(request.StoreName, SubscribeErrorCode); cmdErr != nil {
+		return cmdErr
+	}
+
+	sub, err := newSubscription(c.notifier, storeNameOrDefault(request.StoreName), request.Expression,
+		request.IncludeValue)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	c.changes.subscriptions.add(sub)
+
+	command.WriteNillableResponse(rw, &SubscribeResponse{ID: sub.id, Topic: ChangeTopic(sub.storeName)}, logger)
+
+	return nil
+}
+
+// Unsubscribe cancels subscription to changes with given ID.
+func (c *Command) Unsubscribe(rw io.Writer, req io.Reader) command.Error {
+	var request UnsubscribeRequest
+
+	err := agentcmd.DecodeRequest(req, &request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if err = c.changes.subscriptions.remove(request.ID); err != nil {
+		return agentcmd.NewExecuteError(UnsubscribeErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, nil, logger)
+
+	return nil
+}
+
+// ConfigureIndexes replaces index definitions of the store. Indexes apply to records written afterwards,
+// RebuildIndexes updates tags of existing records.
+func (c *Command) ConfigureIndexes(rw io.Writer, req io.Reader) command.Error {
+	var request ConfigureIndexesRequest
+
+	err := agentcmd.DecodeRequest(req, &request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if _, err = (&storeIndexes{Indexes: request.Indexes}).compile(); err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	_, cmdErr := c.openStore(request.StoreName, ConfigureIndexesErrorCode)
+	if cmdErr != nil {
+		return cmdErr
+	}
+
+	c.state.writeMu.Lock()
+	defer c.state.writeMu.Unlock()
+
+	if err = c.indexes.set(storeNameOrDefault(request.StoreName), request.Indexes); err != nil {
+		return agentcmd.NewExecuteError(ConfigureIndexesErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, nil, logger)
 
-		values = append(values, value)
+	return nil
+}
+
+// GetIndexes returns index definitions of the store.
+func (c *Command) GetIndexes(rw io.Writer, req io.Reader) command.Error {
+	var request GetIndexesRequest
 
-		var errNext error
+	err := agentcmd.DecodeRequest(req, &request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
 
-		moreRecords, errNext = iterator.Next()
-		if errNext != nil {
-			logutil.LogError(logger, CommandName, QueryCommandMethod, errNext.Error())
+	_, cmdErr := c.openStore(request.StoreName, GetIndexesErrorCode)
+	if cmdErr != nil {
+		return cmdErr
+	}
+
+	definitions, _, err := c.indexes.get(storeNameOrDefault(request.StoreName))
+	if err != nil {
+		return agentcmd.NewExecuteError(GetIndexesErrorCode, err)
+	}
+
+	response := &GetIndexesResponse{Indexes: definitions.Indexes, Stale: definitions.Stale}
+	if response.Indexes == nil {
+		response.Indexes = []IndexDefinition{}
+	}
+
+	command.WriteNillableResponse(rw, response, logger)
+
+	return nil
+}
 
-			return nil, command.NewExecuteError(QueryErrorCode, errNext)
+// RebuildIndexes updates index tags of existing records of the store in a single batch, removing tags
+// of removed indexes. Records written through the store command are found by their internal record tag;
+// records written to the storage otherwise are only found by tags from the store configuration, index tags
+// or any of requested tags, as storage does not support listing all records.
+func (c *Command) RebuildIndexes(rw io.Writer, req io.Reader) command.Error {
+	var request RebuildIndexesRequest
+
+	err := agentcmd.DecodeRequest(req, &request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	store, cmdErr := c.openStore(request.StoreName, RebuildIndexesErrorCode)
+	if cmdErr != nil {
+		return cmdErr
+	}
+
+	c.state.writeMu.Lock()
+	defer c.state.writeMu.Unlock()
+
+	updated, err := c.rebuildIndexes(store, storeNameOrDefault(request.StoreName), request.TagNames)
+	if err != nil {
+		return agentcmd.NewExecuteError(RebuildIndexesErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, &RebuildIndexesResponse{Updated: updated}, logger)
+
+	return nil
+}
+
+// versionError returns conflict error for records whose version does not match, or error with given code otherwise.
+func versionError(errCode command.Code, err error) command.Error {
+	if errors.Is(err, errVersionConflict) {
+		return agentcmd.NewExecuteError(ConflictErrorCode, err)
+	}
+
+	return agentcmd.NewExecuteError(errCode, err)
+}
+
+// openStore returns store with given name, errors are reported with given error code
+// unless the store is not allowed.
+func (c *Command) openStore(name string, errCode command.Code) (storage.Store, command.Error) {
+	store, err := c.stores.get(name)
+	if err != nil {
+		if errors.Is(err, errStoreNotAllowed) {
+			return nil, agentcmd.NewValidationError(StoreNotAllowedErrorCode, err)
 		}
+
+		return nil, agentcmd.NewExecuteError(errCode, err)
 	}
 
-	return values, nil
+	return store, nil
 }
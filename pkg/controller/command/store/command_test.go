@@ -9,15 +9,32 @@ package store_test
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
 	storeutil "github.com/hyperledger/aries-framework-go/component/storageutil/mock"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	cryptoapi "github.com/hyperledger/aries-framework-go/pkg/crypto"
+	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/localkms"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock/noop"
 	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/square/go-jose/v3"
 	"github.com/stretchr/testify/require"
 
+	agentcmd "github.com/trustbloc/agent-sdk/pkg/controller/command"
 	. "github.com/trustbloc/agent-sdk/pkg/controller/command/store"
 	"github.com/trustbloc/agent-sdk/pkg/controller/internal/mocks"
 	"github.com/trustbloc/agent-sdk/pkg/controller/internal/mocks/protocol"
@@ -33,6 +50,10 @@ func TestNew(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, cmd)
 
+	definition, ok := agentcmd.LookupError(ConflictErrorCode)
+	require.True(t, ok)
+	require.Equal(t, "STORE_CONFLICT", definition.Name)
+
 	storeProvider.ErrOpenStoreHandle = errors.New("error")
 
 	cmd, err = New(mock)
@@ -45,7 +66,7 @@ func TestCommand_GetHandlers(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, cmd)
 
-	require.Len(t, cmd.GetHandlers(), 5)
+	require.Len(t, cmd.GetHandlers(), 19)
 }
 
 func TestCommand_Put(t *testing.T) {
@@ -69,6 +90,17 @@ func TestCommand_Put(t *testing.T) {
 		require.EqualError(t, cmd.Put(res, bytes.NewBufferString(``)), io.EOF.Error())
 	})
 
+	t.Run("Request not matching schema", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mocks.NewMockStoreProvider()})
+		require.NoError(t, err)
+
+		cmdErr := cmd.Put(&bytes.Buffer{}, bytes.NewBufferString(`{"key":"key","valu":"dmFsdWU=","ttl":"1"}`))
+		require.EqualError(t, cmdErr,
+			"request does not match schema: $.ttl: expected integer; $.valu: unknown field")
+		require.Equal(t, command.ValidationError, cmdErr.Type())
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+	})
+
 	t.Run("Success", func(t *testing.T) {
 		storeProvider := mocks.NewMockStoreProvider()
 		storeProvider.Store = &mocks.MockStore{Store: map[string][]byte{}}
@@ -191,6 +223,33 @@ func TestCommand_Query(t *testing.T) {
 
 		require.Len(t, resp.Results, 1)
 		require.Equal(t, "Value", string(resp.Results[0]))
+		require.Empty(t, resp.Cursor)
+		require.Nil(t, resp.TotalItems)
+	})
+
+	t.Run("Success with records", func(t *testing.T) {
+		storeProvider := mocks.NewMockStoreProvider()
+		storeProvider.Store = &mocks.MockStore{QueryReturnItr: &mocks.MockIterator{MoreResults: true}}
+
+		cmd, err := New(&protocol.MockProvider{StoreProvider: storeProvider})
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+
+		res := &bytes.Buffer{}
+
+		req, err := json.Marshal(QueryRequest{Expression: "expression", PageSize: 5})
+		require.NoError(t, err)
+		require.NoError(t, cmd.QueryRecords(res, bytes.NewBuffer(req)))
+
+		var resp *QueryRecordsResponse
+		require.NoError(t, json.Unmarshal(res.Bytes(), &resp))
+
+		require.Len(t, resp.Records, 1)
+		require.Equal(t, "Key", resp.Records[0].Key)
+		require.Equal(t, "Value", string(resp.Records[0].Value))
+		require.Equal(t, []storage.Tag{{Name: "Tag"}}, resp.Records[0].Tags)
+		require.Empty(t, resp.Cursor)
+		require.Nil(t, resp.TotalItems)
 	})
 
 	t.Run("pageSize 0", func(t *testing.T) {
@@ -222,6 +281,504 @@ func TestCommand_Query(t *testing.T) {
 
 		require.Len(t, resp.Results, 1)
 		require.Equal(t, "Value", string(resp.Results[0]))
+		require.Empty(t, resp.Cursor)
+		require.Nil(t, resp.TotalItems)
+	})
+}
+
+func TestCommand_QueryPagination(t *testing.T) {
+	newCommand := func(t *testing.T, count int) *Command {
+		t.Helper()
+
+		cmd, err := New(&protocol.MockProvider{StoreProvider: &storeutil.Provider{
+			OpenStoreReturn: &mockStore{
+				queryFunc: func(string, ...storage.QueryOption) (storage.Iterator, error) {
+					return newMockIterator(count), nil
+				},
+			},
+		}})
+		require.NoError(t, err)
+
+		return cmd
+	}
+
+	query := func(t *testing.T, cmd *Command, request *QueryRequest) *QueryRecordsResponse {
+		t.Helper()
+
+		req, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		res := &bytes.Buffer{}
+		require.NoError(t, cmd.QueryRecords(res, bytes.NewBuffer(req)))
+
+		var resp QueryRecordsResponse
+		require.NoError(t, json.Unmarshal(res.Bytes(), &resp))
+
+		return &resp
+	}
+
+	t.Run("Pages through all records", func(t *testing.T) {
+		cmd := newCommand(t, 5)
+
+		request := &QueryRequest{Expression: "tag", Limit: 2, WithTotal: true}
+
+		var keys []string
+
+		for page := 0; ; page++ {
+			require.Less(t, page, 3)
+
+			resp := query(t, cmd, request)
+			require.NotNil(t, resp.TotalItems)
+			require.Equal(t, 5, *resp.TotalItems)
+
+			for _, record := range resp.Records {
+				keys = append(keys, record.Key)
+			}
+
+			if resp.Cursor == "" {
+				require.Len(t, resp.Records, 1)
+
+				break
+			}
+
+			require.Len(t, resp.Records, 2)
+
+			request.Cursor = resp.Cursor
+		}
+
+		require.Equal(t, []string{"key0", "key1", "key2", "key3", "key4"}, keys)
+	})
+
+	t.Run("Limit matching all records", func(t *testing.T) {
+		resp := query(t, newCommand(t, 2), &QueryRequest{Expression: "tag", Limit: 2})
+		require.Len(t, resp.Records, 2)
+		require.Empty(t, resp.Cursor)
+	})
+
+	t.Run("No records", func(t *testing.T) {
+		resp := query(t, newCommand(t, 0), &QueryRequest{Expression: "tag", Limit: 2})
+		require.NotNil(t, resp.Records)
+		require.Empty(t, resp.Records)
+		require.Empty(t, resp.Cursor)
+	})
+
+	t.Run("Negative limit", func(t *testing.T) {
+		cmdErr := newCommand(t, 1).QueryRecords(&bytes.Buffer{}, bytes.NewBufferString(`{"expression":"tag","limit":-1}`))
+		require.EqualError(t, cmdErr, "limit must not be negative")
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		cmd := newCommand(t, 5)
+
+		cmdErr := cmd.QueryRecords(&bytes.Buffer{}, bytes.NewBufferString(`{"expression":"tag","cursor":"%%%"}`))
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), "invalid cursor")
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+
+		cmdErr = cmd.QueryRecords(&bytes.Buffer{}, bytes.NewBufferString(`{"expression":"tag","cursor":"bm90LWpzb24"}`))
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), "invalid cursor")
+
+		resp := query(t, cmd, &QueryRequest{Expression: "tag", Limit: 2})
+		require.NotEmpty(t, resp.Cursor)
+
+		req, err := json.Marshal(&QueryRequest{Expression: "other", Cursor: resp.Cursor})
+		require.NoError(t, err)
+
+		cmdErr = cmd.QueryRecords(&bytes.Buffer{}, bytes.NewBuffer(req))
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), "cursor does not belong to the query")
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Sorting options", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: &storeutil.Provider{
+			OpenStoreReturn: &mockStore{
+				queryFunc: func(_ string, options ...storage.QueryOption) (storage.Iterator, error) {
+					queryOptions := &storage.QueryOptions{}
+
+					for _, option := range options {
+						option(queryOptions)
+					}
+
+					require.Equal(t, 10, queryOptions.PageSize)
+					require.Equal(t, &storage.SortOptions{Order: storage.SortDescending, TagName: "created"},
+						queryOptions.SortOptions)
+
+					return newMockIterator(1), nil
+				},
+			},
+		}})
+		require.NoError(t, err)
+
+		resp := query(t, cmd, &QueryRequest{
+			Expression: "tag", PageSize: 10, SortTagName: "created", SortDescending: true,
+		})
+		require.Len(t, resp.Records, 1)
+	})
+
+	t.Run("Total items count records matching filter", func(t *testing.T) {
+		records := numberedRecords("a", "b", "c", "d")
+
+		resp := query(t, newRecordsCommand(t, &records), &QueryRequest{
+			Filter: &QueryFilter{TagName: "n", Min: "2"}, Limit: 1, WithTotal: true,
+		})
+		require.Equal(t, []string{"b"}, recordKeys(resp))
+		require.NotEmpty(t, resp.Cursor)
+		require.Equal(t, 3, *resp.TotalItems)
+
+		resp = query(t, newRecordsCommand(t, &records), &QueryRequest{
+			Filter: &QueryFilter{TagName: "n", Max: "2"}, Limit: 2,
+		})
+		require.Equal(t, []string{"a", "b"}, recordKeys(resp))
+		require.Empty(t, resp.Cursor)
+	})
+
+	t.Run("Cursor points to last record", func(t *testing.T) {
+		records := numberedRecords("a", "b", "c", "d")
+		cmd := newRecordsCommand(t, &records)
+
+		request := &QueryRequest{Expression: "n", SortTagName: "n", Limit: 2}
+
+		resp := query(t, cmd, request)
+		require.Equal(t, []string{"a", "b"}, recordKeys(resp))
+
+		// records before the cursor are deleted, including the record it points to, and added
+		records = []testRecord{
+			{key: "x", tags: []storage.Tag{{Name: "n", Value: "1.5"}}}, records[2], records[3],
+		}
+
+		request.Cursor = resp.Cursor
+
+		resp = query(t, cmd, request)
+		require.Equal(t, []string{"c", "d"}, recordKeys(resp))
+		require.Empty(t, resp.Cursor)
+
+		unsorted := &QueryRequest{Expression: "n", Limit: 1}
+
+		resp = query(t, cmd, unsorted)
+		require.Equal(t, []string{"x"}, recordKeys(resp))
+
+		records = records[1:]
+		unsorted.Cursor = resp.Cursor
+
+		req, err := json.Marshal(unsorted)
+		require.NoError(t, err)
+
+		cmdErr := cmd.QueryRecords(&bytes.Buffer{}, bytes.NewBuffer(req))
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), "record the cursor points to no longer matches the query")
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Failure after records were written", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: &storeutil.Provider{
+			OpenStoreReturn: &mockStore{
+				queryFunc: func(string, ...storage.QueryOption) (storage.Iterator, error) {
+					iterator := newMockIterator(3)
+					iterator.errTags = errors.New("tags failure")
+					iterator.errTagsFrom = 1
+
+					return iterator, nil
+				},
+			},
+		}})
+		require.NoError(t, err)
+
+		res := &bytes.Buffer{}
+		cmdErr := cmd.QueryRecords(res, bytes.NewBufferString(`{"expression":"tag"}`))
+		require.EqualError(t, cmdErr, "tags failure")
+		require.Equal(t, QueryErrorCode, cmdErr.Code())
+
+		// response written so far stays valid
+		var resp QueryRecordsResponse
+		require.NoError(t, json.Unmarshal(res.Bytes(), &resp))
+		require.Equal(t, []string{"key0"}, recordKeys(&resp))
+		require.Equal(t, "tags failure", resp.Error)
+
+		res = &bytes.Buffer{}
+		cmdErr = cmd.Query(res, bytes.NewBufferString(`{"expression":"tag"}`))
+		require.EqualError(t, cmdErr, "tags failure")
+		require.JSONEq(t, `{"results":["dmFsdWUw"],"error":"tags failure"}`, res.String())
+	})
+
+	t.Run("Failure while reading record", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: &storeutil.Provider{
+			OpenStoreReturn: &mockStore{
+				queryFunc: func(string, ...storage.QueryOption) (storage.Iterator, error) {
+					iterator := newMockIterator(2)
+					iterator.errTags = errors.New("tags failure")
+
+					return iterator, nil
+				},
+			},
+		}})
+		require.NoError(t, err)
+
+		cmdErr := cmd.QueryRecords(&bytes.Buffer{}, bytes.NewBufferString(`{"expression":"tag"}`))
+		require.EqualError(t, cmdErr, "tags failure")
+		require.Equal(t, QueryErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Failure while skipping records", func(t *testing.T) {
+		cmd := newCommand(t, 5)
+
+		resp := query(t, cmd, &QueryRequest{Expression: "tag", Limit: 2})
+
+		cmd, err := New(&protocol.MockProvider{StoreProvider: &storeutil.Provider{
+			OpenStoreReturn: &mockStore{
+				queryFunc: func(string, ...storage.QueryOption) (storage.Iterator, error) {
+					return &mocks.MockIterator{MoreResults: true, ErrNext: errors.New("next failure")}, nil
+				},
+			},
+		}})
+		require.NoError(t, err)
+
+		req, err := json.Marshal(&QueryRequest{Expression: "tag", Limit: 2, Cursor: resp.Cursor})
+		require.NoError(t, err)
+
+		cmdErr := cmd.QueryRecords(&bytes.Buffer{}, bytes.NewBuffer(req))
+		require.EqualError(t, cmdErr, "next failure")
+		require.Equal(t, QueryErrorCode, cmdErr.Code())
+	})
+}
+
+func TestCommand_ConditionalWrites(t *testing.T) {
+	put := func(t *testing.T, cmd *Command, request *PutRequest) (string, command.Error) {
+		t.Helper()
+
+		req, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		res := &bytes.Buffer{}
+
+		cmdErr := cmd.Put(res, bytes.NewBuffer(req))
+		if cmdErr != nil {
+			return "", cmdErr
+		}
+
+		var resp PutResponse
+		require.NoError(t, json.Unmarshal(res.Bytes(), &resp))
+
+		return resp.Version, nil
+	}
+
+	t.Run("Put and delete with matching version", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		version, cmdErr := put(t, cmd, &PutRequest{Key: "key", Value: []byte("value1")})
+		require.NoError(t, cmdErr)
+		require.NotEmpty(t, version)
+
+		res := &bytes.Buffer{}
+		require.NoError(t, cmd.Get(res, bytes.NewBufferString(`{"key":"key"}`)))
+
+		var getResp GetResponse
+		require.NoError(t, json.Unmarshal(res.Bytes(), &getResp))
+		require.Equal(t, version, getResp.Version)
+
+		newVersion, cmdErr := put(t, cmd, &PutRequest{Key: "key", Value: []byte("value2"), IfMatch: version})
+		require.NoError(t, cmdErr)
+		require.NotEqual(t, version, newVersion)
+
+		req, err := json.Marshal(DeleteRequest{Key: "key", IfMatch: newVersion})
+		require.NoError(t, err)
+		require.NoError(t, cmd.Delete(&bytes.Buffer{}, bytes.NewBuffer(req)))
+	})
+
+	t.Run("Conflicting writes", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		version, cmdErr := put(t, cmd, &PutRequest{Key: "key", Value: []byte("value1")})
+		require.NoError(t, cmdErr)
+
+		_, cmdErr = put(t, cmd, &PutRequest{Key: "key", Value: []byte("device1"), IfMatch: version})
+		require.NoError(t, cmdErr)
+
+		_, cmdErr = put(t, cmd, &PutRequest{Key: "key", Value: []byte("device2"), IfMatch: version})
+		require.Error(t, cmdErr)
+		require.Equal(t, ConflictErrorCode, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), "record version does not match")
+
+		req, err := json.Marshal(DeleteRequest{Key: "key", IfMatch: version})
+		require.NoError(t, err)
+
+		cmdErr = cmd.Delete(&bytes.Buffer{}, bytes.NewBuffer(req))
+		require.Error(t, cmdErr)
+		require.Equal(t, ConflictErrorCode, cmdErr.Code())
+
+		_, cmdErr = put(t, cmd, &PutRequest{Key: "missing", Value: []byte("value"), IfMatch: version})
+		require.Error(t, cmdErr)
+		require.Equal(t, ConflictErrorCode, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), "record missing not found")
+	})
+
+	t.Run("Failed to check version", func(t *testing.T) {
+		storeProvider := mocks.NewMockStoreProvider()
+		storeProvider.Store = &mocks.MockStore{Store: map[string][]byte{}, ErrGet: errors.New("get failure")}
+
+		cmd, err := New(&protocol.MockProvider{StoreProvider: storeProvider})
+		require.NoError(t, err)
+
+		_, cmdErr := put(t, cmd, &PutRequest{Key: "key", Value: []byte("value"), IfMatch: "version"})
+		require.EqualError(t, cmdErr, "get failure")
+		require.Equal(t, PutErrorCode, cmdErr.Code())
+
+		cmdErr = cmd.Delete(&bytes.Buffer{}, bytes.NewBufferString(`{"key":"key","ifMatch":"version"}`))
+		require.EqualError(t, cmdErr, "get failure")
+		require.Equal(t, DeleteErrorCode, cmdErr.Code())
+	})
+}
+
+func TestCommand_Increment(t *testing.T) {
+	increment := func(t *testing.T, cmd *Command, request string) (*IncrementResponse, command.Error) {
+		t.Helper()
+
+		res := &bytes.Buffer{}
+
+		cmdErr := cmd.Increment(res, bytes.NewBufferString(request))
+		if cmdErr != nil {
+			return nil, cmdErr
+		}
+
+		var resp IncrementResponse
+		require.NoError(t, json.Unmarshal(res.Bytes(), &resp))
+
+		return &resp, nil
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		resp, cmdErr := increment(t, cmd, `{"key":"counter","delta":5}`)
+		require.NoError(t, cmdErr)
+		require.EqualValues(t, 5, resp.Value)
+
+		req, err := json.Marshal(PutRequest{
+			Key: "counter", Value: []byte("5"), Tags: []storage.Tag{{Name: "counter"}}, IfMatch: resp.Version,
+		})
+		require.NoError(t, err)
+		require.NoError(t, cmd.Put(&bytes.Buffer{}, bytes.NewBuffer(req)))
+
+		resp, cmdErr = increment(t, cmd, `{"key":"counter","delta":-7}`)
+		require.NoError(t, cmdErr)
+		require.EqualValues(t, -2, resp.Value)
+
+		res := &bytes.Buffer{}
+		require.NoError(t, cmd.GetTags(res, bytes.NewBufferString(`{"key":"counter"}`)))
+
+		var tagsResp GetTagsResponse
+		require.NoError(t, json.Unmarshal(res.Bytes(), &tagsResp))
+		require.Equal(t, []storage.Tag{{Name: "counter"}}, tagsResp.Tags)
+	})
+
+	t.Run("Concurrent increments", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		const count = 20
+
+		var wg sync.WaitGroup
+
+		errs := make(chan command.Error, count)
+
+		for i := 0; i < count; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				errs <- cmd.Increment(&bytes.Buffer{}, bytes.NewBufferString(`{"key":"counter","delta":1}`))
+			}()
+		}
+
+		wg.Wait()
+		close(errs)
+
+		for e := range errs {
+			require.NoError(t, e)
+		}
+
+		resp, cmdErr := increment(t, cmd, `{"key":"counter"}`)
+		require.NoError(t, cmdErr)
+		require.EqualValues(t, count, resp.Value)
+	})
+
+	t.Run("Concurrent increments of commands sharing state", func(t *testing.T) {
+		provider := &protocol.MockProvider{StoreProvider: mem.NewProvider()}
+		state := NewSharedState()
+
+		cmds := make([]*Command, 2)
+
+		for i := range cmds {
+			c, err := New(provider, WithSharedState(state))
+			require.NoError(t, err)
+
+			cmds[i] = c
+		}
+
+		defer func() {
+			for _, c := range cmds {
+				require.NoError(t, c.Close())
+			}
+		}()
+
+		const count = 20
+
+		var wg sync.WaitGroup
+
+		errs := make(chan command.Error, count)
+
+		for i := 0; i < count; i++ {
+			wg.Add(1)
+
+			go func(c *Command) {
+				defer wg.Done()
+
+				errs <- c.Increment(&bytes.Buffer{}, bytes.NewBufferString(`{"key":"counter","delta":1}`))
+			}(cmds[i%len(cmds)])
+		}
+
+		wg.Wait()
+		close(errs)
+
+		for e := range errs {
+			require.NoError(t, e)
+		}
+
+		resp, cmdErr := increment(t, cmds[0], `{"key":"counter"}`)
+		require.NoError(t, cmdErr)
+		require.EqualValues(t, count, resp.Value)
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		_, cmdErr := increment(t, cmd, ``)
+		require.EqualError(t, cmdErr, io.EOF.Error())
+
+		_, cmdErr = increment(t, cmd, `{"delta":1}`)
+		require.EqualError(t, cmdErr, "key is mandatory")
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Value is not a counter", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		req, err := json.Marshal(PutRequest{Key: "key", Value: []byte("value")})
+		require.NoError(t, err)
+		require.NoError(t, cmd.Put(&bytes.Buffer{}, bytes.NewBuffer(req)))
+
+		_, cmdErr := increment(t, cmd, `{"key":"key","delta":1}`)
+		require.Error(t, cmdErr)
+		require.Equal(t, IncrementErrorCode, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), "record value is not an integer")
 	})
 }
 
@@ -295,42 +852,1459 @@ func TestCommand_Flush(t *testing.T) {
 	})
 }
 
-type mockStore struct {
-	queryFunc func(string, ...storage.QueryOption) (storage.Iterator, error)
-}
+func TestCommand_NamedStores(t *testing.T) {
+	t.Run("Stores are isolated", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()}, WithAllowedStores("app1", "app2"))
+		require.NoError(t, err)
 
-func (m *mockStore) Put(key string, value []byte, tags ...storage.Tag) error {
-	panic("implement me")
-}
+		for _, name := range []string{"", "app1", "app2"} {
+			req, e := json.Marshal(PutRequest{Key: "key", Value: []byte("value-" + name), StoreName: name})
+			require.NoError(t, e)
+			require.NoError(t, cmd.Put(&bytes.Buffer{}, bytes.NewBuffer(req)))
+		}
 
-func (m *mockStore) Get(key string) ([]byte, error) {
-	panic("implement me")
-}
+		for _, name := range []string{"", "app1", "app2"} {
+			res := &bytes.Buffer{}
 
-func (m *mockStore) GetTags(key string) ([]storage.Tag, error) {
-	panic("implement me")
-}
+			req, e := json.Marshal(GetRequest{Key: "key", StoreName: name})
+			require.NoError(t, e)
+			require.NoError(t, cmd.Get(res, bytes.NewBuffer(req)))
 
-func (m *mockStore) GetBulk(keys ...string) ([][]byte, error) {
-	panic("implement me")
-}
+			var resp GetResponse
+			require.NoError(t, json.Unmarshal(res.Bytes(), &resp))
+			require.Equal(t, "value-"+name, string(resp.Result))
+		}
 
-func (m *mockStore) Query(expression string, options ...storage.QueryOption) (storage.Iterator, error) {
-	return m.queryFunc(expression, options...)
-}
+		req, err := json.Marshal(DeleteRequest{Key: "key", StoreName: "app1"})
+		require.NoError(t, err)
+		require.NoError(t, cmd.Delete(&bytes.Buffer{}, bytes.NewBuffer(req)))
 
-func (m *mockStore) Delete(key string) error {
-	panic("implement me")
-}
+		req, err = json.Marshal(GetRequest{Key: "key", StoreName: "app1"})
+		require.NoError(t, err)
+		require.EqualError(t, cmd.Get(&bytes.Buffer{}, bytes.NewBuffer(req)), storage.ErrDataNotFound.Error())
 
-func (m *mockStore) Batch(operations []storage.Operation) error {
-	panic("implement me")
-}
+		req, err = json.Marshal(GetRequest{Key: "key", StoreName: "app2"})
+		require.NoError(t, err)
+		require.NoError(t, cmd.Get(&bytes.Buffer{}, bytes.NewBuffer(req)))
+	})
 
-func (m *mockStore) Flush() error {
-	panic("implement me")
+	t.Run("Prefix allowlist", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()}, WithAllowedStores("myapp_*"))
+		require.NoError(t, err)
+
+		req, err := json.Marshal(PutRequest{Key: "key", Value: []byte("value"), StoreName: "myapp_settings"})
+		require.NoError(t, err)
+		require.NoError(t, cmd.Put(&bytes.Buffer{}, bytes.NewBuffer(req)))
+
+		req, err = json.Marshal(PutRequest{Key: "key", Value: []byte("value"), StoreName: "otherapp_settings"})
+		require.NoError(t, err)
+
+		cmdErr := cmd.Put(&bytes.Buffer{}, bytes.NewBuffer(req))
+		require.Error(t, cmdErr)
+		require.Equal(t, StoreNotAllowedErrorCode, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), "store is not allowed: otherapp_settings")
+	})
+
+	t.Run("Internal stores are not allowed by default", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		for _, method := range []func(io.Writer, io.Reader) command.Error{cmd.Get, cmd.Query, cmd.Delete} {
+			cmdErr := method(&bytes.Buffer{}, bytes.NewBufferString(`{"storeName":"didexchange"}`))
+			require.Error(t, cmdErr)
+			require.Equal(t, StoreNotAllowedErrorCode, cmdErr.Code())
+		}
+	})
+
+	t.Run("Failed to open store", func(t *testing.T) {
+		storeProvider := mocks.NewMockStoreProvider()
+		storeProvider.FailNamespace = "app"
+
+		cmd, err := New(&protocol.MockProvider{StoreProvider: storeProvider}, WithAllowedStores("app"))
+		require.NoError(t, err)
+
+		cmdErr := cmd.Get(&bytes.Buffer{}, bytes.NewBufferString(`{"key":"key","storeName":"app"}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, GetErrorCode, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), "failed to open store app")
+	})
+}
+
+func TestCommand_ListStores(t *testing.T) {
+	provider := &protocol.MockProvider{StoreProvider: mem.NewProvider()}
+	state := NewSharedState()
+
+	cmd, err := New(provider, WithSharedState(state), WithAllowedStores("app", "myapp_*"))
+	require.NoError(t, err)
+
+	req, err := json.Marshal(PutRequest{Key: "key", Value: []byte("value"), StoreName: "myapp_settings"})
+	require.NoError(t, err)
+	require.NoError(t, cmd.Put(&bytes.Buffer{}, bytes.NewBuffer(req)))
+
+	res := &bytes.Buffer{}
+	require.NoError(t, cmd.ListStores(res, nil))
+
+	var resp ListStoresResponse
+	require.NoError(t, json.Unmarshal(res.Bytes(), &resp))
+	require.Equal(t, []string{"myapp_settings", DefaultStoreName}, resp.Stores)
+	require.Equal(t, []string{DefaultStoreName, "app", "myapp_*"}, resp.Allowed)
+
+	t.Run("Stores opened by other commands sharing state", func(t *testing.T) {
+		other, e := New(provider, WithSharedState(state), WithAllowedStores("myapp_*"))
+		require.NoError(t, e)
+
+		res.Reset()
+		require.NoError(t, other.ListStores(res, nil))
+		require.NoError(t, json.Unmarshal(res.Bytes(), &resp))
+		require.Equal(t, []string{"myapp_settings", DefaultStoreName}, resp.Stores)
+
+		defaultOnly, e := New(provider, WithSharedState(state))
+		require.NoError(t, e)
+
+		res.Reset()
+		require.NoError(t, defaultOnly.ListStores(res, nil))
+		require.NoError(t, json.Unmarshal(res.Bytes(), &resp))
+		require.Equal(t, []string{DefaultStoreName}, resp.Stores)
+	})
+
+	t.Run("Stores opened before restart", func(t *testing.T) {
+		restarted := &protocol.MockProvider{StoreProvider: mem.NewProvider()}
+
+		before, e := New(restarted, WithAllowedStores("myapp_*"))
+		require.NoError(t, e)
+		require.NoError(t, before.Put(&bytes.Buffer{}, bytes.NewBuffer(req)))
+		require.NoError(t, before.Close())
+
+		after, e := New(restarted, WithAllowedStores("myapp_*"))
+		require.NoError(t, e)
+
+		defer func() { require.NoError(t, after.Close()) }()
+
+		res.Reset()
+		require.NoError(t, after.ListStores(res, nil))
+		require.NoError(t, json.Unmarshal(res.Bytes(), &resp))
+		require.Equal(t, []string{"myapp_settings", DefaultStoreName}, resp.Stores)
+	})
+
+	t.Run("Failed to query store names", func(t *testing.T) {
+		failing, e := New(&protocol.MockProvider{StoreProvider: &storeutil.Provider{
+			OpenStoreReturn: &mockStore{
+				queryFunc: func(string, ...storage.QueryOption) (storage.Iterator, error) {
+					return nil, errors.New("query failure")
+				},
+			},
+		}}, WithSweepInterval(0))
+		require.NoError(t, e)
+
+		cmdErr := failing.ListStores(&bytes.Buffer{}, nil)
+		require.Error(t, cmdErr)
+		require.Equal(t, ListStoresErrorCode, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), "query failure")
+	})
+}
+
+func TestCommand_GetTags(t *testing.T) {
+	t.Run("Empty request", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		require.EqualError(t, cmd.GetTags(&bytes.Buffer{}, bytes.NewBufferString(``)), io.EOF.Error())
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		cmdErr := cmd.GetTags(&bytes.Buffer{}, bytes.NewBufferString(`{"key":"key"}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, GetTagsErrorCode, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), storage.ErrDataNotFound.Error())
+	})
+
+	t.Run("Store not allowed", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		cmdErr := cmd.GetTags(&bytes.Buffer{}, bytes.NewBufferString(`{"key":"key","storeName":"other"}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, StoreNotAllowedErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		tags := []storage.Tag{{Name: "type", Value: "credential"}, {Name: "favorite"}}
+
+		req, err := json.Marshal(PutRequest{Key: "key", Value: []byte("value"), Tags: tags})
+		require.NoError(t, err)
+		require.NoError(t, cmd.Put(&bytes.Buffer{}, bytes.NewBuffer(req)))
+
+		res := &bytes.Buffer{}
+		require.NoError(t, cmd.GetTags(res, bytes.NewBufferString(`{"key":"key"}`)))
+
+		var resp GetTagsResponse
+		require.NoError(t, json.Unmarshal(res.Bytes(), &resp))
+		require.ElementsMatch(t, tags, resp.Tags)
+	})
+}
+
+func TestCommand_GetBulk(t *testing.T) {
+	t.Run("Empty request", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		require.EqualError(t, cmd.GetBulk(&bytes.Buffer{}, bytes.NewBufferString(``)), io.EOF.Error())
+	})
+
+	t.Run("No keys", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		cmdErr := cmd.GetBulk(&bytes.Buffer{}, bytes.NewBufferString(`{}`))
+		require.EqualError(t, cmdErr, "keys are mandatory")
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Store error", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: &storeutil.Provider{
+			OpenStoreReturn: &mockStore{
+				getBulkFunc: func(keys ...string) ([][]byte, error) {
+					return nil, errors.New("get bulk failure")
+				},
+			},
+		}})
+		require.NoError(t, err)
+
+		cmdErr := cmd.GetBulk(&bytes.Buffer{}, bytes.NewBufferString(`{"keys":["key"]}`))
+		require.EqualError(t, cmdErr, "get bulk failure")
+		require.Equal(t, GetBulkErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		for _, key := range []string{"key1", "key2"} {
+			req, e := json.Marshal(PutRequest{Key: key, Value: []byte("value-" + key)})
+			require.NoError(t, e)
+			require.NoError(t, cmd.Put(&bytes.Buffer{}, bytes.NewBuffer(req)))
+		}
+
+		res := &bytes.Buffer{}
+
+		req, err := json.Marshal(GetBulkRequest{Keys: []string{"key2", "missing", "key1"}})
+		require.NoError(t, err)
+		require.NoError(t, cmd.GetBulk(res, bytes.NewBuffer(req)))
+
+		var resp GetBulkResponse
+		require.NoError(t, json.Unmarshal(res.Bytes(), &resp))
+		require.Equal(t, [][]byte{[]byte("value-key2"), nil, []byte("value-key1")}, resp.Results)
+	})
+}
+
+func TestCommand_Batch(t *testing.T) {
+	t.Run("Empty request", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		require.EqualError(t, cmd.Batch(&bytes.Buffer{}, bytes.NewBufferString(``)), io.EOF.Error())
+	})
+
+	t.Run("No operations", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		cmdErr := cmd.Batch(&bytes.Buffer{}, bytes.NewBufferString(`{}`))
+		require.EqualError(t, cmdErr, "operations are mandatory")
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Operation without key", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		cmdErr := cmd.Batch(&bytes.Buffer{}, bytes.NewBufferString(`{"operations":[{"value":"dmFsdWU="}]}`))
+		require.EqualError(t, cmdErr, "key of batch operation is mandatory")
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Store error", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: &storeutil.Provider{
+			OpenStoreReturn: &mockStore{
+				batchFunc: func(operations []storage.Operation) error {
+					require.Len(t, operations, 1)
+
+					return errors.New("batch failure")
+				},
+			},
+		}})
+		require.NoError(t, err)
+
+		cmdErr := cmd.Batch(&bytes.Buffer{}, bytes.NewBufferString(`{"operations":[{"key":"key"}]}`))
+		require.EqualError(t, cmdErr, "batch failure")
+		require.Equal(t, BatchErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()}, WithAllowedStores("app"))
+		require.NoError(t, err)
+
+		req, err := json.Marshal(PutRequest{Key: "key1", Value: []byte("value1"), StoreName: "app"})
+		require.NoError(t, err)
+		require.NoError(t, cmd.Put(&bytes.Buffer{}, bytes.NewBuffer(req)))
+
+		req, err = json.Marshal(BatchRequest{
+			StoreName: "app",
+			Operations: []BatchOperation{
+				{Key: "key1"},
+				{Key: "key2", Value: []byte("value2"), Tags: []storage.Tag{{Name: "tag"}}},
+			},
+		})
+		require.NoError(t, err)
+		require.NoError(t, cmd.Batch(&bytes.Buffer{}, bytes.NewBuffer(req)))
+
+		res := &bytes.Buffer{}
+
+		req, err = json.Marshal(GetBulkRequest{Keys: []string{"key1", "key2"}, StoreName: "app"})
+		require.NoError(t, err)
+		require.NoError(t, cmd.GetBulk(res, bytes.NewBuffer(req)))
+
+		var resp GetBulkResponse
+		require.NoError(t, json.Unmarshal(res.Bytes(), &resp))
+		require.Equal(t, [][]byte{nil, []byte("value2")}, resp.Results)
+
+		res = &bytes.Buffer{}
+		require.NoError(t, cmd.GetTags(res, bytes.NewBufferString(`{"key":"key2","storeName":"app"}`)))
+
+		var tagsResp GetTagsResponse
+		require.NoError(t, json.Unmarshal(res.Bytes(), &tagsResp))
+		require.Equal(t, []storage.Tag{{Name: "tag"}}, tagsResp.Tags)
+	})
+}
+
+func TestCommand_TTL(t *testing.T) {
+	t.Run("Invalid TTL", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		cmdErr := cmd.Put(&bytes.Buffer{}, bytes.NewBufferString(`{"key":"key","value":"dmFsdWU=","ttl":-1}`))
+		require.EqualError(t, cmdErr, "ttl must not be negative")
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+
+		cmdErr = cmd.Batch(&bytes.Buffer{}, bytes.NewBufferString(`{"operations":[{"key":"key","ttl":-1}]}`))
+		require.EqualError(t, cmdErr, "ttl must not be negative")
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Reserved tag", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		cmdErr := cmd.Put(&bytes.Buffer{},
+			bytes.NewBufferString(`{"key":"key","value":"dmFsdWU=","tags":[{"name":"_expiresAt","value":"0"}]}`))
+		require.EqualError(t, cmdErr, "tag _expiresAt is reserved")
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Expired records are hidden", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()}, WithSweepInterval(0))
+		require.NoError(t, err)
+
+		tags := []storage.Tag{{Name: "tag"}}
+
+		req, err := json.Marshal(PutRequest{Key: "temp", Value: []byte("1"), Tags: tags, TTL: 1})
+		require.NoError(t, err)
+		require.NoError(t, cmd.Put(&bytes.Buffer{}, bytes.NewBuffer(req)))
+
+		req, err = json.Marshal(BatchRequest{Operations: []BatchOperation{
+			{Key: "kept", Value: []byte("kept"), Tags: tags},
+			{Key: "temp2", Value: []byte("temp2"), Tags: tags, TTL: 1},
+		}})
+		require.NoError(t, err)
+		require.NoError(t, cmd.Batch(&bytes.Buffer{}, bytes.NewBuffer(req)))
+
+		res := &bytes.Buffer{}
+		require.NoError(t, cmd.GetTags(res, bytes.NewBufferString(`{"key":"temp"}`)))
+
+		var tagsResp GetTagsResponse
+		require.NoError(t, json.Unmarshal(res.Bytes(), &tagsResp))
+		require.Equal(t, tags, tagsResp.Tags)
+
+		require.Eventually(t, func() bool {
+			return cmd.Get(&bytes.Buffer{}, bytes.NewBufferString(`{"key":"temp"}`)) != nil
+		}, 3*time.Second, 100*time.Millisecond)
+
+		cmdErr := cmd.Get(&bytes.Buffer{}, bytes.NewBufferString(`{"key":"temp2"}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, GetErrorCode, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), storage.ErrDataNotFound.Error())
+
+		cmdErr = cmd.GetTags(&bytes.Buffer{}, bytes.NewBufferString(`{"key":"temp"}`))
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), storage.ErrDataNotFound.Error())
+
+		res = &bytes.Buffer{}
+		require.NoError(t, cmd.GetBulk(res, bytes.NewBufferString(`{"keys":["temp","kept"]}`)))
+
+		var bulkResp GetBulkResponse
+		require.NoError(t, json.Unmarshal(res.Bytes(), &bulkResp))
+		require.Equal(t, [][]byte{nil, []byte("kept")}, bulkResp.Results)
+
+		res = &bytes.Buffer{}
+		require.NoError(t, cmd.QueryRecords(res, bytes.NewBufferString(`{"expression":"tag"}`)))
+
+		var queryResp QueryRecordsResponse
+		require.NoError(t, json.Unmarshal(res.Bytes(), &queryResp))
+		require.Len(t, queryResp.Records, 1)
+		require.Equal(t, "kept", queryResp.Records[0].Key)
+
+		res = &bytes.Buffer{}
+		require.NoError(t, cmd.Increment(res, bytes.NewBufferString(`{"key":"temp","delta":5}`)))
+
+		var incResp IncrementResponse
+		require.NoError(t, json.Unmarshal(res.Bytes(), &incResp))
+		require.EqualValues(t, 5, incResp.Value)
+
+		cmdErr = cmd.Delete(&bytes.Buffer{}, bytes.NewBufferString(`{"key":"temp2","ifMatch":"version"}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, ConflictErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Sweeper deletes expired records", func(t *testing.T) {
+		provider := mem.NewProvider()
+
+		cmd, err := New(&protocol.MockProvider{StoreProvider: provider},
+			WithSweepInterval(50*time.Millisecond), WithSweepBatchSize(1))
+		require.NoError(t, err)
+
+		defer func() { require.NoError(t, cmd.Close()) }()
+
+		for _, key := range []string{"key1", "key2"} {
+			req, e := json.Marshal(PutRequest{Key: key, Value: []byte("value"), TTL: 1})
+			require.NoError(t, e)
+			require.NoError(t, cmd.Put(&bytes.Buffer{}, bytes.NewBuffer(req)))
+		}
+
+		require.NoError(t, cmd.Put(&bytes.Buffer{}, bytes.NewBufferString(`{"key":"key3","value":"dmFsdWU="}`)))
+
+		var stats SweeperStatsResponse
+
+		require.Eventually(t, func() bool {
+			res := &bytes.Buffer{}
+			require.NoError(t, cmd.SweeperStats(res, nil))
+			require.NoError(t, json.Unmarshal(res.Bytes(), &stats))
+
+			return stats.ExpiredRecords == 2
+		}, 3*time.Second, 50*time.Millisecond)
+
+		require.True(t, stats.Enabled)
+		require.Equal(t, "50ms", stats.Interval)
+		require.NotNil(t, stats.LastRunAt)
+		require.Empty(t, stats.LastError)
+
+		store, err := provider.OpenStore(DefaultStoreName)
+		require.NoError(t, err)
+
+		_, err = store.Get("key1")
+		require.ErrorIs(t, err, storage.ErrDataNotFound)
+
+		_, err = store.Get("key3")
+		require.NoError(t, err)
+	})
+
+	t.Run("Sweeper error", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: &storeutil.Provider{
+			OpenStoreReturn: &mockStore{
+				queryFunc: func(string, ...storage.QueryOption) (storage.Iterator, error) {
+					return nil, errors.New("query failure")
+				},
+			},
+		}}, WithSweepInterval(10*time.Millisecond))
+		require.NoError(t, err)
+
+		defer func() { require.NoError(t, cmd.Close()) }()
+
+		require.Eventually(t, func() bool {
+			res := &bytes.Buffer{}
+			require.NoError(t, cmd.SweeperStats(res, nil))
+
+			var stats SweeperStatsResponse
+			require.NoError(t, json.Unmarshal(res.Bytes(), &stats))
+
+			return stats.LastError == "failed to query store names: query failure"
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Sweeper of shared state", func(t *testing.T) {
+		provider := &protocol.MockProvider{StoreProvider: mem.NewProvider()}
+		state := NewSharedState()
+
+		stats := func(t *testing.T, cmd *Command) *SweeperStatsResponse {
+			t.Helper()
+
+			res := &bytes.Buffer{}
+			require.NoError(t, cmd.SweeperStats(res, nil))
+
+			var resp SweeperStatsResponse
+			require.NoError(t, json.Unmarshal(res.Bytes(), &resp))
+
+			return &resp
+		}
+
+		first, err := New(provider, WithSharedState(state), WithSweepInterval(0))
+		require.NoError(t, err)
+
+		second, err := New(provider, WithSharedState(state), WithSweepInterval(time.Hour))
+		require.NoError(t, err)
+		require.False(t, stats(t, second).Enabled)
+
+		require.NoError(t, first.Close())
+		require.NoError(t, first.Close())
+		require.False(t, stats(t, second).Enabled)
+
+		require.NoError(t, second.Close())
+
+		third, err := New(provider, WithSharedState(state), WithSweepInterval(time.Hour))
+		require.NoError(t, err)
+		require.True(t, stats(t, third).Enabled)
+		require.NoError(t, third.Close())
+	})
+
+	t.Run("Sweeper disabled", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()}, WithSweepInterval(0))
+		require.NoError(t, err)
+
+		res := &bytes.Buffer{}
+		require.NoError(t, cmd.SweeperStats(res, nil))
+
+		var stats SweeperStatsResponse
+		require.NoError(t, json.Unmarshal(res.Bytes(), &stats))
+		require.False(t, stats.Enabled)
+		require.Zero(t, stats.Runs)
+	})
+}
+
+func TestCommand_ExportImportStores(t *testing.T) {
+	put := func(t *testing.T, cmd *Command, request *PutRequest) {
+		t.Helper()
+
+		req, err := json.Marshal(request)
+		require.NoError(t, err)
+		require.NoError(t, cmd.Put(&bytes.Buffer{}, bytes.NewBuffer(req)))
+	}
+
+	exportStores := func(t *testing.T, cmd *Command, request *ExportStoresRequest) *ExportStoresResponse {
+		t.Helper()
+
+		req, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		res := &bytes.Buffer{}
+		require.NoError(t, cmd.ExportStores(res, bytes.NewBuffer(req)))
+
+		var resp ExportStoresResponse
+		require.NoError(t, json.Unmarshal(res.Bytes(), &resp))
+
+		return &resp
+	}
+
+	importStores := func(t *testing.T, cmd *Command, request *ImportStoresRequest) (*ImportStoresResponse,
+		command.Error) {
+		t.Helper()
+
+		req, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		res := &bytes.Buffer{}
+
+		if cmdErr := cmd.ImportStores(res, bytes.NewBuffer(req)); cmdErr != nil {
+			return nil, cmdErr
+		}
+
+		var resp ImportStoresResponse
+		require.NoError(t, json.Unmarshal(res.Bytes(), &resp))
+
+		return &resp, nil
+	}
+
+	t.Run("Invalid export request", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		require.EqualError(t, cmd.ExportStores(&bytes.Buffer{}, bytes.NewBufferString(``)), io.EOF.Error())
+
+		for request, errMsg := range map[string]string{
+			`{"passphrase":"secret"}`:                                   "store names are mandatory",
+			`{"storeNames":["store"]}`:                                  "exactly one of passphrase, key or key ID",
+			`{"storeNames":["store"],"passphrase":"secret","key":{}}`:   "exactly one of passphrase, key or key ID",
+			`{"storeNames":["store"],"key":{},"keyID":"key-1"}`:         "exactly one of passphrase, key or key ID",
+			`{"storeNames":["store"],"keyID":"key-1"}`:                  "key manager of the store command is not set",
+			`{"storeNames":["store"],"key":{"kty":"unknown"}}`:          "invalid key",
+			`{"storeNames":["didexchange"],"passphrase":"secret"}`:      "store is not allowed",
+			`{"storeNames":["store"],"key":{"kty":"EC","crv":"P-256"}}`: "invalid key",
+		} {
+			cmdErr := cmd.ExportStores(&bytes.Buffer{}, bytes.NewBufferString(request))
+			require.Error(t, cmdErr, request)
+			require.Contains(t, cmdErr.Error(), errMsg, request)
+		}
+
+		// A256KW requires 32 bytes long key
+		cmdErr := cmd.ExportStores(&bytes.Buffer{},
+			bytes.NewBufferString(`{"storeNames":["store"],"key":{"kty":"oct","k":"c2VjcmV0"}}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, ExportStoresErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Invalid import request", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		require.EqualError(t, cmd.ImportStores(&bytes.Buffer{}, bytes.NewBufferString(``)), io.EOF.Error())
+
+		_, cmdErr := importStores(t, cmd, &ImportStoresRequest{Archive: "archive", Passphrase: "secret", Mode: "append"})
+		require.EqualError(t, cmdErr, "import mode must be either merge or replace")
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+
+		_, cmdErr = importStores(t, cmd, &ImportStoresRequest{Archive: "archive"})
+		require.EqualError(t, cmdErr, "exactly one of passphrase, key or key ID is mandatory")
+
+		_, cmdErr = importStores(t, cmd, &ImportStoresRequest{Archive: "archive", Passphrase: "secret"})
+		require.Error(t, cmdErr)
+		require.Equal(t, InvalidArchiveErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Passphrase", func(t *testing.T) {
+		source := mem.NewProvider()
+
+		cmd, err := New(&protocol.MockProvider{StoreProvider: source}, WithAllowedStores("app"))
+		require.NoError(t, err)
+
+		tags := []storage.Tag{{Name: "tag", Value: "value"}}
+
+		put(t, cmd, &PutRequest{Key: "key1", Value: []byte("value1"), Tags: tags})
+		put(t, cmd, &PutRequest{Key: "key2", Value: []byte("value2"), Tags: tags, TTL: 60})
+		put(t, cmd, &PutRequest{Key: "untagged", Value: []byte("untagged")})
+		put(t, cmd, &PutRequest{Key: "key3", Value: []byte("value3"), Tags: []storage.Tag{{Name: "type"}}, StoreName: "app"})
+		require.NoError(t, source.SetStoreConfig("app", storage.StoreConfiguration{TagNames: []string{"type"}}))
+
+		exported := exportStores(t, cmd, &ExportStoresRequest{StoreNames: []string{"", "app"}, Passphrase: "secret"})
+		require.Equal(t, []ExportedStore{{Name: DefaultStoreName, Records: 3}, {Name: "app", Records: 1}}, exported.Stores)
+
+		archive := exported.Archive
+
+		target := mem.NewProvider()
+
+		targetCmd, err := New(&protocol.MockProvider{StoreProvider: target}, WithAllowedStores("app"))
+		require.NoError(t, err)
+
+		_, cmdErr := importStores(t, targetCmd, &ImportStoresRequest{Archive: archive, Passphrase: "wrong"})
+		require.Error(t, cmdErr)
+		require.Equal(t, InvalidArchiveErrorCode, cmdErr.Code())
+
+		resp, cmdErr := importStores(t, targetCmd, &ImportStoresRequest{Archive: archive, Passphrase: "secret"})
+		require.NoError(t, cmdErr)
+		require.Equal(t, []ImportedStore{{Name: DefaultStoreName, Records: 3}, {Name: "app", Records: 1}}, resp.Stores)
+
+		res := &bytes.Buffer{}
+		require.NoError(t, targetCmd.GetBulk(res, bytes.NewBufferString(`{"keys":["key1","key2","untagged"]}`)))
+		require.JSONEq(t, `{"results":["dmFsdWUx","dmFsdWUy","dW50YWdnZWQ="]}`, res.String())
+
+		res = &bytes.Buffer{}
+		require.NoError(t, targetCmd.GetTags(res, bytes.NewBufferString(`{"key":"key2"}`)))
+		require.JSONEq(t, `{"tags":[{"name":"tag","value":"value"}]}`, res.String())
+
+		res = &bytes.Buffer{}
+		require.NoError(t, targetCmd.Get(res, bytes.NewBufferString(`{"key":"key3","storeName":"app"}`)))
+
+		config, err := target.GetStoreConfig("app")
+		require.NoError(t, err)
+		require.Equal(t, []string{"type"}, config.TagNames)
+	})
+
+	t.Run("Merge and replace", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		tags := []storage.Tag{{Name: "tag"}}
+
+		put(t, cmd, &PutRequest{Key: "key1", Value: []byte("value1"), Tags: tags})
+
+		archive := exportStores(t, cmd, &ExportStoresRequest{
+			StoreNames: []string{DefaultStoreName}, Passphrase: "secret",
+		}).Archive
+
+		put(t, cmd, &PutRequest{Key: "key1", Value: []byte("changed"), Tags: tags})
+		put(t, cmd, &PutRequest{Key: "key2", Value: []byte("value2")})
+
+		_, cmdErr := importStores(t, cmd, &ImportStoresRequest{Archive: archive, Passphrase: "secret"})
+		require.NoError(t, cmdErr)
+
+		res := &bytes.Buffer{}
+		require.NoError(t, cmd.GetBulk(res, bytes.NewBufferString(`{"keys":["key1","key2"]}`)))
+		require.JSONEq(t, `{"results":["dmFsdWUx","dmFsdWUy"]}`, res.String())
+
+		_, cmdErr = importStores(t, cmd, &ImportStoresRequest{
+			Archive: archive, Passphrase: "secret", Mode: ImportModeReplace,
+		})
+		require.NoError(t, cmdErr)
+
+		res = &bytes.Buffer{}
+		require.NoError(t, cmd.GetBulk(res, bytes.NewBufferString(`{"keys":["key1","key2"]}`)))
+		require.JSONEq(t, `{"results":["dmFsdWUx",null]}`, res.String())
+	})
+
+	t.Run("Key", func(t *testing.T) {
+		privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		publicJWK, err := json.Marshal(&jose.JSONWebKey{Key: &privateKey.PublicKey})
+		require.NoError(t, err)
+
+		privateJWK, err := json.Marshal(&jose.JSONWebKey{Key: privateKey})
+		require.NoError(t, err)
+
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		put(t, cmd, &PutRequest{Key: "key", Value: []byte("value"), Tags: []storage.Tag{{Name: "tag"}}})
+
+		archive := exportStores(t, cmd, &ExportStoresRequest{
+			StoreNames: []string{DefaultStoreName}, TagNames: []string{"tag"}, Key: publicJWK,
+		}).Archive
+
+		targetCmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		_, cmdErr := importStores(t, targetCmd, &ImportStoresRequest{Archive: archive, Passphrase: "secret"})
+		require.Error(t, cmdErr)
+		require.Contains(t, cmdErr.Error(), "archive is not encrypted to a passphrase")
+
+		resp, cmdErr := importStores(t, targetCmd, &ImportStoresRequest{Archive: archive, Key: privateJWK})
+		require.NoError(t, cmdErr)
+		require.Equal(t, []ImportedStore{{Name: DefaultStoreName, Records: 1}}, resp.Stores)
+
+		require.NoError(t, targetCmd.Get(&bytes.Buffer{}, bytes.NewBufferString(`{"key":"key"}`)))
+	})
+
+	t.Run("Key ID", func(t *testing.T) {
+		keyManager, crypto := newKeyManager(t)
+
+		keyID, _, err := keyManager.Create(kms.NISTP256ECDHKWType)
+		require.NoError(t, err)
+
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()}, WithKeyManager(keyManager, crypto))
+		require.NoError(t, err)
+
+		put(t, cmd, &PutRequest{Key: "key", Value: []byte("value")})
+
+		archive := exportStores(t, cmd, &ExportStoresRequest{StoreNames: []string{DefaultStoreName}, KeyID: keyID}).Archive
+
+		withoutKeyManager, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		_, cmdErr := importStores(t, withoutKeyManager, &ImportStoresRequest{Archive: archive, KeyID: keyID})
+		require.Error(t, cmdErr)
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+
+		otherKeyID, _, err := keyManager.Create(kms.NISTP256ECDHKWType)
+		require.NoError(t, err)
+
+		targetCmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()},
+			WithKeyManager(keyManager, crypto))
+		require.NoError(t, err)
+
+		_, cmdErr = importStores(t, targetCmd, &ImportStoresRequest{Archive: archive, KeyID: otherKeyID})
+		require.Error(t, cmdErr)
+		require.Equal(t, InvalidArchiveErrorCode, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), "archive is not encrypted to key")
+
+		resp, cmdErr := importStores(t, targetCmd, &ImportStoresRequest{Archive: archive, KeyID: keyID})
+		require.NoError(t, cmdErr)
+		require.Equal(t, []ImportedStore{{Name: DefaultStoreName, Records: 1}}, resp.Stores)
+
+		require.NoError(t, targetCmd.Get(&bytes.Buffer{}, bytes.NewBufferString(`{"key":"key"}`)))
+	})
+
+	t.Run("Store not allowed", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()}, WithAllowedStores("app"))
+		require.NoError(t, err)
+
+		put(t, cmd, &PutRequest{Key: "key", Value: []byte("value"), Tags: []storage.Tag{{Name: "tag"}}, StoreName: "app"})
+
+		archive := exportStores(t, cmd, &ExportStoresRequest{
+			StoreNames: []string{"app"}, TagNames: []string{"tag"}, Passphrase: "secret",
+		}).Archive
+
+		targetCmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		_, cmdErr := importStores(t, targetCmd, &ImportStoresRequest{Archive: archive, Passphrase: "secret"})
+		require.Error(t, cmdErr)
+		require.Equal(t, StoreNotAllowedErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Index tags are derived on import", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		put(t, cmd, &PutRequest{Key: "key", Value: []byte(`{"type":"degree"}`)})
+
+		archive := exportStores(t, cmd, &ExportStoresRequest{
+			StoreNames: []string{DefaultStoreName}, Passphrase: "secret",
+		}).Archive
+
+		targetCmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+		require.NoError(t, targetCmd.ConfigureIndexes(&bytes.Buffer{},
+			bytes.NewBufferString(`{"indexes":[{"name":"type","path":"$.type"}]}`)))
+
+		_, cmdErr := importStores(t, targetCmd, &ImportStoresRequest{Archive: archive, Passphrase: "secret"})
+		require.NoError(t, cmdErr)
+
+		res := &bytes.Buffer{}
+		require.NoError(t, targetCmd.GetTags(res, bytes.NewBufferString(`{"key":"key"}`)))
+		require.JSONEq(t, `{"tags":[{"name":"type","value":"degree"}]}`, res.String())
+	})
+
+	t.Run("Failed import restores stores", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()}, WithAllowedStores("app"))
+		require.NoError(t, err)
+
+		put(t, cmd, &PutRequest{Key: "key1", Value: []byte("value1")})
+		put(t, cmd, &PutRequest{Key: "key2", Value: []byte("value2"), StoreName: "app"})
+
+		archive := exportStores(t, cmd, &ExportStoresRequest{
+			StoreNames: []string{DefaultStoreName, "app"}, Passphrase: "secret",
+		}).Archive
+
+		target := &failingBatchProvider{Provider: mem.NewProvider(), storeName: "app"}
+
+		targetCmd, err := New(&protocol.MockProvider{StoreProvider: target}, WithAllowedStores("app"))
+		require.NoError(t, err)
+
+		put(t, targetCmd, &PutRequest{Key: "key1", Value: []byte("old")})
+		put(t, targetCmd, &PutRequest{Key: "key3", Value: []byte("value3")})
+
+		_, cmdErr := importStores(t, targetCmd, &ImportStoresRequest{
+			Archive: archive, Passphrase: "secret", Mode: ImportModeReplace,
+		})
+		require.Error(t, cmdErr)
+		require.Equal(t, ImportStoresErrorCode, cmdErr.Code())
+		require.Contains(t, cmdErr.Error(), "batch failure")
+
+		res := &bytes.Buffer{}
+		require.NoError(t, targetCmd.GetBulk(res, bytes.NewBufferString(`{"keys":["key1","key3"]}`)))
+		require.JSONEq(t, `{"results":["b2xk","dmFsdWUz"]}`, res.String())
+	})
+}
+
+func TestCommand_Subscribe(t *testing.T) {
+	t.Run("Notifications disabled", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		cmdErr := cmd.Subscribe(&bytes.Buffer{}, bytes.NewBufferString(`{}`))
+		require.EqualError(t, cmdErr, "notifications are not enabled")
+		require.Equal(t, SubscribeErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Invalid request", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()}, WithNotifier(mocks.NewMockNotifier()))
+		require.NoError(t, err)
+
+		require.EqualError(t, cmd.Subscribe(&bytes.Buffer{}, bytes.NewBufferString(``)), io.EOF.Error())
+		require.EqualError(t, cmd.Unsubscribe(&bytes.Buffer{}, bytes.NewBufferString(``)), io.EOF.Error())
+
+		cmdErr := cmd.Subscribe(&bytes.Buffer{}, bytes.NewBufferString(`{"expression":"a:b:c"}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+
+		cmdErr = cmd.Subscribe(&bytes.Buffer{}, bytes.NewBufferString(`{"storeName":"other"}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, StoreNotAllowedErrorCode, cmdErr.Code())
+
+		cmdErr = cmd.Unsubscribe(&bytes.Buffer{}, bytes.NewBufferString(`{"id":"unknown"}`))
+		require.EqualError(t, cmdErr, "subscription not found: unknown")
+		require.Equal(t, UnsubscribeErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Changes", func(t *testing.T) {
+		var (
+			mutex  sync.Mutex
+			events []ChangeEvent
+		)
+
+		notifier := mocks.NewMockNotifier()
+		notifier.NotifyFunc = func(topic string, message []byte) error {
+			require.Equal(t, ChangeTopic(DefaultStoreName), topic)
+
+			var event ChangeEvent
+			require.NoError(t, json.Unmarshal(message, &event))
+
+			mutex.Lock()
+			events = append(events, event)
+			mutex.Unlock()
+
+			return nil
+		}
+
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()}, WithNotifier(notifier))
+		require.NoError(t, err)
+
+		subscribe := func(request string) string {
+			res := &bytes.Buffer{}
+			require.NoError(t, cmd.Subscribe(res, bytes.NewBufferString(request)))
+
+			var resp SubscribeResponse
+			require.NoError(t, json.Unmarshal(res.Bytes(), &resp))
+			require.Equal(t, "store-changes-store", resp.Topic)
+
+			return resp.ID
+		}
+
+		allID := subscribe(`{"includeValue":true}`)
+		credentialID := subscribe(`{"expression":"type:credential"}`)
+
+		credential := []storage.Tag{{Name: "type", Value: "credential"}}
+
+		require.NoError(t, cmd.Put(&bytes.Buffer{},
+			bytes.NewBufferString(`{"key":"key1","value":"dmFsdWU=","tags":[{"name":"type","value":"credential"}]}`)))
+		require.NoError(t, cmd.Put(&bytes.Buffer{},
+			bytes.NewBufferString(`{"key":"key2","value":"dmFsdWU=","tags":[{"name":"type","value":"other"}]}`)))
+		require.NoError(t, cmd.Delete(&bytes.Buffer{}, bytes.NewBufferString(`{"key":"key1"}`)))
+		require.NoError(t, cmd.Batch(&bytes.Buffer{}, bytes.NewBufferString(`{"operations":[{"key":"key2"},`+
+			`{"key":"key3","value":"dmFsdWU=","tags":[{"name":"type","value":"credential"}],"ttl":60}]}`)))
+
+		require.NoError(t, cmd.Unsubscribe(&bytes.Buffer{}, bytes.NewBufferString(`{"id":"`+allID+`"}`)))
+		require.NoError(t, cmd.Increment(&bytes.Buffer{}, bytes.NewBufferString(`{"key":"counter","delta":1}`)))
+
+		byID := map[string][]ChangeEvent{}
+
+		for _, event := range events {
+			require.Equal(t, DefaultStoreName, event.StoreName)
+
+			byID[event.SubscriptionID] = append(byID[event.SubscriptionID], event)
+		}
+
+		require.Equal(t, []ChangeEvent{
+			{Key: "key1", Operation: ChangeOperationPut, Tags: credential, Value: []byte("value")},
+			{Key: "key2", Operation: ChangeOperationPut, Tags: []storage.Tag{{Name: "type", Value: "other"}},
+				Value: []byte("value")},
+			{Key: "key1", Operation: ChangeOperationDelete, Tags: credential},
+			{Key: "key2", Operation: ChangeOperationDelete, Tags: []storage.Tag{{Name: "type", Value: "other"}}},
+			{Key: "key3", Operation: ChangeOperationPut, Tags: credential, Value: []byte("value")},
+		}, withoutIDs(byID[allID]))
+
+		require.Equal(t, []ChangeEvent{
+			{Key: "key1", Operation: ChangeOperationPut, Tags: credential},
+			{Key: "key1", Operation: ChangeOperationDelete, Tags: credential},
+			{Key: "key3", Operation: ChangeOperationPut, Tags: credential},
+		}, withoutIDs(byID[credentialID]))
+	})
+
+	t.Run("Changes of commands sharing state, sweeper and import", func(t *testing.T) {
+		var (
+			mutex  sync.Mutex
+			events []ChangeEvent
+		)
+
+		notifier := mocks.NewMockNotifier()
+		notifier.NotifyFunc = func(topic string, message []byte) error {
+			var event ChangeEvent
+			require.NoError(t, json.Unmarshal(message, &event))
+
+			mutex.Lock()
+			events = append(events, event)
+			mutex.Unlock()
+
+			return nil
+		}
+
+		received := func() []ChangeEvent {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			return withoutIDs(events)
+		}
+
+		provider := &protocol.MockProvider{StoreProvider: mem.NewProvider()}
+
+		state := NewSharedState()
+
+		subscriber, err := New(provider, WithSharedState(state), WithNotifier(notifier),
+			WithSweepInterval(20*time.Millisecond))
+		require.NoError(t, err)
+
+		defer func() { require.NoError(t, subscriber.Close()) }()
+
+		writer, err := New(provider, WithSharedState(state))
+		require.NoError(t, err)
+
+		defer func() { require.NoError(t, writer.Close()) }()
+
+		require.NoError(t, subscriber.Subscribe(&bytes.Buffer{}, bytes.NewBufferString(`{"expression":"type"}`)))
+
+		tags := []storage.Tag{{Name: "type", Value: "temp"}}
+
+		req, err := json.Marshal(PutRequest{Key: "temp", Value: []byte("value"), Tags: tags, TTL: 1})
+		require.NoError(t, err)
+		require.NoError(t, writer.Put(&bytes.Buffer{}, bytes.NewBuffer(req)))
+
+		require.Eventually(t, func() bool {
+			return len(received()) == 2
+		}, 3*time.Second, 20*time.Millisecond)
+
+		require.Equal(t, []ChangeEvent{
+			{Key: "temp", Operation: ChangeOperationPut, Tags: tags},
+			{Key: "temp", Operation: ChangeOperationDelete, Tags: tags},
+		}, received())
+
+		req, err = json.Marshal(PutRequest{Key: "kept", Value: []byte("value"), Tags: tags})
+		require.NoError(t, err)
+		require.NoError(t, writer.Put(&bytes.Buffer{}, bytes.NewBuffer(req)))
+
+		res := &bytes.Buffer{}
+		require.NoError(t, writer.ExportStores(res, bytes.NewBufferString(`{"storeNames":[""],"tagNames":["type"],`+
+			`"passphrase":"secret"}`)))
+
+		var exported ExportStoresResponse
+		require.NoError(t, json.Unmarshal(res.Bytes(), &exported))
+
+		req, err = json.Marshal(ImportStoresRequest{Archive: exported.Archive, Passphrase: "secret"})
+		require.NoError(t, err)
+		require.NoError(t, writer.ImportStores(&bytes.Buffer{}, bytes.NewBuffer(req)))
+
+		require.Equal(t, []ChangeEvent{
+			{Key: "temp", Operation: ChangeOperationPut, Tags: tags},
+			{Key: "temp", Operation: ChangeOperationDelete, Tags: tags},
+			{Key: "kept", Operation: ChangeOperationPut, Tags: tags},
+			{Key: "kept", Operation: ChangeOperationPut, Tags: tags},
+		}, received())
+	})
+}
+
+func TestCommand_Indexes(t *testing.T) {
+	t.Run("Invalid request", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		require.EqualError(t, cmd.ConfigureIndexes(&bytes.Buffer{}, bytes.NewBufferString(``)), io.EOF.Error())
+		require.EqualError(t, cmd.GetIndexes(&bytes.Buffer{}, bytes.NewBufferString(``)), io.EOF.Error())
+		require.EqualError(t, cmd.RebuildIndexes(&bytes.Buffer{}, bytes.NewBufferString(``)), io.EOF.Error())
+
+		for _, indexes := range []string{
+			`[{"path":"$.a"}]`,
+			`[{"name":"_expiresAt","path":"$.a"}]`,
+			`[{"name":"a:b","path":"$.a"}]`,
+			`[{"name":"a","path":"$.a"},{"name":"a","path":"$.b"}]`,
+			`[{"name":"a","path":"$"}]`,
+			`[{"name":"a","path":"$.a..b"}]`,
+			`[{"name":"a","path":"$.a[x]"}]`,
+			`[{"name":"a","path":"$.a[0"}]`,
+		} {
+			cmdErr := cmd.ConfigureIndexes(&bytes.Buffer{}, bytes.NewBufferString(`{"indexes":`+indexes+`}`))
+			require.Error(t, cmdErr, indexes)
+			require.Equal(t, InvalidRequestErrorCode, cmdErr.Code(), indexes)
+		}
+
+		cmdErr := cmd.ConfigureIndexes(&bytes.Buffer{}, bytes.NewBufferString(`{"storeName":"agent-sdk-store-indexes"}`))
+		require.Error(t, cmdErr)
+		require.Equal(t, StoreNotAllowedErrorCode, cmdErr.Code())
+
+		cmdErr = cmd.QueryRecords(&bytes.Buffer{}, bytes.NewBufferString(`{"filter":{"prefix":"a"}}`))
+		require.EqualError(t, cmdErr, "tag name of filter is mandatory")
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+	})
+
+	t.Run("Index, query and rebuild", func(t *testing.T) {
+		provider := mem.NewProvider()
+
+		cmd, err := New(&protocol.MockProvider{StoreProvider: provider})
+		require.NoError(t, err)
+
+		put := func(key, value string, tags ...storage.Tag) {
+			req, e := json.Marshal(PutRequest{Key: key, Value: []byte(value), Tags: tags})
+			require.NoError(t, e)
+			require.NoError(t, cmd.Put(&bytes.Buffer{}, bytes.NewBuffer(req)))
+		}
+
+		getTags := func(key string) []storage.Tag {
+			res := &bytes.Buffer{}
+			require.NoError(t, cmd.GetTags(res, bytes.NewBufferString(`{"key":"`+key+`"}`)))
+
+			var resp GetTagsResponse
+			require.NoError(t, json.Unmarshal(res.Bytes(), &resp))
+
+			return resp.Tags
+		}
+
+		query := func(filter *QueryFilter) []string {
+			req, e := json.Marshal(QueryRequest{Filter: filter})
+			require.NoError(t, e)
+
+			res := &bytes.Buffer{}
+			require.NoError(t, cmd.QueryRecords(res, bytes.NewBuffer(req)))
+
+			var resp QueryRecordsResponse
+			require.NoError(t, json.Unmarshal(res.Bytes(), &resp))
+
+			var keys []string
+
+			for _, record := range resp.Records {
+				keys = append(keys, record.Key)
+			}
+
+			return keys
+		}
+
+		put("old", `{"age":40,"name":"Carol"}`, storage.Tag{Name: "type", Value: "person"})
+
+		require.NoError(t, cmd.ConfigureIndexes(&bytes.Buffer{}, bytes.NewBufferString(`{"indexes":[`+
+			`{"name":"age","path":"$.age"},{"name":"name","path":"name"},{"name":"kind","path":"$.kinds[0]"}]}`)))
+
+		put("alice", `{"age":30,"name":"Alice","kinds":["admin","user"]}`, storage.Tag{Name: "age", Value: "1"})
+		put("bob", `{"age":9,"name":"Bob"}`)
+		put("text", `not json`, storage.Tag{Name: "type", Value: "text"})
+
+		require.NoError(t, cmd.Batch(&bytes.Buffer{}, bytes.NewBufferString(
+			`{"operations":[{"key":"dave","value":"eyJhZ2UiOjUwLCJuYW1lIjoiRGF2ZSJ9"}]}`)))
+
+		require.Equal(t, []storage.Tag{
+			{Name: "age", Value: "30"}, {Name: "name", Value: "Alice"}, {Name: "kind", Value: "admin"},
+		}, getTags("alice"))
+		require.Equal(t, []storage.Tag{{Name: "age", Value: "50"}, {Name: "name", Value: "Dave"}}, getTags("dave"))
+		require.Equal(t, []storage.Tag{{Name: "type", Value: "text"}}, getTags("text"))
+
+		require.ElementsMatch(t, []string{"alice", "bob"}, query(&QueryFilter{TagName: "age", Max: "30"}))
+		require.ElementsMatch(t, []string{"alice", "dave"}, query(&QueryFilter{TagName: "age", Min: "10"}))
+		require.ElementsMatch(t, []string{"bob"}, query(&QueryFilter{TagName: "name", Prefix: "B"}))
+		require.ElementsMatch(t, []string{"alice", "bob"}, query(&QueryFilter{TagName: "name", Min: "A", Max: "C"}))
+		require.Empty(t, query(&QueryFilter{TagName: "age", Min: "60"}))
+
+		res := &bytes.Buffer{}
+		require.NoError(t, cmd.RebuildIndexes(res, bytes.NewBufferString(`{"tagNames":["type"]}`)))
+		require.JSONEq(t, `{"updated":1}`, res.String())
+		require.Equal(t, []storage.Tag{
+			{Name: "type", Value: "person"}, {Name: "age", Value: "40"}, {Name: "name", Value: "Carol"},
+		}, getTags("old"))
+
+		require.NoError(t, cmd.ConfigureIndexes(&bytes.Buffer{},
+			bytes.NewBufferString(`{"indexes":[{"name":"age","path":"$.age"}]}`)))
+
+		res = &bytes.Buffer{}
+		require.NoError(t, cmd.GetIndexes(res, bytes.NewBufferString(`{}`)))
+		require.JSONEq(t, `{"indexes":[{"name":"age","path":"$.age"}],"stale":["name","kind"]}`, res.String())
+
+		// definitions are persisted
+		restarted, err := New(&protocol.MockProvider{StoreProvider: provider})
+		require.NoError(t, err)
+
+		res = &bytes.Buffer{}
+		require.NoError(t, restarted.RebuildIndexes(res, bytes.NewBufferString(`{}`)))
+		require.JSONEq(t, `{"updated":4}`, res.String())
+		require.Equal(t, []storage.Tag{{Name: "age", Value: "30"}}, getTags("alice"))
+		require.Equal(t, []storage.Tag{{Name: "type", Value: "person"}, {Name: "age", Value: "40"}}, getTags("old"))
+
+		res = &bytes.Buffer{}
+		require.NoError(t, restarted.GetIndexes(res, bytes.NewBufferString(`{}`)))
+		require.JSONEq(t, `{"indexes":[{"name":"age","path":"$.age"}]}`, res.String())
+	})
+
+	t.Run("Index values containing colons", func(t *testing.T) {
+		cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+
+		require.NoError(t, cmd.ConfigureIndexes(&bytes.Buffer{}, bytes.NewBufferString(`{"indexes":[`+
+			`{"name":"issuer","path":"$.issuer"},{"name":"issued","path":"$.issued"}]}`)))
+
+		for key, value := range map[string]string{
+			"first":  `{"issuer":"did:example:123","issued":"2022-01-01T10:00:00Z"}`,
+			"second": `{"issuer":"did:example:456","issued":"2022-02-01T10:00:00Z"}`,
+			"third":  `{"issuer":"100%","issued":"2021-12-31T23:59:59Z"}`,
+		} {
+			req, e := json.Marshal(PutRequest{Key: key, Value: []byte(value)})
+			require.NoError(t, e)
+			require.NoError(t, cmd.Put(&bytes.Buffer{}, bytes.NewBuffer(req)))
+		}
+
+		res := &bytes.Buffer{}
+		require.NoError(t, cmd.GetTags(res, bytes.NewBufferString(`{"key":"first"}`)))
+		require.JSONEq(t, `{"tags":[{"name":"issuer","value":"did%3Aexample%3A123"},`+
+			`{"name":"issued","value":"2022-01-01T10%3A00%3A00Z"}]}`, res.String())
+
+		query := func(request *QueryRequest) []string {
+			req, e := json.Marshal(request)
+			require.NoError(t, e)
+
+			out := &bytes.Buffer{}
+			require.NoError(t, cmd.QueryRecords(out, bytes.NewBuffer(req)))
+
+			var resp QueryRecordsResponse
+			require.NoError(t, json.Unmarshal(out.Bytes(), &resp))
+
+			return recordKeys(&resp)
+		}
+
+		require.Equal(t, []string{"first"}, query(&QueryRequest{Expression: "issuer:did%3Aexample%3A123"}))
+		require.Equal(t, []string{"third"}, query(&QueryRequest{Expression: "issuer:100%25"}))
+		require.ElementsMatch(t, []string{"first", "second"},
+			query(&QueryRequest{Filter: &QueryFilter{TagName: "issuer", Prefix: "did:example:"}}))
+		require.ElementsMatch(t, []string{"first", "third"}, query(&QueryRequest{Filter: &QueryFilter{
+			TagName: "issued", Min: "2021-12-31T00:00:00Z", Max: "2022-01-01T10:00:00Z",
+		}}))
+	})
+
+	t.Run("Indexes of commands sharing state", func(t *testing.T) {
+		provider := &protocol.MockProvider{StoreProvider: mem.NewProvider()}
+		state := NewSharedState()
+
+		configuring, err := New(provider, WithSharedState(state))
+		require.NoError(t, err)
+
+		writing, err := New(provider, WithSharedState(state))
+		require.NoError(t, err)
+
+		require.NoError(t, writing.Put(&bytes.Buffer{}, bytes.NewBufferString(`{"key":"untagged","value":"eyJhZ2UiOjF9"}`)))
+
+		require.NoError(t, configuring.ConfigureIndexes(&bytes.Buffer{},
+			bytes.NewBufferString(`{"indexes":[{"name":"age","path":"$.age"}]}`)))
+
+		require.NoError(t, writing.Put(&bytes.Buffer{}, bytes.NewBufferString(`{"key":"new","value":"eyJhZ2UiOjJ9"}`)))
+
+		res := &bytes.Buffer{}
+		require.NoError(t, writing.GetTags(res, bytes.NewBufferString(`{"key":"new"}`)))
+		require.JSONEq(t, `{"tags":[{"name":"age","value":"2"}]}`, res.String())
+
+		res = &bytes.Buffer{}
+		require.NoError(t, configuring.RebuildIndexes(res, bytes.NewBufferString(`{}`)))
+		require.JSONEq(t, `{"updated":1}`, res.String())
+
+		res = &bytes.Buffer{}
+		require.NoError(t, writing.GetTags(res, bytes.NewBufferString(`{"key":"untagged"}`)))
+		require.JSONEq(t, `{"tags":[{"name":"age","value":"1"}]}`, res.String())
+	})
+}
+
+// withoutIDs returns given change events without subscription IDs and store names.
+func withoutIDs(events []ChangeEvent) []ChangeEvent {
+	result := make([]ChangeEvent, len(events))
+
+	for i, event := range events {
+		event.SubscriptionID, event.StoreName = "", ""
+		result[i] = event
+	}
+
+	return result
+}
+
+// failingBatchProvider opens stores whose batches fail for the store with given name.
+type failingBatchProvider struct {
+	storage.Provider
+	storeName string
+}
+
+func (p *failingBatchProvider) OpenStore(name string) (storage.Store, error) {
+	store, err := p.Provider.OpenStore(name)
+	if err != nil || name != p.storeName {
+		return store, err
+	}
+
+	return &failingBatchStore{Store: store}, nil
+}
+
+type failingBatchStore struct {
+	storage.Store
+}
+
+func (s *failingBatchStore) Batch([]storage.Operation) error {
+	return errors.New("batch failure")
+}
+
+func newKeyManager(t *testing.T) (kms.KeyManager, cryptoapi.Crypto) {
+	t.Helper()
+
+	kmsStore, err := kms.NewAriesProviderWrapper(mem.NewProvider())
+	require.NoError(t, err)
+
+	keyManager, err := localkms.New("local-lock://test/master/key/", &kmsProvider{store: kmsStore, lock: &noop.NoLock{}})
+	require.NoError(t, err)
+
+	crypto, err := tinkcrypto.New()
+	require.NoError(t, err)
+
+	return keyManager, crypto
+}
+
+type kmsProvider struct {
+	store kms.Store
+	lock  secretlock.Service
+}
+
+func (p *kmsProvider) StorageProvider() kms.Store {
+	return p.store
+}
+
+func (p *kmsProvider) SecretLock() secretlock.Service {
+	return p.lock
+}
+
+type mockStore struct {
+	queryFunc   func(string, ...storage.QueryOption) (storage.Iterator, error)
+	getTagsFunc func(string) ([]storage.Tag, error)
+	getBulkFunc func(...string) ([][]byte, error)
+	batchFunc   func([]storage.Operation) error
+}
+
+func (m *mockStore) Put(key string, value []byte, tags ...storage.Tag) error {
+	panic("implement me")
+}
+
+func (m *mockStore) Get(key string) ([]byte, error) {
+	return nil, storage.ErrDataNotFound
+}
+
+func (m *mockStore) GetTags(key string) ([]storage.Tag, error) {
+	return m.getTagsFunc(key)
+}
+
+func (m *mockStore) GetBulk(keys ...string) ([][]byte, error) {
+	return m.getBulkFunc(keys...)
+}
+
+func (m *mockStore) Query(expression string, options ...storage.QueryOption) (storage.Iterator, error) {
+	return m.queryFunc(expression, options...)
+}
+
+func (m *mockStore) Delete(key string) error {
+	panic("implement me")
+}
+
+func (m *mockStore) Batch(operations []storage.Operation) error {
+	return m.batchFunc(operations)
+}
+
+func (m *mockStore) Flush() error {
+	panic("implement me")
 }
 
 func (m *mockStore) Close() error {
 	panic("implement me")
 }
+
+// mockIterator iterates over given number of records with keys 'key0', 'key1', etc.
+type mockIterator struct {
+	count   int
+	current int
+	errTags error
+	// errTagsFrom is the index of the first record whose tags fail with errTags.
+	errTagsFrom int
+}
+
+func newMockIterator(count int) *mockIterator {
+	return &mockIterator{count: count, current: -1}
+}
+
+func (m *mockIterator) Next() (bool, error) {
+	m.current++
+
+	return m.current < m.count, nil
+}
+
+func (m *mockIterator) Key() (string, error) {
+	return fmt.Sprintf("key%d", m.current), nil
+}
+
+func (m *mockIterator) Value() ([]byte, error) {
+	return []byte(fmt.Sprintf("value%d", m.current)), nil
+}
+
+func (m *mockIterator) Tags() ([]storage.Tag, error) {
+	if m.current >= m.errTagsFrom {
+		return []storage.Tag{{Name: "tag"}}, m.errTags
+	}
+
+	return []storage.Tag{{Name: "tag"}}, nil
+}
+
+func (m *mockIterator) TotalItems() (int, error) {
+	return m.count, nil
+}
+
+func (m *mockIterator) Close() error {
+	return nil
+}
+
+type testRecord struct {
+	key  string
+	tags []storage.Tag
+}
+
+// numberedRecords returns records with given keys and tag 'n' numbering them from 1.
+func numberedRecords(keys ...string) []testRecord {
+	records := make([]testRecord, len(keys))
+
+	for i, key := range keys {
+		records[i] = testRecord{key: key, tags: []storage.Tag{{Name: "n", Value: strconv.Itoa(i + 1)}}}
+	}
+
+	return records
+}
+
+// newRecordsCommand returns command whose queries iterate over given records in their order.
+func newRecordsCommand(t *testing.T, records *[]testRecord) *Command {
+	t.Helper()
+
+	cmd, err := New(&protocol.MockProvider{StoreProvider: &storeutil.Provider{
+		OpenStoreReturn: &mockStore{
+			queryFunc: func(string, ...storage.QueryOption) (storage.Iterator, error) {
+				return &recordsIterator{records: *records, current: -1}, nil
+			},
+		},
+	}})
+	require.NoError(t, err)
+
+	return cmd
+}
+
+func recordKeys(resp *QueryRecordsResponse) []string {
+	var keys []string
+
+	for _, record := range resp.Records {
+		keys = append(keys, record.Key)
+	}
+
+	return keys
+}
+
+// recordsIterator iterates over given records, values of records are their keys.
+type recordsIterator struct {
+	records []testRecord
+	current int
+}
+
+func (r *recordsIterator) Next() (bool, error) {
+	r.current++
+
+	return r.current < len(r.records), nil
+}
+
+func (r *recordsIterator) Key() (string, error) {
+	return r.records[r.current].key, nil
+}
+
+func (r *recordsIterator) Value() ([]byte, error) {
+	return []byte(r.records[r.current].key), nil
+}
+
+func (r *recordsIterator) Tags() ([]storage.Tag, error) {
+	return r.records[r.current].tags, nil
+}
+
+func (r *recordsIterator) TotalItems() (int, error) {
+	return len(r.records), nil
+}
+
+func (r *recordsIterator) Close() error {
+	return nil
+}
@@ -0,0 +1,500 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+)
+
+// indexesStoreName is the name of the internal store keeping index definitions of stores, it is never allowed
+// to be accessed by clients.
+const indexesStoreName = "agent-sdk-store-indexes"
+
+var (
+	errInvalidIndex = errors.New("invalid index")
+	errInvalidPath  = errors.New("invalid JSON path")
+)
+
+// Storage providers do not allow ':' in tag values, so it is escaped in values derived by indexes, along
+// with the escape character itself. Filters unescape values of index tags before matching them.
+//
+//nolint:gochecknoglobals
+var (
+	tagValueEscaper   = strings.NewReplacer("%", "%25", ":", "%3A")
+	tagValueUnescaper = strings.NewReplacer("%25", "%", "%3A", ":")
+)
+
+// jsonPath is a parsed JSON path of an index, e.g. '$.credentialSubject.degree.type' or '$.type[0]'.
+type jsonPath []pathSegment
+
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parsePath parses JSON path consisting of object keys and array indexes. Leading '$' is optional.
+func parsePath(path string) (jsonPath, error) {
+	rest := strings.TrimPrefix(path, "$")
+	if rest == path {
+		rest = "." + path
+	}
+
+	var segments jsonPath
+
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[") + 1
+			if end == 0 {
+				end = len(rest)
+			}
+
+			if end == 1 {
+				return nil, fmt.Errorf("%w: %s", errInvalidPath, path)
+			}
+
+			segments = append(segments, pathSegment{key: rest[1:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("%w: %s", errInvalidPath, path)
+			}
+
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("%w: %s", errInvalidPath, path)
+			}
+
+			segments = append(segments, pathSegment{index: index, isIndex: true})
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("%w: %s", errInvalidPath, path)
+		}
+	}
+
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("%w: %s", errInvalidPath, path)
+	}
+
+	return segments, nil
+}
+
+// values returns scalar values found at the path in given JSON document. Array of scalars yields a value
+// for each of its items; objects and nulls yield no values.
+func (p jsonPath) values(doc interface{}) []string {
+	current := doc
+
+	for _, segment := range p {
+		if segment.isIndex {
+			array, ok := current.([]interface{})
+			if !ok || segment.index >= len(array) {
+				return nil
+			}
+
+			current = array[segment.index]
+
+			continue
+		}
+
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		if current, ok = object[segment.key]; !ok {
+			return nil
+		}
+	}
+
+	items, ok := current.([]interface{})
+	if !ok {
+		items = []interface{}{current}
+	}
+
+	var values []string
+
+	for _, item := range items {
+		switch value := item.(type) {
+		case string:
+			values = append(values, value)
+		case json.Number:
+			values = append(values, value.String())
+		case bool:
+			values = append(values, strconv.FormatBool(value))
+		}
+	}
+
+	return values
+}
+
+type index struct {
+	name string
+	path jsonPath
+}
+
+// storeIndexes is the persisted form of index definitions of a store. Stale are names of removed indexes
+// whose tags are left on records until indexes are rebuilt.
+type storeIndexes struct {
+	Indexes []IndexDefinition `json:"indexes"`
+	Stale   []string          `json:"stale,omitempty"`
+}
+
+func (s *storeIndexes) compile() ([]index, error) {
+	indexes := make([]index, len(s.Indexes))
+	names := map[string]bool{}
+
+	for i, definition := range s.Indexes {
+		if definition.Name == "" || isReservedTag(definition.Name) || strings.Contains(definition.Name, ":") ||
+			names[definition.Name] {
+			return nil, fmt.Errorf("%w: missing, reserved or duplicate name '%s'", errInvalidIndex, definition.Name)
+		}
+
+		names[definition.Name] = true
+
+		path, err := parsePath(definition.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%w %s: %s", errInvalidIndex, definition.Name, err)
+		}
+
+		indexes[i] = index{name: definition.Name, path: path}
+	}
+
+	return indexes, nil
+}
+
+// indexRegistry keeps index definitions of stores in the internal store, caching them once loaded. It is shared
+// by store commands of a shared state, so that the cache is never stale.
+type indexRegistry struct {
+	store storage.Store
+
+	mu      sync.RWMutex
+	loaded  map[string]*storeIndexes
+	indexes map[string][]index
+}
+
+func newIndexRegistry(store storage.Store) *indexRegistry {
+	return &indexRegistry{store: store, loaded: map[string]*storeIndexes{}, indexes: map[string][]index{}}
+}
+
+// get returns index definitions of the store with given name, loading them if needed.
+func (r *indexRegistry) get(storeName string) (*storeIndexes, []index, error) {
+	r.mu.RLock()
+	definitions, ok := r.loaded[storeName]
+	indexes := r.indexes[storeName]
+	r.mu.RUnlock()
+
+	if ok {
+		return definitions, indexes, nil
+	}
+
+	definitions = &storeIndexes{}
+
+	definitionsBytes, err := r.store.Get(storeName)
+
+	switch {
+	case errors.Is(err, storage.ErrDataNotFound):
+	case err != nil:
+		return nil, nil, fmt.Errorf("failed to get indexes of store %s: %w", storeName, err)
+	default:
+		if err = json.Unmarshal(definitionsBytes, definitions); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse indexes of store %s: %w", storeName, err)
+		}
+	}
+
+	indexes, err = definitions.compile()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.loaded[storeName] = definitions
+	r.indexes[storeName] = indexes
+
+	return definitions, indexes, nil
+}
+
+// set replaces index definitions of the store with given name, names of removed indexes become stale.
+func (r *indexRegistry) set(storeName string, definitions []IndexDefinition) error {
+	current, _, err := r.get(storeName)
+	if err != nil {
+		return err
+	}
+
+	updated := &storeIndexes{Indexes: definitions}
+
+	indexes, err := updated.compile()
+	if err != nil {
+		return err
+	}
+
+	names := map[string]bool{}
+
+	for _, definition := range definitions {
+		names[definition.Name] = true
+	}
+
+	for _, name := range append(current.names(), current.Stale...) {
+		if !names[name] {
+			names[name] = true
+			updated.Stale = append(updated.Stale, name)
+		}
+	}
+
+	return r.save(storeName, updated, indexes)
+}
+
+// clearStale forgets stale index names of the store with given name, after its indexes were rebuilt.
+func (r *indexRegistry) clearStale(storeName string) error {
+	current, indexes, err := r.get(storeName)
+	if err != nil || len(current.Stale) == 0 {
+		return err
+	}
+
+	return r.save(storeName, &storeIndexes{Indexes: current.Indexes}, indexes)
+}
+
+func (r *indexRegistry) save(storeName string, definitions *storeIndexes, indexes []index) error {
+	var err error
+
+	if len(definitions.Indexes) == 0 && len(definitions.Stale) == 0 {
+		err = r.store.Delete(storeName)
+	} else {
+		var definitionsBytes []byte
+
+		definitionsBytes, err = json.Marshal(definitions)
+		if err == nil {
+			err = r.store.Put(storeName, definitionsBytes)
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to save indexes of store %s: %w", storeName, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.loaded[storeName] = definitions
+	r.indexes[storeName] = indexes
+
+	return nil
+}
+
+func (s *storeIndexes) names() []string {
+	names := make([]string, len(s.Indexes))
+
+	for i, definition := range s.Indexes {
+		names[i] = definition.Name
+	}
+
+	return names
+}
+
+// indexTags returns given tags with tags derived from JSON value by given indexes, replacing tags of the same
+// names and tags with given stale names. Values which are not JSON yield no index tags.
+func indexTags(indexes []index, stale []string, value []byte, tags []storage.Tag) []storage.Tag {
+	if len(indexes) == 0 && len(stale) == 0 {
+		return tags
+	}
+
+	derived := map[string]bool{}
+
+	for _, name := range stale {
+		derived[name] = true
+	}
+
+	for _, idx := range indexes {
+		derived[idx.name] = true
+	}
+
+	result := make([]storage.Tag, 0, len(tags))
+
+	for _, tag := range tags {
+		if !derived[tag.Name] {
+			result = append(result, tag)
+		}
+	}
+
+	var doc interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&doc); err != nil {
+		return result
+	}
+
+	for _, idx := range indexes {
+		for _, v := range idx.path.values(doc) {
+			result = append(result, storage.Tag{Name: idx.name, Value: tagValueEscaper.Replace(v)})
+		}
+	}
+
+	return result
+}
+
+// indexTags returns given tags with tags derived by indexes of the store with given name from given value.
+func (c *Command) indexTags(storeName string, value []byte, tags []storage.Tag) ([]storage.Tag, error) {
+	_, indexes, err := c.indexes.get(storeName)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexTags(indexes, nil, value, tags), nil
+}
+
+// indexOperations derives tags of put operations by indexes of the store with given name.
+func (c *Command) indexOperations(storeName string, operations []storage.Operation) error {
+	_, indexes, err := c.indexes.get(storeName)
+	if err != nil {
+		return err
+	}
+
+	for i := range operations {
+		if operations[i].Value != nil {
+			operations[i].Tags = indexTags(indexes, nil, operations[i].Value, operations[i].Tags)
+		}
+	}
+
+	return nil
+}
+
+// rebuildIndexes updates index tags of records in given store which can be found by the record tag, tags
+// of the store configuration, index tags or given tags, returns number of updated records. Record tag is added
+// to records missing it, so that they are found afterwards.
+func (c *Command) rebuildIndexes(store storage.Store, storeName string, tagNames []string) (int, error) {
+	definitions, indexes, err := c.indexes.get(storeName)
+	if err != nil {
+		return 0, err
+	}
+
+	config, err := storeConfig(c.provider, storeName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get configuration of store %s: %w", storeName, err)
+	}
+
+	names := append(append(append(append([]string{recordTag}, config.TagNames...), tagNames...),
+		definitions.names()...), definitions.Stale...)
+
+	records, err := queryRecords(store, names)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read records of store %s: %w", storeName, err)
+	}
+
+	var operations []storage.Operation
+
+	for _, record := range records {
+		tags := withRecordTag(indexTags(indexes, definitions.Stale, record.Value, record.Tags))
+
+		if !equalTags(tags, record.Tags) {
+			operations = append(operations, storage.Operation{Key: record.Key, Value: record.Value, Tags: tags})
+		}
+	}
+
+	if len(operations) > 0 {
+		if err = store.Batch(operations); err != nil {
+			return 0, fmt.Errorf("failed to update records of store %s: %w", storeName, err)
+		}
+	}
+
+	if err = c.indexes.clearStale(storeName); err != nil {
+		return 0, err
+	}
+
+	return len(operations), nil
+}
+
+func equalTags(tags, other []storage.Tag) bool {
+	if len(tags) != len(other) {
+		return false
+	}
+
+	for i := range tags {
+		if tags[i] != other[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filterMatches checks whether given tags match the range and prefix filter of a query, values of the filtered
+// tag are unescaped first if it is an index tag.
+func filterMatches(filter *QueryFilter, indexed bool, tags []storage.Tag) bool {
+	if filter == nil {
+		return true
+	}
+
+	for _, tag := range tags {
+		if tag.Name != filter.TagName {
+			continue
+		}
+
+		value := tag.Value
+		if indexed {
+			value = tagValueUnescaper.Replace(value)
+		}
+
+		if !strings.HasPrefix(value, filter.Prefix) {
+			continue
+		}
+
+		if (filter.Min == "" || compareValues(value, filter.Min) >= 0) &&
+			(filter.Max == "" || compareValues(value, filter.Max) <= 0) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isIndex checks whether tag with given name is derived by an index of the store with given name.
+func (c *Command) isIndex(storeName, tagName string) (bool, error) {
+	_, indexes, err := c.indexes.get(storeName)
+	if err != nil {
+		return false, err
+	}
+
+	for _, idx := range indexes {
+		if idx.name == tagName {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// compareValues compares tag values as numbers if both are numbers, as strings otherwise.
+func compareValues(value, other string) int {
+	x, errX := strconv.ParseFloat(value, 64)
+	y, errY := strconv.ParseFloat(other, 64)
+
+	if errX != nil || errY != nil {
+		return strings.Compare(value, other)
+	}
+
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
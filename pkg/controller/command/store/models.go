@@ -7,22 +7,41 @@ SPDX-License-Identifier: Apache-2.0
 
 package store
 
-import "github.com/hyperledger/aries-framework-go/spi/storage"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+)
 
 // PutRequest model
 //
 // This is used for putting data in the store.
 type PutRequest struct {
-	Key   string        `json:"key"`
-	Value []byte        `json:"value"`
-	Tags  []storage.Tag `json:"tags"`
+	Key       string        `json:"key"`
+	Value     []byte        `json:"value"`
+	Tags      []storage.Tag `json:"tags"`
+	StoreName string        `json:"storeName,omitempty"`
+	// IfMatch is the version the record must have for the put to succeed, record is put unconditionally if not set.
+	IfMatch string `json:"ifMatch,omitempty"`
+	// TTL is the number of seconds after which the record expires, record does not expire if not set.
+	TTL int64 `json:"ttl,omitempty"`
+}
+
+// PutResponse model
+//
+// Represents a response of Put command.
+type PutResponse struct {
+	// Version of the record put.
+	Version string `json:"version"`
 }
 
 // GetRequest model
 //
 // This is used for getting data (value or tags) from the store.
 type GetRequest struct {
-	Key string `json:"key"`
+	Key       string `json:"key"`
+	StoreName string `json:"storeName,omitempty"`
 }
 
 // GetResponse model
@@ -30,26 +49,358 @@ type GetRequest struct {
 // Represents a response of Get command.
 type GetResponse struct {
 	Result []byte `json:"result"`
+	// Version of the record, to be used as ifMatch condition of subsequent writes.
+	Version string `json:"version,omitempty"`
 }
 
 // QueryRequest model
 //
-// This is used for getting data (values only, without tags) from the store.
+// This is used for querying records from the store.
 type QueryRequest struct {
 	Expression string `json:"expression"`
-	PageSize   int    `json:"pageSize"`
+	// PageSize is a hint for the storage provider on how many records to fetch at once.
+	PageSize  int    `json:"pageSize"`
+	StoreName string `json:"storeName,omitempty"`
+	// Limit is the maximum number of records returned, all matching records are returned if not set.
+	Limit int `json:"limit,omitempty"`
+	// Cursor returned by the previous query, to continue with the records following the last returned record.
+	// Unsorted results cannot be continued if that record was deleted or no longer matches the query.
+	Cursor string `json:"cursor,omitempty"`
+	// SortTagName is the name of the tag by whose values records are sorted.
+	// Sorting is recommended when paging through results, as the order of unsorted results is storage specific.
+	SortTagName    string `json:"sortTagName,omitempty"`
+	SortDescending bool   `json:"sortDescending,omitempty"`
+	// WithTotal requests the total number of records returned by the query over all pages, which requires
+	// reading all records matching the expression.
+	WithTotal bool `json:"withTotal,omitempty"`
+	// Filter restricts records matching the expression by prefix or range of a tag value, e.g. of an index.
+	// Expression defaults to the name of the filtered tag if not set.
+	Filter *QueryFilter `json:"filter,omitempty"`
+}
+
+// QueryFilter model
+//
+// Represents prefix and range conditions on values of a tag, records having any value of the tag
+// matching all conditions pass. Min and max are compared as numbers if both values are numbers.
+// Conditions are evaluated by the command on records matching the query expression, storage is not queried
+// by them: all records matching the expression, i.e. all records carrying the tag by default, are read.
+// Values of index tags are unescaped before they are compared.
+type QueryFilter struct {
+	TagName string `json:"tagName"`
+	Prefix  string `json:"prefix,omitempty"`
+	// Min is the inclusive lower bound of tag values.
+	Min string `json:"min,omitempty"`
+	// Max is the inclusive upper bound of tag values.
+	Max string `json:"max,omitempty"`
 }
 
 // QueryResponse model
 //
 // Represents a response of Query command.
 type QueryResponse struct {
+	// Results are values of records.
 	Results [][]byte `json:"results"`
+	// Cursor for retrieving the next records, present only if more records match.
+	Cursor string `json:"cursor,omitempty"`
+	// TotalItems is the total number of records returned by the query over all pages, present only if requested.
+	TotalItems *int `json:"totalItems,omitempty"`
+	// Error of reading records after some of them were written, results are incomplete and there is no cursor.
+	// Command fails in that case, this is for clients receiving the response as it is written.
+	Error string `json:"error,omitempty"`
+}
+
+// QueryRecordsResponse model
+//
+// Represents a response of QueryRecords command.
+type QueryRecordsResponse struct {
+	Records []QueryRecord `json:"records"`
+	// Cursor for retrieving the next records, present only if more records match.
+	Cursor string `json:"cursor,omitempty"`
+	// TotalItems is the total number of records returned by the query over all pages, present only if requested.
+	TotalItems *int `json:"totalItems,omitempty"`
+	// Error of reading records after some of them were written, records are incomplete and there is no cursor.
+	// Command fails in that case, this is for clients receiving the response as it is written.
+	Error string `json:"error,omitempty"`
+}
+
+// QueryRecord model
+//
+// Represents a record returned by QueryRecords command.
+type QueryRecord struct {
+	Key     string        `json:"key"`
+	Value   []byte        `json:"value"`
+	Tags    []storage.Tag `json:"tags"`
+	Version string        `json:"version"`
 }
 
 // DeleteRequest model
 //
 // This is used for deleting data from the store.
 type DeleteRequest struct {
+	Key       string `json:"key"`
+	StoreName string `json:"storeName,omitempty"`
+	// IfMatch is the version the record must have for the delete to succeed, record is deleted unconditionally
+	// if not set.
+	IfMatch string `json:"ifMatch,omitempty"`
+}
+
+// ListStoresResponse model
+//
+// Represents a response of ListStores command.
+type ListStoresResponse struct {
+	// Stores ever opened through store commands, including before restarts, which are allowed by the allowlist.
+	Stores []string `json:"stores"`
+	// Allowed names of stores, names ending with '*' allow all stores with given prefix.
+	Allowed []string `json:"allowed"`
+}
+
+// GetTagsRequest model
+//
+// This is used for getting tags of a record from the store.
+type GetTagsRequest struct {
+	Key       string `json:"key"`
+	StoreName string `json:"storeName,omitempty"`
+}
+
+// GetTagsResponse model
+//
+// Represents a response of GetTags command.
+type GetTagsResponse struct {
+	Tags []storage.Tag `json:"tags"`
+}
+
+// GetBulkRequest model
+//
+// This is used for getting values of multiple records from the store.
+type GetBulkRequest struct {
+	Keys      []string `json:"keys"`
+	StoreName string   `json:"storeName,omitempty"`
+}
+
+// GetBulkResponse model
+//
+// Represents a response of GetBulk command, results are in the same order as requested keys
+// and are null for keys not found.
+type GetBulkResponse struct {
+	Results [][]byte `json:"results"`
+}
+
+// BatchRequest model
+//
+// This is used for performing multiple put and delete operations on the store atomically.
+type BatchRequest struct {
+	Operations []BatchOperation `json:"operations"`
+	StoreName  string           `json:"storeName,omitempty"`
+}
+
+// BatchOperation model
+//
+// Represents a single operation of Batch command, operations without value delete the record.
+type BatchOperation struct {
+	Key   string        `json:"key"`
+	Value []byte        `json:"value,omitempty"`
+	Tags  []storage.Tag `json:"tags,omitempty"`
+	// TTL is the number of seconds after which the put record expires.
+	TTL int64 `json:"ttl,omitempty"`
+}
+
+// IncrementRequest model
+//
+// This is used for atomically incrementing an integer counter stored in the record.
+type IncrementRequest struct {
 	Key string `json:"key"`
+	// Delta added to the counter, may be negative. Missing record is treated as a counter with zero value.
+	Delta     int64  `json:"delta"`
+	StoreName string `json:"storeName,omitempty"`
+}
+
+// IncrementResponse model
+//
+// Represents a response of Increment command.
+type IncrementResponse struct {
+	// Value of the counter after increment.
+	Value   int64  `json:"value"`
+	Version string `json:"version"`
+}
+
+// SweeperStatsResponse model
+//
+// Represents a response of SweeperStats command.
+type SweeperStatsResponse struct {
+	// Enabled tells whether the sweeper deleting expired records runs.
+	Enabled  bool   `json:"enabled"`
+	Interval string `json:"interval"`
+	// Runs is the number of sweeper runs so far.
+	Runs int `json:"runs"`
+	// ExpiredRecords is the number of expired records deleted by all runs.
+	ExpiredRecords        int        `json:"expiredRecords"`
+	LastRunAt             *time.Time `json:"lastRunAt,omitempty"`
+	LastRunDuration       string     `json:"lastRunDuration,omitempty"`
+	LastRunExpiredRecords int        `json:"lastRunExpiredRecords"`
+	// LastError of the last run, if deleting expired records failed for any store.
+	LastError string `json:"lastError,omitempty"`
+}
+
+// ExportStoresRequest model
+//
+// This is used for exporting stores into an encrypted archive.
+type ExportStoresRequest struct {
+	StoreNames []string `json:"storeNames"`
+	// TagNames of records written to the storage otherwise than through the store command to be exported,
+	// in addition to tags from store configurations and index tags. Storage can't list all records, so such
+	// records without any of these tags are not exported.
+	TagNames []string `json:"tagNames,omitempty"`
+	// Passphrase the archive is encrypted to, exactly one of passphrase, key or key ID must be set.
+	Passphrase string `json:"passphrase,omitempty"`
+	// Key is the public JWK the archive is encrypted to.
+	Key json.RawMessage `json:"key,omitempty"`
+	// KeyID is ID of the key agreement key of the agent's key manager the archive is encrypted to,
+	// e.g. a key of NISTP256ECDHKW type.
+	KeyID string `json:"keyID,omitempty"`
+}
+
+// ExportStoresResponse model
+//
+// Represents a response of ExportStores command.
+type ExportStoresResponse struct {
+	// Archive encrypted as a compact JWE.
+	Archive string `json:"archive"`
+	// Stores lists numbers of records exported from each store. Only records written through the store command
+	// and records having tags from store configurations, index tags or requested tags are exported, so numbers
+	// lower than expected mean that records written otherwise were missed.
+	Stores []ExportedStore `json:"stores"`
+}
+
+// ExportedStore model
+//
+// Number of records exported from the store with given name.
+type ExportedStore struct {
+	Name    string `json:"name"`
+	Records int    `json:"records"`
+}
+
+// ImportStoresRequest model
+//
+// This is used for importing stores from an archive created by ExportStores.
+type ImportStoresRequest struct {
+	Archive string `json:"archive"`
+	// Passphrase the archive was encrypted to, exactly one of passphrase, key or key ID must be set.
+	Passphrase string `json:"passphrase,omitempty"`
+	// Key is the private JWK the archive was encrypted to. Prefer key ID, so that private keys are not
+	// passed in requests.
+	Key json.RawMessage `json:"key,omitempty"`
+	// KeyID is ID of the key of the agent's key manager the archive was encrypted to.
+	KeyID string `json:"keyID,omitempty"`
+	// Mode is either 'merge' (default), keeping records not in the archive, or 'replace', deleting them.
+	Mode string `json:"mode,omitempty"`
+}
+
+// ImportStoresResponse model
+//
+// Represents a response of ImportStores command.
+type ImportStoresResponse struct {
+	Stores []ImportedStore `json:"stores"`
+}
+
+// ImportedStore model
+//
+// Number of records imported to the store with given name.
+type ImportedStore struct {
+	Name    string `json:"name"`
+	Records int    `json:"records"`
+}
+
+// SubscribeRequest model
+//
+// This is used for subscribing to changes of records in the store.
+type SubscribeRequest struct {
+	StoreName string `json:"storeName,omitempty"`
+	// Expression is either 'tagName' or 'tagName:tagValue', changes of all records are published if not set.
+	Expression string `json:"expression,omitempty"`
+	// IncludeValue tells whether values of put records are included in change events.
+	IncludeValue bool `json:"includeValue,omitempty"`
+}
+
+// SubscribeResponse model
+//
+// Represents a response of Subscribe command.
+type SubscribeResponse struct {
+	// ID of the subscription, carried by its change events.
+	ID string `json:"id"`
+	// Topic on which change events are published.
+	Topic string `json:"topic"`
+}
+
+// UnsubscribeRequest model
+//
+// This is used for cancelling subscription to changes.
+type UnsubscribeRequest struct {
+	ID string `json:"id"`
+}
+
+// ChangeEvent model
+//
+// This is published on the change topic of the store for each record put or deleted.
+type ChangeEvent struct {
+	SubscriptionID string `json:"subscriptionID"`
+	StoreName      string `json:"storeName"`
+	Key            string `json:"key"`
+	// Operation is either 'put' or 'delete'.
+	Operation string        `json:"operation"`
+	Tags      []storage.Tag `json:"tags,omitempty"`
+	// Value of put record, if the subscription includes values.
+	Value []byte `json:"value,omitempty"`
+}
+
+// IndexDefinition model
+//
+// Represents an index deriving tags from JSON values of records. Each scalar at the path, or each scalar item
+// of an array at the path, becomes a value of the tag with the index name. As storage does not allow ':' in tag
+// values, ':' and '%' are escaped in derived values as '%3A' and '%25', e.g. 'did:example:123' becomes tag value
+// 'did%3Aexample%3A123'. Query expressions must use escaped values, while query filters match unescaped values.
+type IndexDefinition struct {
+	Name string `json:"name"`
+	// Path in the JSON value, e.g. '$.credentialSubject.degree.type' or '$.type[0]'.
+	Path string `json:"path"`
+}
+
+// ConfigureIndexesRequest model
+//
+// This is used for replacing index definitions of the store.
+type ConfigureIndexesRequest struct {
+	StoreName string            `json:"storeName,omitempty"`
+	Indexes   []IndexDefinition `json:"indexes"`
+}
+
+// GetIndexesRequest model
+//
+// This is used for getting index definitions of the store.
+type GetIndexesRequest struct {
+	StoreName string `json:"storeName,omitempty"`
+}
+
+// GetIndexesResponse model
+//
+// Represents a response of GetIndexes command.
+type GetIndexesResponse struct {
+	Indexes []IndexDefinition `json:"indexes"`
+	// Stale are names of removed indexes whose tags remain on records until indexes are rebuilt.
+	Stale []string `json:"stale,omitempty"`
+}
+
+// RebuildIndexesRequest model
+//
+// This is used for updating index tags of records written before indexes were configured.
+type RebuildIndexesRequest struct {
+	StoreName string `json:"storeName,omitempty"`
+	// TagNames of records to be reindexed in addition to tags from the store configuration and index tags.
+	TagNames []string `json:"tagNames,omitempty"`
+}
+
+// RebuildIndexesResponse model
+//
+// Represents a response of RebuildIndexes command.
+type RebuildIndexesResponse struct {
+	// Updated is the number of records whose tags changed.
+	Updated int `json:"updated"`
 }
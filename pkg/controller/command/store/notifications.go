@@ -0,0 +1,232 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+)
+
+const (
+	// ChangeTopicPrefix prefixes notifier topics on which changes of records are published. Topic of a store
+	// is the prefix followed by the store name, e.g. 'store-changes-store' for the default store.
+	ChangeTopicPrefix = "store-changes-"
+
+	// ChangeOperationPut is the operation of change events for records put to a store.
+	ChangeOperationPut = "put"
+	// ChangeOperationDelete is the operation of change events for records deleted from a store.
+	ChangeOperationDelete = "delete"
+)
+
+var (
+	errNotificationsDisabled = errors.New("notifications are not enabled")
+	errSubscriptionNotFound  = errors.New("subscription not found")
+	errInvalidExpression     = errors.New("expression must be either 'tagName' or 'tagName:tagValue'")
+)
+
+// ChangeTopic returns notifier topic on which changes of records in the store with given name are published.
+func ChangeTopic(storeName string) string {
+	return ChangeTopicPrefix + storeName
+}
+
+// subscription selects records of a store whose changes are published on the notifier of the store command
+// which created it.
+type subscription struct {
+	id           string
+	notifier     command.Notifier
+	storeName    string
+	tagName      string
+	tagValue     string
+	matchValue   bool
+	includeValue bool
+}
+
+// newSubscription creates subscription to changes of records with tags matching given expression,
+// empty expression matches all records.
+func newSubscription(notifier command.Notifier, storeName, expression string,
+	includeValue bool) (*subscription, error) {
+	sub := &subscription{
+		id: uuid.New().String(), notifier: notifier, storeName: storeName, includeValue: includeValue,
+	}
+
+	if expression == "" {
+		return sub, nil
+	}
+
+	parts := strings.Split(expression, ":")
+	if len(parts) > 2 || parts[0] == "" {
+		return nil, errInvalidExpression
+	}
+
+	sub.tagName = parts[0]
+
+	if len(parts) == 2 {
+		sub.tagValue = parts[1]
+		sub.matchValue = true
+	}
+
+	return sub, nil
+}
+
+func (s *subscription) matches(tags []storage.Tag) bool {
+	if s.tagName == "" {
+		return true
+	}
+
+	for _, tag := range tags {
+		if tag.Name == s.tagName && (!s.matchValue || tag.Value == s.tagValue) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// subscriptions keeps subscriptions to changes in memory, they are shared by store commands of the storage
+// provider and do not survive restart of the agent.
+type subscriptions struct {
+	mu   sync.RWMutex
+	byID map[string]*subscription
+}
+
+func newSubscriptions() *subscriptions {
+	return &subscriptions{byID: map[string]*subscription{}}
+}
+
+func (s *subscriptions) add(sub *subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.byID[sub.id] = sub
+}
+
+func (s *subscriptions) remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.byID[id]; !ok {
+		return fmt.Errorf("%w: %s", errSubscriptionNotFound, id)
+	}
+
+	delete(s.byID, id)
+
+	return nil
+}
+
+// forStore returns subscriptions to changes of the store with given name.
+func (s *subscriptions) forStore(storeName string) []*subscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var subs []*subscription
+
+	for _, sub := range s.byID {
+		if sub.storeName == storeName {
+			subs = append(subs, sub)
+		}
+	}
+
+	return subs
+}
+
+// change of a record to be published.
+type change struct {
+	key       string
+	operation string
+	value     []byte
+	tags      []storage.Tag
+}
+
+// changeNotifier publishes changes of records to subscriptions matching their tags.
+type changeNotifier struct {
+	subscriptions *subscriptions
+}
+
+func newChangeNotifier() *changeNotifier {
+	return &changeNotifier{subscriptions: newSubscriptions()}
+}
+
+// subscribed checks whether there are any subscriptions to changes of the store with given name, so that
+// tags of deleted records need not be read otherwise.
+func (n *changeNotifier) subscribed(storeName string) bool {
+	return len(n.subscriptions.forStore(storeName)) > 0
+}
+
+// deleted returns change for deleting the record with given key, reading its tags if there are subscriptions.
+func (n *changeNotifier) deleted(store storage.Store, storeName, key string) *change {
+	deleted := &change{key: key, operation: ChangeOperationDelete}
+
+	if !n.subscribed(storeName) {
+		return deleted
+	}
+
+	tags, err := store.GetTags(key)
+	if err != nil && !errors.Is(err, storage.ErrDataNotFound) {
+		logger.Warnf("failed to get tags of deleted record %s: %s", key, err)
+	}
+
+	deleted.tags = tags
+
+	return deleted
+}
+
+// batch returns changes for given batch operations, reading tags of deleted records if there are subscriptions.
+func (n *changeNotifier) batch(store storage.Store, storeName string, operations []storage.Operation) []*change {
+	changes := make([]*change, len(operations))
+
+	for i, op := range operations {
+		if op.Value == nil {
+			changes[i] = n.deleted(store, storeName, op.Key)
+		} else {
+			changes[i] = &change{key: op.Key, operation: ChangeOperationPut, value: op.Value, tags: op.Tags}
+		}
+	}
+
+	return changes
+}
+
+// notify publishes given changes of records in the store with given name. Failures are logged only,
+// as the changes were already written.
+func (n *changeNotifier) notify(storeName string, changes ...*change) {
+	for _, sub := range n.subscriptions.forStore(storeName) {
+		for _, ch := range changes {
+			if !sub.matches(ch.tags) {
+				continue
+			}
+
+			event := &ChangeEvent{
+				SubscriptionID: sub.id,
+				StoreName:      storeName,
+				Key:            ch.key,
+				Operation:      ch.operation,
+				Tags:           withoutReservedTags(ch.tags),
+			}
+
+			if sub.includeValue {
+				event.Value = ch.value
+			}
+
+			msg, err := json.Marshal(event)
+			if err != nil {
+				logger.Warnf("failed to marshal change notification: %s", err)
+
+				continue
+			}
+
+			if err = sub.notifier.Notify(ChangeTopic(storeName), msg); err != nil {
+				logger.Warnf("failed to publish change notification: %s", err)
+			}
+		}
+	}
+}
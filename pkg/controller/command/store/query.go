@@ -0,0 +1,378 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+)
+
+var (
+	errInvalidCursor = errors.New("invalid cursor")
+	errInvalidLimit  = errors.New("limit must not be negative")
+)
+
+// queryCursor is the decoded form of the opaque continuation cursor returned by Query. It points to the last
+// returned record by its key and value of the sort tag, so that records written or deleted meanwhile do not
+// shift the following page. Cursor is bound to the query it was returned for, so that it cannot be used
+// to continue a different query.
+type queryCursor struct {
+	Expression     string `json:"e"`
+	SortTagName    string `json:"t,omitempty"`
+	SortDescending bool   `json:"d,omitempty"`
+	FilterTagName  string `json:"f,omitempty"`
+	FilterPrefix   string `json:"p,omitempty"`
+	FilterMin      string `json:"n,omitempty"`
+	FilterMax      string `json:"x,omitempty"`
+	Key            string `json:"k"`
+	SortValue      string `json:"v,omitempty"`
+}
+
+func newQueryCursor(request *QueryRequest, key, sortValue string) *queryCursor {
+	cursor := &queryCursor{
+		Expression:     request.Expression,
+		SortTagName:    request.SortTagName,
+		SortDescending: request.SortDescending,
+		Key:            key,
+		SortValue:      sortValue,
+	}
+
+	if request.Filter != nil {
+		cursor.FilterTagName = request.Filter.TagName
+		cursor.FilterPrefix = request.Filter.Prefix
+		cursor.FilterMin = request.Filter.Min
+		cursor.FilterMax = request.Filter.Max
+	}
+
+	return cursor
+}
+
+func (c *queryCursor) encode() (string, error) {
+	cursorBytes, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(cursorBytes), nil
+}
+
+// decodeQueryCursor decodes cursor of given query request and checks that it belongs to the query.
+func decodeQueryCursor(request *QueryRequest) (*queryCursor, error) {
+	cursorBytes, err := base64.RawURLEncoding.DecodeString(request.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidCursor, err)
+	}
+
+	cursor := &queryCursor{}
+
+	err = json.Unmarshal(cursorBytes, cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidCursor, err)
+	}
+
+	if cursor.Key == "" || *cursor != *newQueryCursor(request, cursor.Key, cursor.SortValue) {
+		return nil, fmt.Errorf("%w: cursor does not belong to the query", errInvalidCursor)
+	}
+
+	return cursor, nil
+}
+
+func queryOptions(request *QueryRequest) []storage.QueryOption {
+	var options []storage.QueryOption
+
+	if request.PageSize > 0 {
+		options = append(options, storage.WithPageSize(request.PageSize))
+	}
+
+	if request.SortTagName != "" {
+		order := storage.SortAscending
+		if request.SortDescending {
+			order = storage.SortDescending
+		}
+
+		options = append(options, storage.WithSortingOptions(&storage.SortOptions{
+			Order:   order,
+			TagName: request.SortTagName,
+		}))
+	}
+
+	return options
+}
+
+// queryScan reads results of a query from the iterator, writing records of the requested page. Expired records
+// and records not passing the filter are skipped. Records up to the one the cursor points to are skipped without
+// reading their values; storage does not support starting a query at a record, so they are still iterated.
+type queryScan struct {
+	request *QueryRequest
+	cursor  *queryCursor
+	writer  *queryResponseWriter
+	// indexed is set if the filtered tag is an index tag, whose values are escaped.
+	indexed bool
+	// total is the number of records matching the query, including records of other pages.
+	total int
+	// next is set if a record matching the query follows the page.
+	next          bool
+	lastKey       string
+	lastSortValue string
+}
+
+// newQueryScan returns scan of given query writing records to given writer, continuing after the cursor
+// of the request if it has one.
+func newQueryScan(request *QueryRequest, writer *queryResponseWriter) (*queryScan, error) {
+	scan := &queryScan{request: request, writer: writer}
+
+	if request.Cursor != "" {
+		cursor, err := decodeQueryCursor(request)
+		if err != nil {
+			return nil, err
+		}
+
+		scan.cursor = cursor
+	}
+
+	return scan, nil
+}
+
+func (s *queryScan) run(iterator storage.Iterator) error {
+	more, err := iterator.Next()
+
+	for ; more && err == nil; more, err = iterator.Next() {
+		done, e := s.visit(iterator)
+		if e != nil {
+			return e
+		}
+
+		if done {
+			return nil
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// unsorted records cannot be positioned relatively to a missing record
+	if s.cursor != nil && s.request.SortTagName == "" {
+		return fmt.Errorf("%w: record the cursor points to no longer matches the query", errInvalidCursor)
+	}
+
+	return nil
+}
+
+// visit handles the current record of the iterator, returns whether the scan is done.
+func (s *queryScan) visit(iterator storage.Iterator) (bool, error) {
+	tags, err := iterator.Tags()
+	if err != nil {
+		return false, err
+	}
+
+	key, err := iterator.Key()
+	if err != nil {
+		return false, err
+	}
+
+	matches := !isExpired(tags) && filterMatches(s.request.Filter, s.indexed, tags)
+
+	if s.cursor != nil && s.beforeCursor(key, tags) {
+		if matches {
+			s.total++
+		}
+
+		return false, nil
+	}
+
+	if !matches {
+		return false, nil
+	}
+
+	s.total++
+
+	if s.request.Limit > 0 && s.writer.count >= s.request.Limit {
+		s.next = true
+
+		return !s.request.WithTotal, nil
+	}
+
+	value, err := iterator.Value()
+	if err != nil {
+		return false, err
+	}
+
+	s.writer.writeRecord(&QueryRecord{
+		Key: key, Value: value, Tags: withoutReservedTags(tags), Version: recordVersion(value),
+	})
+	s.lastKey, s.lastSortValue = key, sortValue(s.request.SortTagName, tags)
+
+	return false, nil
+}
+
+// beforeCursor checks whether record with given key and tags precedes or is the record the cursor points to.
+// Cursor is cleared once the record following it is found. If the record the cursor points to was deleted,
+// sorted records continue after the records with its sort value.
+func (s *queryScan) beforeCursor(key string, tags []storage.Tag) bool {
+	if s.request.SortTagName != "" {
+		order := compareValues(sortValue(s.request.SortTagName, tags), s.cursor.SortValue)
+		if s.request.SortDescending {
+			order = -order
+		}
+
+		if order > 0 {
+			s.cursor = nil
+
+			return false
+		}
+	}
+
+	if key == s.cursor.Key {
+		s.cursor = nil
+	}
+
+	return true
+}
+
+// nextCursor returns cursor of the page, which is empty if no more records match the query.
+func (s *queryScan) nextCursor() (string, error) {
+	if !s.next {
+		return "", nil
+	}
+
+	return newQueryCursor(s.request, s.lastKey, s.lastSortValue).encode()
+}
+
+// sortValue returns value of the tag with given name, which records are sorted by.
+func sortValue(tagName string, tags []storage.Tag) string {
+	for _, tag := range tags {
+		if tag.Name == tagName {
+			return tag.Value
+		}
+	}
+
+	return ""
+}
+
+// response fields records are written to, values of records are written to results.
+const (
+	resultsField = "results"
+	recordsField = "records"
+)
+
+// queryResponseWriter writes QueryResponse or QueryRecordsResponse record by record, so that records need not
+// be held in memory.
+type queryResponseWriter struct {
+	w io.Writer
+	// field is the name of the response field records are written to.
+	field string
+	count int
+	err   error
+}
+
+func (w *queryResponseWriter) write(p []byte) {
+	if w.err == nil {
+		_, w.err = w.w.Write(p)
+	}
+}
+
+func (w *queryResponseWriter) writeRecord(record *QueryRecord) {
+	var item interface{} = record
+	if w.field == resultsField {
+		item = record.Value
+	}
+
+	recordBytes, err := json.Marshal(item)
+	if err != nil {
+		w.err = err
+
+		return
+	}
+
+	if w.count == 0 {
+		w.open()
+	} else {
+		w.write([]byte(`,`))
+	}
+
+	w.write(recordBytes)
+	w.count++
+}
+
+// close ends the response with given cursor and total items, either of which may be omitted.
+func (w *queryResponseWriter) close(cursor string, totalItems *int) error {
+	w.closeRecords()
+
+	if cursor != "" {
+		w.write([]byte(`,"cursor":"` + cursor + `"`))
+	}
+
+	if totalItems != nil {
+		w.write([]byte(fmt.Sprintf(`,"totalItems":%d`, *totalItems)))
+	}
+
+	w.write([]byte(`}`))
+
+	return w.err
+}
+
+// abort ends the response with given error after records written so far, so that the response stays valid
+// JSON when reading records fails after some of them were written.
+func (w *queryResponseWriter) abort(cause error) error {
+	w.closeRecords()
+
+	causeBytes, err := json.Marshal(cause.Error())
+	if err != nil {
+		return err
+	}
+
+	w.write([]byte(`,"error":`))
+	w.write(causeBytes)
+	w.write([]byte(`}`))
+
+	return w.err
+}
+
+func (w *queryResponseWriter) open() {
+	w.write([]byte(`{"` + w.field + `":[`))
+}
+
+func (w *queryResponseWriter) closeRecords() {
+	if w.count == 0 {
+		w.open()
+	}
+
+	w.write([]byte(`]`))
+}
+
+// readRecord reads key, value and tags of current iterator record, returns whether the record has expired
+// instead if it has.
+func readRecord(iterator storage.Iterator) (*QueryRecord, bool, error) {
+	tags, err := iterator.Tags()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if isExpired(tags) {
+		return nil, true, nil
+	}
+
+	key, err := iterator.Key()
+	if err != nil {
+		return nil, false, err
+	}
+
+	value, err := iterator.Value()
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &QueryRecord{
+		Key: key, Value: value, Tags: withoutReservedTags(tags), Version: recordVersion(value),
+	}, false, nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package store
+
+import (
+	"sync"
+
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+)
+
+// SharedState is state shared by store commands created with WithSharedState, e.g. by commands serving command
+// handlers and REST handlers of an agent, so that they behave as a single store command. Commands sharing
+// the state must be created for the same storage provider.
+type SharedState struct {
+	mu      sync.Mutex
+	refs    int
+	stores  *openStores
+	indexes *indexRegistry
+	changes *changeNotifier
+	sweeper *sweeper
+	// writeMu serializes writes, so that version checks and increments are atomic.
+	writeMu sync.Mutex
+}
+
+// NewSharedState returns new state to be shared by store commands. It is initialized by the first command
+// created with it, and released when the last of the commands is closed.
+func NewSharedState() *SharedState {
+	return &SharedState{}
+}
+
+// acquire takes reference to the state. If it is not initialized, it is initialized with given storage provider
+// and internal stores of index definitions and store names, and its sweeper is started with options
+// of the command acquiring it.
+func (s *SharedState) acquire(p storage.Provider, indexesStore, namesStore storage.Store, opts *options) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.refs == 0 {
+		s.stores = newOpenStores(p, namesStore)
+		s.indexes = newIndexRegistry(indexesStore)
+		s.changes = newChangeNotifier()
+		s.sweeper = newSweeper(s.stores, s.changes, &s.writeMu, opts.sweepInterval, opts.sweepBatchSize)
+		s.sweeper.start()
+	}
+
+	s.refs++
+}
+
+// release drops reference to the state taken by acquire. The sweeper is stopped when the last reference
+// is dropped.
+func (s *SharedState) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.refs == 0 {
+		return
+	}
+
+	s.refs--
+
+	if s.refs > 0 {
+		return
+	}
+
+	s.sweeper.close()
+}
@@ -0,0 +1,215 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+)
+
+const (
+	// wildcard suffix of allowed store names matching all stores with given prefix.
+	wildcard = "*"
+	// storeNamesStoreName is the name of the internal store keeping names of stores opened through store
+	// commands, so that they are known after restarts. It is never allowed for store requests.
+	storeNamesStoreName = "agent-sdk-store-names"
+	// storeNameTag tags names of stores in the internal store of store names.
+	storeNameTag = "storeName"
+)
+
+var errStoreNotAllowed = errors.New("store is not allowed")
+
+// openStores opens named stores lazily and keeps them for later requests of all store commands
+// of a shared state. Names of opened stores are recorded in given internal store.
+type openStores struct {
+	provider storage.Provider
+	registry storage.Store
+
+	mu   sync.RWMutex
+	open map[string]storage.Store
+}
+
+func newOpenStores(p storage.Provider, registry storage.Store) *openStores {
+	return &openStores{provider: p, registry: registry, open: map[string]storage.Store{}}
+}
+
+// stores gives access to open stores matching the allowlist of a store command.
+type stores struct {
+	allowed []string
+	opened  *openStores
+}
+
+func newStores(opened *openStores, allowed []string) *stores {
+	return &stores{allowed: allowed, opened: opened}
+}
+
+// isAllowed checks whether store with given name matches an allowed name or prefix.
+// Internal stores of index definitions and store names are never allowed.
+func (s *stores) isAllowed(name string) bool {
+	if name == indexesStoreName || name == storeNamesStoreName {
+		return false
+	}
+
+	for _, allowed := range s.allowed {
+		if strings.HasSuffix(allowed, wildcard) {
+			if strings.HasPrefix(name, strings.TrimSuffix(allowed, wildcard)) {
+				return true
+			}
+
+			continue
+		}
+
+		if name == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// get returns store with given name, opening it through the provider if needed.
+// Empty name refers to the default store.
+func (s *stores) get(name string) (storage.Store, error) {
+	name = storeNameOrDefault(name)
+
+	if !s.isAllowed(name) {
+		return nil, fmt.Errorf("%w: %s", errStoreNotAllowed, name)
+	}
+
+	return s.opened.get(name)
+}
+
+// names returns sorted names of allowed stores ever opened through store commands.
+func (s *stores) names() ([]string, error) {
+	opened, err := s.opened.names()
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+
+	for _, name := range opened {
+		if s.isAllowed(name) {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// get returns store with given name, opening it through the provider if needed.
+func (s *openStores) get(name string) (storage.Store, error) {
+	s.mu.RLock()
+	store, ok := s.open[name]
+	s.mu.RUnlock()
+
+	if ok {
+		return store, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if store, ok = s.open[name]; ok {
+		return store, nil
+	}
+
+	store, err := s.provider.OpenStore(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store %s: %w", name, err)
+	}
+
+	if err = s.record(name); err != nil {
+		return nil, err
+	}
+
+	s.open[name] = store
+
+	return store, nil
+}
+
+// add keeps given store opened by the caller, unless a store with the same name was opened before.
+func (s *openStores) add(name string, store storage.Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.open[name]; !ok {
+		s.open[name] = store
+	}
+}
+
+// record records name of opened store in the internal store of store names.
+func (s *openStores) record(name string) error {
+	err := s.registry.Put(name, []byte(name), storage.Tag{Name: storeNameTag})
+	if err != nil {
+		return fmt.Errorf("failed to record name of store %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// storeNameOrDefault returns given store name, or name of the default store if it is empty.
+func storeNameOrDefault(name string) string {
+	if name == "" {
+		return DefaultStoreName
+	}
+
+	return name
+}
+
+// names returns sorted names of stores open now and stores ever opened through store commands of
+// the storage provider, including those opened before restarts.
+func (s *openStores) names() ([]string, error) {
+	iterator, err := s.registry.Query(storeNameTag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query store names: %w", err)
+	}
+
+	defer func() {
+		if e := iterator.Close(); e != nil {
+			logger.Warnf("failed to close iterator: %s", e)
+		}
+	}()
+
+	known := map[string]bool{}
+
+	s.mu.RLock()
+	for name := range s.open {
+		known[name] = true
+	}
+	s.mu.RUnlock()
+
+	more, err := iterator.Next()
+
+	for ; more && err == nil; more, err = iterator.Next() {
+		name, e := iterator.Key()
+		if e != nil {
+			return nil, fmt.Errorf("failed to get store name: %w", e)
+		}
+
+		known[name] = true
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store names: %w", err)
+	}
+
+	names := make([]string, 0, len(known))
+
+	for name := range known {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
@@ -0,0 +1,373 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+)
+
+const (
+	// DefaultSweepInterval is the default interval between runs of the expired records sweeper.
+	DefaultSweepInterval = time.Minute
+	// DefaultSweepBatchSize is the default number of expired records deleted by the sweeper at once.
+	DefaultSweepBatchSize = 100
+
+	// expiresAtTag is the reserved tag holding expiry time of records put with TTL, in unix seconds.
+	expiresAtTag = "_expiresAt"
+	// recordTag is the reserved tag of every record written by the command, so that all records of a store
+	// can be found, as storage does not support listing records otherwise.
+	recordTag = "_record"
+)
+
+var errInvalidTTL = errors.New("ttl must not be negative")
+
+// isReservedTag checks whether tag with given name is reserved for the command.
+func isReservedTag(name string) bool {
+	return name == expiresAtTag || name == recordTag
+}
+
+// withExpiry validates given tags and adds record tag to them, along with expiry tag if ttl (in seconds) is set.
+func withExpiry(tags []storage.Tag, ttl int64) ([]storage.Tag, error) {
+	if ttl < 0 {
+		return nil, errInvalidTTL
+	}
+
+	for _, tag := range tags {
+		if isReservedTag(tag.Name) {
+			return nil, fmt.Errorf("tag %s is reserved", tag.Name)
+		}
+	}
+
+	tags = withRecordTag(tags)
+
+	if ttl == 0 {
+		return tags, nil
+	}
+
+	expiresAt := time.Now().Add(time.Duration(ttl) * time.Second).Unix()
+
+	return append(tags, storage.Tag{Name: expiresAtTag, Value: strconv.FormatInt(expiresAt, 10)}), nil
+}
+
+// withRecordTag returns given tags with the record tag, unless they already have it.
+func withRecordTag(tags []storage.Tag) []storage.Tag {
+	for _, tag := range tags {
+		if tag.Name == recordTag {
+			return tags
+		}
+	}
+
+	return append(append(make([]storage.Tag, 0, len(tags)+1), tags...), storage.Tag{Name: recordTag})
+}
+
+// isExpired checks whether record with given tags has expired.
+func isExpired(tags []storage.Tag) bool {
+	for _, tag := range tags {
+		if tag.Name != expiresAtTag {
+			continue
+		}
+
+		expiresAt, err := strconv.ParseInt(tag.Value, 10, 64)
+
+		return err == nil && time.Now().Unix() >= expiresAt
+	}
+
+	return false
+}
+
+// withoutReservedTags returns given tags without the reserved expiry and record tags.
+func withoutReservedTags(tags []storage.Tag) []storage.Tag {
+	var result []storage.Tag
+
+	for _, tag := range tags {
+		if !isReservedTag(tag.Name) {
+			result = append(result, tag)
+		}
+	}
+
+	return result
+}
+
+// getRecord returns value and tags of the record with given key, expired records are reported as not found.
+func getRecord(store storage.Store, key string) ([]byte, []storage.Tag, error) {
+	value, err := store.Get(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tags, err := store.GetTags(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if isExpired(tags) {
+		return nil, nil, errExpired(key)
+	}
+
+	return value, tags, nil
+}
+
+// getTags returns tags of the record with given key without the reserved tags, expired records are reported
+// as not found.
+func getTags(store storage.Store, key string) ([]storage.Tag, error) {
+	tags, err := store.GetTags(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if isExpired(tags) {
+		return nil, errExpired(key)
+	}
+
+	return withoutReservedTags(tags), nil
+}
+
+// hideExpired replaces values of expired records in results of GetBulk for given keys with nil.
+func hideExpired(store storage.Store, keys []string, values [][]byte) error {
+	for i, key := range keys {
+		if values[i] == nil {
+			continue
+		}
+
+		tags, err := store.GetTags(key)
+		if err != nil {
+			return err
+		}
+
+		if isExpired(tags) {
+			values[i] = nil
+		}
+	}
+
+	return nil
+}
+
+func errExpired(key string) error {
+	return fmt.Errorf("record %s has expired: %w", key, storage.ErrDataNotFound)
+}
+
+// sweeper periodically deletes expired records from stores opened through store commands of a shared state.
+type sweeper struct {
+	stores    *openStores
+	changes   *changeNotifier
+	writeMu   *sync.Mutex
+	interval  time.Duration
+	batchSize int
+	stop      chan struct{}
+	stopOnce  sync.Once
+
+	mu    sync.RWMutex
+	stats SweeperStatsResponse
+}
+
+func newSweeper(s *openStores, changes *changeNotifier, writeMu *sync.Mutex, interval time.Duration,
+	batchSize int) *sweeper {
+	return &sweeper{
+		stores:    s,
+		changes:   changes,
+		writeMu:   writeMu,
+		interval:  interval,
+		batchSize: batchSize,
+		stop:      make(chan struct{}),
+		stats: SweeperStatsResponse{
+			Enabled:  interval > 0,
+			Interval: interval.String(),
+		},
+	}
+}
+
+func (s *sweeper) start() {
+	if s.interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.sweep()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (s *sweeper) close() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// sweep deletes expired records from all stores ever opened through store commands.
+func (s *sweeper) sweep() {
+	started := time.Now()
+
+	var (
+		expired int
+		errMsg  string
+	)
+
+	names, err := s.stores.names()
+	if err != nil {
+		logger.Warnf("failed to delete expired records: %s", err)
+
+		errMsg = err.Error()
+	}
+
+	for _, name := range names {
+		store, e := s.stores.get(name)
+		if e == nil {
+			var count int
+
+			count, e = s.sweepStore(store, name)
+			expired += count
+		}
+
+		if e != nil {
+			logger.Warnf("failed to delete expired records from store %s: %s", name, e)
+
+			errMsg = fmt.Sprintf("store %s: %s", name, e)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.Runs++
+	s.stats.ExpiredRecords += expired
+	s.stats.LastRunAt = &started
+	s.stats.LastRunDuration = time.Since(started).String()
+	s.stats.LastRunExpiredRecords = expired
+	s.stats.LastError = errMsg
+}
+
+// sweepStore deletes expired records from given store in batches, returns number of deleted records.
+func (s *sweeper) sweepStore(store storage.Store, name string) (int, error) {
+	keys, err := expiredKeys(store)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+
+	for len(keys) > 0 {
+		n := s.batchSize
+		if n > len(keys) {
+			n = len(keys)
+		}
+
+		count, e := s.deleteExpired(store, name, keys[:n])
+		deleted += count
+
+		if e != nil {
+			return deleted, e
+		}
+
+		keys = keys[n:]
+	}
+
+	return deleted, nil
+}
+
+// deleteExpired deletes records with given keys which are still expired, as they may have been put again
+// since they were found. Deletions are published to subscriptions like other changes.
+func (s *sweeper) deleteExpired(store storage.Store, name string, keys []string) (int, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	var (
+		operations []storage.Operation
+		changes    []*change
+	)
+
+	for _, key := range keys {
+		tags, err := store.GetTags(key)
+		if errors.Is(err, storage.ErrDataNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		if isExpired(tags) {
+			operations = append(operations, storage.Operation{Key: key})
+			changes = append(changes, &change{key: key, operation: ChangeOperationDelete, tags: tags})
+		}
+	}
+
+	if len(operations) == 0 {
+		return 0, nil
+	}
+
+	if err := store.Batch(operations); err != nil {
+		return 0, err
+	}
+
+	s.changes.notify(name, changes...)
+
+	return len(operations), nil
+}
+
+func (s *sweeper) getStats() *SweeperStatsResponse {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := s.stats
+
+	return &stats
+}
+
+// expiredKeys returns keys of expired records in given store.
+func expiredKeys(store storage.Store) ([]string, error) {
+	iterator, err := store.Query(expiresAtTag)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if e := iterator.Close(); e != nil {
+			logger.Warnf("failed to close iterator: %s", e)
+		}
+	}()
+
+	var keys []string
+
+	more, err := iterator.Next()
+
+	for ; more && err == nil; more, err = iterator.Next() {
+		tags, e := iterator.Tags()
+		if e != nil {
+			return nil, e
+		}
+
+		if !isExpired(tags) {
+			continue
+		}
+
+		key, e := iterator.Key()
+		if e != nil {
+			return nil, e
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
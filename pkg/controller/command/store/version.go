@@ -0,0 +1,81 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+)
+
+var (
+	errVersionConflict = errors.New("record version does not match")
+	errNotCounter      = errors.New("record value is not an integer")
+)
+
+// recordVersion returns version of the record with given value, which is the hash of the value.
+func recordVersion(value []byte) string {
+	hash := sha256.Sum256(value)
+
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// checkVersion checks that the record with given key exists and has given version.
+func checkVersion(store storage.Store, key, version string) error {
+	value, _, err := getRecord(store, key)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return fmt.Errorf("%w: record %s not found", errVersionConflict, key)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if current := recordVersion(value); current != version {
+		return fmt.Errorf("%w: current version is %s", errVersionConflict, current)
+	}
+
+	return nil
+}
+
+// increment adds delta to the counter stored in the record with given key, keeping tags of the record,
+// which are returned along with the new value. Missing or expired record is treated as a counter with zero value.
+func increment(store storage.Store, key string, delta int64) (int64, []storage.Tag, error) {
+	var (
+		counter int64
+		tags    []storage.Tag
+	)
+
+	value, recordTags, err := getRecord(store, key)
+
+	switch {
+	case errors.Is(err, storage.ErrDataNotFound):
+	case err != nil:
+		return 0, nil, err
+	default:
+		counter, err = strconv.ParseInt(string(value), 10, 64)
+		if err != nil {
+			return 0, nil, fmt.Errorf("%w: %s", errNotCounter, key)
+		}
+
+		tags = recordTags
+	}
+
+	counter += delta
+	tags = withRecordTag(tags)
+
+	err = store.Put(key, []byte(strconv.FormatInt(counter, 10)), tags...)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return counter, tags, nil
+}
@@ -0,0 +1,315 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package webhook provides commands managing webhook subscriptions at runtime. Subscriptions are persisted
+// and receive messages published by the Dispatcher on topics matching their filters.
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/trustbloc/edge-core/pkg/log"
+
+	agentcmd "github.com/trustbloc/agent-sdk/pkg/controller/command"
+	"github.com/trustbloc/agent-sdk/pkg/controller/internal/cmdutil"
+)
+
+const (
+	// CommandName package command name.
+	CommandName = "webhook"
+	// RegisterSubscriptionCommandMethod command method.
+	RegisterSubscriptionCommandMethod = "RegisterSubscription"
+	// ListSubscriptionsCommandMethod command method.
+	ListSubscriptionsCommandMethod = "ListSubscriptions"
+	// DeleteSubscriptionCommandMethod command method.
+	DeleteSubscriptionCommandMethod = "DeleteSubscription"
+
+	// subscriptionsStoreName is the name of the store keeping webhook subscriptions.
+	subscriptionsStoreName = "agent-sdk-webhook-subscriptions"
+	// subscriptionTag tags records of subscriptions, so that they can be queried.
+	subscriptionTag = "subscription"
+	// redactedHeaderValue replaces values of headers of listed subscriptions, as they typically carry secrets.
+	redactedHeaderValue = "*****"
+)
+
+const (
+	// InvalidRequestErrorCode is typically a code for validation errors.
+	InvalidRequestErrorCode = command.Code(iota + agentcmd.Webhook)
+	// RegisterSubscriptionErrorCode is typically a code for RegisterSubscription errors.
+	RegisterSubscriptionErrorCode
+	// DeleteSubscriptionErrorCode is typically a code for DeleteSubscription errors.
+	DeleteSubscriptionErrorCode
+)
+
+var logger = log.New("agent-sdk-webhook")
+
+var (
+	errInvalidURL           = errors.New("url must be an absolute http or https URL")
+	errEmptyTopic           = errors.New("topic must not be empty")
+	errEmptyHeaderName      = errors.New("header name must not be empty")
+	errEmptyID              = errors.New("id is mandatory")
+	errSubscriptionNotFound = errors.New("subscription not found")
+)
+
+// Provider describes dependencies for the command.
+type Provider interface {
+	StorageProvider() storage.Provider
+}
+
+// Command is controller command for webhook subscriptions.
+type Command struct {
+	store         storage.Store
+	dispatcher    *Dispatcher
+	subscriptions *subscriptions
+}
+
+// New returns new webhook controller command instance, loading persisted subscriptions. Subscriptions are kept
+// by given dispatcher, which publishes to them, so that they are shared by commands of the dispatcher.
+func New(p Provider, dispatcher *Dispatcher) (*Command, error) {
+	if err := agentcmd.RegisterErrors(Errors()...); err != nil {
+		return nil, err
+	}
+
+	store, err := p.StorageProvider().OpenStore(subscriptionsStoreName)
+	if err != nil {
+		return nil, err
+	}
+
+	subs := dispatcher.subscriptions
+
+	subs.writeMu.Lock()
+	defer subs.writeMu.Unlock()
+
+	loaded, err := loadSubscriptions(store)
+	if err != nil {
+		return nil, err
+	}
+
+	subs.set(loaded)
+
+	return &Command{store: store, dispatcher: dispatcher, subscriptions: subs}, nil
+}
+
+// GetHandlers returns list of all commands supported by this controller command.
+func (c *Command) GetHandlers() []command.Handler {
+	return []command.Handler{
+		cmdutil.NewCommandHandler(CommandName, RegisterSubscriptionCommandMethod, c.RegisterSubscription),
+		cmdutil.NewCommandHandler(CommandName, ListSubscriptionsCommandMethod, c.ListSubscriptions),
+		cmdutil.NewCommandHandler(CommandName, DeleteSubscriptionCommandMethod, c.DeleteSubscription),
+	}
+}
+
+// Schemas returns JSON schemas of request and response models of webhook command methods.
+func Schemas() []agentcmd.MethodSchema {
+	return []agentcmd.MethodSchema{
+		agentcmd.NewMethodSchema(CommandName, RegisterSubscriptionCommandMethod, &RegisterSubscriptionRequest{},
+			&RegisterSubscriptionResponse{}),
+		agentcmd.NewMethodSchema(CommandName, ListSubscriptionsCommandMethod, nil, &ListSubscriptionsResponse{}),
+		agentcmd.NewMethodSchema(CommandName, DeleteSubscriptionCommandMethod, &DeleteSubscriptionRequest{}, nil),
+	}
+}
+
+// Errors returns definitions of webhook error codes for the error catalog.
+func Errors() []agentcmd.ErrorDefinition {
+	return []agentcmd.ErrorDefinition{
+		{
+			Code: InvalidRequestErrorCode, Name: "WEBHOOK_INVALID_REQUEST", Command: CommandName,
+			Description: "invalid request",
+		},
+		{
+			Code: RegisterSubscriptionErrorCode, Name: "WEBHOOK_REGISTER_FAILED", Command: CommandName,
+			Description: "failed to register webhook subscription",
+		},
+		{
+			Code: DeleteSubscriptionErrorCode, Name: "WEBHOOK_DELETE_FAILED", Command: CommandName,
+			Description: "failed to delete webhook subscription",
+		},
+	}
+}
+
+// RegisterSubscription registers webhook receiving messages on topics matching the filter of the subscription.
+func (c *Command) RegisterSubscription(rw io.Writer, req io.Reader) command.Error {
+	var request RegisterSubscriptionRequest
+
+	err := agentcmd.DecodeRequest(req, &request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if err = c.validateSubscription(&request); err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	sub := Subscription{ID: uuid.New().String(), URL: request.URL, Topics: request.Topics, Headers: request.Headers}
+
+	c.subscriptions.writeMu.Lock()
+	defer c.subscriptions.writeMu.Unlock()
+
+	if err = saveSubscription(c.store, &sub); err != nil {
+		return agentcmd.NewExecuteError(RegisterSubscriptionErrorCode, err)
+	}
+
+	c.subscriptions.add(sub)
+
+	command.WriteNillableResponse(rw, &RegisterSubscriptionResponse{ID: sub.ID}, logger)
+
+	return nil
+}
+
+// ListSubscriptions lists registered webhook subscriptions, values of their headers are redacted.
+func (c *Command) ListSubscriptions(rw io.Writer, _ io.Reader) command.Error {
+	subs := c.subscriptions.list()
+
+	for i := range subs {
+		subs[i].Headers = redactedHeaders(subs[i].Headers)
+	}
+
+	command.WriteNillableResponse(rw, &ListSubscriptionsResponse{Subscriptions: subs}, logger)
+
+	return nil
+}
+
+// DeleteSubscription deletes webhook subscription with given ID.
+func (c *Command) DeleteSubscription(rw io.Writer, req io.Reader) command.Error {
+	var request DeleteSubscriptionRequest
+
+	err := agentcmd.DecodeRequest(req, &request)
+	if err != nil {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, err)
+	}
+
+	if request.ID == "" {
+		return agentcmd.NewValidationError(InvalidRequestErrorCode, errEmptyID)
+	}
+
+	c.subscriptions.writeMu.Lock()
+	defer c.subscriptions.writeMu.Unlock()
+
+	if err = c.deleteSubscription(request.ID); err != nil {
+		return agentcmd.NewExecuteError(DeleteSubscriptionErrorCode, err)
+	}
+
+	command.WriteNillableResponse(rw, nil, logger)
+
+	return nil
+}
+
+func (c *Command) deleteSubscription(id string) error {
+	_, err := c.store.Get(id)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return errSubscriptionNotFound
+	}
+
+	if err == nil {
+		err = c.store.Delete(id)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to delete subscription %s: %w", id, err)
+	}
+
+	c.subscriptions.remove(id)
+
+	return nil
+}
+
+func (c *Command) validateSubscription(request *RegisterSubscriptionRequest) error {
+	u, err := url.Parse(request.URL)
+	if err != nil || u.Host == "" || u.Scheme != "http" && u.Scheme != "https" {
+		return errInvalidURL
+	}
+
+	if err = c.dispatcher.checkURL(u); err != nil {
+		return err
+	}
+
+	for _, topic := range request.Topics {
+		if topic == "" {
+			return errEmptyTopic
+		}
+	}
+
+	for name := range request.Headers {
+		if name == "" {
+			return errEmptyHeaderName
+		}
+	}
+
+	return nil
+}
+
+func redactedHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+
+	redacted := make(map[string]string, len(headers))
+
+	for name := range headers {
+		redacted[name] = redactedHeaderValue
+	}
+
+	return redacted
+}
+
+func saveSubscription(store storage.Store, sub *Subscription) error {
+	subBytes, err := json.Marshal(sub)
+	if err != nil {
+		return fmt.Errorf("failed to marshal subscription: %w", err)
+	}
+
+	if err = store.Put(sub.ID, subBytes, storage.Tag{Name: subscriptionTag}); err != nil {
+		return fmt.Errorf("failed to save subscription: %w", err)
+	}
+
+	return nil
+}
+
+func loadSubscriptions(store storage.Store) ([]Subscription, error) {
+	iterator, err := store.Query(subscriptionTag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subscriptions: %w", err)
+	}
+
+	defer func() {
+		if errClose := iterator.Close(); errClose != nil {
+			logger.Warnf("failed to close iterator: %s", errClose)
+		}
+	}()
+
+	var subs []Subscription
+
+	for {
+		ok, e := iterator.Next()
+		if e != nil {
+			return nil, fmt.Errorf("failed to load subscriptions: %w", e)
+		}
+
+		if !ok {
+			return subs, nil
+		}
+
+		value, e := iterator.Value()
+		if e != nil {
+			return nil, fmt.Errorf("failed to load subscriptions: %w", e)
+		}
+
+		var sub Subscription
+
+		if e = json.Unmarshal(value, &sub); e != nil {
+			return nil, fmt.Errorf("failed to parse subscription: %w", e)
+		}
+
+		subs = append(subs, sub)
+	}
+}
@@ -0,0 +1,391 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webhook_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/trustbloc/agent-sdk/pkg/controller/command/webhook"
+	"github.com/trustbloc/agent-sdk/pkg/controller/internal/mocks"
+	"github.com/trustbloc/agent-sdk/pkg/controller/internal/mocks/protocol"
+)
+
+func TestNew(t *testing.T) {
+	cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()}, NewDispatcher(nil))
+	require.NoError(t, err)
+	require.NotNil(t, cmd)
+	require.Len(t, cmd.GetHandlers(), 3)
+
+	storeProvider := mocks.NewMockStoreProvider()
+	storeProvider.ErrOpenStoreHandle = errors.New("error")
+
+	cmd, err = New(&protocol.MockProvider{StoreProvider: storeProvider}, NewDispatcher(nil))
+	require.EqualError(t, err, "error")
+	require.Nil(t, cmd)
+}
+
+func TestCommand_Subscriptions(t *testing.T) {
+	provider := &protocol.MockProvider{StoreProvider: mem.NewProvider()}
+
+	cmd, err := New(provider, NewDispatcher(nil))
+	require.NoError(t, err)
+
+	register := func(t *testing.T, request *RegisterSubscriptionRequest) string {
+		t.Helper()
+
+		var rw bytes.Buffer
+
+		require.Nil(t, cmd.RegisterSubscription(&rw, marshal(t, request)))
+
+		var response RegisterSubscriptionResponse
+
+		require.NoError(t, json.Unmarshal(rw.Bytes(), &response))
+		require.NotEmpty(t, response.ID)
+
+		return response.ID
+	}
+
+	list := func(t *testing.T, c *Command) []Subscription {
+		t.Helper()
+
+		var rw bytes.Buffer
+
+		require.Nil(t, c.ListSubscriptions(&rw, nil))
+
+		var response ListSubscriptionsResponse
+
+		require.NoError(t, json.Unmarshal(rw.Bytes(), &response))
+
+		return response.Subscriptions
+	}
+
+	require.Empty(t, list(t, cmd))
+
+	id1 := register(t, &RegisterSubscriptionRequest{URL: "http://example.com/hook"})
+	id2 := register(t, &RegisterSubscriptionRequest{
+		URL: "https://example.com", Topics: []string{"store-changes-*"}, Headers: map[string]string{"X-Key": "k"},
+	})
+
+	require.Equal(t, []Subscription{
+		{ID: id1, URL: "http://example.com/hook"},
+		{ID: id2, URL: "https://example.com", Topics: []string{"store-changes-*"}, Headers: map[string]string{
+			"X-Key": "*****",
+		}},
+	}, list(t, cmd))
+
+	t.Run("subscriptions are persisted", func(t *testing.T) {
+		reloaded, e := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()}, NewDispatcher(nil))
+		require.NoError(t, e)
+		require.Empty(t, list(t, reloaded))
+
+		store, e := provider.StorageProvider().OpenStore("agent-sdk-webhook-subscriptions")
+		require.NoError(t, e)
+
+		reloaded, e = New(&protocol.MockProvider{StoreProvider: mocks.NewCustomMockStoreProvider(store)},
+			NewDispatcher(nil))
+		require.NoError(t, e)
+		require.ElementsMatch(t, list(t, cmd), list(t, reloaded))
+	})
+
+	require.Nil(t, cmd.DeleteSubscription(&bytes.Buffer{}, marshal(t, &DeleteSubscriptionRequest{ID: id1})))
+	require.Equal(t, []string{id2}, ids(list(t, cmd)))
+
+	reloaded, err := New(provider, NewDispatcher(nil))
+	require.NoError(t, err)
+	require.Equal(t, []string{id2}, ids(list(t, reloaded)))
+
+	t.Run("subscription not found", func(t *testing.T) {
+		cmdErr := cmd.DeleteSubscription(&bytes.Buffer{}, marshal(t, &DeleteSubscriptionRequest{ID: id1}))
+		require.EqualError(t, cmdErr, "subscription not found")
+		require.Equal(t, DeleteSubscriptionErrorCode, cmdErr.Code())
+		require.Equal(t, command.ExecuteError, cmdErr.Type())
+	})
+}
+
+func TestCommand_InvalidRequests(t *testing.T) {
+	cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()}, NewDispatcher(nil))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		exec    command.Exec
+		request string
+		err     string
+	}{
+		{"invalid JSON", cmd.RegisterSubscription, `{`, "unexpected EOF"},
+		{"unknown field", cmd.RegisterSubscription, `{"uri":"http://example.com"}`, "$.uri: unknown field"},
+		{"missing URL", cmd.RegisterSubscription, `{}`, "url must be an absolute http or https URL"},
+		{"relative URL", cmd.RegisterSubscription, `{"url":"/hook"}`, "url must be an absolute http or https URL"},
+		{"invalid scheme", cmd.RegisterSubscription, `{"url":"ftp://example.com"}`, "absolute http or https URL"},
+		{"empty topic", cmd.RegisterSubscription, `{"url":"http://example.com","topics":[""]}`, "topic must not be empty"},
+		{
+			"empty header name", cmd.RegisterSubscription, `{"url":"http://example.com","headers":{"":"value"}}`,
+			"header name must not be empty",
+		},
+		{"loopback address", cmd.RegisterSubscription, `{"url":"http://127.0.0.1:8080"}`, "private, loopback"},
+		{"private address", cmd.RegisterSubscription, `{"url":"https://[fd00::1]/hook"}`, "private, loopback"},
+		{"localhost", cmd.RegisterSubscription, `{"url":"http://localhost/hook"}`, "private, loopback"},
+		{"missing ID", cmd.DeleteSubscription, `{}`, "id is mandatory"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cmdErr := tc.exec(&bytes.Buffer{}, bytes.NewBufferString(tc.request))
+			require.Error(t, cmdErr)
+			require.Contains(t, cmdErr.Error(), tc.err)
+			require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+			require.Equal(t, command.ValidationError, cmdErr.Type())
+		})
+	}
+
+	t.Run("allowed hosts", func(t *testing.T) {
+		allowing, e := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()},
+			NewDispatcher(nil, WithAllowedHosts("localhost", "*.example.com")))
+		require.NoError(t, e)
+
+		for _, u := range []string{"http://localhost:8080/hook", "https://hooks.example.com"} {
+			require.Nil(t, allowing.RegisterSubscription(&bytes.Buffer{}, bytes.NewBufferString(`{"url":"`+u+`"}`)))
+		}
+
+		cmdErr := allowing.RegisterSubscription(&bytes.Buffer{}, bytes.NewBufferString(`{"url":"https://example.org"}`))
+		require.EqualError(t, cmdErr, "host of url is not allowed")
+		require.Equal(t, InvalidRequestErrorCode, cmdErr.Code())
+	})
+
+	t.Run("store failures", func(t *testing.T) {
+		memStore, e := mem.NewProvider().OpenStore("test")
+		require.NoError(t, e)
+
+		store := &failingStore{Store: memStore}
+		provider := &protocol.MockProvider{StoreProvider: mocks.NewCustomMockStoreProvider(store)}
+
+		failingCmd, e := New(provider, NewDispatcher(nil))
+		require.NoError(t, e)
+
+		store.errPut = errors.New("put error")
+
+		cmdErr := failingCmd.RegisterSubscription(&bytes.Buffer{}, bytes.NewBufferString(`{"url":"http://example.com"}`))
+		require.EqualError(t, cmdErr, "failed to save subscription: put error")
+		require.Equal(t, RegisterSubscriptionErrorCode, cmdErr.Code())
+
+		store.errGet = errors.New("get error")
+
+		cmdErr = failingCmd.DeleteSubscription(&bytes.Buffer{}, bytes.NewBufferString(`{"id":"id"}`))
+		require.EqualError(t, cmdErr, "failed to delete subscription id: get error")
+		require.Equal(t, DeleteSubscriptionErrorCode, cmdErr.Code())
+
+		store.errQuery = errors.New("query error")
+
+		_, e = New(provider, NewDispatcher(nil))
+		require.EqualError(t, e, "failed to query subscriptions: query error")
+	})
+}
+
+func TestDispatcher_Notify(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+
+		mu.Lock()
+		received = append(received, req.URL.Path+" "+req.Header.Get("X-Key")+" "+string(body))
+		mu.Unlock()
+
+		if req.URL.Path == "/failing/topic" {
+			rw.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	receivedMessages := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]string{}, received...)
+	}
+
+	var notified []string
+
+	dispatcher := NewDispatcher(&mocks.Notifier{NotifyFunc: func(topic string, message []byte) error {
+		notified = append(notified, topic)
+
+		return nil
+	}}, WithHTTPClient(server.Client()), WithAllowedHosts("127.0.0.1"))
+
+	provider := &protocol.MockProvider{StoreProvider: mem.NewProvider()}
+
+	_, err := New(provider, dispatcher)
+	require.NoError(t, err)
+
+	// subscriptions registered by another command of the dispatcher are published to
+	cmd, err := New(provider, dispatcher)
+	require.NoError(t, err)
+
+	for _, request := range []*RegisterSubscriptionRequest{
+		{URL: server.URL + "/all"},
+		{URL: server.URL + "/filtered/", Topics: []string{"store-changes-*", "exact"}, Headers: map[string]string{
+			"X-Key": "secret",
+		}},
+		{URL: server.URL + "/failing", Topics: []string{"topic"}},
+	} {
+		require.Nil(t, cmd.RegisterSubscription(&bytes.Buffer{}, marshal(t, request)))
+	}
+
+	require.NoError(t, dispatcher.Notify("store-changes-app", []byte(`{"key":"k1"}`)))
+	require.NoError(t, dispatcher.Notify("exact", []byte(`{}`)))
+	require.NoError(t, dispatcher.Notify("topic", []byte(`{}`)))
+	require.NoError(t, dispatcher.Notify("exact-not", []byte(`{}`)))
+
+	require.Equal(t, []string{"store-changes-app", "exact", "topic", "exact-not"}, notified)
+
+	expected := []string{
+		`/all/store-changes-app  {"key":"k1"}`,
+		`/filtered/store-changes-app secret {"key":"k1"}`,
+		"/all/exact  {}",
+		"/filtered/exact secret {}",
+		"/all/topic  {}",
+		"/failing/topic  {}",
+		"/all/exact-not  {}",
+	}
+
+	require.Eventually(t, func() bool {
+		return len(receivedMessages()) == len(expected)
+	}, time.Second, 10*time.Millisecond)
+	require.ElementsMatch(t, expected, receivedMessages())
+
+	t.Run("notifier failure", func(t *testing.T) {
+		failing := NewDispatcher(&mocks.Notifier{NotifyFunc: func(string, []byte) error {
+			return errors.New("notify error")
+		}})
+		require.EqualError(t, failing.Notify("topic", nil), "failed to publish message on topic topic: notify error")
+	})
+}
+
+func TestDispatcher_Queue(t *testing.T) {
+	requests, release := make(chan string, 10), make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		requests <- req.URL.Path
+		<-release
+	}))
+	defer server.Close()
+
+	dispatcher := NewDispatcher(nil, WithHTTPClient(server.Client()), WithAllowedHosts("127.0.0.1"), WithQueue(1, 1))
+
+	cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()}, dispatcher)
+	require.NoError(t, err)
+	require.Nil(t, cmd.RegisterSubscription(&bytes.Buffer{}, marshal(t, &RegisterSubscriptionRequest{URL: server.URL})))
+
+	// the first message is being delivered, the second one waits in the queue
+	require.NoError(t, dispatcher.Notify("first", nil))
+	require.Equal(t, "/first", <-requests)
+	require.NoError(t, dispatcher.Notify("second", nil))
+
+	err = dispatcher.Notify("third", nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "delivery queue is full")
+
+	close(release)
+
+	require.Equal(t, "/second", <-requests)
+}
+
+func TestDispatcher_Redirects(t *testing.T) {
+	requests := make(chan string, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests <- req.URL.Path
+
+		http.Redirect(rw, req, "/target", http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	dispatcher := NewDispatcher(nil, WithAllowedHosts("127.0.0.1"))
+
+	cmd, err := New(&protocol.MockProvider{StoreProvider: mem.NewProvider()}, dispatcher)
+	require.NoError(t, err)
+	require.Nil(t, cmd.RegisterSubscription(&bytes.Buffer{}, marshal(t, &RegisterSubscriptionRequest{
+		URL: server.URL + "/hook",
+	})))
+
+	require.NoError(t, dispatcher.Notify("topic", nil))
+	require.Equal(t, "/hook/topic", <-requests)
+
+	select {
+	case path := <-requests:
+		t.Fatalf("redirect to %s was followed", path)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func marshal(t *testing.T, request interface{}) io.Reader {
+	t.Helper()
+
+	requestBytes, err := json.Marshal(request)
+	require.NoError(t, err)
+
+	return bytes.NewBuffer(requestBytes)
+}
+
+func ids(subs []Subscription) []string {
+	result := make([]string, len(subs))
+
+	for i, sub := range subs {
+		result[i] = sub.ID
+	}
+
+	return result
+}
+
+// failingStore fails operations of the wrapped store with given errors.
+type failingStore struct {
+	storage.Store
+	errPut   error
+	errGet   error
+	errQuery error
+}
+
+func (s *failingStore) Put(key string, value []byte, tags ...storage.Tag) error {
+	if s.errPut != nil {
+		return s.errPut
+	}
+
+	return s.Store.Put(key, value, tags...)
+}
+
+func (s *failingStore) Get(key string) ([]byte, error) {
+	if s.errGet != nil {
+		return nil, s.errGet
+	}
+
+	return s.Store.Get(key)
+}
+
+func (s *failingStore) Query(expression string, options ...storage.QueryOption) (storage.Iterator, error) {
+	if s.errQuery != nil {
+		return nil, s.errQuery
+	}
+
+	return s.Store.Query(expression, options...)
+}
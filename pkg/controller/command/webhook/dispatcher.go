@@ -0,0 +1,281 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+
+	"github.com/trustbloc/agent-sdk/pkg/controller/internal/netguard"
+)
+
+const (
+	// DefaultTimeout is the default timeout of requests to webhooks.
+	DefaultTimeout = 10 * time.Second
+	// DefaultQueueSize is the default number of messages waiting for delivery to webhook subscriptions.
+	DefaultQueueSize = 1000
+	// DefaultWorkers is the default number of concurrent deliveries to webhook subscriptions.
+	DefaultWorkers = 4
+)
+
+var (
+	errHostNotAllowed    = errors.New("host of url is not allowed")
+	errPrivateAddress    = errors.New("url must not point to a private, loopback or link-local address")
+	errRedirectForbidden = errors.New("webhooks must not redirect")
+)
+
+// Dispatcher is a notifier publishing messages to the wrapped notifier, e.g. webhooks set at start-up and
+// websocket clients, and to webhook subscriptions with matching topics. Messages are delivered to subscriptions
+// asynchronously through a bounded queue, so that publishers are not blocked by slow webhooks.
+type Dispatcher struct {
+	notifier     command.Notifier
+	client       *http.Client
+	allowedHosts []string
+	queue        *deliveryQueue
+
+	subscriptions *subscriptions
+}
+
+// DispatcherOpt represents a dispatcher option.
+type DispatcherOpt func(d *Dispatcher)
+
+// WithHTTPClient sets HTTP client used for requests to webhooks. Defaults to a client with DefaultTimeout,
+// which does not follow redirects and refuses to connect to private addresses of hosts not allowed explicitly.
+func WithHTTPClient(client *http.Client) DispatcherOpt {
+	return func(d *Dispatcher) {
+		d.client = client
+	}
+}
+
+// WithAllowedHosts restricts URLs of subscriptions to given hosts, a host starting with '*.' allows all its
+// subdomains. Allowed hosts may have private addresses. Subscriptions may use any host with public addresses
+// if not set.
+func WithAllowedHosts(hosts ...string) DispatcherOpt {
+	return func(d *Dispatcher) {
+		d.allowedHosts = hosts
+	}
+}
+
+// WithQueue sets the number of messages waiting for delivery to subscriptions and the number of concurrent
+// deliveries. Defaults to DefaultQueueSize and DefaultWorkers.
+func WithQueue(size, workers int) DispatcherOpt {
+	return func(d *Dispatcher) {
+		d.queue = newDeliveryQueue(size, workers)
+	}
+}
+
+// NewDispatcher returns new dispatcher wrapping given notifier, which may be nil. Subscriptions are loaded
+// and managed by webhook commands of the dispatcher.
+func NewDispatcher(notifier command.Notifier, opts ...DispatcherOpt) *Dispatcher {
+	d := &Dispatcher{
+		notifier:      notifier,
+		queue:         newDeliveryQueue(DefaultQueueSize, DefaultWorkers),
+		subscriptions: &subscriptions{},
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	if d.client == nil {
+		d.client = d.defaultClient()
+	}
+
+	return d
+}
+
+// Notify publishes the message on given topic. Failures of the wrapped notifier and subscriptions whose
+// deliveries could not be queued do not stop publishing to the others, they are returned together. Failed
+// deliveries to subscriptions are logged.
+func (d *Dispatcher) Notify(topic string, message []byte) error {
+	var errs []string
+
+	if d.notifier != nil {
+		if err := d.notifier.Notify(topic, message); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	for _, sub := range d.subscriptions.matching(topic) {
+		sub := sub
+
+		if !d.queue.push(func() { d.deliver(&sub, topic, message) }) {
+			logger.Warnf("delivery queue is full, dropped message on topic %s for webhook subscription %s",
+				topic, sub.ID)
+
+			errs = append(errs, fmt.Sprintf("subscription %s: delivery queue is full", sub.ID))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to publish message on topic %s: %s", topic, strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) deliver(sub *Subscription, topic string, message []byte) {
+	if err := d.post(sub, topic, message); err != nil {
+		logger.Warnf("failed to notify webhook subscription %s: %s", sub.ID, err)
+	}
+}
+
+func (d *Dispatcher) post(sub *Subscription, topic string, message []byte) error {
+	target := strings.TrimSuffix(sub.URL, "/") + "/" + topic
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, target, bytes.NewReader(message))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for name, value := range sub.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	if err = resp.Body.Close(); err != nil {
+		logger.Warnf("failed to close response body of webhook subscription %s: %s", sub.ID, err)
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// checkURL checks that subscriptions may post to given URL: its host must be allowed, if allowed hosts are set,
+// and must not be a private address otherwise. Host names are checked when connecting by the default client.
+func (d *Dispatcher) checkURL(u *url.URL) error {
+	host := u.Hostname()
+
+	if netguard.MatchesHost(d.allowedHosts, host) {
+		return nil
+	}
+
+	if len(d.allowedHosts) > 0 {
+		return errHostNotAllowed
+	}
+
+	if netguard.IsPrivateHost(host) {
+		return errPrivateAddress
+	}
+
+	return nil
+}
+
+// defaultClient returns client which does not follow redirects and refuses to connect to private addresses,
+// unless the host of the request is allowed explicitly.
+func (d *Dispatcher) defaultClient() *http.Client {
+	guarded := netguard.NewDialer(DefaultTimeout)
+	trusted := &net.Dialer{Timeout: DefaultTimeout}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+		if err == nil && netguard.MatchesHost(d.allowedHosts, host) {
+			return trusted.DialContext(ctx, network, address)
+		}
+
+		return guarded.DialContext(ctx, network, address)
+	}
+	transport.Proxy = nil
+
+	return &http.Client{
+		Timeout:   DefaultTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return errRedirectForbidden
+		},
+	}
+}
+
+// deliveryQueue runs queued deliveries by a bounded number of workers. Workers are started on demand and exit
+// when the queue is empty, so that idle dispatchers do not keep goroutines.
+type deliveryQueue struct {
+	size       int
+	maxWorkers int
+
+	mu      sync.Mutex
+	pending []func()
+	workers int
+}
+
+func newDeliveryQueue(size, workers int) *deliveryQueue {
+	return &deliveryQueue{size: size, maxWorkers: workers}
+}
+
+// push queues given delivery, returns false if the queue is full.
+func (q *deliveryQueue) push(delivery func()) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.pending) >= q.size {
+		return false
+	}
+
+	q.pending = append(q.pending, delivery)
+
+	if q.workers < q.maxWorkers {
+		q.workers++
+
+		go q.work()
+	}
+
+	return true
+}
+
+func (q *deliveryQueue) work() {
+	for {
+		q.mu.Lock()
+
+		if len(q.pending) == 0 {
+			q.workers--
+			q.mu.Unlock()
+
+			return
+		}
+
+		delivery := q.pending[0]
+		q.pending = q.pending[1:]
+
+		q.mu.Unlock()
+
+		delivery()
+	}
+}
+
+// matchesTopic checks whether given topic matches any of given topics, or topics are not set.
+func matchesTopic(topics []string, topic string) bool {
+	if len(topics) == 0 {
+		return true
+	}
+
+	for _, t := range topics {
+		if t == topic || strings.HasSuffix(t, "*") && strings.HasPrefix(topic, strings.TrimSuffix(t, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
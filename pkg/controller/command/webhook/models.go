@@ -0,0 +1,53 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webhook
+
+// Subscription model
+//
+// Represents a webhook receiving messages published on matching topics.
+type Subscription struct {
+	ID string `json:"id"`
+	// URL of the webhook, messages are posted to the URL followed by '/' and the topic.
+	URL string `json:"url"`
+	// Topics selects messages posted to the webhook, all topics are matched if not set.
+	// A topic ending with '*' matches all topics with given prefix, e.g. 'store-changes-*'.
+	Topics []string `json:"topics,omitempty"`
+	// Headers are set on each request to the webhook, e.g. 'Authorization'. Their values are redacted
+	// in listed subscriptions.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// RegisterSubscriptionRequest model
+//
+// This is used for registering a webhook subscription.
+type RegisterSubscriptionRequest struct {
+	URL     string            `json:"url"`
+	Topics  []string          `json:"topics,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// RegisterSubscriptionResponse model
+//
+// Represents a response of RegisterSubscription command.
+type RegisterSubscriptionResponse struct {
+	// ID of the registered subscription.
+	ID string `json:"id"`
+}
+
+// ListSubscriptionsResponse model
+//
+// Represents a response of ListSubscriptions command.
+type ListSubscriptionsResponse struct {
+	Subscriptions []Subscription `json:"subscriptions"`
+}
+
+// DeleteSubscriptionRequest model
+//
+// This is used for deleting a webhook subscription.
+type DeleteSubscriptionRequest struct {
+	ID string `json:"id"`
+}
@@ -0,0 +1,74 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webhook
+
+import (
+	"sync"
+)
+
+// subscriptions are webhook subscriptions a dispatcher publishes to.
+type subscriptions struct {
+	// writeMu serializes changes of subscriptions, so that the store and the subscriptions stay in sync.
+	writeMu sync.Mutex
+
+	mu    sync.RWMutex
+	items []Subscription
+}
+
+// matching returns subscriptions to given topic.
+func (s *subscriptions) matching(topic string) []Subscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var subs []Subscription
+
+	for _, sub := range s.items {
+		if matchesTopic(sub.Topics, topic) {
+			subs = append(subs, sub)
+		}
+	}
+
+	return subs
+}
+
+// list returns all subscriptions.
+func (s *subscriptions) list() []Subscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]Subscription{}, s.items...)
+}
+
+// set replaces all subscriptions.
+func (s *subscriptions) set(subs []Subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items = subs
+}
+
+// add adds given subscription.
+func (s *subscriptions) add(sub Subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items = append(s.items, sub)
+}
+
+// remove removes subscription with given ID.
+func (s *subscriptions) remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, sub := range s.items {
+		if sub.ID == id {
+			s.items = append(s.items[:i:i], s.items[i+1:]...)
+
+			return
+		}
+	}
+}
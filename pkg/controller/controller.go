@@ -8,20 +8,15 @@ SPDX-License-Identifier: Apache-2.0
 package controller
 
 import (
-	"fmt"
+	"sync"
 
 	ariescmd "github.com/hyperledger/aries-framework-go/pkg/controller/command"
 	"github.com/hyperledger/aries-framework-go/pkg/controller/webnotifier"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/context"
 
-	"github.com/trustbloc/agent-sdk/pkg/controller/command/blindedrouting"
-	didclientcmd "github.com/trustbloc/agent-sdk/pkg/controller/command/didclient"
-	mediatorclientcmd "github.com/trustbloc/agent-sdk/pkg/controller/command/mediatorclient"
-	"github.com/trustbloc/agent-sdk/pkg/controller/command/store"
+	agentcmd "github.com/trustbloc/agent-sdk/pkg/controller/command"
+	"github.com/trustbloc/agent-sdk/pkg/controller/command/webhook"
 	"github.com/trustbloc/agent-sdk/pkg/controller/rest"
-	blindedroutingrest "github.com/trustbloc/agent-sdk/pkg/controller/rest/blindedrouting"
-	"github.com/trustbloc/agent-sdk/pkg/controller/rest/didclient"
-	"github.com/trustbloc/agent-sdk/pkg/controller/rest/mediatorclient"
 )
 
 const wsPath = "/ws"
@@ -34,6 +29,12 @@ type allOpts struct {
 	msgHandler               ariescmd.MessageHandler
 	notifier                 ariescmd.Notifier
 	webhookURLs              []string
+	webhookAllowedHosts      []string
+	invitationURLHosts       []string
+	blindedRouter            bool
+	allowedStores            []string
+	modules                  []Module
+	disabledModules          []string
 }
 
 // Opt represents a controller option.
@@ -75,12 +76,31 @@ func WithMessageHandler(handler ariescmd.MessageHandler) Opt {
 }
 
 // WithWebhookURLs is an option for setting up a webhook dispatcher which will notify clients of events.
+// Webhooks set by this option receive all topics, see the webhook module for subscriptions managed at runtime.
 func WithWebhookURLs(webhookURLs ...string) Opt {
 	return func(opts *allOpts) {
 		opts.webhookURLs = webhookURLs
 	}
 }
 
+// WithWebhookAllowedHosts is an option restricting webhook subscriptions managed at runtime to given hosts,
+// a host starting with '*.' allows all its subdomains. Subscriptions may post to any host with public addresses
+// if not set.
+func WithWebhookAllowedHosts(hosts ...string) Opt {
+	return func(opts *allOpts) {
+		opts.webhookAllowedHosts = hosts
+	}
+}
+
+// WithInvitationURLHosts is an option enabling mediator client to resolve shortened invitation URLs of given hosts,
+// a host starting with '*.' allows all its subdomains and '*' allows any host. Hosts with private addresses
+// are refused. Only invitation URLs with invitation in query are decoded if not set.
+func WithInvitationURLHosts(hosts ...string) Opt {
+	return func(opts *allOpts) {
+		opts.invitationURLHosts = hosts
+	}
+}
+
 // WithNotifier is an option for setting up a notifier which will notify clients of events.
 func WithNotifier(notifier ariescmd.Notifier) Opt {
 	return func(opts *allOpts) {
@@ -88,54 +108,93 @@ func WithNotifier(notifier ariescmd.Notifier) Opt {
 	}
 }
 
-// GetCommandHandlers returns all command handlers provided by controller.
-func GetCommandHandlers(ctx *context.Provider, opts ...Opt) ([]ariescmd.Handler, error) { //nolint:interfacer
-	cmdOpts := &allOpts{}
-	// Apply options
-	for _, opt := range opts {
-		opt(cmdOpts)
+// WithBlindedRouter is an option allowing this agent to act as a blinded router for other agents.
+func WithBlindedRouter(enable bool) Opt {
+	return func(opts *allOpts) {
+		opts.blindedRouter = enable
 	}
+}
 
-	notifier := cmdOpts.notifier
-	if notifier == nil {
-		notifier = webnotifier.New(wsPath, cmdOpts.webhookURLs)
+// WithAllowedStores is an option allowing clients of store commands to access stores with given names.
+// A name ending with '*' allows all stores with given prefix.
+func WithAllowedStores(names ...string) Opt {
+	return func(opts *allOpts) {
+		opts.allowedStores = names
 	}
+}
 
-	// did client command operation.
-	didClientCmd, err := didclientcmd.NewWithMediator(cmdOpts.blocDomain, cmdOpts.didAnchorOrigin, cmdOpts.sidetreeToken,
-		cmdOpts.unanchoredDIDMaxLifeTime, ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize DID client: %w", err)
+// WithModules is an option adding modules whose handlers are returned along with built-in and registered ones.
+func WithModules(modules ...Module) Opt {
+	return func(opts *allOpts) {
+		opts.modules = append(opts.modules, modules...)
 	}
+}
 
-	// mediator client command operation,
-	mediatorClientCmd, err := mediatorclientcmd.New(ctx, cmdOpts.msgHandler, notifier)
-	if err != nil {
-		return nil, err
+// WithDisabledModules is an option disabling built-in or registered modules with given names,
+// e.g. StoreModule.
+func WithDisabledModules(names ...string) Opt {
+	return func(opts *allOpts) {
+		opts.disabledModules = append(opts.disabledModules, names...)
 	}
+}
 
-	// blindedRoutingCmd command operation.
-	blindedRoutingCmd, err := blindedrouting.New(ctx, cmdOpts.msgHandler, notifier)
-	if err != nil {
-		return nil, err
+// WithMiddlewares is an option adding middlewares intercepting command methods, e.g. for access control or
+// metrics, see command.UseMiddlewares. Middlewares apply to handlers of all controllers and are added once,
+// when the option is first applied, so that the same options may be passed for command and REST handlers.
+func WithMiddlewares(mw ...agentcmd.Middleware) Opt {
+	var once sync.Once
+
+	return func(*allOpts) {
+		once.Do(func() {
+			agentcmd.UseMiddlewares(mw...)
+		})
 	}
+}
 
-	storeCmd, err := store.New(ctx)
+// newDispatcher returns notifier publishing to webhooks and websocket clients, or to the notifier set by options,
+// and to webhook subscriptions managed by the webhook module.
+func newDispatcher(opts *allOpts) *webhook.Dispatcher {
+	notifier := opts.notifier
+	if notifier == nil {
+		notifier = webnotifier.New(wsPath, opts.webhookURLs)
+	}
+
+	return webhook.NewDispatcher(notifier, webhook.WithAllowedHosts(opts.webhookAllowedHosts...))
+}
+
+// GetCommandHandlers returns command handlers of all enabled modules.
+func GetCommandHandlers(ctx *context.Provider, opts ...Opt) ([]ariescmd.Handler, error) { //nolint:interfacer
+	cmdOpts := &allOpts{}
+	// Apply options
+	for _, opt := range opts {
+		opt(cmdOpts)
+	}
+
+	modules, err := enabledModules(cmdOpts, newDispatcher(cmdOpts))
 	if err != nil {
 		return nil, err
 	}
 
 	// creat handlers for all command operations.
 	var allHandlers []ariescmd.Handler
-	allHandlers = append(allHandlers, didClientCmd.GetHandlers()...)
-	allHandlers = append(allHandlers, mediatorClientCmd.GetHandlers()...)
-	allHandlers = append(allHandlers, blindedRoutingCmd.GetHandlers()...)
-	allHandlers = append(allHandlers, storeCmd.GetHandlers()...)
+
+	for _, module := range modules {
+		if module.Command == nil {
+			continue
+		}
+
+		handlers, e := module.Command(ctx)
+		if e != nil {
+			return nil, e
+		}
+
+		allHandlers = append(allHandlers, handlers...)
+	}
 
 	return allHandlers, nil
 }
 
-// GetRESTHandlers returns all REST handlers provided by controller.
+// GetRESTHandlers returns REST handlers of all enabled modules.
 func GetRESTHandlers(ctx *context.Provider, opts ...Opt) ([]rest.Handler, error) { //nolint:interfacer
 	restOpts := &allOpts{}
 	// Apply options
@@ -143,35 +202,22 @@ func GetRESTHandlers(ctx *context.Provider, opts ...Opt) ([]rest.Handler, error)
 		opt(restOpts)
 	}
 
-	notifier := restOpts.notifier
-	if notifier == nil {
-		notifier = webnotifier.New(wsPath, restOpts.webhookURLs)
-	}
-
-	// DID Client REST operation.
-	didClientOp, err := didclient.New(ctx, restOpts.blocDomain, restOpts.didAnchorOrigin, restOpts.sidetreeToken,
-		restOpts.unanchoredDIDMaxLifeTime)
-	if err != nil {
-		return nil, err
-	}
-
-	// mediator client REST operation.
-	mediatorClientOp, err := mediatorclient.New(ctx, restOpts.msgHandler, notifier)
-	if err != nil {
-		return nil, err
-	}
-
-	// blinded routing REST operation.
-	blindedRoutingOp, err := blindedroutingrest.New(ctx, restOpts.msgHandler, notifier)
+	modules, err := enabledModules(restOpts, newDispatcher(restOpts))
 	if err != nil {
 		return nil, err
 	}
 
 	// creat handlers from all REST operations.
 	var allHandlers []rest.Handler
-	allHandlers = append(allHandlers, didClientOp.GetRESTHandlers()...)
-	allHandlers = append(allHandlers, mediatorClientOp.GetRESTHandlers()...)
-	allHandlers = append(allHandlers, blindedRoutingOp.GetRESTHandlers()...)
+
+	for i := range modules {
+		handlers, e := modules[i].restHandlers(ctx)
+		if e != nil {
+			return nil, e
+		}
+
+		allHandlers = append(allHandlers, handlers...)
+	}
 
 	return allHandlers, nil
 }
@@ -6,8 +6,16 @@ SPDX-License-Identifier: Apache-2.0
 package controller_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/api"
 	"github.com/hyperledger/aries-framework-go/pkg/framework/aries/defaults"
@@ -16,7 +24,13 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/trustbloc/agent-sdk/pkg/controller"
+	agentcmd "github.com/trustbloc/agent-sdk/pkg/controller/command"
+	"github.com/trustbloc/agent-sdk/pkg/controller/command/schema"
+	"github.com/trustbloc/agent-sdk/pkg/controller/command/store"
+	"github.com/trustbloc/agent-sdk/pkg/controller/command/webhook"
+	"github.com/trustbloc/agent-sdk/pkg/controller/internal/cmdutil"
 	"github.com/trustbloc/agent-sdk/pkg/controller/internal/mocks"
+	"github.com/trustbloc/agent-sdk/pkg/controller/rest"
 )
 
 func TestGetCommandHandlers(t *testing.T) {
@@ -89,3 +103,245 @@ func TestGetRESTHandlers(t *testing.T) {
 		require.EqualError(t, err, "failed to initialize did-client command: service not found")
 	})
 }
+
+func TestModules(t *testing.T) {
+	builtin := controller.WithDisabledModules(controller.DIDClientModule, controller.MediatorClientModule,
+		controller.BlindedRoutingModule, controller.StoreModule, controller.WebhookModule, controller.SchemaModule)
+
+	exec := func(rw io.Writer, req io.Reader) command.Error { return nil }
+
+	module := controller.Module{
+		Name: "custom",
+		Command: func(ctx *context.Provider) ([]command.Handler, error) {
+			return []command.Handler{cmdutil.NewCommandHandler("custom", "Method", exec)}, nil
+		},
+		REST: func(ctx *context.Provider) ([]rest.Handler, error) {
+			return []rest.Handler{cmdutil.NewHTTPHandler("/custom/method", http.MethodPost,
+				func(rw http.ResponseWriter, req *http.Request) {})}, nil
+		},
+	}
+
+	t.Run("Module given by option", func(t *testing.T) {
+		handlers, err := controller.GetCommandHandlers(&context.Provider{}, builtin, controller.WithModules(module))
+		require.NoError(t, err)
+		require.Len(t, handlers, 1)
+		require.Equal(t, "custom", handlers[0].Name())
+
+		restHandlers, err := controller.GetRESTHandlers(&context.Provider{}, builtin, controller.WithModules(module))
+		require.NoError(t, err)
+		require.Len(t, restHandlers, 1)
+		require.Equal(t, "/custom/method", restHandlers[0].Path())
+
+		handlers, err = controller.GetCommandHandlers(&context.Provider{}, builtin, controller.WithModules(module),
+			controller.WithDisabledModules("custom"))
+		require.NoError(t, err)
+		require.Empty(t, handlers)
+	})
+
+	t.Run("Registered module", func(t *testing.T) {
+		registered := controller.Module{Name: "registered", Command: module.Command}
+
+		require.NoError(t, controller.RegisterModule(registered))
+		require.EqualError(t, controller.RegisterModule(registered), "module registered is already registered")
+		require.EqualError(t, controller.RegisterModule(controller.Module{}), "module name is mandatory")
+
+		handlers, err := controller.GetCommandHandlers(&context.Provider{}, builtin)
+		require.NoError(t, err)
+		require.Len(t, handlers, 1)
+
+		restHandlers, err := controller.GetRESTHandlers(&context.Provider{}, builtin)
+		require.NoError(t, err)
+		require.Len(t, restHandlers, 1)
+		require.Equal(t, "/custom/method", restHandlers[0].Path())
+
+		restHandlers, err = controller.GetRESTHandlers(&context.Provider{}, builtin,
+			controller.WithDisabledModules("registered"), controller.WithModules(controller.Module{
+				Name: "adapted", Command: module.Command, RESTOpts: []rest.AdapterOpt{
+					rest.WithPath("custom", "Method", "/adapted"),
+				},
+			}, controller.Module{Name: "empty"}))
+		require.NoError(t, err)
+		require.Len(t, restHandlers, 1)
+		require.Equal(t, "/adapted", restHandlers[0].Path())
+
+		_, err = controller.GetCommandHandlers(&context.Provider{}, builtin,
+			controller.WithModules(controller.Module{Name: "registered"}))
+		require.EqualError(t, err, "duplicate module registered")
+
+		_, err = controller.GetRESTHandlers(&context.Provider{}, controller.WithModules(controller.Module{}))
+		require.EqualError(t, err, "module name is mandatory")
+	})
+
+	t.Run("Module failure", func(t *testing.T) {
+		failing := controller.Module{
+			Name: "failing",
+			Command: func(ctx *context.Provider) ([]command.Handler, error) {
+				return nil, errors.New("command failure")
+			},
+			REST: func(ctx *context.Provider) ([]rest.Handler, error) {
+				return nil, errors.New("rest failure")
+			},
+		}
+
+		_, err := controller.GetCommandHandlers(&context.Provider{}, builtin, controller.WithModules(failing))
+		require.EqualError(t, err, "command failure")
+
+		_, err = controller.GetRESTHandlers(&context.Provider{}, builtin, controller.WithModules(failing))
+		require.EqualError(t, err, "rest failure")
+	})
+}
+
+func TestWithMiddlewares(t *testing.T) {
+	defer agentcmd.ResetMiddlewares()
+
+	var methods []string
+
+	opts := []controller.Opt{
+		controller.WithDisabledModules(controller.DIDClientModule, controller.MediatorClientModule,
+			controller.BlindedRoutingModule, controller.StoreModule, controller.WebhookModule, controller.SchemaModule,
+			"registered"),
+		controller.WithModules(controller.Module{
+			Name: "intercepted",
+			Command: func(ctx *context.Provider) ([]command.Handler, error) {
+				return []command.Handler{cmdutil.NewCommandHandler("intercepted", "Method",
+					func(rw io.Writer, req io.Reader) command.Error { return nil })}, nil
+			},
+		}),
+		controller.WithMiddlewares(func(inv *agentcmd.Invocation, next agentcmd.Next) command.Error {
+			methods = append(methods, inv.Name+"."+inv.Method)
+
+			return next(inv)
+		}),
+	}
+
+	handlers, err := controller.GetCommandHandlers(&context.Provider{}, opts...)
+	require.NoError(t, err)
+	require.Len(t, handlers, 1)
+
+	restHandlers, err := controller.GetRESTHandlers(&context.Provider{}, opts...)
+	require.NoError(t, err)
+	require.Len(t, restHandlers, 1)
+
+	require.Nil(t, handlers[0].Handle()(&bytes.Buffer{}, bytes.NewBufferString(`{}`)))
+
+	rr := httptest.NewRecorder()
+	restHandlers[0].Handle()(rr, httptest.NewRequest(http.MethodPost, restHandlers[0].Path(),
+		bytes.NewBufferString(`{}`)))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// middlewares are added once though options are applied for both command and REST handlers.
+	require.Equal(t, []string{"intercepted.Method", "intercepted.Method"}, methods)
+}
+
+func TestSchemaModule(t *testing.T) {
+	builtin := controller.WithDisabledModules(controller.DIDClientModule, controller.MediatorClientModule,
+		controller.BlindedRoutingModule, controller.StoreModule, controller.WebhookModule)
+
+	module := controller.Module{
+		Name: "custom",
+		Schemas: func() []agentcmd.MethodSchema {
+			return []agentcmd.MethodSchema{agentcmd.NewMethodSchema("custom", "Method", &agentcmd.SchemaViolation{}, nil)}
+		},
+	}
+
+	handlers, err := controller.GetCommandHandlers(&context.Provider{}, builtin, controller.WithModules(module))
+	require.NoError(t, err)
+	require.Len(t, handlers, 2)
+	require.Equal(t, schema.CommandName, handlers[0].Name())
+
+	var rw bytes.Buffer
+
+	require.Nil(t, handlers[0].Handle()(&rw, bytes.NewBufferString(`{"command":"custom"}`)))
+
+	var response schema.GetSchemasResponse
+
+	require.NoError(t, json.Unmarshal(rw.Bytes(), &response))
+	require.Len(t, response.Schemas, 1)
+	require.Equal(t, "Method", response.Schemas[0].Method)
+	require.Equal(t, "SchemaViolation", response.Schemas[0].Request["title"])
+
+	restHandlers, err := controller.GetRESTHandlers(&context.Provider{}, builtin, controller.WithModules(module))
+	require.NoError(t, err)
+	require.Len(t, restHandlers, 2)
+	require.Equal(t, "/schema/get-schemas", restHandlers[0].Path())
+	require.Equal(t, "/schema/get-errors", restHandlers[1].Path())
+}
+
+func TestModuleErrors(t *testing.T) {
+	builtin := controller.WithDisabledModules(controller.DIDClientModule, controller.MediatorClientModule,
+		controller.BlindedRoutingModule, controller.StoreModule, controller.WebhookModule)
+
+	definition := agentcmd.ErrorDefinition{Code: 9701, Name: "CUSTOM_FAILED", Command: "custom", Description: "failed"}
+
+	module := controller.Module{
+		Name: "custom",
+		Errors: func() []agentcmd.ErrorDefinition {
+			return []agentcmd.ErrorDefinition{definition}
+		},
+	}
+
+	_, err := controller.GetCommandHandlers(&context.Provider{}, builtin, controller.WithModules(module))
+	require.NoError(t, err)
+
+	registered, ok := agentcmd.LookupError(definition.Code)
+	require.True(t, ok)
+	require.Equal(t, definition, registered)
+
+	t.Run("conflicting error codes", func(t *testing.T) {
+		conflicting := controller.Module{
+			Name: "conflicting",
+			Errors: func() []agentcmd.ErrorDefinition {
+				return []agentcmd.ErrorDefinition{{Code: definition.Code, Name: "CONFLICTING_FAILED"}}
+			},
+		}
+
+		_, err := controller.GetCommandHandlers(&context.Provider{}, builtin, controller.WithModules(conflicting))
+		require.EqualError(t, err, "module conflicting: error code 9701 is already defined as CUSTOM_FAILED")
+	})
+}
+
+func TestWebhookModule(t *testing.T) {
+	received := make(chan string, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		received <- req.URL.Path + " " + req.Header.Get("X-Key")
+	}))
+	defer server.Close()
+
+	ctx, err := context.New(context.WithStorageProvider(mem.NewProvider()))
+	require.NoError(t, err)
+
+	var notified []string
+
+	handlers, err := controller.GetCommandHandlers(ctx,
+		controller.WithDisabledModules(controller.DIDClientModule, controller.MediatorClientModule,
+			controller.BlindedRoutingModule, controller.SchemaModule),
+		controller.WithNotifier(&mocks.Notifier{NotifyFunc: func(topic string, _ []byte) error {
+			notified = append(notified, topic)
+
+			return nil
+		}}))
+	require.NoError(t, err)
+
+	handle := func(t *testing.T, name, method, request string) {
+		t.Helper()
+
+		for _, handler := range handlers {
+			if handler.Name() == name && handler.Method() == method {
+				require.Nil(t, handler.Handle()(&bytes.Buffer{}, bytes.NewBufferString(request)))
+
+				return
+			}
+		}
+
+		t.Fatalf("handler %s %s not found", name, method)
+	}
+
+	handle(t, webhook.CommandName, webhook.RegisterSubscriptionCommandMethod,
+		`{"url":"`+server.URL+`","topics":["store-changes-*"],"headers":{"X-Key":"secret"}}`)
+	handle(t, store.CommandName, store.SubscribeCommandMethod, `{}`)
+	handle(t, store.CommandName, store.PutCommandMethod, `{"key":"key","value":"dmFsdWU="}`)
+
+	require.Equal(t, "/store-changes-store secret", <-received)
+	require.Equal(t, []string{"store-changes-store"}, notified)
+}
@@ -10,6 +10,8 @@ import (
 	"net/http"
 
 	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+
+	agentcmd "github.com/trustbloc/agent-sdk/pkg/controller/command"
 )
 
 // NewHTTPHandler returns instance of HTTPHandler which can be used handle
@@ -42,9 +44,9 @@ func (h *HTTPHandler) Handle() http.HandlerFunc {
 }
 
 // NewCommandHandler returns instance of CommandHandler which can be used handle
-// controller commands.
+// controller commands, the command is executed through the middleware chain.
 func NewCommandHandler(name, method string, exec command.Exec) *CommandHandler {
-	return &CommandHandler{name: name, method: method, handle: exec}
+	return &CommandHandler{name: name, method: method, handle: agentcmd.Intercept(name, method, exec)}
 }
 
 // CommandHandler contains command handling details which can be used to build controller
@@ -6,6 +6,7 @@ SPDX-License-Identifier: Apache-2.0
 package cmdutil_test
 
 import (
+	"bytes"
 	"io"
 	"net/http"
 	"testing"
@@ -14,6 +15,7 @@ import (
 	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
 	"github.com/stretchr/testify/require"
 
+	agentcmd "github.com/trustbloc/agent-sdk/pkg/controller/command"
 	"github.com/trustbloc/agent-sdk/pkg/controller/internal/cmdutil"
 )
 
@@ -69,3 +71,30 @@ func TestNewCommandHandler(t *testing.T) {
 		t.Fatal("handler function didnt get executed")
 	}
 }
+
+func TestNewCommandHandler_Middlewares(t *testing.T) {
+	t.Cleanup(agentcmd.ResetMiddlewares)
+
+	var invocation *agentcmd.Invocation
+
+	agentcmd.UseMiddlewares(func(inv *agentcmd.Invocation, next agentcmd.Next) command.Error {
+		invocation = inv
+
+		return next(inv)
+	})
+
+	handler := cmdutil.NewCommandHandler("foo", "bar", func(rw io.Writer, req io.Reader) command.Error {
+		_, err := io.Copy(rw, req)
+		require.NoError(t, err)
+
+		return nil
+	})
+
+	var rw bytes.Buffer
+
+	require.Nil(t, handler.Handle()(&rw, bytes.NewBufferString(`{"id":"1"}`)))
+	require.Equal(t, `{"id":"1"}`, rw.String())
+	require.Equal(t, &agentcmd.Invocation{
+		Name: "foo", Method: "bar", Request: []byte(`{"id":"1"}`), Response: &rw,
+	}, invocation)
+}
@@ -0,0 +1,576 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package mediation provides client of coordinate mediation 2.0 protocol used with DIDComm V2 routers and
+// keeps local keylists of router connections, shared by mediator client and DID client commands.
+package mediation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	oobv2 "github.com/hyperledger/aries-framework-go/pkg/didcomm/protocol/outofbandv2"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/trustbloc/edge-core/pkg/log"
+)
+
+var logger = log.New("agent-sdk-mediation")
+
+const (
+	// MediateRequestMsgType is coordinate mediation 2.0 mediate request message type.
+	MediateRequestMsgType = "https://didcomm.org/coordinate-mediation/2.0/mediate-request"
+	// MediateGrantMsgType is coordinate mediation 2.0 mediate grant message type.
+	MediateGrantMsgType = "https://didcomm.org/coordinate-mediation/2.0/mediate-grant"
+	// MediateDenyMsgType is coordinate mediation 2.0 mediate deny message type.
+	MediateDenyMsgType = "https://didcomm.org/coordinate-mediation/2.0/mediate-deny"
+	// RecipientUpdateMsgType is coordinate mediation 2.0 recipient update message type.
+	RecipientUpdateMsgType = "https://didcomm.org/coordinate-mediation/2.0/recipient-update"
+	// RecipientUpdateResponseMsgType is coordinate mediation 2.0 recipient update response message type.
+	RecipientUpdateResponseMsgType = "https://didcomm.org/coordinate-mediation/2.0/recipient-update-response"
+	// RecipientQueryMsgType is coordinate mediation 2.0 recipient query message type.
+	RecipientQueryMsgType = "https://didcomm.org/coordinate-mediation/2.0/recipient-query"
+	// RecipientMsgType is coordinate mediation 2.0 recipient message type.
+	RecipientMsgType = "https://didcomm.org/coordinate-mediation/2.0/recipient"
+
+	// ActionAdd adds recipient DID to router.
+	ActionAdd = "add"
+	// ActionRemove removes recipient DID from router.
+	ActionRemove = "remove"
+
+	// ResultSuccess is result of successful recipient update.
+	ResultSuccess = "success"
+
+	// accept profile of invitations from DIDComm V2 mediators.
+	didCommV2Profile = "didcomm/v2"
+
+	// store name and tag for mediation records of DIDComm V2 routers.
+	storeName = "mediatorclient_mediation"
+	recordTag = "mediation"
+
+	// prefix of keys of local keylists of router connections.
+	keylistKeyPrefix = "keylist_"
+
+	// prefix of names of message services receiving replies from routers.
+	replyServicePrefix = "mediatorclient-reply-"
+
+	// replyTimeout is the time to wait for reply from router.
+	replyTimeout = 120 * time.Second
+)
+
+// ErrDenied is returned when router denies mediation.
+var ErrDenied = errors.New("mediation request denied by router")
+
+var errNoReplies = errors.New("replies from router can't be received without message handler")
+
+// Provider describes dependencies of the client.
+type Provider interface {
+	StorageProvider() storage.Provider
+	ProtocolStateStorageProvider() storage.Provider
+	Messenger() service.Messenger
+}
+
+// Record is persisted mediation granted by a DIDComm V2 router.
+type Record struct {
+	ConnectionID string    `json:"connectionID"`
+	RoutingDIDs  []string  `json:"routingDIDs"`
+	GrantedAt    time.Time `json:"grantedAt"`
+}
+
+// Paginate contains pagination parameters of recipient query.
+type Paginate struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// Pagination contains pagination details of recipient query response.
+type Pagination struct {
+	Count     int `json:"count"`
+	Offset    int `json:"offset"`
+	Remaining int `json:"remaining"`
+}
+
+// Recipients is response to recipient query.
+type Recipients struct {
+	DIDs       []Recipient `json:"dids"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Recipient is recipient DID registered with router.
+type Recipient struct {
+	RecipientDID string `json:"recipient_did"`
+}
+
+// Update is a single update of recipient update message.
+type Update struct {
+	RecipientDID string `json:"recipient_did"`
+	Action       string `json:"action"`
+}
+
+// UpdateResult is result of a single update returned by router.
+type UpdateResult struct {
+	RecipientDID string `json:"recipient_did"`
+	Action       string `json:"action"`
+	Result       string `json:"result"`
+}
+
+type mediateGrantBody struct {
+	RoutingDID []string `json:"routing_did"`
+}
+
+// Client is client of coordinate mediation 2.0 protocol.
+type Client struct {
+	store       storage.Store
+	messenger   service.Messenger
+	connections *connection.Lookup
+	replies     *replyService
+	keylistLock sync.Mutex
+}
+
+// New returns new coordinate mediation 2.0 client, replies from routers are received through a message service
+// registered with given registrar. Clients receiving replies through the same registrar should be shared, since
+// only one of their message services is given replies. Messages can't be sent to routers if registrar is nil.
+func New(p Provider, registrar command.MessageHandler) (*Client, error) {
+	store, err := p.StorageProvider().OpenStore(storeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mediation store: %w", err)
+	}
+
+	connections, err := connection.NewLookup(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection lookup: %w", err)
+	}
+
+	c := &Client{
+		store:       store,
+		messenger:   p.Messenger(),
+		connections: connections,
+	}
+
+	if registrar == nil {
+		return c, nil
+	}
+
+	c.replies = &replyService{
+		name:    replyServicePrefix + uuid.New().String(),
+		waiters: map[string]*replyWaiter{},
+	}
+
+	err = registrar.Register(c.replies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register reply handler: %w", err)
+	}
+
+	return c, nil
+}
+
+// Supported tells whether router inviting with given invitation supports coordinate mediation 2.0,
+// i.e. whether invitation accepts DIDComm V2 envelopes.
+func Supported(inv *oobv2.Invitation) bool {
+	if inv.Body == nil {
+		return false
+	}
+
+	for _, profile := range inv.Body.Accept {
+		if strings.HasPrefix(profile, didCommV2Profile) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RequestMediation requests mediation from router over given connection and saves routing DIDs
+// of granted mediation.
+func (c *Client) RequestMediation(ctx context.Context, connID string) (*Record, error) {
+	reply, err := c.sendAndWait(ctx, connID, map[string]interface{}{
+		"type": MediateRequestMsgType,
+		"body": map[string]interface{}{},
+	}, MediateGrantMsgType, MediateDenyMsgType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request mediation: %w", err)
+	}
+
+	if reply.Type() == MediateDenyMsgType {
+		return nil, ErrDenied
+	}
+
+	grant := struct {
+		Body mediateGrantBody `json:"body"`
+	}{}
+
+	err = reply.Decode(&grant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse mediate grant: %w", err)
+	}
+
+	record := &Record{
+		ConnectionID: connID,
+		RoutingDIDs:  grant.Body.RoutingDID,
+		GrantedAt:    time.Now().UTC(),
+	}
+
+	err = c.Save(record)
+	if err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// QueryRecipients queries recipient DIDs registered with router.
+func (c *Client) QueryRecipients(ctx context.Context, connID string, paginate *Paginate) (*Recipients, error) {
+	body := map[string]interface{}{}
+	if paginate != nil {
+		body["paginate"] = paginate
+	}
+
+	reply, err := c.sendAndWait(ctx, connID, map[string]interface{}{
+		"type": RecipientQueryMsgType,
+		"body": body,
+	}, RecipientMsgType)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients := struct {
+		Body Recipients `json:"body"`
+	}{}
+
+	err = reply.Decode(&recipients)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse recipient response: %w", err)
+	}
+
+	return &recipients.Body, nil
+}
+
+// UpdateRecipients applies given action to given recipient DIDs registered with router, waits for
+// recipient update response and returns results reported by router.
+func (c *Client) UpdateRecipients(ctx context.Context, connID string, recipients []string,
+	action string,
+) ([]UpdateResult, error) {
+	reply, err := c.sendAndWait(ctx, connID, map[string]interface{}{
+		"type": RecipientUpdateMsgType,
+		"body": map[string]interface{}{
+			"updates": newUpdates(recipients, action),
+		},
+	}, RecipientUpdateResponseMsgType)
+	if err != nil {
+		return nil, err
+	}
+
+	response := struct {
+		Body struct {
+			Updated []UpdateResult `json:"updated"`
+		} `json:"body"`
+	}{}
+
+	err = reply.Decode(&response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse recipient update response: %w", err)
+	}
+
+	err = c.UpdateKeylist(connID, response.Body.Updated)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Body.Updated, nil
+}
+
+// Get returns mediation granted by router over given connection, storage.ErrDataNotFound is returned
+// for connections to DIDComm V1 routers.
+func (c *Client) Get(connID string) (*Record, error) {
+	recordBytes, err := c.store.Get(connID)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &Record{}
+
+	err = json.Unmarshal(recordBytes, record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal mediation record: %w", err)
+	}
+
+	return record, nil
+}
+
+// Save saves given mediation.
+func (c *Client) Save(record *Record) error {
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal mediation record: %w", err)
+	}
+
+	err = c.store.Put(record.ConnectionID, recordBytes, storage.Tag{Name: recordTag})
+	if err != nil {
+		return fmt.Errorf("failed to save mediation record: %w", err)
+	}
+
+	return nil
+}
+
+// IsMediated tells whether router granted mediation over given connection using coordinate mediation 2.0.
+func (c *Client) IsMediated(connID string) (bool, error) {
+	_, err := c.Get(connID)
+
+	switch {
+	case errors.Is(err, storage.ErrDataNotFound):
+		return false, nil
+	case err != nil:
+		return false, err
+	default:
+		return true, nil
+	}
+}
+
+// Keylist returns recipient keys and DIDs registered with router over given connection, as confirmed by
+// the router.
+func (c *Client) Keylist(connID string) ([]string, error) {
+	keysBytes, err := c.store.Get(keylistKeyPrefix + connID)
+	if errors.Is(err, storage.ErrDataNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get keylist: %w", err)
+	}
+
+	var keys []string
+
+	err = json.Unmarshal(keysBytes, &keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal keylist: %w", err)
+	}
+
+	return keys, nil
+}
+
+// UpdateKeylist applies updates confirmed by router over given connection to the local keylist, updates
+// the router refused are ignored.
+func (c *Client) UpdateKeylist(connID string, results []UpdateResult) error {
+	c.keylistLock.Lock()
+	defer c.keylistLock.Unlock()
+
+	keys, err := c.Keylist(connID)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		if result.Result != ResultSuccess {
+			continue
+		}
+
+		keys = removeKey(keys, result.RecipientDID)
+
+		if result.Action == ActionAdd {
+			keys = append(keys, result.RecipientDID)
+		}
+	}
+
+	keysBytes, err := json.Marshal(keys)
+	if err != nil {
+		return fmt.Errorf("failed to marshal keylist: %w", err)
+	}
+
+	err = c.store.Put(keylistKeyPrefix+connID, keysBytes)
+	if err != nil {
+		return fmt.Errorf("failed to save keylist: %w", err)
+	}
+
+	return nil
+}
+
+// Connections returns connections to routers which granted mediation.
+func (c *Client) Connections() ([]string, error) {
+	iter, err := c.store.Query(recordTag)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if e := iter.Close(); e != nil {
+			logger.Warnf("failed to close iterator: %s", e)
+		}
+	}()
+
+	var connections []string
+
+	more, err := iter.Next()
+
+	for ; more && err == nil; more, err = iter.Next() {
+		key, e := iter.Key()
+		if e != nil {
+			return nil, e
+		}
+
+		connections = append(connections, key)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return connections, nil
+}
+
+// sendAndWait sends given message to router over given connection and waits for reply of any of given types.
+func (c *Client) sendAndWait(ctx context.Context, connID string, msg map[string]interface{},
+	replyTypes ...string,
+) (service.DIDCommMsgMap, error) {
+	if c.replies == nil {
+		return nil, errNoReplies
+	}
+
+	record, err := c.connections.GetConnectionRecord(connID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection %s: %w", connID, err)
+	}
+
+	msgID := uuid.New().String()
+	msg["id"] = msgID
+
+	replies := c.replies.wait(msgID, replyTypes)
+	defer c.replies.done(msgID)
+
+	err = c.messenger.Send(msg, record.MyDID, record.TheirDID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, replyTimeout)
+	defer cancel()
+
+	select {
+	case reply := <-replies:
+		return reply, nil
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return nil, fmt.Errorf("cancelled waiting for reply from router: %w", ctx.Err())
+		}
+
+		return nil, fmt.Errorf("timeout waiting for reply from router")
+	}
+}
+
+func newUpdates(recipients []string, action string) []Update {
+	updates := make([]Update, len(recipients))
+	for i, recipient := range recipients {
+		updates[i] = Update{RecipientDID: recipient, Action: action}
+	}
+
+	return updates
+}
+
+func removeKey(keys []string, key string) []string {
+	for i, k := range keys {
+		if k == key {
+			return append(keys[:i], keys[i+1:]...)
+		}
+	}
+
+	return keys
+}
+
+// replyService is a message service receiving replies to messages sent to routers, delivering them to senders
+// waiting for replies in the same thread.
+type replyService struct {
+	name    string
+	mu      sync.Mutex
+	waiters map[string]*replyWaiter
+}
+
+// replyWaiter is sender waiting for reply of any of given types.
+type replyWaiter struct {
+	types   []string
+	replies chan service.DIDCommMsgMap
+}
+
+// Name of the message service.
+func (r *replyService) Name() string {
+	return r.name
+}
+
+// Accept matches given message type with reply types of coordinate mediation 2.0.
+func (r *replyService) Accept(msgType string, _ []string) bool {
+	switch msgType {
+	case MediateGrantMsgType, MediateDenyMsgType, RecipientUpdateResponseMsgType, RecipientMsgType:
+		return true
+	default:
+		return false
+	}
+}
+
+// HandleInbound delivers reply to the sender waiting for reply of its type in the thread of the reply,
+// other replies are ignored.
+func (r *replyService) HandleInbound(msg service.DIDCommMsg, _ service.DIDCommContext) (string, error) {
+	thID, err := msg.ThreadID()
+	if err != nil {
+		return "", nil
+	}
+
+	msgMap, ok := msg.(service.DIDCommMsgMap)
+	if !ok {
+		return "", fmt.Errorf("unexpected message format")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	waiter, ok := r.waiters[thID]
+	if !ok || !contains(waiter.types, msg.Type()) {
+		return "", nil
+	}
+
+	select {
+	case waiter.replies <- msgMap:
+	default:
+	}
+
+	return "", nil
+}
+
+// wait registers sender of message with given ID waiting for reply of any of given types.
+func (r *replyService) wait(msgID string, replyTypes []string) <-chan service.DIDCommMsgMap {
+	waiter := &replyWaiter{types: replyTypes, replies: make(chan service.DIDCommMsgMap, 1)}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.waiters[msgID] = waiter
+
+	return waiter.replies
+}
+
+// done removes sender of message with given ID.
+func (r *replyService) done(msgID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.waiters, msgID)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
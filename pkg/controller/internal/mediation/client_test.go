@@ -0,0 +1,338 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package mediation_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/didcomm/common/service"
+	mockmsghandler "github.com/hyperledger/aries-framework-go/pkg/mock/didcomm/msghandler"
+	mockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/store/connection"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/agent-sdk/pkg/controller/internal/mediation"
+	sdkmockprotocol "github.com/trustbloc/agent-sdk/pkg/controller/internal/mocks/protocol"
+)
+
+const (
+	connID     = "router-connection"
+	routingDID = "did:peer:2.Ez6LSrouter"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("test success", func(t *testing.T) {
+		prov, _ := newMockProvider(t)
+
+		c, err := mediation.New(prov, mockmsghandler.NewMockMsgServiceProvider())
+		require.NoError(t, err)
+		require.NotNil(t, c)
+	})
+
+	t.Run("test open store error", func(t *testing.T) {
+		prov, _ := newMockProvider(t)
+		prov.StoreProvider = &mockstorage.MockStoreProvider{ErrOpenStoreHandle: fmt.Errorf("sample-error")}
+
+		c, err := mediation.New(prov, mockmsghandler.NewMockMsgServiceProvider())
+		require.Error(t, err)
+		require.Nil(t, c)
+		require.Contains(t, err.Error(), "failed to open mediation store")
+	})
+
+	t.Run("test register reply handler error", func(t *testing.T) {
+		prov, _ := newMockProvider(t)
+
+		c, err := mediation.New(prov, &mockmsghandler.MockMsgSvcProvider{RegisterErr: fmt.Errorf("sample-error")})
+		require.Error(t, err)
+		require.Nil(t, c)
+		require.Contains(t, err.Error(), "failed to register reply handler")
+	})
+
+	t.Run("test without registrar", func(t *testing.T) {
+		prov, _ := newMockProvider(t)
+
+		c, err := mediation.New(prov, nil)
+		require.NoError(t, err)
+
+		_, err = c.QueryRecipients(context.Background(), connID, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "replies from router can't be received")
+	})
+}
+
+func TestClient_Replies(t *testing.T) {
+	const recipientMsgStr = `{
+		"id": "123456783",
+		"type": "https://didcomm.org/coordinate-mediation/2.0/recipient",
+		"thid": "%s",
+		"body": {"dids": [{"recipient_did": "%s"}]}
+	}`
+
+	type queryResult struct {
+		recipients *mediation.Recipients
+		err        error
+	}
+
+	prov, messenger := newMockProvider(t)
+	registrar := mockmsghandler.NewMockMsgServiceProvider()
+
+	c, err := mediation.New(prov, registrar)
+	require.NoError(t, err)
+	require.Len(t, registrar.Services(), 1)
+
+	svc := registrar.Services()[0]
+	require.True(t, svc.Accept(mediation.RecipientMsgType, nil))
+	require.False(t, svc.Accept("https://didcomm.org/basicmessage/2.0/message", nil))
+
+	query := func(results chan<- queryResult) {
+		recipients, e := c.QueryRecipients(context.Background(), connID, nil)
+		results <- queryResult{recipients: recipients, err: e}
+	}
+
+	first := make(chan queryResult, 1)
+	go query(first)
+
+	firstID := waitForMessage(messenger, "")
+
+	second := make(chan queryResult, 1)
+	go query(second)
+
+	secondID := waitForMessage(messenger, firstID)
+
+	deliver := func(msgStr string) {
+		msg, e := service.ParseDIDCommMsgMap([]byte(msgStr))
+		require.NoError(t, e)
+
+		_, e = svc.HandleInbound(msg, &sdkmockprotocol.MockDIDCommContext{})
+		require.NoError(t, e)
+	}
+
+	deliver(fmt.Sprintf(recipientMsgStr, "unknown-thread", "did:example:unknown"))
+	deliver(fmt.Sprintf(`{"id": "1", "type": "%s", "thid": "%s", "body": {}}`, mediation.MediateDenyMsgType, firstID))
+	deliver(fmt.Sprintf(recipientMsgStr, secondID, "did:example:second"))
+	deliver(fmt.Sprintf(recipientMsgStr, firstID, "did:example:first"))
+
+	result := <-first
+	require.NoError(t, result.err)
+	require.Equal(t, []mediation.Recipient{{RecipientDID: "did:example:first"}}, result.recipients.DIDs)
+
+	result = <-second
+	require.NoError(t, result.err)
+	require.Equal(t, []mediation.Recipient{{RecipientDID: "did:example:second"}}, result.recipients.DIDs)
+}
+
+func TestClient_RequestMediation(t *testing.T) {
+	const grantMsgStr = `{
+		"id": "123456781",
+		"type": "https://didcomm.org/coordinate-mediation/2.0/mediate-grant",
+		"thid": "%s",
+		"body": {"routing_did": ["did:peer:2.Ez6LSrouter"]}
+	}`
+
+	prov, messenger := newMockProvider(t)
+	registrar := mockmsghandler.NewMockMsgServiceProvider()
+
+	c, err := mediation.New(prov, registrar)
+	require.NoError(t, err)
+
+	mediated, err := c.IsMediated(connID)
+	require.NoError(t, err)
+	require.False(t, mediated)
+
+	go replyFromRouter(t, registrar, messenger, mediation.MediateGrantMsgType, grantMsgStr)
+
+	record, err := c.RequestMediation(context.Background(), connID)
+	require.NoError(t, err)
+	require.Equal(t, []string{routingDID}, record.RoutingDIDs)
+
+	mediated, err = c.IsMediated(connID)
+	require.NoError(t, err)
+	require.True(t, mediated)
+
+	connections, err := c.Connections()
+	require.NoError(t, err)
+	require.Equal(t, []string{connID}, connections)
+
+	require.Len(t, registrar.Services(), 1)
+}
+
+func TestClient_UpdateRecipients(t *testing.T) {
+	t.Run("test success", func(t *testing.T) {
+		const updateResponseMsgStr = `{
+		"id": "123456782",
+		"type": "https://didcomm.org/coordinate-mediation/2.0/recipient-update-response",
+		"thid": "%s",
+		"body": {"updated": [{"recipient_did": "did:peer:1zQmRouted", "action": "add", "result": "success"}]}
+		}`
+
+		prov, messenger := newMockProvider(t)
+		registrar := mockmsghandler.NewMockMsgServiceProvider()
+
+		c, err := mediation.New(prov, registrar)
+		require.NoError(t, err)
+
+		go replyFromRouter(t, registrar, messenger, mediation.RecipientUpdateResponseMsgType, updateResponseMsgStr)
+
+		results, err := c.UpdateRecipients(context.Background(), connID, []string{"did:peer:1zQmRouted"},
+			mediation.ActionAdd)
+		require.NoError(t, err)
+		require.Equal(t, []mediation.UpdateResult{{
+			RecipientDID: "did:peer:1zQmRouted", Action: mediation.ActionAdd, Result: mediation.ResultSuccess,
+		}}, results)
+
+		keys, err := c.Keylist(connID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"did:peer:1zQmRouted"}, keys)
+
+		require.Len(t, registrar.Services(), 1)
+	})
+
+	t.Run("test unknown connection", func(t *testing.T) {
+		prov, _ := newMockProvider(t)
+
+		c, err := mediation.New(prov, mockmsghandler.NewMockMsgServiceProvider())
+		require.NoError(t, err)
+
+		results, err := c.UpdateRecipients(context.Background(), "unknown", []string{"did:peer:1zQmRouted"},
+			mediation.ActionAdd)
+		require.Error(t, err)
+		require.Nil(t, results)
+		require.Contains(t, err.Error(), "failed to get connection unknown")
+	})
+
+	t.Run("test cancelled while waiting for response", func(t *testing.T) {
+		prov, _ := newMockProvider(t)
+		registrar := mockmsghandler.NewMockMsgServiceProvider()
+
+		c, err := mediation.New(prov, registrar)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		results, err := c.UpdateRecipients(ctx, connID, []string{"did:peer:1zQmRouted"}, mediation.ActionRemove)
+		require.Error(t, err)
+		require.Nil(t, results)
+		require.Contains(t, err.Error(), "cancelled waiting for reply from router")
+
+		require.Len(t, registrar.Services(), 1)
+	})
+}
+
+func TestClient_UpdateKeylist(t *testing.T) {
+	t.Run("test success", func(t *testing.T) {
+		prov, _ := newMockProvider(t)
+
+		c, err := mediation.New(prov, mockmsghandler.NewMockMsgServiceProvider())
+		require.NoError(t, err)
+
+		keys, err := c.Keylist(connID)
+		require.NoError(t, err)
+		require.Empty(t, keys)
+
+		require.NoError(t, c.UpdateKeylist(connID, []mediation.UpdateResult{
+			{RecipientDID: "key-1", Action: mediation.ActionAdd, Result: mediation.ResultSuccess},
+			{RecipientDID: "key-2", Action: mediation.ActionAdd, Result: mediation.ResultSuccess},
+			{RecipientDID: "key-3", Action: mediation.ActionAdd, Result: "server_error"},
+		}))
+
+		require.NoError(t, c.UpdateKeylist(connID, []mediation.UpdateResult{
+			{RecipientDID: "key-1", Action: mediation.ActionRemove, Result: mediation.ResultSuccess},
+			{RecipientDID: "key-2", Action: mediation.ActionRemove, Result: "client_error"},
+		}))
+
+		keys, err = c.Keylist(connID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"key-2"}, keys)
+	})
+
+	t.Run("test store error", func(t *testing.T) {
+		prov, _ := newMockProvider(t)
+		prov.StoreProvider = mockstorage.NewCustomMockStoreProvider(&mockstorage.MockStore{
+			Store:  make(map[string]mockstorage.DBEntry),
+			ErrPut: fmt.Errorf("sample-error"),
+		})
+
+		c, err := mediation.New(prov, mockmsghandler.NewMockMsgServiceProvider())
+		require.NoError(t, err)
+
+		err = c.UpdateKeylist(connID, []mediation.UpdateResult{
+			{RecipientDID: "key-1", Action: mediation.ActionAdd, Result: mediation.ResultSuccess},
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to save keylist")
+	})
+}
+
+// newMockProvider returns provider with connection to router.
+func newMockProvider(t *testing.T) (*sdkmockprotocol.MockProvider, *sdkmockprotocol.MockMessenger) {
+	t.Helper()
+
+	connBytes, err := json.Marshal(&connection.Record{
+		ConnectionID: connID,
+		State:        "completed", MyDID: "mydid", TheirDID: "theirDID-001",
+	})
+	require.NoError(t, err)
+
+	mockStore := &mockstorage.MockStore{Store: make(map[string]mockstorage.DBEntry)}
+	require.NoError(t, mockStore.Put("conn_"+connID, connBytes))
+
+	messenger := sdkmockprotocol.NewMockMessenger()
+
+	prov := sdkmockprotocol.NewMockProvider()
+	prov.StoreProvider = mockstorage.NewCustomMockStoreProvider(mockStore)
+	prov.ProtocolStateStoreProvider = mockstorage.NewMockStoreProvider()
+	prov.CustomMessenger = messenger
+
+	return prov, messenger
+}
+
+// waitForMessage returns ID of the message sent after the message with given ID.
+func waitForMessage(messenger *sdkmockprotocol.MockMessenger, previousID string) string {
+	for {
+		if id := messenger.GetLastID(); id != "" && id != previousID {
+			return id
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// replyFromRouter delivers reply of given type to the last message sent, once reply handler is registered.
+func replyFromRouter(t *testing.T, registrar *mockmsghandler.MockMsgSvcProvider,
+	messenger *sdkmockprotocol.MockMessenger, msgType, replyFormat string,
+) {
+	t.Helper()
+
+	for {
+		if messenger.GetLastID() == "" {
+			continue
+		}
+
+		for _, svc := range registrar.Services() {
+			if !svc.Accept(msgType, nil) {
+				continue
+			}
+
+			replyMsg, e := service.ParseDIDCommMsgMap([]byte(fmt.Sprintf(replyFormat, messenger.GetLastID())))
+			require.NoError(t, e)
+
+			_, e = svc.HandleInbound(replyMsg, &sdkmockprotocol.MockDIDCommContext{
+				MyDIDValue:    "mydid",
+				TheirDIDValue: "theirDID-001",
+			})
+			require.NoError(t, e)
+
+			return
+		}
+	}
+}
@@ -119,9 +119,20 @@ func (s *MockStore) Get(k string) ([]byte, error) {
 	return val, s.ErrGet
 }
 
-// GetTags is not implemented.
-func (s *MockStore) GetTags(string) ([]storage.Tag, error) {
-	panic("implement me")
+// GetTags returns no tags for stored keys, as the mock does not keep tags.
+func (s *MockStore) GetTags(k string) ([]storage.Tag, error) {
+	if s.ErrGet != nil {
+		return nil, s.ErrGet
+	}
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if _, ok := s.Store[k]; !ok {
+		return nil, storage.ErrDataNotFound
+	}
+
+	return nil, nil
 }
 
 // GetBulk is not implemented.
@@ -175,9 +186,9 @@ func (m *MockIterator) Next() (bool, error) {
 	return false, m.ErrNext
 }
 
-// Key is not implemented.
+// Key returns a mocked key.
 func (m *MockIterator) Key() (string, error) {
-	panic("implement me")
+	return "Key", nil
 }
 
 // Value returns a mocked value.
@@ -185,9 +196,9 @@ func (m *MockIterator) Value() ([]byte, error) {
 	return []byte("Value"), nil
 }
 
-// Tags is not implemented.
+// Tags returns mocked tags.
 func (m *MockIterator) Tags() ([]storage.Tag, error) {
-	panic("implement me")
+	return []storage.Tag{{Name: "Tag"}}, nil
 }
 
 // TotalItems is not implemented.
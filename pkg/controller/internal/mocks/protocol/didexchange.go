@@ -38,3 +38,12 @@ func (m *MockDIDExchangeSvc) RegisterMsgEvent(ch chan<- service.StateMsg) error
 
 	return nil
 }
+
+// RegisterActionEvent register action event.
+func (m *MockDIDExchangeSvc) RegisterActionEvent(ch chan<- service.DIDCommAction) error {
+	if m.MockDIDExchangeSvc == nil {
+		return nil
+	}
+
+	return m.MockDIDExchangeSvc.RegisterActionEvent(ch)
+}
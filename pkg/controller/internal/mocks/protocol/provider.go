@@ -54,8 +54,9 @@ func NewMockMessenger() *MockMessenger {
 // MockMessenger mock implementation of messenger.
 type MockMessenger struct {
 	*mocksvc.MockMessenger
-	lastID string
-	lock   sync.RWMutex
+	lastID    string
+	lastReply service.DIDCommMsgMap
+	lock      sync.RWMutex
 }
 
 // Send mock messenger Send.
@@ -82,6 +83,29 @@ func (m *MockMessenger) ReplyToNested(msg service.DIDCommMsgMap, opts *service.N
 	return nil
 }
 
+// ReplyTo mock messenger ReplyTo.
+func (m *MockMessenger) ReplyTo(msgID string, msg service.DIDCommMsgMap, _ ...service.Opt) error {
+	if m.ErrReplyTo != nil {
+		return m.ErrReplyTo
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.lastID = msg.ID()
+	m.lastReply = msg
+
+	return nil
+}
+
+// GetLastReply returns the last reply sent.
+func (m *MockMessenger) GetLastReply() service.DIDCommMsgMap {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.lastReply
+}
+
 // GetLastID returns ID of the last message received.
 func (m *MockMessenger) GetLastID() string {
 	m.lock.RLock()
@@ -0,0 +1,71 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package netguard guards HTTP requests which commands send to URLs given by clients, e.g. webhook subscriptions
+// and shortened invitation URLs, against server-side request forgery: hosts are checked against allowed hosts
+// and connections to private addresses are refused.
+package netguard
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress is returned when connecting to a private, loopback or link-local address is refused.
+var ErrPrivateAddress = errors.New("address must not be private, loopback or link-local")
+
+// MatchesHost tells whether given host matches one of allowed hosts, a host starting with '*.' matches all its
+// subdomains. Hosts are compared case-insensitively.
+func MatchesHost(allowedHosts []string, host string) bool {
+	for _, allowed := range allowedHosts {
+		if strings.EqualFold(allowed, host) ||
+			strings.HasPrefix(allowed, "*.") && strings.HasSuffix(strings.ToLower(host), strings.ToLower(allowed[1:])) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsPublicIP tells whether given IP is a public address, i.e. not a loopback, private, link-local, multicast
+// or unspecified one.
+func IsPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// IsPrivateHost tells whether given host is localhost or an IP address which is not public. Other host names
+// are checked when connecting by dialers returned by NewDialer.
+func IsPrivateHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && !IsPublicIP(ip)
+}
+
+// NewDialer returns dialer with given timeout which refuses to connect to addresses which are not public
+// with ErrPrivateAddress. Addresses are checked after host names are resolved, so that host names resolving
+// to private addresses are refused too.
+func NewDialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{Timeout: timeout, Control: func(_, address string, _ syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+
+		if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+			return ErrPrivateAddress
+		}
+
+		return nil
+	}}
+}
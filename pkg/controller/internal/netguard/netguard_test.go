@@ -0,0 +1,63 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package netguard_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/agent-sdk/pkg/controller/internal/netguard"
+)
+
+func TestMatchesHost(t *testing.T) {
+	allowed := []string{"example.com", "*.Example.org"}
+
+	require.True(t, netguard.MatchesHost(allowed, "example.com"))
+	require.True(t, netguard.MatchesHost(allowed, "EXAMPLE.com"))
+	require.True(t, netguard.MatchesHost(allowed, "links.example.org"))
+	require.True(t, netguard.MatchesHost(allowed, "a.b.example.org"))
+	require.False(t, netguard.MatchesHost(allowed, "example.org"))
+	require.False(t, netguard.MatchesHost(allowed, "links.example.com"))
+	require.False(t, netguard.MatchesHost(allowed, "badexample.org"))
+	require.False(t, netguard.MatchesHost(nil, "example.com"))
+}
+
+func TestIsPrivateHost(t *testing.T) {
+	for _, host := range []string{"localhost", "LocalHost", "127.0.0.1", "10.1.2.3", "192.168.0.1", "169.254.1.1",
+		"0.0.0.0", "224.0.0.1", "::1", "fd00::1", "fe80::1"} {
+		require.True(t, netguard.IsPrivateHost(host), host)
+	}
+
+	for _, host := range []string{"8.8.8.8", "2001:4860:4860::8888", "example.com"} {
+		require.False(t, netguard.IsPrivateHost(host), host)
+	}
+
+	require.True(t, netguard.IsPublicIP(net.ParseIP("1.1.1.1")))
+	require.False(t, netguard.IsPublicIP(net.ParseIP("172.16.0.1")))
+}
+
+func TestNewDialer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to private address")
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err := netguard.NewDialer(time.Second).DialContext(ctx, "tcp", srv.Listener.Addr().String())
+	require.ErrorIs(t, err, netguard.ErrPrivateAddress)
+
+	_, err = netguard.NewDialer(time.Second).DialContext(ctx, "tcp", "localhost:1")
+	require.ErrorIs(t, err, netguard.ErrPrivateAddress)
+}
@@ -0,0 +1,421 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	ariescmd "github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	"github.com/hyperledger/aries-framework-go/pkg/framework/context"
+
+	agentcmd "github.com/trustbloc/agent-sdk/pkg/controller/command"
+	"github.com/trustbloc/agent-sdk/pkg/controller/command/blindedrouting"
+	didclientcmd "github.com/trustbloc/agent-sdk/pkg/controller/command/didclient"
+	mediatorclientcmd "github.com/trustbloc/agent-sdk/pkg/controller/command/mediatorclient"
+	"github.com/trustbloc/agent-sdk/pkg/controller/command/schema"
+	"github.com/trustbloc/agent-sdk/pkg/controller/command/store"
+	"github.com/trustbloc/agent-sdk/pkg/controller/command/webhook"
+	"github.com/trustbloc/agent-sdk/pkg/controller/internal/mediation"
+	"github.com/trustbloc/agent-sdk/pkg/controller/rest"
+	blindedroutingrest "github.com/trustbloc/agent-sdk/pkg/controller/rest/blindedrouting"
+	"github.com/trustbloc/agent-sdk/pkg/controller/rest/didclient"
+	"github.com/trustbloc/agent-sdk/pkg/controller/rest/mediatorclient"
+	storerest "github.com/trustbloc/agent-sdk/pkg/controller/rest/store"
+)
+
+// names of built-in modules.
+const (
+	DIDClientModule      = "didclient"
+	MediatorClientModule = "mediatorclient"
+	BlindedRoutingModule = "blindedrouting"
+	StoreModule          = "store"
+	SchemaModule         = "schema"
+	WebhookModule        = "webhook"
+)
+
+var errEmptyModuleName = errors.New("module name is mandatory")
+
+// CommandFactory creates command handlers of a module.
+type CommandFactory func(ctx *context.Provider) ([]ariescmd.Handler, error)
+
+// RESTFactory creates REST handlers of a module.
+type RESTFactory func(ctx *context.Provider) ([]rest.Handler, error)
+
+// Module provides command and REST handlers of a command package, either of the factories may be nil.
+// Modules without REST factory serve their command handlers on conventional paths, see rest.CommandPath.
+type Module struct {
+	Name    string
+	Command CommandFactory
+	REST    RESTFactory
+	// RESTOpts override paths of command handlers served without REST factory.
+	RESTOpts []rest.AdapterOpt
+	// Schemas returns JSON schemas of command methods of the module, exported by the schema module.
+	Schemas func() []agentcmd.MethodSchema
+	// Errors returns definitions of error codes of the module, registered in the error catalog.
+	Errors func() []agentcmd.ErrorDefinition
+}
+
+// restHandlers returns REST handlers of the module, adapting its command handlers if it has no REST factory.
+func (m *Module) restHandlers(ctx *context.Provider) ([]rest.Handler, error) {
+	if m.REST != nil {
+		return m.REST(ctx)
+	}
+
+	if m.Command == nil {
+		return nil, nil
+	}
+
+	handlers, err := m.Command(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return rest.AdaptCommandHandlers(handlers, m.RESTOpts...), nil
+}
+
+var registry = struct { //nolint:gochecknoglobals
+	mu      sync.RWMutex
+	modules []Module
+}{}
+
+// RegisterModule registers module whose handlers are returned by GetCommandHandlers and GetRESTHandlers
+// unless disabled, so that agents built with the package registering it pick it up. Packages typically
+// register their modules in init function.
+func RegisterModule(module Module) error {
+	if module.Name == "" {
+		return errEmptyModuleName
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	for _, registered := range registry.modules {
+		if registered.Name == module.Name {
+			return fmt.Errorf("module %s is already registered", module.Name)
+		}
+	}
+
+	registry.modules = append(registry.modules, module)
+
+	return nil
+}
+
+func registeredModules() []Module {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	return append([]Module{}, registry.modules...)
+}
+
+// enabledModules returns built-in, registered and given modules which are not disabled by options,
+// registering error codes of enabled modules in the error catalog.
+func enabledModules(opts *allOpts, dispatcher *webhook.Dispatcher) ([]Module, error) {
+	var modules []Module
+
+	all := append(append(builtinModules(opts, dispatcher), registeredModules()...), opts.modules...)
+	all = append(all, schemaModule(&modules))
+
+	disabled := map[string]bool{}
+
+	for _, name := range opts.disabledModules {
+		disabled[name] = true
+	}
+
+	names := map[string]bool{}
+
+	for _, module := range all {
+		if module.Name == "" {
+			return nil, errEmptyModuleName
+		}
+
+		if names[module.Name] {
+			return nil, fmt.Errorf("duplicate module %s", module.Name)
+		}
+
+		names[module.Name] = true
+
+		if disabled[module.Name] {
+			continue
+		}
+
+		if module.Errors != nil {
+			if err := agentcmd.RegisterErrors(module.Errors()...); err != nil {
+				return nil, fmt.Errorf("module %s: %w", module.Name, err)
+			}
+		}
+
+		modules = append(modules, module)
+	}
+
+	return modules, nil
+}
+
+func builtinModules(opts *allOpts, dispatcher *webhook.Dispatcher) []Module {
+	mediations := &sharedMediation{registrar: opts.msgHandler}
+	guard := &sharedInvitationGuard{notifier: dispatcher}
+
+	return []Module{
+		didClientModule(opts, mediations),
+		mediatorClientModule(opts, dispatcher, mediations, guard),
+		blindedRoutingModule(opts, dispatcher),
+		storeModule(opts, dispatcher, store.NewSharedState()),
+		webhookModule(dispatcher),
+	}
+}
+
+// sharedMediation creates coordinate mediation 2.0 client shared by built-in modules, since only one of message
+// services of clients registered with the same message handler is given replies of routers.
+type sharedMediation struct {
+	registrar ariescmd.MessageHandler
+	once      sync.Once
+	client    *mediation.Client
+	err       error
+}
+
+func (s *sharedMediation) get(ctx *context.Provider) (*mediation.Client, error) {
+	s.once.Do(func() {
+		s.client, s.err = mediation.New(ctx, s.registrar)
+		if s.err != nil {
+			s.err = fmt.Errorf("failed to create mediation client: %w", s.err)
+		}
+	})
+
+	return s.client, s.err
+}
+
+// sharedInvitationGuard creates guard of mediator client invitations shared by mediator client commands, so that
+// the did exchange service is guarded once.
+type sharedInvitationGuard struct {
+	notifier ariescmd.Notifier
+	once     sync.Once
+	guard    *mediatorclientcmd.InvitationGuard
+	err      error
+}
+
+func (s *sharedInvitationGuard) get(ctx *context.Provider) (*mediatorclientcmd.InvitationGuard, error) {
+	s.once.Do(func() {
+		s.guard, s.err = mediatorclientcmd.NewInvitationGuard(ctx, s.notifier)
+	})
+
+	return s.guard, s.err
+}
+
+// didClientOpts returns options of DID client command, sharing mediation client if replies of routers are received.
+func didClientOpts(ctx *context.Provider, opts *allOpts, mediations *sharedMediation) ([]didclientcmd.Opt, error) {
+	cmdOpts := []didclientcmd.Opt{didclientcmd.WithMessageHandler(opts.msgHandler)}
+
+	if opts.msgHandler == nil {
+		return cmdOpts, nil
+	}
+
+	client, err := mediations.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(cmdOpts, didclientcmd.WithMediationClient(client)), nil
+}
+
+// mediatorClientOpts returns options of mediator client command, sharing invitation guard and mediation client
+// if replies of routers are received.
+func mediatorClientOpts(ctx *context.Provider, opts *allOpts, mediations *sharedMediation,
+	guards *sharedInvitationGuard,
+) ([]mediatorclientcmd.Opt, error) {
+	guard, err := guards.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cmdOpts := []mediatorclientcmd.Opt{
+		mediatorclientcmd.WithInvitationURLHosts(opts.invitationURLHosts...),
+		mediatorclientcmd.WithInvitationGuard(guard),
+	}
+
+	if opts.msgHandler == nil {
+		return cmdOpts, nil
+	}
+
+	client, err := mediations.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(cmdOpts, mediatorclientcmd.WithMediationClient(client)), nil
+}
+
+func didClientModule(opts *allOpts, mediations *sharedMediation) Module {
+	return Module{
+		Name:    DIDClientModule,
+		Schemas: didclientcmd.Schemas,
+		Errors:  didclientcmd.Errors,
+		Command: func(ctx *context.Provider) ([]ariescmd.Handler, error) {
+			cmdOpts, err := didClientOpts(ctx, opts, mediations)
+			if err != nil {
+				return nil, err
+			}
+
+			// did client command operation.
+			cmd, err := didclientcmd.NewWithMediator(opts.blocDomain, opts.didAnchorOrigin, opts.sidetreeToken,
+				opts.unanchoredDIDMaxLifeTime, ctx, cmdOpts...)
+			if err != nil {
+				return nil, fmt.Errorf("failed to initialize DID client: %w", err)
+			}
+
+			return cmd.GetHandlers(), nil
+		},
+		REST: func(ctx *context.Provider) ([]rest.Handler, error) {
+			cmdOpts, err := didClientOpts(ctx, opts, mediations)
+			if err != nil {
+				return nil, err
+			}
+
+			// DID Client REST operation.
+			op, err := didclient.New(ctx, opts.blocDomain, opts.didAnchorOrigin, opts.sidetreeToken,
+				opts.unanchoredDIDMaxLifeTime, cmdOpts...)
+			if err != nil {
+				return nil, err
+			}
+
+			return op.GetRESTHandlers(), nil
+		},
+	}
+}
+
+func mediatorClientModule(opts *allOpts, notifier ariescmd.Notifier, mediations *sharedMediation,
+	guard *sharedInvitationGuard,
+) Module {
+	return Module{
+		Name:    MediatorClientModule,
+		Schemas: mediatorclientcmd.Schemas,
+		Errors:  mediatorclientcmd.Errors,
+		Command: func(ctx *context.Provider) ([]ariescmd.Handler, error) {
+			cmdOpts, err := mediatorClientOpts(ctx, opts, mediations, guard)
+			if err != nil {
+				return nil, err
+			}
+
+			cmd, err := mediatorclientcmd.New(ctx, opts.msgHandler, notifier, cmdOpts...)
+			if err != nil {
+				return nil, err
+			}
+
+			return cmd.GetHandlers(), nil
+		},
+		REST: func(ctx *context.Provider) ([]rest.Handler, error) {
+			cmdOpts, err := mediatorClientOpts(ctx, opts, mediations, guard)
+			if err != nil {
+				return nil, err
+			}
+
+			op, err := mediatorclient.New(ctx, opts.msgHandler, notifier, cmdOpts...)
+			if err != nil {
+				return nil, err
+			}
+
+			return op.GetRESTHandlers(), nil
+		},
+	}
+}
+
+func blindedRoutingModule(opts *allOpts, notifier ariescmd.Notifier) Module {
+	return Module{
+		Name:    BlindedRoutingModule,
+		Schemas: blindedrouting.Schemas,
+		Errors:  blindedrouting.Errors,
+		Command: func(ctx *context.Provider) ([]ariescmd.Handler, error) {
+			cmd, err := blindedrouting.New(ctx, opts.msgHandler, notifier, blindedrouting.WithRouter(opts.blindedRouter))
+			if err != nil {
+				return nil, err
+			}
+
+			return cmd.GetHandlers(), nil
+		},
+		REST: func(ctx *context.Provider) ([]rest.Handler, error) {
+			op, err := blindedroutingrest.New(ctx, opts.msgHandler, notifier,
+				blindedrouting.WithRouter(opts.blindedRouter))
+			if err != nil {
+				return nil, err
+			}
+
+			return op.GetRESTHandlers(), nil
+		},
+	}
+}
+
+// storeOpts returns options of store commands, sharing state of command and REST handlers, archives are encrypted
+// with keys of the agent's key manager.
+func storeOpts(ctx *context.Provider, opts *allOpts, notifier ariescmd.Notifier, state *store.SharedState,
+) []store.Opt {
+	return []store.Opt{
+		store.WithAllowedStores(opts.allowedStores...),
+		store.WithNotifier(notifier),
+		store.WithKeyManager(ctx.KMS(), ctx.Crypto()),
+		store.WithSharedState(state),
+	}
+}
+
+func storeModule(opts *allOpts, notifier ariescmd.Notifier, state *store.SharedState) Module {
+	return Module{
+		Name:    StoreModule,
+		Schemas: store.Schemas,
+		Errors:  store.Errors,
+		Command: func(ctx *context.Provider) ([]ariescmd.Handler, error) {
+			cmd, err := store.New(ctx, storeOpts(ctx, opts, notifier, state)...)
+			if err != nil {
+				return nil, err
+			}
+
+			return cmd.GetHandlers(), nil
+		},
+		REST: func(ctx *context.Provider) ([]rest.Handler, error) {
+			op, err := storerest.New(ctx, storeOpts(ctx, opts, notifier, state)...)
+			if err != nil {
+				return nil, err
+			}
+
+			return op.GetRESTHandlers(), nil
+		},
+	}
+}
+
+func webhookModule(dispatcher *webhook.Dispatcher) Module {
+	return Module{
+		Name:    WebhookModule,
+		Schemas: webhook.Schemas,
+		Errors:  webhook.Errors,
+		Command: func(ctx *context.Provider) ([]ariescmd.Handler, error) {
+			cmd, err := webhook.New(ctx, dispatcher)
+			if err != nil {
+				return nil, err
+			}
+
+			return cmd.GetHandlers(), nil
+		},
+	}
+}
+
+// schemaModule exports JSON schemas of given modules, which are read when its command is created, and the error
+// catalog.
+func schemaModule(modules *[]Module) Module {
+	return Module{
+		Name:   SchemaModule,
+		Errors: schema.Errors,
+		Command: func(*context.Provider) ([]ariescmd.Handler, error) {
+			var schemas []agentcmd.MethodSchema
+
+			for _, module := range *modules {
+				if module.Schemas != nil {
+					schemas = append(schemas, module.Schemas()...)
+				}
+			}
+
+			return schema.New(schemas).GetHandlers(), nil
+		},
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package rest
+
+import (
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+)
+
+type adapterOpts struct {
+	paths map[string]string
+}
+
+// AdapterOpt represents an option of command handler adapter.
+type AdapterOpt func(opts *adapterOpts)
+
+// WithPath overrides conventional path of the command method with given name.
+func WithPath(name, method, path string) AdapterOpt {
+	return func(opts *adapterOpts) {
+		opts.paths[name+"/"+method] = path
+	}
+}
+
+// CommandPath returns conventional path of the command method with given name, which is the command name
+// followed by the method name in kebab case, e.g. '/store/list-stores' for method ListStores of command store.
+func CommandPath(name, method string) string {
+	return "/" + name + "/" + kebabCase(method)
+}
+
+// AdaptCommandHandlers returns handlers serving given command handlers as POST requests on conventional
+// or overridden paths. Request body is passed to the command as is, validation errors result in status 400.
+func AdaptCommandHandlers(handlers []command.Handler, opts ...AdapterOpt) []Handler {
+	adapterOpts := &adapterOpts{paths: map[string]string{}}
+
+	for _, opt := range opts {
+		opt(adapterOpts)
+	}
+
+	restHandlers := make([]Handler, len(handlers))
+
+	for i, handler := range handlers {
+		path, ok := adapterOpts.paths[handler.Name()+"/"+handler.Method()]
+		if !ok {
+			path = CommandPath(handler.Name(), handler.Method())
+		}
+
+		restHandlers[i] = &commandHandler{path: path, exec: handler.Handle()}
+	}
+
+	return restHandlers
+}
+
+// commandHandler serves command handler as REST handler.
+type commandHandler struct {
+	path string
+	exec command.Exec
+}
+
+func (h *commandHandler) Path() string {
+	return h.path
+}
+
+func (h *commandHandler) Method() string {
+	return http.MethodPost
+}
+
+func (h *commandHandler) Handle() http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		Execute(h.exec, rw, req.Body)
+	}
+}
+
+// kebabCase converts name in camel case to kebab case, acronyms are kept together,
+// e.g. 'CreateOrbDID' to 'create-orb-did'.
+func kebabCase(name string) string {
+	runes := []rune(name)
+
+	var sb strings.Builder
+
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) &&
+			(unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			sb.WriteByte('-')
+		}
+
+		sb.WriteRune(unicode.ToLower(r))
+	}
+
+	return sb.String()
+}
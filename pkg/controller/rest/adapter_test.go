@@ -0,0 +1,74 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package rest //nolint:testpackage // uses internal implementation details
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/agent-sdk/pkg/controller/internal/cmdutil"
+)
+
+func TestCommandPath(t *testing.T) {
+	for method, path := range map[string]string{
+		"Put":                     "/store/put",
+		"ListStores":              "/store/list-stores",
+		"CreateOrbDID":            "/store/create-orb-did",
+		"SendDIDDocRequest":       "/store/send-did-doc-request",
+		"ResolveWebDIDFromOrbDID": "/store/resolve-web-did-from-orb-did",
+	} {
+		require.Equal(t, path, CommandPath("store", method))
+	}
+}
+
+func TestAdaptCommandHandlers(t *testing.T) {
+	echo := func(rw io.Writer, req io.Reader) command.Error {
+		body, err := io.ReadAll(req)
+		if err != nil {
+			return command.NewExecuteError(sampleErr1, err)
+		}
+
+		if len(body) == 0 {
+			return command.NewValidationError(sampleErr2, errors.New("empty request"))
+		}
+
+		_, err = rw.Write(body)
+		if err != nil {
+			return command.NewExecuteError(sampleErr1, err)
+		}
+
+		return nil
+	}
+
+	handlers := AdaptCommandHandlers([]command.Handler{
+		cmdutil.NewCommandHandler("sample", "EchoRequest", echo),
+		cmdutil.NewCommandHandler("sample", "Other", echo),
+	}, WithPath("sample", "Other", "/sample/custom"))
+
+	require.Len(t, handlers, 2)
+	require.Equal(t, "/sample/echo-request", handlers[0].Path())
+	require.Equal(t, http.MethodPost, handlers[0].Method())
+	require.Equal(t, "/sample/custom", handlers[1].Path())
+
+	rr := httptest.NewRecorder()
+	handlers[0].Handle()(rr, httptest.NewRequest(http.MethodPost, handlers[0].Path(), bytes.NewBufferString(`{}`)))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, `{}`, rr.Body.String())
+	require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	rr = httptest.NewRecorder()
+	handlers[1].Handle()(rr, httptest.NewRequest(http.MethodPost, handlers[1].Path(), http.NoBody))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	require.Contains(t, rr.Body.String(), "empty request")
+}
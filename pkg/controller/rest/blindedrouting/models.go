@@ -57,3 +57,84 @@ type registerRouteResponse struct {
 	// in: body
 	Response blindedrouting.RegisterRouteResponse
 }
+
+// listRoutesRequest model
+//
+// Request for listing persisted blinded routing exchanges.
+//
+// swagger:parameters listRoutes
+type listRoutesRequest struct { //nolint: unused,deadcode
+	// Params for listing routes.
+	//
+	// in: body
+	Request blindedrouting.ListRoutesRequest
+}
+
+// listRoutesResponse model
+//
+// Response of list routes operation.
+//
+// swagger:response listRoutesResponse
+type listRoutesResponse struct {
+	// in: body
+	Response blindedrouting.ListRoutesResponse
+}
+
+// getRouteRequest model
+//
+// Request for getting persisted blinded routing exchange.
+//
+// swagger:parameters getRoute
+type getRouteRequest struct { //nolint: unused,deadcode
+	// Params for getting route.
+	//
+	// in: body
+	// required: true
+	Request blindedrouting.GetRouteRequest
+}
+
+// getRouteResponse model
+//
+// Response of get route operation.
+//
+// swagger:response getRouteResponse
+type getRouteResponse struct {
+	// in: body
+	Response blindedrouting.GetRouteResponse
+}
+
+// removeRouteRequest model
+//
+// Request for removing persisted blinded routing exchange.
+//
+// swagger:parameters removeRoute
+type removeRouteRequest struct { //nolint: unused,deadcode
+	// Params for removing route.
+	//
+	// in: body
+	// required: true
+	Request blindedrouting.RemoveRouteRequest
+}
+
+// establishRouteRequest model
+//
+// Request for establishing blinded route with a router.
+//
+// swagger:parameters establishRoute
+type establishRouteRequest struct { //nolint: unused,deadcode
+	// Params for establishing blinded route.
+	//
+	// in: body
+	// required: true
+	Request blindedrouting.EstablishBlindedRouteRequest
+}
+
+// establishRouteResponse model
+//
+// Response of establish blinded route operation.
+//
+// swagger:response establishRouteResponse
+type establishRouteResponse struct {
+	// in: body
+	Response blindedrouting.EstablishBlindedRouteResponse
+}
@@ -24,6 +24,10 @@ const (
 	OperationID              = "/blindedrouting"
 	SendDIDDocRequestPath    = OperationID + "/send-diddoc-request"
 	SendRegisterRouteRequest = OperationID + "/send-router-registration"
+	ListRoutesPath           = OperationID + "/list-routes"
+	GetRoutePath             = OperationID + "/get-route"
+	RemoveRoutePath          = OperationID + "/remove-route"
+	EstablishRoutePath       = OperationID + "/establish-route"
 )
 
 // Operation is controller REST service controller for blinded routing.
@@ -34,9 +38,9 @@ type Operation struct {
 
 // New returns new blinded routing rest instance.
 func New(ctx blindedrouting.Provider, msgHandler ariescmd.MessageHandler,
-	notifier ariescmd.Notifier,
+	notifier ariescmd.Notifier, opts ...blindedrouting.Opt,
 ) (*Operation, error) {
-	client, err := blindedrouting.New(ctx, msgHandler, notifier)
+	client, err := blindedrouting.New(ctx, msgHandler, notifier, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize blinded routing command: %w", err)
 	}
@@ -58,6 +62,10 @@ func (c *Operation) registerHandler() {
 	c.handlers = []rest.Handler{
 		cmdutil.NewHTTPHandler(SendDIDDocRequestPath, http.MethodPost, c.SendDIDDocRequest),
 		cmdutil.NewHTTPHandler(SendRegisterRouteRequest, http.MethodPost, c.SendRegisterRouteRequest),
+		cmdutil.NewHTTPHandler(ListRoutesPath, http.MethodPost, c.ListRoutes),
+		cmdutil.NewHTTPHandler(GetRoutePath, http.MethodPost, c.GetRoute),
+		cmdutil.NewHTTPHandler(RemoveRoutePath, http.MethodPost, c.RemoveRoute),
+		cmdutil.NewHTTPHandler(EstablishRoutePath, http.MethodPost, c.EstablishBlindedRoute),
 	}
 }
 
@@ -70,7 +78,8 @@ func (c *Operation) registerHandler() {
 //	default: genericError
 //	200: didDocResponse
 func (c *Operation) SendDIDDocRequest(rw http.ResponseWriter, req *http.Request) {
-	rest.Execute(c.command.SendDIDDocRequest, rw, req.Body)
+	rest.ExecuteCommand(blindedrouting.CommandName, blindedrouting.SendDIDDocRequest,
+		c.command.SendDIDDocRequest, rw, req.Body)
 }
 
 // SendRegisterRouteRequest Sends register route request as a response to reply from send DID doc request.
@@ -84,5 +93,55 @@ func (c *Operation) SendDIDDocRequest(rw http.ResponseWriter, req *http.Request)
 //	default: genericError
 //	200: registerRouteResponse
 func (c *Operation) SendRegisterRouteRequest(rw http.ResponseWriter, req *http.Request) {
-	rest.Execute(c.command.SendRegisterRouteRequest, rw, req.Body)
+	rest.ExecuteCommand(blindedrouting.CommandName, blindedrouting.SendRegisterRouteRequest,
+		c.command.SendRegisterRouteRequest, rw, req.Body)
+}
+
+// ListRoutes swagger:route POST /blindedrouting/list-routes blindedrouting listRoutes
+//
+// Lists persisted blinded routing exchanges with routers.
+//
+// Responses:
+//
+//	default: genericError
+//	200: listRoutesResponse
+func (c *Operation) ListRoutes(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(blindedrouting.CommandName, blindedrouting.ListRoutes, c.command.ListRoutes, rw, req.Body)
+}
+
+// GetRoute swagger:route POST /blindedrouting/get-route blindedrouting getRoute
+//
+// Returns persisted blinded routing exchange with a router.
+//
+// Responses:
+//
+//	default: genericError
+//	200: getRouteResponse
+func (c *Operation) GetRoute(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(blindedrouting.CommandName, blindedrouting.GetRoute, c.command.GetRoute, rw, req.Body)
+}
+
+// RemoveRoute swagger:route POST /blindedrouting/remove-route blindedrouting removeRoute
+//
+// Removes persisted blinded routing exchange with a router, route registered with router is not affected.
+//
+// Responses:
+//
+//	default: genericError
+func (c *Operation) RemoveRoute(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(blindedrouting.CommandName, blindedrouting.RemoveRoute, c.command.RemoveRoute, rw, req.Body)
+}
+
+// EstablishBlindedRoute swagger:route POST /blindedrouting/establish-route blindedrouting establishRoute
+//
+// Establishes blinded route over a connection to router, or resumes previously started blinded routing exchange.
+// Failed exchange is restarted from a fresh DID doc request.
+//
+// Responses:
+//
+//	default: genericError
+//	200: establishRouteResponse
+func (c *Operation) EstablishBlindedRoute(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(blindedrouting.CommandName, blindedrouting.EstablishBlindedRoute,
+		c.command.EstablishBlindedRoute, rw, req.Body)
 }
@@ -32,7 +32,7 @@ func TestNew(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, c)
 		require.NotEmpty(t, c.GetRESTHandlers())
-		require.Len(t, c.GetRESTHandlers(), 2)
+		require.Len(t, c.GetRESTHandlers(), 6)
 	})
 
 	t.Run("test failure while creating mediator client", func(t *testing.T) {
@@ -35,9 +35,9 @@ type Operation struct {
 
 // New returns new DID client rest instance.
 func New(ctx didclient.ProviderWithMediator, domain, didAnchorOrigin, token string,
-	unanchoredDIDMaxLifeTime int,
+	unanchoredDIDMaxLifeTime int, opts ...didclient.Opt,
 ) (*Operation, error) {
-	client, err := didclient.NewWithMediator(domain, didAnchorOrigin, token, unanchoredDIDMaxLifeTime, ctx)
+	client, err := didclient.NewWithMediator(domain, didAnchorOrigin, token, unanchoredDIDMaxLifeTime, ctx, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize did-client command: %w", err)
 	}
@@ -74,7 +74,8 @@ func (c *Operation) registerHandler() {
 //	default: genericError
 //	200: createDIDResp
 func (c *Operation) CreateOrbDID(rw http.ResponseWriter, req *http.Request) {
-	rest.Execute(c.command.CreateOrbDID, rw, req.Body)
+	rest.ExecuteCommand(didclient.CommandName, didclient.CreateOrbDIDCommandMethod,
+		c.command.CreateOrbDID, rw, req.Body)
 }
 
 // ResolveOrbDID swagger:route POST /didclient/resolve-orb-did didclient resolveOrbDID
@@ -86,7 +87,8 @@ func (c *Operation) CreateOrbDID(rw http.ResponseWriter, req *http.Request) {
 //	default: genericError
 //	200: resolveDIDResp
 func (c *Operation) ResolveOrbDID(rw http.ResponseWriter, req *http.Request) {
-	rest.Execute(c.command.ResolveOrbDID, rw, req.Body)
+	rest.ExecuteCommand(didclient.CommandName, didclient.ResolveOrbDIDCommandMethod,
+		c.command.ResolveOrbDID, rw, req.Body)
 }
 
 // ResolveWebDIDFromOrbDID swagger:route POST /didclient/resolve-web-did-from-orb-did didclient resolveWebDIDFromOrbDID
@@ -98,7 +100,8 @@ func (c *Operation) ResolveOrbDID(rw http.ResponseWriter, req *http.Request) {
 //	default: genericError
 //	200: resolveDIDResp
 func (c *Operation) ResolveWebDIDFromOrbDID(rw http.ResponseWriter, req *http.Request) {
-	rest.Execute(c.command.ResolveWebDIDFromOrbDID, rw, req.Body)
+	rest.ExecuteCommand(didclient.CommandName, didclient.ResolveWebDIDFromOrbDIDCommandMethod,
+		c.command.ResolveWebDIDFromOrbDID, rw, req.Body)
 }
 
 // VerifyWebDIDFromOrbDID swagger:route POST /didclient/verify-web-did-from-orb-did didclient verifyWebDIDFromOrbDID
@@ -109,7 +112,8 @@ func (c *Operation) ResolveWebDIDFromOrbDID(rw http.ResponseWriter, req *http.Re
 //
 //	default: genericError
 func (c *Operation) VerifyWebDIDFromOrbDID(rw http.ResponseWriter, req *http.Request) {
-	rest.Execute(c.command.VerifyWebDIDFromOrbDID, rw, req.Body)
+	rest.ExecuteCommand(didclient.CommandName, didclient.VerifyWebDIDFromOrbDIDCommandMethod,
+		c.command.VerifyWebDIDFromOrbDID, rw, req.Body)
 }
 
 // CreatePeerDID swagger:route POST /didclient/create-peer-did didclient createPeerDID
@@ -121,5 +125,6 @@ func (c *Operation) VerifyWebDIDFromOrbDID(rw http.ResponseWriter, req *http.Req
 //	default: genericError
 //	200: createDIDResp
 func (c *Operation) CreatePeerDID(rw http.ResponseWriter, req *http.Request) {
-	rest.Execute(c.command.CreatePeerDID, rw, req.Body)
+	rest.ExecuteCommand(didclient.CommandName, didclient.CreatePeerDIDCommandMethod,
+		c.command.CreatePeerDID, rw, req.Body)
 }
@@ -79,3 +79,140 @@ type createConnectionResponse struct { //nolint: unused,deadcode
 	// in: body
 	Response mediatorclient.CreateConnectionResponse
 }
+
+// operationRequest model
+//
+// Request for getting status of or cancelling an asynchronous operation.
+//
+// swagger:parameters getOperation cancelOperation
+type operationRequest struct { //nolint: unused,deadcode
+	// Params for identifying operation.
+	//
+	// in: body
+	// required: true
+	Request mediatorclient.OperationRequest
+}
+
+// operationResponse model
+//
+//	Response containing current state of an asynchronous operation.
+//
+// swagger:response operationResponse
+type operationResponse struct { //nolint: unused,deadcode
+	// in: body
+	Response mediatorclient.OperationResponse
+}
+
+// keylistQueryRequest model
+//
+// Request for querying recipient keys registered with the router.
+//
+// swagger:parameters queryKeylist
+type keylistQueryRequest struct { //nolint: unused,deadcode
+	// Params for keylist query.
+	//
+	// in: body
+	// required: true
+	Request mediatorclient.KeylistQueryRequest
+}
+
+// keylistQueryResponse model
+//
+//	Response of keylist query.
+//
+// swagger:response keylistQueryResponse
+type keylistQueryResponse struct { //nolint: unused,deadcode
+	// in: body
+	Response mediatorclient.KeylistQueryResponse
+}
+
+// removeKeysRequest model
+//
+// Request for removing recipient keys registered with the router.
+//
+// swagger:parameters removeKeys
+type removeKeysRequest struct { //nolint: unused,deadcode
+	// Params for removing keys.
+	//
+	// in: body
+	// required: true
+	Request mediatorclient.RemoveKeysRequest
+}
+
+// removeKeysResponse model
+//
+//	Response of removing recipient keys registered with the router.
+//
+// swagger:response removeKeysResponse
+type removeKeysResponse struct { //nolint: unused,deadcode
+	// in: body
+	Response mediatorclient.RemoveKeysResponse
+}
+
+// decodeInvitationRequest model
+//
+// Request for decoding out-of-band invitation from invitation URL.
+//
+// swagger:parameters decodeInvitation
+type decodeInvitationRequest struct { //nolint: unused,deadcode
+	// Params for decoding invitation.
+	//
+	// in: body
+	// required: true
+	Request mediatorclient.DecodeInvitationRequest
+}
+
+// decodeInvitationResponse model
+//
+//	Response of decoding out-of-band invitation from invitation URL.
+//
+// swagger:response decodeInvitationResponse
+type decodeInvitationResponse struct { //nolint: unused,deadcode
+	// in: body
+	Response mediatorclient.DecodeInvitationResponse
+}
+
+// listInvitationsRequest model
+//
+// Request for listing invitations created through mediator client.
+//
+// swagger:parameters listInvitations
+type listInvitationsRequest struct { //nolint: unused,deadcode
+	// Params for listing invitations.
+	//
+	// in: body
+	Request mediatorclient.ListInvitationsRequest
+}
+
+// listInvitationsResponse model
+//
+//	Response of listing invitations created through mediator client.
+//
+// swagger:response listInvitationsResponse
+type listInvitationsResponse struct { //nolint: unused,deadcode
+	// in: body
+	Response mediatorclient.ListInvitationsResponse
+}
+
+// revokeInvitationRequest model
+//
+// Request for revoking invitation created through mediator client.
+//
+// swagger:parameters revokeInvitation
+type revokeInvitationRequest struct { //nolint: unused,deadcode
+	// Params for revoking invitation.
+	//
+	// in: body
+	// required: true
+	Request mediatorclient.RevokeInvitationRequest
+}
+
+// revokeInvitationResponse model
+//
+//	Response of revoking invitation created through mediator client.
+//
+// swagger:response revokeInvitationResponse
+type revokeInvitationResponse struct { //nolint: unused,deadcode
+	// in: body
+	Response mediatorclient.RevokeInvitationResponse
+}
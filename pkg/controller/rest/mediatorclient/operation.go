@@ -25,6 +25,13 @@ const (
 	ConnectPath                 = OperationID + "/connect"
 	CreateInvitationPath        = OperationID + "/create-invitation"
 	SendCreateConnectionRequest = OperationID + "/send-connection-request"
+	GetOperationPath            = OperationID + "/get-operation"
+	CancelOperationPath         = OperationID + "/cancel-operation"
+	QueryKeylistPath            = OperationID + "/keylist-query"
+	RemoveKeysPath              = OperationID + "/remove-keys"
+	DecodeInvitationPath        = OperationID + "/decode-invitation"
+	ListInvitationsPath         = OperationID + "/list-invitations"
+	RevokeInvitationPath        = OperationID + "/revoke-invitation"
 )
 
 // Operation is controller REST service controller for mediator Client.
@@ -35,9 +42,9 @@ type Operation struct {
 
 // New returns new mediator client rest instance.
 func New(ctx mediatorclient.Provider, msgHandler ariescmd.MessageHandler,
-	notifier ariescmd.Notifier,
+	notifier ariescmd.Notifier, opts ...mediatorclient.Opt,
 ) (*Operation, error) {
-	client, err := mediatorclient.New(ctx, msgHandler, notifier)
+	client, err := mediatorclient.New(ctx, msgHandler, notifier, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize mediator-client command: %w", err)
 	}
@@ -60,6 +67,13 @@ func (c *Operation) registerHandler() {
 		cmdutil.NewHTTPHandler(ConnectPath, http.MethodPost, c.Connect),
 		cmdutil.NewHTTPHandler(CreateInvitationPath, http.MethodPost, c.CreateInvitation),
 		cmdutil.NewHTTPHandler(SendCreateConnectionRequest, http.MethodPost, c.SendCreateConnectionRequest),
+		cmdutil.NewHTTPHandler(GetOperationPath, http.MethodPost, c.GetOperation),
+		cmdutil.NewHTTPHandler(CancelOperationPath, http.MethodPost, c.CancelOperation),
+		cmdutil.NewHTTPHandler(QueryKeylistPath, http.MethodPost, c.QueryKeylist),
+		cmdutil.NewHTTPHandler(RemoveKeysPath, http.MethodPost, c.RemoveKeys),
+		cmdutil.NewHTTPHandler(DecodeInvitationPath, http.MethodPost, c.DecodeInvitation),
+		cmdutil.NewHTTPHandler(ListInvitationsPath, http.MethodPost, c.ListInvitations),
+		cmdutil.NewHTTPHandler(RevokeInvitationPath, http.MethodPost, c.RevokeInvitation),
 	}
 }
 
@@ -72,7 +86,7 @@ func (c *Operation) registerHandler() {
 //	default: genericError
 //	200: connectionResponse
 func (c *Operation) Connect(rw http.ResponseWriter, req *http.Request) {
-	rest.Execute(c.command.Connect, rw, req.Body)
+	rest.ExecuteCommand(mediatorclient.CommandName, mediatorclient.Connect, c.command.Connect, rw, req.Body)
 }
 
 // CreateInvitation swagger:route POST /mediatorclient/create-invitation mediatorclient createMediatorInvitation
@@ -84,7 +98,8 @@ func (c *Operation) Connect(rw http.ResponseWriter, req *http.Request) {
 //	default: genericError
 //	200: createInvitationResponse
 func (c *Operation) CreateInvitation(rw http.ResponseWriter, req *http.Request) {
-	rest.Execute(c.command.CreateInvitation, rw, req.Body)
+	rest.ExecuteCommand(mediatorclient.CommandName, mediatorclient.CreateInvitation,
+		c.command.CreateInvitation, rw, req.Body)
 }
 
 // SendCreateConnectionRequest Sends create connection request to mediator.
@@ -98,5 +113,95 @@ func (c *Operation) CreateInvitation(rw http.ResponseWriter, req *http.Request)
 //	default: genericError
 //	200: createConnectionResponse
 func (c *Operation) SendCreateConnectionRequest(rw http.ResponseWriter, req *http.Request) {
-	rest.Execute(c.command.SendCreateConnectionRequest, rw, req.Body)
+	rest.ExecuteCommand(mediatorclient.CommandName, mediatorclient.SendCreateConnectionRequest,
+		c.command.SendCreateConnectionRequest, rw, req.Body)
+}
+
+// GetOperation swagger:route POST /mediatorclient/get-operation mediatorclient getOperation
+//
+// Gets status and result of an asynchronous mediator client operation.
+//
+// Responses:
+//
+//	default: genericError
+//	200: operationResponse
+func (c *Operation) GetOperation(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(mediatorclient.CommandName, mediatorclient.GetOperation, c.command.GetOperation, rw, req.Body)
+}
+
+// CancelOperation swagger:route POST /mediatorclient/cancel-operation mediatorclient cancelOperation
+//
+// Cancels an asynchronous mediator client operation.
+//
+// Responses:
+//
+//	default: genericError
+//	200: operationResponse
+func (c *Operation) CancelOperation(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(mediatorclient.CommandName, mediatorclient.CancelOperation,
+		c.command.CancelOperation, rw, req.Body)
+}
+
+// QueryKeylist swagger:route POST /mediatorclient/keylist-query mediatorclient queryKeylist
+//
+// Queries recipient keys registered with the router over given connection or all router connections.
+//
+// Responses:
+//
+//	default: genericError
+//	200: keylistQueryResponse
+func (c *Operation) QueryKeylist(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(mediatorclient.CommandName, mediatorclient.QueryKeylist, c.command.QueryKeylist, rw, req.Body)
+}
+
+// RemoveKeys swagger:route POST /mediatorclient/remove-keys mediatorclient removeKeys
+//
+// Removes recipient keys or all keys of a DID registered with the router.
+//
+// Responses:
+//
+//	default: genericError
+//	200: removeKeysResponse
+func (c *Operation) RemoveKeys(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(mediatorclient.CommandName, mediatorclient.RemoveKeys, c.command.RemoveKeys, rw, req.Body)
+}
+
+// DecodeInvitation swagger:route POST /mediatorclient/decode-invitation mediatorclient decodeInvitation
+//
+// Decodes out-of-band invitation from invitation URL.
+//
+// Responses:
+//
+//	default: genericError
+//	200: decodeInvitationResponse
+func (c *Operation) DecodeInvitation(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(mediatorclient.CommandName, mediatorclient.DecodeInvitation,
+		c.command.DecodeInvitation, rw, req.Body)
+}
+
+// ListInvitations swagger:route POST /mediatorclient/list-invitations mediatorclient listInvitations
+//
+// Lists invitations created through mediator client along with their status.
+//
+// Responses:
+//
+//	default: genericError
+//	200: listInvitationsResponse
+func (c *Operation) ListInvitations(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(mediatorclient.CommandName, mediatorclient.ListInvitations,
+		c.command.ListInvitations, rw, req.Body)
+}
+
+// RevokeInvitation swagger:route POST /mediatorclient/revoke-invitation mediatorclient revokeInvitation
+//
+// Revokes invitation created through mediator client, connection requests against revoked invitation are stopped.
+// DIDComm V2 invitations can't be revoked.
+//
+// Responses:
+//
+//	default: genericError
+//	200: revokeInvitationResponse
+func (c *Operation) RevokeInvitation(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(mediatorclient.CommandName, mediatorclient.RevokeInvitation,
+		c.command.RevokeInvitation, rw, req.Body)
 }
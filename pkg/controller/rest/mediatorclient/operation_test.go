@@ -90,7 +90,7 @@ func TestNew(t *testing.T) {
 		require.NoError(t, err)
 		require.NotNil(t, c)
 		require.NotEmpty(t, c.GetRESTHandlers())
-		require.Len(t, c.GetRESTHandlers(), 3)
+		require.Len(t, c.GetRESTHandlers(), 10)
 	})
 
 	t.Run("test failure while creating mediator client", func(t *testing.T) {
@@ -269,11 +269,11 @@ func TestOperation_SendCreateConnectionRequest(t *testing.T) {
 
 		go func() {
 			for {
-				if len(registrar.Services()) > 0 && mockmsgr.GetLastID() != "" { //nolint: gocritic
+				if len(registrar.Services()) > 1 && mockmsgr.GetLastID() != "" { //nolint: gocritic
 					replyMsg, e := service.ParseDIDCommMsgMap([]byte(fmt.Sprintf(replyMsgStr, mockmsgr.GetLastID())))
 					require.NoError(t, e)
 
-					_, e = registrar.Services()[0].HandleInbound(replyMsg, &sdkmockprotocol.MockDIDCommContext{
+					_, e = registrar.Services()[1].HandleInbound(replyMsg, &sdkmockprotocol.MockDIDCommContext{
 						MyDIDValue:    "sampleDID",
 						TheirDIDValue: "sampleTheirDID",
 					})
@@ -320,6 +320,140 @@ func TestOperation_SendCreateConnectionRequest(t *testing.T) {
 	})
 }
 
+func TestOperation_GetOperation(t *testing.T) {
+	t.Run("test success", func(t *testing.T) {
+		cmd, err := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+
+		operationID := startAsyncConnect(t, cmd)
+
+		handler := testutil.LookupHandler(t, cmd, GetOperationPath)
+
+		buf, err := testutil.GetSuccessResponseFromHandler(handler,
+			bytes.NewBufferString(fmt.Sprintf(`{"operationID":%q}`, operationID)), handler.Path())
+		require.NoError(t, err)
+
+		resp := &operationResponse{}
+		err = json.NewDecoder(buf).Decode(&resp.Response)
+		require.NoError(t, err)
+
+		require.Equal(t, operationID, resp.Response.Operation.ID)
+		require.Equal(t, mediatorclient.Connect, resp.Response.Operation.Method)
+		require.Contains(t, []string{mediatorclient.OperationStatusPending, mediatorclient.OperationStatusRunning},
+			resp.Response.Operation.Status)
+	})
+
+	t.Run("test failure due to invalid request", func(t *testing.T) {
+		cmd, err := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+
+		handler := testutil.LookupHandler(t, cmd, GetOperationPath)
+
+		buf, code, err := testutil.SendRequestToHandler(handler, bytes.NewBufferString(`{}`), handler.Path())
+		require.NoError(t, err)
+		require.NotEmpty(t, buf)
+
+		require.Equal(t, http.StatusBadRequest, code)
+		testutil.VerifyError(t, mediatorclient.InvalidRequestErrorCode, "invalid operation ID", buf.Bytes())
+	})
+
+	t.Run("test failure due to unknown operation", func(t *testing.T) {
+		cmd, err := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+
+		handler := testutil.LookupHandler(t, cmd, GetOperationPath)
+
+		buf, code, err := testutil.SendRequestToHandler(handler, bytes.NewBufferString(`{"operationID":"unknown"}`),
+			handler.Path())
+		require.NoError(t, err)
+		require.NotEmpty(t, buf)
+
+		require.Equal(t, http.StatusInternalServerError, code)
+		testutil.VerifyError(t, mediatorclient.GetOperationError, "operation not found: unknown", buf.Bytes())
+	})
+}
+
+func TestOperation_CancelOperation(t *testing.T) {
+	t.Run("test success", func(t *testing.T) {
+		cmd, err := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+
+		operationID := startAsyncConnect(t, cmd)
+		request := fmt.Sprintf(`{"operationID":%q}`, operationID)
+
+		handler := testutil.LookupHandler(t, cmd, CancelOperationPath)
+
+		buf, err := testutil.GetSuccessResponseFromHandler(handler, bytes.NewBufferString(request), handler.Path())
+		require.NoError(t, err)
+
+		resp := &operationResponse{}
+		err = json.NewDecoder(buf).Decode(&resp.Response)
+		require.NoError(t, err)
+
+		require.Equal(t, operationID, resp.Response.Operation.ID)
+		require.Equal(t, mediatorclient.OperationStatusCancelled, resp.Response.Operation.Status)
+
+		handler = testutil.LookupHandler(t, cmd, GetOperationPath)
+
+		buf, err = testutil.GetSuccessResponseFromHandler(handler, bytes.NewBufferString(request), handler.Path())
+		require.NoError(t, err)
+
+		resp = &operationResponse{}
+		err = json.NewDecoder(buf).Decode(&resp.Response)
+		require.NoError(t, err)
+
+		require.Equal(t, mediatorclient.OperationStatusCancelled, resp.Response.Operation.Status)
+	})
+
+	t.Run("test failure due to unknown operation", func(t *testing.T) {
+		cmd, err := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+
+		handler := testutil.LookupHandler(t, cmd, CancelOperationPath)
+
+		buf, code, err := testutil.SendRequestToHandler(handler, bytes.NewBufferString(`{"operationID":"unknown"}`),
+			handler.Path())
+		require.NoError(t, err)
+		require.NotEmpty(t, buf)
+
+		require.Equal(t, http.StatusInternalServerError, code)
+		testutil.VerifyError(t, mediatorclient.CancelOperationError, "operation not found: unknown", buf.Bytes())
+	})
+}
+
+// startAsyncConnect starts asynchronous connect which keeps waiting for router to respond and returns
+// ID of its operation.
+func startAsyncConnect(t *testing.T, cmd *Operation) string {
+	t.Helper()
+
+	const request = `{
+		"invitation": {
+			"@id": "3ae3d2cb-83bf-429f-93ea-0802f92ecf42",
+			"@type": "https://didcomm.org/out-of-band/1.0/invitation",
+			"label": "hub-router",
+			"protocols": ["https://didcomm.org/didexchange/1.0"]
+		},
+		"async": true
+	}`
+
+	handler := testutil.LookupHandler(t, cmd, ConnectPath)
+
+	buf, err := testutil.GetSuccessResponseFromHandler(handler, bytes.NewBufferString(request), handler.Path())
+	require.NoError(t, err)
+
+	resp := &connectionResponse{}
+	err = json.NewDecoder(buf).Decode(&resp.Response)
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Response.OperationID)
+
+	return resp.Response.OperationID
+}
+
 func newMockProvider(serviceMap map[string]interface{}) *sdkmockprotocol.MockProvider {
 	if serviceMap == nil {
 		serviceMap = map[string]interface{}{
@@ -339,3 +473,35 @@ func newMockProvider(serviceMap map[string]interface{}) *sdkmockprotocol.MockPro
 
 	return prov
 }
+
+func TestOperation_RemoveKeys(t *testing.T) {
+	t.Run("test failure due to invalid request", func(t *testing.T) {
+		cmd, err := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+
+		handler := testutil.LookupHandler(t, cmd, RemoveKeysPath)
+
+		buf, code, err := testutil.SendRequestToHandler(handler, bytes.NewBufferString(`{}`), handler.Path())
+		require.NoError(t, err)
+		require.NotEmpty(t, buf)
+
+		require.Equal(t, http.StatusBadRequest, code)
+		testutil.VerifyError(t, mediatorclient.InvalidRequestErrorCode, "either keys or DID is required", buf.Bytes())
+	})
+
+	t.Run("test failure due to missing router connections", func(t *testing.T) {
+		cmd, err := New(newMockProvider(nil), mockmsghandler.NewMockMsgServiceProvider(), mocks.NewMockNotifier())
+		require.NoError(t, err)
+		require.NotNil(t, cmd)
+
+		handler := testutil.LookupHandler(t, cmd, QueryKeylistPath)
+
+		buf, code, err := testutil.SendRequestToHandler(handler, bytes.NewBufferString(`{}`), handler.Path())
+		require.NoError(t, err)
+		require.NotEmpty(t, buf)
+
+		require.Equal(t, http.StatusInternalServerError, code)
+		testutil.VerifyError(t, mediatorclient.QueryKeylistError, "no connection found", buf.Bytes())
+	})
+}
@@ -14,6 +14,8 @@ import (
 
 	"github.com/hyperledger/aries-framework-go/pkg/common/log"
 	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+
+	agentcmd "github.com/trustbloc/agent-sdk/pkg/controller/command"
 )
 
 var logger = log.New("agent-sdk/rest")
@@ -26,22 +28,41 @@ type Handler interface {
 }
 
 // Execute executes given command with args provided and writes error to
-// response writer.
+// response writer. Errors of commands which already started writing their response (e.g. query failing after
+// some records were streamed) are reported in the response body by the command itself.
 func Execute(exec command.Exec, rw http.ResponseWriter, req io.Reader) {
 	rw.Header().Set("Content-Type", "application/json")
 
-	err := exec(rw, req)
-	if err != nil {
+	writer := &responseWriter{ResponseWriter: rw}
+
+	err := exec(writer, req)
+	if err != nil && !writer.written {
 		SendError(rw, err)
 	}
 }
 
-type genericErrorBody struct {
-	Code    command.Code `json:"code"`
-	Message string       `json:"message"`
+// ExecuteCommand executes given method of named command through the middleware chain, see Execute.
+func ExecuteCommand(name, method string, exec command.Exec, rw http.ResponseWriter, req io.Reader) {
+	Execute(agentcmd.Intercept(name, method, exec), rw, req)
+}
+
+// responseWriter tracks whether the response was started.
+type responseWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	w.written = true
+
+	return w.ResponseWriter.Write(p)
 }
 
-// SendError sends command error as http response in generic error format.
+// genericErrorBody is the machine-readable form of command errors, named by the error catalog.
+type genericErrorBody = agentcmd.ErrorInfo
+
+// SendError sends command error as http response in generic error format, conflicts named by the error catalog
+// are reported with status 409.
 func SendError(rw http.ResponseWriter, err command.Error) {
 	var status int
 
@@ -50,6 +71,10 @@ func SendError(rw http.ResponseWriter, err command.Error) {
 		status = http.StatusBadRequest
 	}
 
+	if definition, ok := agentcmd.LookupError(err.Code()); ok && definition.Conflict {
+		status = http.StatusConflict
+	}
+
 	SendHTTPStatusError(rw, status, err.Code(), err)
 }
 
@@ -57,10 +82,7 @@ func SendError(rw http.ResponseWriter, err command.Error) {
 func SendHTTPStatusError(rw http.ResponseWriter, httpStatus int, code command.Code, err error) {
 	rw.WriteHeader(httpStatus)
 
-	e := json.NewEncoder(rw).Encode(genericErrorBody{
-		Code:    code,
-		Message: err.Error(),
-	})
+	e := json.NewEncoder(rw).Encode(agentcmd.DescribeError(code, err))
 	if e != nil {
 		logger.Errorf("Unable to send error response, %s", e)
 	}
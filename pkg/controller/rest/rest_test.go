@@ -39,7 +39,7 @@ func TestSendError(t *testing.T) {
 		}{
 			{
 				fmt.Errorf(errMsg), sampleErr1, http.StatusOK,
-				genericErrorBody{Code: sampleErr1, Message: errMsg},
+				genericErrorBody{Code: sampleErr1, Name: "UNKNOWN", Message: errMsg},
 			},
 			{
 				fmt.Errorf(errMsg), sampleErr2, http.StatusForbidden,
@@ -79,7 +79,7 @@ func TestSendError(t *testing.T) {
 		}{
 			{
 				command.NewValidationError(sampleErr1, fmt.Errorf(errMsg)), http.StatusBadRequest,
-				genericErrorBody{Code: sampleErr1, Message: errMsg},
+				genericErrorBody{Code: sampleErr1, Name: "UNKNOWN", Message: errMsg},
 			},
 			{
 				command.NewExecuteError(sampleErr2, fmt.Errorf(errMsg)), http.StatusInternalServerError,
@@ -111,6 +111,48 @@ func TestSendError(t *testing.T) {
 	})
 }
 
+func TestSendError_Catalog(t *testing.T) {
+	const code = command.Code(9901)
+
+	require.NoError(t, agentcmd.RegisterErrors(agentcmd.ErrorDefinition{
+		Code: code, Name: "TEST_UNAVAILABLE", Description: "test", Retryable: true,
+	}))
+
+	send := func(err command.Error) genericErrorBody {
+		rr := httptest.NewRecorder()
+
+		SendError(rr, err)
+
+		var response genericErrorBody
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+
+		return response
+	}
+
+	require.Equal(t, genericErrorBody{Code: code, Name: "TEST_UNAVAILABLE", Message: "unavailable", Retryable: true},
+		send(agentcmd.NewExecuteError(code, fmt.Errorf("unavailable"))))
+
+	schemaErr := &agentcmd.SchemaError{
+		Violations: []agentcmd.SchemaViolation{{Field: "$.id", Description: "expected string"}},
+	}
+
+	require.Equal(t, genericErrorBody{
+		Code: code, Name: "TEST_UNAVAILABLE", Message: schemaErr.Error(),
+		Details: []agentcmd.ErrorDetail{{Field: "$.id", Description: "expected string"}},
+	}, send(agentcmd.NewValidationError(code, schemaErr)))
+
+	const conflictCode = command.Code(9902)
+
+	require.NoError(t, agentcmd.RegisterErrors(agentcmd.ErrorDefinition{
+		Code: conflictCode, Name: "TEST_CONFLICT", Description: "test", Conflict: true,
+	}))
+
+	rr := httptest.NewRecorder()
+	SendError(rr, agentcmd.NewExecuteError(conflictCode, fmt.Errorf("conflict")))
+	require.Equal(t, http.StatusConflict, rr.Code)
+}
+
 func TestSendErrorFailures(t *testing.T) {
 	rw := &mockRWriter{}
 	SendHTTPStatusError(rw, http.StatusBadRequest, command.UnknownStatus, fmt.Errorf("sample error"))
@@ -124,6 +166,39 @@ func TestExecute(t *testing.T) {
 	rw := httptest.NewRecorder()
 	Execute(cmd, rw, nil)
 	require.Contains(t, rw.Body.String(), `{"code":1,"message":"sample"}`)
+
+	t.Run("error of started response", func(t *testing.T) {
+		streaming := func(rw io.Writer, req io.Reader) command.Error {
+			_, err := rw.Write([]byte(`{"results":[`))
+			require.NoError(t, err)
+
+			return command.NewExecuteError(1, fmt.Errorf("sample"))
+		}
+
+		rr := httptest.NewRecorder()
+		Execute(streaming, rr, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, `{"results":[`, rr.Body.String())
+	})
+}
+
+func TestExecuteCommand(t *testing.T) {
+	defer agentcmd.ResetMiddlewares()
+
+	var intercepted string
+
+	agentcmd.UseMiddlewares(func(inv *agentcmd.Invocation, next agentcmd.Next) command.Error {
+		intercepted = inv.Name + "." + inv.Method
+
+		return next(inv)
+	})
+
+	rr := httptest.NewRecorder()
+	ExecuteCommand("test", "Method", func(rw io.Writer, req io.Reader) command.Error {
+		return command.NewValidationError(1, fmt.Errorf("sample"))
+	}, rr, nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	require.Equal(t, "test.Method", intercepted)
 }
 
 // mockRWriter to recreate response writer error scenario.
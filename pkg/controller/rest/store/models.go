@@ -0,0 +1,345 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package store
+
+import (
+	"github.com/trustbloc/agent-sdk/pkg/controller/command/store"
+)
+
+// putRequest model
+//
+// Request for putting data in the store.
+//
+// swagger:parameters storePut
+type putRequest struct { //nolint: unused,deadcode
+	// Params for putting data in the store.
+	//
+	// in: body
+	// required: true
+	Request store.PutRequest
+}
+
+// putResponse model
+//
+// Response of put request.
+//
+// swagger:response putResponse
+type putResponse struct {
+	// in: body
+	Response store.PutResponse
+}
+
+// getRequest model
+//
+// Request for getting data from the store.
+//
+// swagger:parameters storeGet
+type getRequest struct { //nolint: unused,deadcode
+	// Params for getting data from the store.
+	//
+	// in: body
+	// required: true
+	Request store.GetRequest
+}
+
+// getResponse model
+//
+// Response of get request.
+//
+// swagger:response getResponse
+type getResponse struct {
+	// in: body
+	Response store.GetResponse
+}
+
+// queryRequest model
+//
+// Request for querying records from the store.
+//
+// swagger:parameters storeQuery
+type queryRequest struct { //nolint: unused,deadcode
+	// Params for querying records from the store.
+	//
+	// in: body
+	// required: true
+	Request store.QueryRequest
+}
+
+// queryResponse model
+//
+// Response of query request.
+//
+// swagger:response queryResponse
+type queryResponse struct {
+	// in: body
+	Response store.QueryResponse
+}
+
+// queryRecordsRequest model
+//
+// Request for querying records along with their keys, tags and versions from the store.
+//
+// swagger:parameters storeQueryRecords
+type queryRecordsRequest struct { //nolint: unused,deadcode
+	// Params for querying records from the store.
+	//
+	// in: body
+	// required: true
+	Request store.QueryRequest
+}
+
+// queryRecordsResponse model
+//
+// Response of query records request.
+//
+// swagger:response queryRecordsResponse
+type queryRecordsResponse struct {
+	// in: body
+	Response store.QueryRecordsResponse
+}
+
+// deleteRequest model
+//
+// Request for deleting data from the store.
+//
+// swagger:parameters storeDelete
+type deleteRequest struct { //nolint: unused,deadcode
+	// Params for deleting data from the store.
+	//
+	// in: body
+	// required: true
+	Request store.DeleteRequest
+}
+
+// listStoresResponse model
+//
+// Response of list stores request.
+//
+// swagger:response listStoresResponse
+type listStoresResponse struct {
+	// in: body
+	Response store.ListStoresResponse
+}
+
+// getTagsRequest model
+//
+// Request for getting tags of a record from the store.
+//
+// swagger:parameters storeGetTags
+type getTagsRequest struct { //nolint: unused,deadcode
+	// Params for getting tags of a record from the store.
+	//
+	// in: body
+	// required: true
+	Request store.GetTagsRequest
+}
+
+// getTagsResponse model
+//
+// Response of get tags request.
+//
+// swagger:response getTagsResponse
+type getTagsResponse struct {
+	// in: body
+	Response store.GetTagsResponse
+}
+
+// getBulkRequest model
+//
+// Request for getting values of multiple records from the store.
+//
+// swagger:parameters storeGetBulk
+type getBulkRequest struct { //nolint: unused,deadcode
+	// Params for getting values of multiple records from the store.
+	//
+	// in: body
+	// required: true
+	Request store.GetBulkRequest
+}
+
+// getBulkResponse model
+//
+// Response of get bulk request.
+//
+// swagger:response getBulkResponse
+type getBulkResponse struct {
+	// in: body
+	Response store.GetBulkResponse
+}
+
+// batchRequest model
+//
+// Request for performing multiple put and delete operations on the store atomically.
+//
+// swagger:parameters storeBatch
+type batchRequest struct { //nolint: unused,deadcode
+	// Params for performing batch operations.
+	//
+	// in: body
+	// required: true
+	Request store.BatchRequest
+}
+
+// incrementRequest model
+//
+// Request for incrementing an integer counter stored in the record.
+//
+// swagger:parameters storeIncrement
+type incrementRequest struct { //nolint: unused,deadcode
+	// Params for incrementing counter.
+	//
+	// in: body
+	// required: true
+	Request store.IncrementRequest
+}
+
+// incrementResponse model
+//
+// Response of increment request.
+//
+// swagger:response incrementResponse
+type incrementResponse struct {
+	// in: body
+	Response store.IncrementResponse
+}
+
+// sweeperStatsResponse model
+//
+// Response of sweeper stats request.
+//
+// swagger:response sweeperStatsResponse
+type sweeperStatsResponse struct {
+	// in: body
+	Response store.SweeperStatsResponse
+}
+
+// exportStoresRequest model
+//
+// Request for exporting stores into an encrypted archive.
+//
+// swagger:parameters storeExportStores
+type exportStoresRequest struct { //nolint: unused,deadcode
+	// in: body
+	// required: true
+	Request store.ExportStoresRequest
+}
+
+// exportStoresResponse model
+//
+// Response of export stores request.
+//
+// swagger:response exportStoresResponse
+type exportStoresResponse struct {
+	// in: body
+	Response store.ExportStoresResponse
+}
+
+// importStoresRequest model
+//
+// Request for importing stores from an encrypted archive.
+//
+// swagger:parameters storeImportStores
+type importStoresRequest struct { //nolint: unused,deadcode
+	// in: body
+	// required: true
+	Request store.ImportStoresRequest
+}
+
+// importStoresResponse model
+//
+// Response of import stores request.
+//
+// swagger:response importStoresResponse
+type importStoresResponse struct {
+	// in: body
+	Response store.ImportStoresResponse
+}
+
+// subscribeRequest model
+//
+// Request for subscribing to changes of records in the store.
+//
+// swagger:parameters storeSubscribe
+type subscribeRequest struct { //nolint: unused,deadcode
+	// in: body
+	// required: true
+	Request store.SubscribeRequest
+}
+
+// subscribeResponse model
+//
+// Response of subscribe request.
+//
+// swagger:response subscribeResponse
+type subscribeResponse struct {
+	// in: body
+	Response store.SubscribeResponse
+}
+
+// unsubscribeRequest model
+//
+// Request for cancelling subscription to changes of records.
+//
+// swagger:parameters storeUnsubscribe
+type unsubscribeRequest struct { //nolint: unused,deadcode
+	// in: body
+	// required: true
+	Request store.UnsubscribeRequest
+}
+
+// configureIndexesRequest model
+//
+// Request for replacing index definitions of the store.
+//
+// swagger:parameters storeConfigureIndexes
+type configureIndexesRequest struct { //nolint: unused,deadcode
+	// in: body
+	// required: true
+	Request store.ConfigureIndexesRequest
+}
+
+// getIndexesRequest model
+//
+// Request for getting index definitions of the store.
+//
+// swagger:parameters storeGetIndexes
+type getIndexesRequest struct { //nolint: unused,deadcode
+	// in: body
+	// required: true
+	Request store.GetIndexesRequest
+}
+
+// getIndexesResponse model
+//
+// Response of get indexes request.
+//
+// swagger:response getIndexesResponse
+type getIndexesResponse struct {
+	// in: body
+	Response store.GetIndexesResponse
+}
+
+// rebuildIndexesRequest model
+//
+// Request for updating index tags of existing records of the store.
+//
+// swagger:parameters storeRebuildIndexes
+type rebuildIndexesRequest struct { //nolint: unused,deadcode
+	// in: body
+	// required: true
+	Request store.RebuildIndexesRequest
+}
+
+// rebuildIndexesResponse model
+//
+// Response of rebuild indexes request.
+//
+// swagger:response rebuildIndexesResponse
+type rebuildIndexesResponse struct {
+	// in: body
+	Response store.RebuildIndexesResponse
+}
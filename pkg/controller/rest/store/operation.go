@@ -0,0 +1,320 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package store provides REST operations for store command.
+package store
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/trustbloc/agent-sdk/pkg/controller/command/store"
+	"github.com/trustbloc/agent-sdk/pkg/controller/internal/cmdutil"
+	"github.com/trustbloc/agent-sdk/pkg/controller/rest"
+)
+
+// constants for endpoints of store.
+const (
+	OperationID          = "/store"
+	PutPath              = OperationID + "/put"
+	GetPath              = OperationID + "/get"
+	QueryPath            = OperationID + "/query"
+	QueryRecordsPath     = OperationID + "/query-records"
+	DeletePath           = OperationID + "/delete"
+	FlushPath            = OperationID + "/flush"
+	ListStoresPath       = OperationID + "/list-stores"
+	GetTagsPath          = OperationID + "/get-tags"
+	GetBulkPath          = OperationID + "/get-bulk"
+	BatchPath            = OperationID + "/batch"
+	IncrementPath        = OperationID + "/increment"
+	SweeperStatsPath     = OperationID + "/sweeper-stats"
+	ExportStoresPath     = OperationID + "/export"
+	ImportStoresPath     = OperationID + "/import"
+	SubscribePath        = OperationID + "/subscribe"
+	UnsubscribePath      = OperationID + "/unsubscribe"
+	ConfigureIndexesPath = OperationID + "/configure-indexes"
+	GetIndexesPath       = OperationID + "/get-indexes"
+	RebuildIndexesPath   = OperationID + "/rebuild-indexes"
+)
+
+// Operation is controller REST service controller for store.
+type Operation struct {
+	command  *store.Command
+	handlers []rest.Handler
+}
+
+// New returns new store rest instance.
+func New(ctx store.Provider, opts ...store.Opt) (*Operation, error) {
+	cmd, err := store.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize store command: %w", err)
+	}
+
+	o := &Operation{command: cmd}
+	o.registerHandler()
+
+	return o, nil
+}
+
+// GetRESTHandlers get all controller API handler available for this service.
+func (c *Operation) GetRESTHandlers() []rest.Handler {
+	return c.handlers
+}
+
+// registerHandler register handlers to be exposed from this service as REST API endpoints.
+func (c *Operation) registerHandler() {
+	c.handlers = []rest.Handler{
+		cmdutil.NewHTTPHandler(PutPath, http.MethodPost, c.Put),
+		cmdutil.NewHTTPHandler(GetPath, http.MethodPost, c.Get),
+		cmdutil.NewHTTPHandler(QueryPath, http.MethodPost, c.Query),
+		cmdutil.NewHTTPHandler(QueryRecordsPath, http.MethodPost, c.QueryRecords),
+		cmdutil.NewHTTPHandler(DeletePath, http.MethodPost, c.Delete),
+		cmdutil.NewHTTPHandler(FlushPath, http.MethodPost, c.Flush),
+		cmdutil.NewHTTPHandler(ListStoresPath, http.MethodPost, c.ListStores),
+		cmdutil.NewHTTPHandler(GetTagsPath, http.MethodPost, c.GetTags),
+		cmdutil.NewHTTPHandler(GetBulkPath, http.MethodPost, c.GetBulk),
+		cmdutil.NewHTTPHandler(BatchPath, http.MethodPost, c.Batch),
+		cmdutil.NewHTTPHandler(IncrementPath, http.MethodPost, c.Increment),
+		cmdutil.NewHTTPHandler(SweeperStatsPath, http.MethodPost, c.SweeperStats),
+		cmdutil.NewHTTPHandler(ExportStoresPath, http.MethodPost, c.ExportStores),
+		cmdutil.NewHTTPHandler(ImportStoresPath, http.MethodPost, c.ImportStores),
+		cmdutil.NewHTTPHandler(SubscribePath, http.MethodPost, c.Subscribe),
+		cmdutil.NewHTTPHandler(UnsubscribePath, http.MethodPost, c.Unsubscribe),
+		cmdutil.NewHTTPHandler(ConfigureIndexesPath, http.MethodPost, c.ConfigureIndexes),
+		cmdutil.NewHTTPHandler(GetIndexesPath, http.MethodPost, c.GetIndexes),
+		cmdutil.NewHTTPHandler(RebuildIndexesPath, http.MethodPost, c.RebuildIndexes),
+	}
+}
+
+// Put swagger:route POST /store/put store storePut
+//
+// Stores the key, value and (optional) tags, optionally only if the record has given version.
+//
+// Responses:
+//
+//	default: genericError
+//	200: putResponse
+func (c *Operation) Put(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(store.CommandName, store.PutCommandMethod, c.command.Put, rw, req.Body)
+}
+
+// Get swagger:route POST /store/get store storeGet
+//
+// Fetches the record based on key.
+//
+// Responses:
+//
+//	default: genericError
+//	200: getResponse
+func (c *Operation) Get(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(store.CommandName, store.GetCommandMethod, c.command.Get, rw, req.Body)
+}
+
+// Query swagger:route POST /store/query store storeQuery
+//
+// Retrieves records matching given expression, at most limit records are returned along with a cursor
+// for retrieving the next ones.
+//
+// Responses:
+//
+//	default: genericError
+//	200: queryResponse
+func (c *Operation) Query(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(store.CommandName, store.QueryCommandMethod, c.command.Query, rw, req.Body)
+}
+
+// QueryRecords swagger:route POST /store/query-records store storeQueryRecords
+//
+// Retrieves records matching given expression along with their keys, tags and versions, at most limit records
+// are returned along with a cursor for retrieving the next ones.
+//
+// Responses:
+//
+//	default: genericError
+//	200: queryRecordsResponse
+func (c *Operation) QueryRecords(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(store.CommandName, store.QueryRecordsCommandMethod, c.command.QueryRecords, rw, req.Body)
+}
+
+// Delete swagger:route POST /store/delete store storeDelete
+//
+// Deletes a record with a given key, optionally only if the record has given version.
+//
+// Responses:
+//
+//	default: genericError
+func (c *Operation) Delete(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(store.CommandName, store.DeleteCommandMethod, c.command.Delete, rw, req.Body)
+}
+
+// Flush swagger:route POST /store/flush store storeFlush
+//
+// Flushes data in all currently open stores.
+//
+// Responses:
+//
+//	default: genericError
+func (c *Operation) Flush(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(store.CommandName, store.FlushCommandMethod, c.command.Flush, rw, req.Body)
+}
+
+// ListStores swagger:route POST /store/list-stores store storeListStores
+//
+// Lists stores ever opened through store commands, including before restarts, and names of stores allowed
+// to be opened.
+//
+// Responses:
+//
+//	default: genericError
+//	200: listStoresResponse
+func (c *Operation) ListStores(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(store.CommandName, store.ListStoresCommandMethod, c.command.ListStores, rw, req.Body)
+}
+
+// GetTags swagger:route POST /store/get-tags store storeGetTags
+//
+// Fetches tags of the record based on key.
+//
+// Responses:
+//
+//	default: genericError
+//	200: getTagsResponse
+func (c *Operation) GetTags(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(store.CommandName, store.GetTagsCommandMethod, c.command.GetTags, rw, req.Body)
+}
+
+// GetBulk swagger:route POST /store/get-bulk store storeGetBulk
+//
+// Fetches records based on keys.
+//
+// Responses:
+//
+//	default: genericError
+//	200: getBulkResponse
+func (c *Operation) GetBulk(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(store.CommandName, store.GetBulkCommandMethod, c.command.GetBulk, rw, req.Body)
+}
+
+// Batch swagger:route POST /store/batch store storeBatch
+//
+// Performs put and delete operations atomically.
+//
+// Responses:
+//
+//	default: genericError
+func (c *Operation) Batch(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(store.CommandName, store.BatchCommandMethod, c.command.Batch, rw, req.Body)
+}
+
+// Increment swagger:route POST /store/increment store storeIncrement
+//
+// Atomically adds delta to the integer counter stored in the record.
+//
+// Responses:
+//
+//	default: genericError
+//	200: incrementResponse
+func (c *Operation) Increment(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(store.CommandName, store.IncrementCommandMethod, c.command.Increment, rw, req.Body)
+}
+
+// SweeperStats swagger:route POST /store/sweeper-stats store storeSweeperStats
+//
+// Returns statistics of the sweeper deleting expired records.
+//
+// Responses:
+//
+//	default: genericError
+//	200: sweeperStatsResponse
+func (c *Operation) SweeperStats(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(store.CommandName, store.SweeperStatsCommandMethod, c.command.SweeperStats, rw, req.Body)
+}
+
+// ExportStores swagger:route POST /store/export store storeExportStores
+//
+// Exports records and configurations of stores into an encrypted archive. Storage can't list all records,
+// so only records written through store commands and records having tags from store configurations, index tags
+// or requested tags are exported; numbers of exported records are returned for each store.
+//
+// Responses:
+//
+//	default: genericError
+//	200: exportStoresResponse
+func (c *Operation) ExportStores(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(store.CommandName, store.ExportStoresCommandMethod, c.command.ExportStores, rw, req.Body)
+}
+
+// ImportStores swagger:route POST /store/import store storeImportStores
+//
+// Imports records and configurations of stores from an encrypted archive. Archives encrypted to a key of
+// the agent's key manager are decrypted by key ID, so that private keys are not sent.
+//
+// Responses:
+//
+//	default: genericError
+//	200: importStoresResponse
+func (c *Operation) ImportStores(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(store.CommandName, store.ImportStoresCommandMethod, c.command.ImportStores, rw, req.Body)
+}
+
+// Subscribe swagger:route POST /store/subscribe store storeSubscribe
+//
+// Subscribes to changes of records in the store, change events are published to webhooks and websocket clients.
+//
+// Responses:
+//
+//	default: genericError
+//	200: subscribeResponse
+func (c *Operation) Subscribe(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(store.CommandName, store.SubscribeCommandMethod, c.command.Subscribe, rw, req.Body)
+}
+
+// Unsubscribe swagger:route POST /store/unsubscribe store storeUnsubscribe
+//
+// Cancels subscription to changes of records.
+//
+// Responses:
+//
+//	default: genericError
+func (c *Operation) Unsubscribe(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(store.CommandName, store.UnsubscribeCommandMethod, c.command.Unsubscribe, rw, req.Body)
+}
+
+// ConfigureIndexes swagger:route POST /store/configure-indexes store storeConfigureIndexes
+//
+// Replaces JSON-path index definitions of the store, index tags are derived from values of records written afterwards.
+//
+// Responses:
+//
+//	default: genericError
+func (c *Operation) ConfigureIndexes(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(store.CommandName, store.ConfigureIndexesCommandMethod,
+		c.command.ConfigureIndexes, rw, req.Body)
+}
+
+// GetIndexes swagger:route POST /store/get-indexes store storeGetIndexes
+//
+// Gets JSON-path index definitions of the store.
+//
+// Responses:
+//
+//	default: genericError
+//	200: getIndexesResponse
+func (c *Operation) GetIndexes(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(store.CommandName, store.GetIndexesCommandMethod, c.command.GetIndexes, rw, req.Body)
+}
+
+// RebuildIndexes swagger:route POST /store/rebuild-indexes store storeRebuildIndexes
+//
+// Updates index tags of existing records of the store.
+//
+// Responses:
+//
+//	default: genericError
+//	200: rebuildIndexesResponse
+func (c *Operation) RebuildIndexes(rw http.ResponseWriter, req *http.Request) {
+	rest.ExecuteCommand(store.CommandName, store.RebuildIndexesCommandMethod, c.command.RebuildIndexes, rw, req.Body)
+}
@@ -0,0 +1,215 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package store //nolint:testpackage // uses internal implementation details
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/component/storageutil/mem"
+	"github.com/hyperledger/aries-framework-go/pkg/controller/command"
+	"github.com/hyperledger/aries-framework-go/spi/storage"
+	"github.com/stretchr/testify/require"
+
+	agentcmd "github.com/trustbloc/agent-sdk/pkg/controller/command"
+	"github.com/trustbloc/agent-sdk/pkg/controller/command/store"
+	"github.com/trustbloc/agent-sdk/pkg/controller/internal/mocks"
+	sdkmockprotocol "github.com/trustbloc/agent-sdk/pkg/controller/internal/mocks/protocol"
+	"github.com/trustbloc/agent-sdk/pkg/controller/internal/testutil"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("test success", func(t *testing.T) {
+		c, err := New(&sdkmockprotocol.MockProvider{StoreProvider: mem.NewProvider()})
+		require.NoError(t, err)
+		require.NotNil(t, c)
+		require.Len(t, c.GetRESTHandlers(), 19)
+	})
+
+	t.Run("test failure while creating store command", func(t *testing.T) {
+		storeProvider := mocks.NewMockStoreProvider()
+		storeProvider.ErrOpenStoreHandle = errors.New("open failure")
+
+		c, err := New(&sdkmockprotocol.MockProvider{StoreProvider: storeProvider})
+		require.Error(t, err)
+		require.Nil(t, c)
+		require.Contains(t, err.Error(), "failed to initialize store command")
+	})
+}
+
+func TestOperation_Records(t *testing.T) {
+	c, err := New(&sdkmockprotocol.MockProvider{StoreProvider: mem.NewProvider()}, store.WithAllowedStores("app"),
+		store.WithNotifier(mocks.NewMockNotifier()))
+	require.NoError(t, err)
+
+	post := func(t *testing.T, path string, request interface{}) *bytes.Buffer {
+		t.Helper()
+
+		reqBytes, e := json.Marshal(request)
+		require.NoError(t, e)
+
+		handler := testutil.LookupHandler(t, c, path)
+
+		buf, e := testutil.GetSuccessResponseFromHandler(handler, bytes.NewBuffer(reqBytes), handler.Path())
+		require.NoError(t, e)
+
+		return buf
+	}
+
+	post(t, PutPath, &store.PutRequest{
+		Key: "key1", Value: []byte("value1"), Tags: []storage.Tag{{Name: "tag"}}, StoreName: "app",
+	})
+	post(t, BatchPath, &store.BatchRequest{
+		StoreName:  "app",
+		Operations: []store.BatchOperation{{Key: "key2", Value: []byte("value2"), Tags: []storage.Tag{{Name: "tag"}}}},
+	})
+
+	var getResp store.GetResponse
+	require.NoError(t, json.Unmarshal(post(t, GetPath, &store.GetRequest{Key: "key1", StoreName: "app"}).Bytes(),
+		&getResp))
+	require.Equal(t, []byte("value1"), getResp.Result)
+
+	var tagsResp store.GetTagsResponse
+	require.NoError(t, json.Unmarshal(post(t, GetTagsPath, &store.GetTagsRequest{Key: "key1", StoreName: "app"}).Bytes(),
+		&tagsResp))
+	require.Equal(t, []storage.Tag{{Name: "tag"}}, tagsResp.Tags)
+
+	var bulkResp store.GetBulkResponse
+	require.NoError(t, json.Unmarshal(post(t, GetBulkPath, &store.GetBulkRequest{
+		Keys: []string{"key1", "key2"}, StoreName: "app",
+	}).Bytes(), &bulkResp))
+	require.Equal(t, [][]byte{[]byte("value1"), []byte("value2")}, bulkResp.Results)
+
+	var queryResp store.QueryResponse
+	require.NoError(t, json.Unmarshal(post(t, QueryPath, &store.QueryRequest{Expression: "tag", StoreName: "app"}).Bytes(),
+		&queryResp))
+	require.Len(t, queryResp.Results, 2)
+
+	var recordsResp store.QueryRecordsResponse
+	require.NoError(t, json.Unmarshal(post(t, QueryRecordsPath,
+		&store.QueryRequest{Expression: "tag", StoreName: "app"}).Bytes(), &recordsResp))
+	require.Len(t, recordsResp.Records, 2)
+
+	post(t, DeletePath, &store.DeleteRequest{Key: "key1", StoreName: "app"})
+	post(t, FlushPath, struct{}{})
+
+	var incResp store.IncrementResponse
+	require.NoError(t, json.Unmarshal(post(t, IncrementPath, &store.IncrementRequest{Key: "counter", Delta: 2}).Bytes(),
+		&incResp))
+	require.EqualValues(t, 2, incResp.Value)
+
+	var listResp store.ListStoresResponse
+	require.NoError(t, json.Unmarshal(post(t, ListStoresPath, struct{}{}).Bytes(), &listResp))
+	require.Equal(t, []string{"app", store.DefaultStoreName}, listResp.Stores)
+
+	post(t, PutPath, &store.PutRequest{Key: "temp", Value: []byte("value"), TTL: 60})
+
+	var statsResp store.SweeperStatsResponse
+	require.NoError(t, json.Unmarshal(post(t, SweeperStatsPath, struct{}{}).Bytes(), &statsResp))
+	require.True(t, statsResp.Enabled)
+	require.Equal(t, store.DefaultSweepInterval.String(), statsResp.Interval)
+
+	var exportResp store.ExportStoresResponse
+	require.NoError(t, json.Unmarshal(post(t, ExportStoresPath, &store.ExportStoresRequest{
+		StoreNames: []string{"app"}, TagNames: []string{"tag"}, Passphrase: "secret",
+	}).Bytes(), &exportResp))
+
+	var importResp store.ImportStoresResponse
+	require.NoError(t, json.Unmarshal(post(t, ImportStoresPath, &store.ImportStoresRequest{
+		Archive: exportResp.Archive, Passphrase: "secret", Mode: store.ImportModeReplace,
+	}).Bytes(), &importResp))
+	require.Equal(t, []store.ImportedStore{{Name: "app", Records: 1}}, importResp.Stores)
+
+	var subscribeResp store.SubscribeResponse
+	require.NoError(t, json.Unmarshal(post(t, SubscribePath, &store.SubscribeRequest{StoreName: "app"}).Bytes(),
+		&subscribeResp))
+	require.Equal(t, store.ChangeTopic("app"), subscribeResp.Topic)
+
+	post(t, UnsubscribePath, &store.UnsubscribeRequest{ID: subscribeResp.ID})
+
+	post(t, ConfigureIndexesPath, &store.ConfigureIndexesRequest{
+		StoreName: "app", Indexes: []store.IndexDefinition{{Name: "type", Path: "$.type"}},
+	})
+
+	var indexesResp store.GetIndexesResponse
+	require.NoError(t, json.Unmarshal(post(t, GetIndexesPath, &store.GetIndexesRequest{StoreName: "app"}).Bytes(),
+		&indexesResp))
+	require.Equal(t, []store.IndexDefinition{{Name: "type", Path: "$.type"}}, indexesResp.Indexes)
+
+	var rebuildResp store.RebuildIndexesResponse
+	require.NoError(t, json.Unmarshal(post(t, RebuildIndexesPath, &store.RebuildIndexesRequest{StoreName: "app"}).Bytes(),
+		&rebuildResp))
+	require.Equal(t, 0, rebuildResp.Updated)
+}
+
+func TestOperation_Errors(t *testing.T) {
+	c, err := New(&sdkmockprotocol.MockProvider{StoreProvider: mem.NewProvider()})
+	require.NoError(t, err)
+
+	t.Run("invalid request", func(t *testing.T) {
+		for _, path := range []string{
+			PutPath, GetPath, QueryPath, QueryRecordsPath, DeletePath, GetTagsPath, GetBulkPath, BatchPath,
+			IncrementPath, ExportStoresPath, ImportStoresPath, SubscribePath, UnsubscribePath, ConfigureIndexesPath,
+			GetIndexesPath, RebuildIndexesPath,
+		} {
+			handler := testutil.LookupHandler(t, c, path)
+
+			buf, code, e := testutil.SendRequestToHandler(handler, bytes.NewBufferString("---"), handler.Path())
+			require.NoError(t, e)
+			require.Equal(t, http.StatusBadRequest, code)
+			testutil.VerifyError(t, store.InvalidRequestErrorCode, "invalid character", buf.Bytes())
+		}
+	})
+
+	t.Run("store not allowed", func(t *testing.T) {
+		handler := testutil.LookupHandler(t, c, GetPath)
+
+		buf, code, e := testutil.SendRequestToHandler(handler,
+			bytes.NewBufferString(`{"key":"key","storeName":"didexchange"}`), handler.Path())
+		require.NoError(t, e)
+		require.Equal(t, http.StatusBadRequest, code)
+		testutil.VerifyError(t, store.StoreNotAllowedErrorCode, "store is not allowed", buf.Bytes())
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		handler := testutil.LookupHandler(t, c, PutPath)
+
+		buf, code, e := testutil.SendRequestToHandler(handler,
+			bytes.NewBufferString(`{"key":"key","value":"dmFsdWU=","ifMatch":"version"}`), handler.Path())
+		require.NoError(t, e)
+		require.Equal(t, http.StatusConflict, code)
+		testutil.VerifyError(t, store.ConflictErrorCode, "record version does not match", buf.Bytes())
+	})
+
+	t.Run("record not found", func(t *testing.T) {
+		handler := testutil.LookupHandler(t, c, GetPath)
+
+		buf, code, e := testutil.SendRequestToHandler(handler, bytes.NewBufferString(`{"key":"key"}`), handler.Path())
+		require.NoError(t, e)
+		require.Equal(t, http.StatusInternalServerError, code)
+		testutil.VerifyError(t, store.GetErrorCode, storage.ErrDataNotFound.Error(), buf.Bytes())
+	})
+
+	t.Run("failure after response was started", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+
+		execute(func(w io.Writer, _ io.Reader) command.Error {
+			_, e := w.Write([]byte(`{"results":[],"error":"query failure"}`))
+			require.NoError(t, e)
+
+			return agentcmd.NewExecuteError(store.QueryErrorCode, errors.New("query failure"))
+		}, rw, bytes.NewBufferString("{}"))
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, `{"results":[],"error":"query failure"}`, rw.Body.String())
+	})
+}
@@ -0,0 +1,35 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package webhook documents REST endpoints of the webhook command, which are served by command handlers
+// adapted by rest.AdaptCommandHandlers on their conventional paths.
+package webhook
+
+// swagger:route POST /webhook/register-subscription webhook webhookRegisterSubscription
+//
+// Registers webhook receiving messages on topics matching the filter of the subscription.
+//
+// Responses:
+//
+//	default: genericError
+//	200: registerSubscriptionResponse
+
+// swagger:route POST /webhook/list-subscriptions webhook webhookListSubscriptions
+//
+// Lists registered webhook subscriptions, values of their headers are redacted.
+//
+// Responses:
+//
+//	default: genericError
+//	200: listSubscriptionsResponse
+
+// swagger:route POST /webhook/delete-subscription webhook webhookDeleteSubscription
+//
+// Deletes webhook subscription.
+//
+// Responses:
+//
+//	default: genericError
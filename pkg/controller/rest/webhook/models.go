@@ -0,0 +1,57 @@
+/*
+Copyright Avast Software. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package webhook
+
+import (
+	"github.com/trustbloc/agent-sdk/pkg/controller/command/webhook"
+)
+
+// registerSubscriptionRequest model
+//
+// Request for registering a webhook subscription.
+//
+// swagger:parameters webhookRegisterSubscription
+type registerSubscriptionRequest struct { //nolint: unused,deadcode
+	// Params for registering a webhook subscription.
+	//
+	// in: body
+	// required: true
+	Request webhook.RegisterSubscriptionRequest
+}
+
+// registerSubscriptionResponse model
+//
+// Response of register subscription request.
+//
+// swagger:response registerSubscriptionResponse
+type registerSubscriptionResponse struct {
+	// in: body
+	Response webhook.RegisterSubscriptionResponse
+}
+
+// listSubscriptionsResponse model
+//
+// Response of list subscriptions request.
+//
+// swagger:response listSubscriptionsResponse
+type listSubscriptionsResponse struct {
+	// in: body
+	Response webhook.ListSubscriptionsResponse
+}
+
+// deleteSubscriptionRequest model
+//
+// Request for deleting a webhook subscription.
+//
+// swagger:parameters webhookDeleteSubscription
+type deleteSubscriptionRequest struct { //nolint: unused,deadcode
+	// Params for deleting a webhook subscription.
+	//
+	// in: body
+	// required: true
+	Request webhook.DeleteSubscriptionRequest
+}